@@ -222,7 +222,8 @@ func (agt *Agent) finishAndAwaitCleanup(status string) (*apimodels.TaskEndRespon
 	}
 
 	t := agt.taskConfig.Project.FindProjectTask(agt.taskConfig.Task.DisplayName)
-	if agt.taskConfig.Project.DisableCleanup || (t != nil && t.DisableCleanup) {
+	cleanup := !agt.taskConfig.Project.DisableCleanup && (t == nil || !t.DisableCleanup)
+	if !cleanup {
 		agt.logger.LogExecution(slogger.INFO, "Skipping process cleanup.")
 	} else {
 		agt.logger.LogExecution(slogger.INFO, "Running process cleanup.")
@@ -231,6 +232,7 @@ func (agt *Agent) finishAndAwaitCleanup(status string) (*apimodels.TaskEndRespon
 			agt.logger.LogExecution(slogger.ERROR, "Error cleaning up spawned processes: %v", err)
 		}
 	}
+	cleanupPlugins(plugin.CommandPlugins, agt.taskConfig.Task.Id, cleanup, agt.logger)
 	err := agt.removeTaskDirectory()
 	if err != nil {
 		agt.logger.LogExecution(slogger.ERROR, "Error removing task directory: %v", err)
@@ -597,7 +599,20 @@ func (agt *Agent) RunCommands(commands []model.PluginCommandConf, returnOnError
 			continue
 		}
 
-		cmds, err := agt.Registry.GetCommands(commandInfo, agt.taskConfig.Project.Functions)
+		// get every plugin command up front, so that a function with an invalid
+		// command isn't partly run. Parallel blocks get their own commands.
+		cmds := make([]plugin.Command, len(parsedCommands))
+		for j, parsedCommand := range parsedCommands {
+			if parsedCommand.Parallel != nil {
+				continue
+			}
+			var pluginCmds []plugin.Command
+			pluginCmds, err = agt.Registry.GetCommands(parsedCommand, agt.taskConfig.Project.Functions)
+			if err != nil {
+				break
+			}
+			cmds[j] = pluginCmds[0]
+		}
 		if err != nil {
 			agt.logger.LogTask(slogger.ERROR, "Don't know how to run plugin action %s: %v", commandInfo.Command, err)
 			if returnOnError {
@@ -607,17 +622,8 @@ func (agt *Agent) RunCommands(commands []model.PluginCommandConf, returnOnError
 		}
 
 		for j, cmd := range cmds {
-			fullCommandName := cmd.Plugin() + "." + cmd.Name()
-
 			parsedCommand := parsedCommands[j]
-
-			if commandInfo.Function != "" {
-				fullCommandName = fmt.Sprintf(`'%v' in "%v"`, fullCommandName, commandInfo.Function)
-			} else if parsedCommand.DisplayName != "" {
-				fullCommandName = fmt.Sprintf(`("%v") %v`, parsedCommand.DisplayName, fullCommandName)
-			} else {
-				fullCommandName = fmt.Sprintf("'%v'", fullCommandName)
-			}
+			fullCommandName := commandName(cmd, commandInfo, parsedCommand)
 
 			// TODO: add validation for this once new config's in place/use
 			if !commandInfo.RunOnVariant(agt.taskConfig.BuildVariant.Name) {
@@ -626,6 +632,22 @@ func (agt *Agent) RunCommands(commands []model.PluginCommandConf, returnOnError
 				continue
 			}
 
+			// conditions can use the vars of the function call, so they are
+			// checked against a copy of the expansions with the vars added
+			run, cond, err := agt.taskConfig.Project.ConditionsHold(agt.taskConfig.Expansions, commandInfo, parsedCommand)
+			if err != nil {
+				agt.logger.LogTask(slogger.ERROR, "Couldn't check whether to run command %v: %v", fullCommandName, err)
+				if returnOnError {
+					return err
+				}
+				continue
+			}
+			if !run {
+				agt.logger.LogTask(slogger.INFO, "Skipping command %v since its condition '%v' does not hold (step %v of %v)",
+					fullCommandName, cond, i+1, len(commands))
+				continue
+			}
+
 			if len(cmds) == 1 {
 				agt.logger.LogTask(slogger.INFO, "Running command %v (step %v of %v)", fullCommandName, i+1, len(commands))
 			} else {
@@ -633,41 +655,14 @@ func (agt *Agent) RunCommands(commands []model.PluginCommandConf, returnOnError
 				agt.logger.LogTask(slogger.INFO, "Running command %v (step %v.%v of %v)", fullCommandName, i+1, j+1, len(commands))
 			}
 
-			var timeoutPeriod = DefaultCmdTimeout
-			if commandInfo.TimeoutSecs > 0 {
-				timeoutPeriod = time.Duration(commandInfo.TimeoutSecs) * time.Second
-			}
-
-			// override function timeout with command specific timeout
-			if parsedCommand.TimeoutSecs > 0 {
-				timeoutPeriod = time.Duration(parsedCommand.TimeoutSecs) * time.Second
-			}
-
-			// create a new command logger to wrap the agent logger
-			commandLogger := &CommandLogger{
-				commandName: fullCommandName,
-				logger:      agt.logger,
-			}
-
-			if len(commandInfo.Vars) > 0 {
-				for key, val := range commandInfo.Vars {
-					newVal, err := agt.taskConfig.Expansions.ExpandString(val)
-					if err != nil {
-						return fmt.Errorf("Can't expand '%v': %v", val, err)
-					}
-					agt.taskConfig.Expansions.Put(key, newVal)
-				}
+			if parsedCommand.Parallel != nil {
+				start := time.Now()
+				err = agt.runParallel(commandInfo, parsedCommand, stop)
+				agt.logger.LogExecution(slogger.INFO, "Finished %v in %v", fullCommandName, time.Since(start).String())
+			} else {
+				err = agt.runCommand(cmd, commandInfo, parsedCommand, fullCommandName, stop)
 			}
 
-			pluginCom := &TaskJSONCommunicator{cmd.Plugin(), agt.TaskCommunicator}
-
-			agt.CheckIn(parsedCommand, timeoutPeriod)
-
-			start := time.Now()
-			err = cmd.Execute(commandLogger, pluginCom, agt.taskConfig, stop)
-
-			agt.logger.LogExecution(slogger.INFO, "Finished %v in %v", fullCommandName, time.Since(start).String())
-
 			if err != nil {
 				agt.logger.LogTask(slogger.ERROR, "Command failed: %v", err)
 				if returnOnError {
@@ -680,6 +675,85 @@ func (agt *Agent) RunCommands(commands []model.PluginCommandConf, returnOnError
 	return nil
 }
 
+// runCommand runs a single plugin command, which was parsed from commandInfo.
+func (agt *Agent) runCommand(cmd plugin.Command, commandInfo, parsedCommand model.PluginCommandConf,
+	fullCommandName string, stop chan bool) error {
+
+	// create a new command logger to wrap the agent logger
+	commandLogger := &CommandLogger{
+		commandName: fullCommandName,
+		logger:      agt.logger,
+	}
+
+	if err := agt.applyVars(commandInfo); err != nil {
+		return err
+	}
+
+	pluginCom := &TaskJSONCommunicator{cmd.Plugin(), agt.TaskCommunicator}
+
+	agt.CheckIn(parsedCommand, commandTimeout(commandInfo, parsedCommand))
+
+	start := time.Now()
+	err := cmd.Execute(commandLogger, pluginCom, agt.taskConfig, stop)
+
+	agt.logger.LogExecution(slogger.INFO, "Finished %v in %v", fullCommandName, time.Since(start).String())
+	return err
+}
+
+// applyVars adds the command's vars to the task's expansions, along with the
+// defaults of the params that a function call doesn't pass.
+func (agt *Agent) applyVars(commandInfo model.PluginCommandConf) error {
+	expansions, err := agt.taskConfig.Project.ExpansionsWithVars(agt.taskConfig.Expansions, commandInfo)
+	if err != nil {
+		return err
+	}
+	agt.taskConfig.Expansions.Update(*expansions)
+	return nil
+}
+
+// commandName returns the name of a command for the logs.
+func commandName(cmd plugin.Command, commandInfo, parsedCommand model.PluginCommandConf) string {
+	if parsedCommand.Parallel != nil {
+		if commandInfo.Function != "" {
+			return fmt.Sprintf(`'parallel' in "%v"`, commandInfo.Function)
+		}
+		return fmt.Sprintf("'%v'", parsedCommand.GetDisplayName())
+	}
+	fullCommandName := cmd.Plugin() + "." + cmd.Name()
+	if commandInfo.Function != "" {
+		return fmt.Sprintf(`'%v' in "%v"`, fullCommandName, commandInfo.Function)
+	} else if parsedCommand.DisplayName != "" {
+		return fmt.Sprintf(`("%v") %v`, parsedCommand.DisplayName, fullCommandName)
+	}
+	return fmt.Sprintf("'%v'", fullCommandName)
+}
+
+// commandTimeout returns how long the command may run without logging before
+// it is timed out. A command's own timeout overrides its function's.
+func commandTimeout(commandInfo, parsedCommand model.PluginCommandConf) time.Duration {
+	if parsedCommand.TimeoutSecs > 0 {
+		return time.Duration(parsedCommand.TimeoutSecs) * time.Second
+	}
+	if commandInfo.TimeoutSecs > 0 {
+		return time.Duration(commandInfo.TimeoutSecs) * time.Second
+	}
+	return DefaultCmdTimeout
+}
+
+// cleanupPlugins has every plugin that leaves resources on the machine clean up
+// after the task, or only forget the task if cleanup is disabled.
+func cleanupPlugins(plugins []plugin.CommandPlugin, taskId string, cleanup bool, logger *StreamLogger) {
+	for _, pl := range plugins {
+		cleaner, ok := pl.(plugin.TaskCleanupPlugin)
+		if !ok {
+			continue
+		}
+		if err := cleaner.CleanupTask(taskId, cleanup, logger); err != nil {
+			logger.LogExecution(slogger.ERROR, "Error cleaning up after plugin %v: %v", pl.Name(), err)
+		}
+	}
+}
+
 // registerPlugins makes plugins available for use by the agent.
 func registerPlugins(registry plugin.Registry, plugins []plugin.CommandPlugin, logger *StreamLogger) error {
 	for _, pl := range plugins {
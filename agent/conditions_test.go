@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCommandConditions(t *testing.T) {
+	Convey("With an agent running commands with conditions", t, func() {
+		registry := plugin.NewSimpleRegistry()
+		So(registry.Register(&sleepPlugin{}), ShouldBeNil)
+		appender := &lockedAppender{}
+		agt := &Agent{
+			logger:             NewTestLogger(appender),
+			Registry:           registry,
+			idleTimeoutWatcher: &TimeoutWatcher{},
+			taskConfig: &model.TaskConfig{
+				Project:      &model.Project{},
+				BuildVariant: &model.BuildVariant{Name: "linux"},
+				Expansions:   command.NewExpansions(map[string]string{}),
+			},
+		}
+		onTarget := func(target string) model.PluginCommandConf {
+			cmd := sleepConf(0, false, "deploying to "+target)
+			cmd.If = "${target} == " + target
+			return cmd
+		}
+		agt.taskConfig.Project.Functions = map[string]*model.YAMLCommandSet{
+			"deploy": {
+				MultiCommand: []model.PluginCommandConf{onTarget("staging"), onTarget("prod")},
+				Params:       []model.FunctionParam{{Name: "target", Default: "staging"}},
+			},
+			"deploy in parallel": {
+				MultiCommand: []model.PluginCommandConf{
+					{Parallel: &model.ParallelBlock{Commands: []model.PluginCommandConf{onTarget("staging"), onTarget("prod")}}},
+				},
+				Params: []model.FunctionParam{{Name: "target", Required: true}},
+			},
+		}
+
+		Convey("the first command of a function should see the call's vars", func() {
+			call := model.PluginCommandConf{Function: "deploy", Vars: map[string]string{"target": "prod"}}
+			So(agt.RunCommands([]model.PluginCommandConf{call}, true, make(chan bool)), ShouldBeNil)
+			So(appender.contains("deploying to prod"), ShouldBeTrue)
+			So(appender.contains("deploying to staging"), ShouldBeFalse)
+		})
+
+		Convey("the defaults of params that the call doesn't pass should be used", func() {
+			call := model.PluginCommandConf{Function: "deploy"}
+			So(agt.RunCommands([]model.PluginCommandConf{call}, true, make(chan bool)), ShouldBeNil)
+			So(appender.contains("deploying to staging"), ShouldBeTrue)
+			So(appender.contains("deploying to prod"), ShouldBeFalse)
+		})
+
+		Convey("the call's own condition should see its vars", func() {
+			call := model.PluginCommandConf{Function: "deploy", Vars: map[string]string{"target": "prod"}, If: "${target} == staging"}
+			So(agt.RunCommands([]model.PluginCommandConf{call}, true, make(chan bool)), ShouldBeNil)
+			So(appender.contains("deploying to"), ShouldBeFalse)
+		})
+
+		Convey("the commands of a parallel block in a function should see the call's vars", func() {
+			call := model.PluginCommandConf{Function: "deploy in parallel", Vars: map[string]string{"target": "prod"}}
+			So(agt.RunCommands([]model.PluginCommandConf{call}, true, make(chan bool)), ShouldBeNil)
+			So(appender.contains("deploying to prod"), ShouldBeTrue)
+			So(appender.contains("deploying to staging"), ShouldBeFalse)
+		})
+	})
+}
@@ -94,6 +94,11 @@ func (lgr *StreamLogger) FlushAndWait() int {
 type CommandLogger struct {
 	commandName string
 	logger      *StreamLogger
+
+	// prefixOutput, if set, prefixes every line the command writes to the logs
+	// with the command's name, so that the output of commands run in parallel
+	// can be told apart.
+	prefixOutput bool
 }
 
 func (cmdLgr *CommandLogger) addCommandToMsgAndArgs(messageFmt string, args []interface{}) (string, []interface{}) {
@@ -101,11 +106,18 @@ func (cmdLgr *CommandLogger) addCommandToMsgAndArgs(messageFmt string, args []in
 }
 
 func (cmdLgr *CommandLogger) GetTaskLogWriter(level slogger.Level) io.Writer {
-	return cmdLgr.logger.GetTaskLogWriter(level)
+	return cmdLgr.wrapWriter(cmdLgr.logger.GetTaskLogWriter(level))
 }
 
 func (cmdLgr *CommandLogger) GetSystemLogWriter(level slogger.Level) io.Writer {
-	return cmdLgr.logger.GetSystemLogWriter(level)
+	return cmdLgr.wrapWriter(cmdLgr.logger.GetSystemLogWriter(level))
+}
+
+func (cmdLgr *CommandLogger) wrapWriter(w io.Writer) io.Writer {
+	if !cmdLgr.prefixOutput {
+		return w
+	}
+	return &prefixWriter{prefix: "[" + cmdLgr.commandName + "] ", wrapped: w}
 }
 
 func (cmdLgr *CommandLogger) LogLocal(level slogger.Level, messageFmt string, args ...interface{}) {
@@ -132,6 +144,25 @@ func (cmdLgr *CommandLogger) Flush() {
 	cmdLgr.logger.Flush()
 }
 
+// prefixWriter writes each line written to it to the wrapped writer, with a
+// prefix. Blank lines are dropped.
+type prefixWriter struct {
+	prefix  string
+	wrapped io.Writer
+}
+
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(string(p), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if _, err := io.WriteString(pw.wrapped, pw.prefix+line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
 // NewStreamLogger creates a StreamLogger wrapper for the apiLogger with a given timeoutWatcher.
 // Any logged messages on the StreamLogger will reset the TimeoutWatcher.
 func NewStreamLogger(timeoutWatcher *TimeoutWatcher, apiLgr *APILogger, logFile string) (*StreamLogger, error) {
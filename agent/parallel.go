@@ -0,0 +1,202 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+)
+
+// parallelBranch is one of the commands of a parallel block, or the commands of
+// one of its functions, which are run in order.
+type parallelBranch struct {
+	name           string
+	commandInfo    model.PluginCommandConf
+	parsedCommands []model.PluginCommandConf
+	cmds           []plugin.Command
+
+	// taskConfig is the task's config with a copy of the expansions of the
+	// branch's own, since the expansions can't be updated concurrently
+	taskConfig *model.TaskConfig
+}
+
+// runParallel runs the commands of a parallel block, which was parsed from
+// commandInfo, at the same time and waits for all of them to finish. If the
+// block fails fast, the rest of its commands are stopped as soon as one fails.
+func (agt *Agent) runParallel(commandInfo, block model.PluginCommandConf, stop chan bool) error {
+	funcs := agt.taskConfig.Project.Functions
+	variant := agt.taskConfig.BuildVariant.Name
+
+	// the vars of the function call the block is in are set first, so that the
+	// branches' conditions and vars can use them
+	if err := agt.applyVars(commandInfo); err != nil {
+		return err
+	}
+
+	// every branch is parsed before any of them are started. The block times
+	// out after the longest timeout of its commands; commands without a timeout
+	// of their own have the block's.
+	blockTimeout := commandTimeout(commandInfo, block)
+	var timeout time.Duration
+	branches := []parallelBranch{}
+	for i, branchInfo := range block.Parallel.Commands {
+		branch := parallelBranch{
+			name:        fmt.Sprintf("#%v", i+1),
+			commandInfo: branchInfo,
+		}
+		if !branchInfo.RunOnVariant(variant) {
+			agt.logger.LogTask(slogger.INFO, "Skipping parallel command %v on variant %v", branch.name, variant)
+			continue
+		}
+
+		var err error
+		branch.parsedCommands, err = agt.Registry.ParseCommandConf(branchInfo, funcs)
+		if err != nil {
+			return fmt.Errorf("couldn't parse parallel command %v: %v", branch.name, err)
+		}
+		branch.cmds, err = agt.Registry.GetCommands(branchInfo, funcs)
+		if err != nil {
+			return fmt.Errorf("don't know how to run parallel command %v: %v", branch.name, err)
+		}
+
+		// conditions are checked before the branches start, since the
+		// expansions can't be read while they are being updated
+		parsedCommands, cmds := branch.parsedCommands, branch.cmds
+		branch.parsedCommands, branch.cmds = nil, nil
+		for j, parsedCommand := range parsedCommands {
+			run, cond, err := agt.taskConfig.Project.ConditionsHold(agt.taskConfig.Expansions, branchInfo, parsedCommand)
+			if err != nil {
+				return fmt.Errorf("couldn't check whether to run parallel command %v: %v", branch.name, err)
+			}
+			if !run {
+				agt.logger.LogTask(slogger.INFO, "Skipping parallel command %v %v since its condition '%v' does not hold",
+					branch.name, commandName(cmds[j], branchInfo, parsedCommand), cond)
+				continue
+			}
+			branch.parsedCommands = append(branch.parsedCommands, parsedCommand)
+			branch.cmds = append(branch.cmds, cmds[j])
+		}
+		if len(branch.cmds) == 0 {
+			continue
+		}
+		for _, parsedCommand := range branch.parsedCommands {
+			t := blockTimeout
+			if branchInfo.TimeoutSecs > 0 || parsedCommand.TimeoutSecs > 0 {
+				t = commandTimeout(branchInfo, parsedCommand)
+			}
+			if t > timeout {
+				timeout = t
+			}
+		}
+		branches = append(branches, branch)
+	}
+	if len(branches) == 0 {
+		return nil
+	}
+
+	// the expansions can't be updated concurrently, so every branch gets its own
+	// copy of them with only its own vars set
+	for i := range branches {
+		expansions, err := agt.taskConfig.Project.ExpansionsWithVars(agt.taskConfig.Expansions, branches[i].commandInfo)
+		if err != nil {
+			return err
+		}
+		taskConfig := *agt.taskConfig
+		taskConfig.Expansions = expansions
+		branches[i].taskConfig = &taskConfig
+	}
+	agt.CheckIn(block, timeout)
+
+	// the branches are stopped if the task is, or when one fails if the block fails fast
+	branchStop := make(chan bool)
+	var stopOnce sync.Once
+	stopBranches := func() {
+		stopOnce.Do(func() { close(branchStop) })
+	}
+	done := make(chan bool)
+	go func() {
+		select {
+		case <-stop:
+			stopBranches()
+		case <-done:
+		}
+	}()
+
+	var firstErr error
+	failed := []string{}
+	errLock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, branch := range branches {
+		wg.Add(1)
+		go func(branch parallelBranch) {
+			defer wg.Done()
+			err := agt.runBranch(branch, branchStop)
+			if err == nil {
+				return
+			}
+			errLock.Lock()
+			defer errLock.Unlock()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%v: %v", branch.name, err)
+			}
+			failed = append(failed, branch.name)
+			if block.Parallel.FailFast() {
+				stopBranches()
+			}
+		}(branch)
+	}
+	wg.Wait()
+	close(done)
+
+	// the expansions the branches updated are merged in the order of the
+	// block, so where branches set the same expansion the last one wins
+	before := agt.taskConfig.Expansions.Copy()
+	for _, branch := range branches {
+		for key, value := range *branch.taskConfig.Expansions {
+			if !before.Exists(key) || before.Get(key) != value {
+				agt.taskConfig.Expansions.Put(key, value)
+			}
+		}
+	}
+
+	if firstErr != nil {
+		return fmt.Errorf("%v of %v parallel commands failed (%v), first failure was %v",
+			len(failed), len(branches), strings.Join(failed, ", "), firstErr)
+	}
+	return nil
+}
+
+// runBranch runs the commands of a branch of a parallel block in order, with
+// their output prefixed by the branch's name.
+func (agt *Agent) runBranch(branch parallelBranch, stop chan bool) error {
+	for j, cmd := range branch.cmds {
+		select {
+		case <-stop:
+			return fmt.Errorf("stopped before running %v", cmd.Plugin()+"."+cmd.Name())
+		default:
+		}
+
+		fullCommandName := fmt.Sprintf("%v %v", branch.name,
+			commandName(cmd, branch.commandInfo, branch.parsedCommands[j]))
+		commandLogger := &CommandLogger{
+			commandName:  fullCommandName,
+			logger:       agt.logger,
+			prefixOutput: true,
+		}
+		pluginCom := &TaskJSONCommunicator{cmd.Plugin(), agt.TaskCommunicator}
+
+		agt.logger.LogTask(slogger.INFO, "Running parallel command %v", fullCommandName)
+		start := time.Now()
+		err := cmd.Execute(commandLogger, pluginCom, branch.taskConfig, stop)
+		agt.logger.LogExecution(slogger.INFO, "Finished %v in %v", fullCommandName, time.Since(start).String())
+		if err != nil {
+			agt.logger.LogTask(slogger.ERROR, "Parallel command %v failed: %v", fullCommandName, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/mitchellh/mapstructure"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// lockedAppender stores log messages from concurrent commands.
+type lockedAppender struct {
+	sync.Mutex
+	messages []string
+}
+
+func (self *lockedAppender) Append(log *slogger.Log) error {
+	self.Lock()
+	defer self.Unlock()
+	self.messages = append(self.messages, slogger.FormatLog(log))
+	return nil
+}
+
+func (self *lockedAppender) contains(s string) bool {
+	self.Lock()
+	defer self.Unlock()
+	for _, msg := range self.messages {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepPlugin has a command that writes a line of output, sleeps, and then
+// succeeds or fails.
+type sleepPlugin struct{}
+
+func (_ *sleepPlugin) Name() string { return "sleep" }
+func (_ *sleepPlugin) NewCommand(cmdName string) (plugin.Command, error) {
+	return &sleepCommand{}, nil
+}
+
+type sleepCommand struct {
+	Millis int               `mapstructure:"millis"`
+	Fail   bool              `mapstructure:"fail"`
+	Output string            `mapstructure:"output"`
+	Set    map[string]string `mapstructure:"set"`
+}
+
+func (_ *sleepCommand) Name() string   { return "sleep" }
+func (_ *sleepCommand) Plugin() string { return "sleep" }
+func (self *sleepCommand) ParseParams(params map[string]interface{}) error {
+	return mapstructure.Decode(params, self)
+}
+func (self *sleepCommand) Execute(pluginLogger plugin.Logger, pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig, stop chan bool) error {
+	output, err := conf.Expansions.ExpandString(self.Output)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(pluginLogger.GetTaskLogWriter(slogger.INFO), output)
+	select {
+	case <-time.After(time.Duration(self.Millis) * time.Millisecond):
+	case <-stop:
+		return fmt.Errorf("stopped")
+	}
+	conf.Expansions.Update(self.Set)
+	if self.Fail {
+		return fmt.Errorf("failed")
+	}
+	return nil
+}
+
+func sleepConf(millis int, fail bool, output string) model.PluginCommandConf {
+	return model.PluginCommandConf{
+		Command: "sleep.sleep",
+		Params:  map[string]interface{}{"millis": millis, "fail": fail, "output": output},
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	Convey("With an agent running a parallel block", t, func() {
+		registry := plugin.NewSimpleRegistry()
+		So(registry.Register(&sleepPlugin{}), ShouldBeNil)
+		appender := &lockedAppender{}
+		agt := &Agent{
+			logger:             NewTestLogger(appender),
+			Registry:           registry,
+			idleTimeoutWatcher: &TimeoutWatcher{},
+			taskConfig: &model.TaskConfig{
+				Project:      &model.Project{},
+				BuildVariant: &model.BuildVariant{Name: "linux"},
+				Expansions:   command.NewExpansions(map[string]string{}),
+			},
+		}
+		block := func(onFailure string, cmds ...model.PluginCommandConf) []model.PluginCommandConf {
+			return []model.PluginCommandConf{
+				{Parallel: &model.ParallelBlock{OnFailure: onFailure, Commands: cmds}},
+			}
+		}
+
+		Convey("its commands should run at the same time with prefixed output", func() {
+			start := time.Now()
+			err := agt.RunCommands(block("", sleepConf(200, false, "one"), sleepConf(200, false, "two")), true, make(chan bool))
+			So(err, ShouldBeNil)
+			So(time.Since(start), ShouldBeLessThan, 390*time.Millisecond)
+			So(appender.contains("[#1 'sleep.sleep'] one"), ShouldBeTrue)
+			So(appender.contains("[#2 'sleep.sleep'] two"), ShouldBeTrue)
+		})
+
+		Convey("a failure should stop the other commands when failing fast", func() {
+			start := time.Now()
+			err := agt.RunCommands(block(model.ParallelFailFast, sleepConf(10, true, ""), sleepConf(5000, false, "")), true, make(chan bool))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "2 of 2 parallel commands failed")
+			So(err.Error(), ShouldContainSubstring, "#1: failed")
+			So(time.Since(start), ShouldBeLessThan, time.Second)
+		})
+
+		Convey("a failure should let the other commands finish when waiting for all", func() {
+			err := agt.RunCommands(block(model.ParallelWaitAll, sleepConf(10, true, ""), sleepConf(100, false, "")), true, make(chan bool))
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "1 of 2 parallel commands failed (#1)")
+		})
+
+		Convey("stopping the task should stop every command", func() {
+			stop := make(chan bool)
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				close(stop)
+			}()
+			err := agt.RunCommands(block(model.ParallelWaitAll, sleepConf(5000, false, ""), sleepConf(5000, false, "")), true, stop)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "2 of 2 parallel commands failed")
+		})
+
+		Convey("expansions set by the commands should be merged in the order of the block", func() {
+			agt.taskConfig.Expansions.Update(map[string]string{"shared": "old", "kept": "old"})
+			first, second := sleepConf(50, false, ""), sleepConf(10, false, "")
+			first.Params["set"] = map[string]string{"first": "1", "shared": "first"}
+			second.Params["set"] = map[string]string{"second": "2", "shared": "second"}
+			So(agt.RunCommands(block("", first, second), true, make(chan bool)), ShouldBeNil)
+			So(agt.taskConfig.Expansions.Get("first"), ShouldEqual, "1")
+			So(agt.taskConfig.Expansions.Get("second"), ShouldEqual, "2")
+			So(agt.taskConfig.Expansions.Get("shared"), ShouldEqual, "second")
+			So(agt.taskConfig.Expansions.Get("kept"), ShouldEqual, "old")
+		})
+
+		Convey("each command should see only its own vars", func() {
+			first, second := sleepConf(10, false, "saw ${target}"), sleepConf(10, false, "saw ${target}")
+			first.Vars = map[string]string{"target": "staging"}
+			second.Vars = map[string]string{"target": "prod"}
+			So(agt.RunCommands(block("", first, second), true, make(chan bool)), ShouldBeNil)
+			So(appender.contains("[#1 'sleep.sleep'] saw staging"), ShouldBeTrue)
+			So(appender.contains("[#2 'sleep.sleep'] saw prod"), ShouldBeTrue)
+		})
+
+		Convey("parallel blocks in functions should be run", func() {
+			agt.taskConfig.Project.Functions = map[string]*model.YAMLCommandSet{
+				"both": {MultiCommand: block("", sleepConf(10, false, "in func"), sleepConf(10, false, ""))},
+			}
+			err := agt.RunCommands([]model.PluginCommandConf{{Function: "both"}}, true, make(chan bool))
+			So(err, ShouldBeNil)
+			So(appender.contains("in func"), ShouldBeTrue)
+		})
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/alert"
 	"github.com/evergreen-ci/evergreen/model/build"
+	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/patch"
 	"github.com/evergreen-ci/evergreen/model/task"
@@ -18,12 +19,19 @@ import (
 	"gopkg.in/mgo.v2/bson"
 )
 
+// RunnerName is the name of the runner that delivers queued alerts.
+const RunnerName = "alerter"
+
 // QueueProcessor handles looping over any unprocessed alerts in the queue and delivers them
 type QueueProcessor struct {
 	config            *evergreen.Settings
 	superUsersConfigs []model.AlertConfig
+	opsConfigs        []model.AlertConfig
 	projectsCache     map[string]*model.ProjectRef
 	render            *render.Render
+
+	// deliverer, if set, delivers e-mail alerts in place of the SMTP deliverer
+	deliverer Deliverer
 }
 
 // Deliverer is an interface which handles the actual delivery of an alert.
@@ -42,12 +50,13 @@ type AlertContext struct {
 	Version      *version.Version
 	Patch        *patch.Patch
 	Host         *host.Host
+	Distro       *distro.Distro
 	FailedTests  []task.TestResult
 	Settings     *evergreen.Settings
 }
 
 func (qp *QueueProcessor) Name() string {
-	return "alerter"
+	return RunnerName
 }
 
 func (qp *QueueProcessor) Description() string {
@@ -69,6 +78,12 @@ func (qp *QueueProcessor) loadAlertContext(a *alert.AlertRequest) (*AlertContext
 			return nil, err
 		}
 	}
+	if len(a.DistroId) > 0 {
+		aCtx.Distro, err = distro.FindOne(distro.ById(a.DistroId))
+		if err != nil {
+			return nil, err
+		}
+	}
 	// Fetch task if there's a task ID present; if we find one, populate build/version IDs from it
 	if len(taskId) > 0 {
 		aCtx.Task, err = task.FindOne(task.ById(taskId))
@@ -171,6 +186,9 @@ func (qp *QueueProcessor) findProject(projectId string) (*model.ProjectRef, erro
 func (qp *QueueProcessor) getDeliverer(alertConf model.AlertConfig) (Deliverer, error) {
 	// TODO email is the only delivery mechanism supported currently.
 	if alertConf.Provider == "email" {
+		if qp.deliverer != nil {
+			return qp.deliverer, nil
+		}
 		return &EmailDeliverer{
 			SMTPSettings{
 				Server:   qp.config.Alerts.SMTP.Server,
@@ -188,7 +206,10 @@ func (qp *QueueProcessor) getDeliverer(alertConf model.AlertConfig) (Deliverer,
 
 func (qp *QueueProcessor) Deliver(req *alert.AlertRequest, ctx *AlertContext) error {
 	var alertConfigs []model.AlertConfig
-	if ctx.ProjectRef != nil {
+	if isOpsTrigger(req.Trigger) {
+		// Infrastructure health alert - use the admin-configured ops channels
+		alertConfigs = qp.opsConfigs
+	} else if ctx.ProjectRef != nil {
 		// Project-specific alert - use alert configs defined on the project
 		// TODO(EVG-223) patch alerts should go to patch owner
 		alertConfigs = ctx.ProjectRef.Alerts[req.Trigger]
@@ -199,13 +220,8 @@ func (qp *QueueProcessor) Deliver(req *alert.AlertRequest, ctx *AlertContext) er
 	}
 
 	for _, alertConfig := range alertConfigs {
-		deliverer, err := qp.getDeliverer(alertConfig)
-		if err != nil {
-			return fmt.Errorf("Failed to get email deliverer: %v", err)
-		}
-		err = deliverer.Deliver(*ctx, alertConfig)
-		if err != nil {
-			return fmt.Errorf("Failed to send alert: %v", err)
+		if err := qp.deliverOne(req, ctx, alertConfig); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -237,6 +253,17 @@ func (qp *QueueProcessor) Run(config *evergreen.Settings) error {
 	}
 	evergreen.Logger.Logf(slogger.INFO, "Super-users config for outgoing alerts is: %#v", qp.superUsersConfigs)
 
+	qp.opsConfigs = qp.superUsersConfigs
+	if len(qp.config.Alerts.Ops.Channels) > 0 {
+		qp.opsConfigs = []model.AlertConfig{}
+		for _, channel := range qp.config.Alerts.Ops.Channels {
+			qp.opsConfigs = append(qp.opsConfigs, model.AlertConfig{
+				Provider: channel.Provider,
+				Settings: bson.M(channel.Settings),
+			})
+		}
+	}
+
 	evergreen.Logger.Logf(slogger.INFO, "Running alert queue processing")
 	for {
 		nextAlert, err := alert.DequeueAlertRequest()
@@ -265,6 +292,17 @@ func (qp *QueueProcessor) Run(config *evergreen.Settings) error {
 		}
 
 	}
+
+	evergreen.Logger.Logf(slogger.INFO, "Delivering pending alert digests")
+	if err = qp.flushDigests(); err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "Failed to deliver alert digests: %v", err)
+		return err
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Delivering alerts held by rate limits")
+	if err = qp.flushHeldAlerts(); err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "Failed to deliver held alerts: %v", err)
+		return err
+	}
 	evergreen.Logger.Logf(slogger.INFO, "Finished alert queue processor run.")
 	return nil
 }
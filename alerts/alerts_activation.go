@@ -1,13 +1,19 @@
 package alerts
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/alert"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/version"
+	"github.com/evergreen-ci/evergreen/repotracker"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -38,17 +44,33 @@ func RunLastRevisionNotFoundTrigger(proj *model.ProjectRef, v *version.Version)
 	return storeTriggerBookkeeping(ctx, []Trigger{trigger})
 }
 
-// RunTaskTriggers queues alerts for any active triggers on the tasks's state change.
+// RunTaskFailureTriggers queues alerts for any active triggers on the tasks's state change.
 func RunTaskFailureTriggers(taskId string) error {
+	return runTaskTriggers(taskId, getActiveTaskFailureTriggers)
+}
+
+// RunTaskDurationTriggers queues alerts for any active triggers on a task that ran longer
+// than expected or timed out.
+func RunTaskDurationTriggers(taskId string) error {
+	return runTaskTriggers(taskId, func(ctx triggerContext) ([]Trigger, error) {
+		return getActiveTriggers(ctx, AvailableTaskDurationTriggers)
+	})
+}
+
+// runTaskTriggers queues alerts for the triggers that getTriggers returns as active for the task.
+func runTaskTriggers(taskId string, getTriggers func(triggerContext) ([]Trigger, error)) error {
 	t, err := task.FindOne(task.ById(taskId))
 	if err != nil {
 		return err
 	}
+	if t == nil {
+		return fmt.Errorf("task %v not found", taskId)
+	}
 	ctx, err := getTaskTriggerContext(t)
 	if err != nil {
 		return err
 	}
-	activeTriggers, err := getActiveTaskFailureTriggers(*ctx)
+	activeTriggers, err := getTriggers(*ctx)
 	if err != nil {
 		return err
 	}
@@ -126,9 +148,131 @@ func RunSpawnWarningTriggers(host *host.Host) error {
 	return nil
 }
 
+// RunOpsTriggers evaluates the infrastructure health triggers against the latest scheduler
+// stats and host events, and queues alerts for the admins for any that are active.
+func RunOpsTriggers(settings *evergreen.Settings) error {
+	allStats, err := getDistroOpsStats(settings)
+	if err != nil {
+		return err
+	}
+	for _, stats := range allStats {
+		ctx := triggerContext{settings: settings, ops: stats}
+		for _, trigger := range DistroOpsTriggers {
+			if err = runOpsTrigger(ctx, trigger); err != nil {
+				return err
+			}
+		}
+	}
+
+	runtime, err := model.FindProcessRuntime(repotracker.RunnerName)
+	if err != nil {
+		return err
+	}
+	ctx := triggerContext{settings: settings}
+	if runtime != nil {
+		ctx.ops.repotrackerLastRun = runtime.FinishedAt
+	}
+	return runOpsTrigger(ctx, RepotrackerStuck{})
+}
+
+// runOpsTrigger queues an ops alert if the trigger is active for the given context.
+func runOpsTrigger(ctx triggerContext, trigger OpsTrigger) error {
+	shouldExec, err := trigger.ShouldExecute(ctx)
+	if err != nil {
+		return err
+	}
+	if !shouldExec {
+		return nil
+	}
+	err = alert.EnqueueAlertRequest(&alert.AlertRequest{
+		Id:        bson.NewObjectId(),
+		Trigger:   trigger.Id(),
+		DistroId:  ctx.ops.distroId,
+		Display:   trigger.Describe(ctx),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return storeTriggerBookkeeping(ctx, []Trigger{trigger})
+}
+
+// getDistroOpsStats gathers the queue wait, provisioning and spawn error stats for each
+// distro over the ops window.
+func getDistroOpsStats(settings *evergreen.Settings) ([]opsStats, error) {
+	distros, err := distro.Find(distro.All)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().Add(-opsWindow(settings))
+
+	statsByDistro := map[string]*opsStats{}
+	for _, d := range distros {
+		stats := &opsStats{distroId: d.Id}
+		statsByDistro[d.Id] = stats
+
+		// only trust queue stats the scheduler recorded within the window
+		schedulerEvents, err := event.Find(event.RecentSchedulerEvents(d.Id, 1))
+		if err != nil {
+			return nil, err
+		}
+		if len(schedulerEvents) > 0 && !schedulerEvents[0].Timestamp.Before(since) {
+			if data, ok := schedulerEvents[0].Data.Data.(*event.SchedulerEventData); ok {
+				stats.maxQueueWait = data.TaskQueueInfo.MaxWaitTime
+			}
+		}
+
+		spawnErrors, err := event.Find(event.DistroEventsOfTypeSince(d.Id, event.EventDistroSpawnFailed, since))
+		if err != nil {
+			return nil, err
+		}
+		stats.spawnErrors = len(spawnErrors)
+	}
+
+	provisionEvents, err := event.Find(event.HostEventsOfTypesSince(
+		[]string{event.EventHostProvisioned, event.EventHostProvisionFailed}, since))
+	if err != nil {
+		return nil, err
+	}
+	hostIds := []string{}
+	for _, e := range provisionEvents {
+		hostIds = append(hostIds, e.ResourceId)
+	}
+	hosts, err := host.Find(host.ByIds(hostIds))
+	if err != nil {
+		return nil, err
+	}
+	distroByHost := map[string]string{}
+	for _, h := range hosts {
+		distroByHost[h.Id] = h.Distro.Id
+	}
+	for _, e := range provisionEvents {
+		stats, ok := statsByDistro[distroByHost[e.ResourceId]]
+		if !ok {
+			continue
+		}
+		if e.EventType == event.EventHostProvisionFailed {
+			stats.provisionFailed++
+		} else {
+			stats.provisioned++
+		}
+	}
+
+	allStats := make([]opsStats, 0, len(distros))
+	for _, d := range distros {
+		allStats = append(allStats, *statsByDistro[d.Id])
+	}
+	return allStats, nil
+}
+
 func getTaskTriggerContext(t *task.Task) (*triggerContext, error) {
 	ctx := triggerContext{task: t}
-	t, err := task.FindOne(task.ByBeforeRevisionWithStatuses(t.RevisionOrderNumber, task.CompletedStatuses, t.BuildVariant,
+	projectRef, err := model.FindOneProjectRef(t.Project)
+	if err != nil {
+		return nil, err
+	}
+	ctx.projectRef = projectRef
+	t, err = task.FindOne(task.ByBeforeRevisionWithStatuses(t.RevisionOrderNumber, task.CompletedStatuses, t.BuildVariant,
 		t.DisplayName, t.Project).
 		Sort([]string{"-" + task.RevisionOrderNumberKey}))
 	if err != nil {
@@ -143,12 +287,39 @@ func getTaskTriggerContext(t *task.Task) (*triggerContext, error) {
 // getActiveTaskTriggers returns a list of the triggers that should be executed for the given task,
 // by testing the result of each one's ShouldExecute method.
 func getActiveTaskFailureTriggers(ctx triggerContext) ([]Trigger, error) {
+	triggers, err := getActiveTriggers(ctx, AvailableTaskFailTriggers)
+	if err != nil {
+		return nil, err
+	}
+
+	// a task that newly timed out is alerted by the timeout trigger instead of the
+	// generic failure triggers, if the project is alerted of timeouts
+	if ctx.projectRef == nil || len(ctx.projectRef.Alerts[alertrecord.TaskTimeoutTransition]) == 0 {
+		return triggers, nil
+	}
+	timedOut, err := TaskTimeoutTransition{}.ShouldExecute(ctx)
+	if err != nil || !timedOut {
+		return triggers, err
+	}
+	filtered := []Trigger{}
+	for _, trigger := range triggers {
+		if trigger.Id() == alertrecord.TaskFailedId || trigger.Id() == alertrecord.TaskFailTransitionId {
+			continue
+		}
+		filtered = append(filtered, trigger)
+	}
+	return filtered, nil
+}
+
+// getActiveTriggers returns the subset of the given task triggers that should be executed
+// for the task in the context.
+func getActiveTriggers(ctx triggerContext, triggers []Trigger) ([]Trigger, error) {
 	if ctx.task == nil {
 		return nil, nil
 	}
 
 	activeTriggers := []Trigger{}
-	for _, trigger := range AvailableTaskFailTriggers {
+	for _, trigger := range triggers {
 		shouldExec, err := trigger.ShouldExecute(ctx)
 		if err != nil {
 			return nil, err
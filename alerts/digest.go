@@ -0,0 +1,222 @@
+package alerts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/alert"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
+	"github.com/evergreen-ci/evergreen/model/version"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DigestDeliverer is implemented by Deliverers that can summarize several alerts
+// for the same recipient and version in a single notification.
+type DigestDeliverer interface {
+	DeliverDigest(DigestContext, model.AlertConfig) error
+}
+
+// DigestContext is the set of alerts that are delivered together to one recipient
+// for one version.
+type DigestContext struct {
+	Recipient  string
+	ProjectRef *model.ProjectRef
+	Version    *version.Version
+	Alerts     []AlertContext
+	Settings   *evergreen.Settings
+}
+
+// canDigest returns true if the alert may be folded into a digest for the given
+// alert config. Only task alerts within a version sent by e-mail are digested.
+func canDigest(ctx *AlertContext, alertConf model.AlertConfig) bool {
+	if alertConf.Provider != "email" || ctx.Task == nil || ctx.Version == nil {
+		return false
+	}
+	_, err := getEmailRecipient(alertConf)
+	return err == nil
+}
+
+// underRateLimit returns true if another alert may be sent to the recipient
+// without exceeding the configured hourly limit.
+func (qp *QueueProcessor) underRateLimit(rcpt string) (bool, error) {
+	limit := qp.config.Alerts.RecipientHourlyLimit
+	if limit <= 0 {
+		return true, nil
+	}
+	sent, err := alertrecord.CountSentToRecipient(rcpt, time.Now().Add(-time.Hour))
+	if err != nil {
+		return false, err
+	}
+	return sent < limit, nil
+}
+
+// deliverOne sends a single alert using the given config, or holds it in a digest if
+// digests are enabled or the recipient has hit their rate limit.
+func (qp *QueueProcessor) deliverOne(req *alert.AlertRequest, ctx *AlertContext, alertConf model.AlertConfig) error {
+	rcpt, rcptErr := getEmailRecipient(alertConf)
+	if rcptErr == nil {
+		underLimit, err := qp.underRateLimit(rcpt)
+		if err != nil {
+			return err
+		}
+		if canDigest(ctx, alertConf) && (qp.config.Alerts.DigestWindowMinutes > 0 || !underLimit) {
+			evergreen.Logger.Logf(slogger.DEBUG, "Adding alert %v to digest for %v on version %v",
+				req.Id.Hex(), rcpt, ctx.Version.Id)
+			return alertrecord.AddToDigest(rcpt, ctx.Version.Identifier, ctx.Version.Id, req.Id)
+		}
+		if !underLimit {
+			evergreen.Logger.Logf(slogger.WARN, "Holding alert %v: rate limit reached for %v",
+				req.Id.Hex(), rcpt)
+			return alertrecord.HoldAlert(rcpt, req.Id)
+		}
+	}
+
+	deliverer, err := qp.getDeliverer(alertConf)
+	if err != nil {
+		return fmt.Errorf("Failed to get email deliverer: %v", err)
+	}
+	err = deliverer.Deliver(*ctx, alertConf)
+	if err != nil {
+		return fmt.Errorf("Failed to send alert: %v", err)
+	}
+	if rcptErr == nil {
+		return alertrecord.RecordSent(rcpt, time.Now())
+	}
+	return nil
+}
+
+// loadDigestContext fetches the alert context for each alert request in the digest,
+// skipping duplicate alerts for the same task execution raised by different triggers.
+func (qp *QueueProcessor) loadDigestContext(d *alertrecord.Digest) (*DigestContext, error) {
+	requests, err := alert.Find(alert.ByIds(d.AlertRequestIds))
+	if err != nil {
+		return nil, err
+	}
+	digestCtx := &DigestContext{Recipient: d.Recipient, Settings: qp.config}
+	seen := map[string]bool{}
+	for i := range requests {
+		key := fmt.Sprintf("%s_%v", requests[i].TaskId, requests[i].Execution)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		aCtx, err := qp.loadAlertContext(&requests[i])
+		if err != nil {
+			return nil, err
+		}
+		if aCtx.Task == nil {
+			continue
+		}
+		if digestCtx.ProjectRef == nil {
+			digestCtx.ProjectRef = aCtx.ProjectRef
+		}
+		if digestCtx.Version == nil {
+			digestCtx.Version = aCtx.Version
+		}
+		digestCtx.Alerts = append(digestCtx.Alerts, *aCtx)
+	}
+	return digestCtx, nil
+}
+
+// flushDigests delivers every pending digest whose window has elapsed. Digests for
+// recipients that have hit their rate limit are held until a later run.
+func (qp *QueueProcessor) flushDigests() error {
+	window := time.Duration(qp.config.Alerts.DigestWindowMinutes) * time.Minute
+	digests, err := alertrecord.FindDigests(alertrecord.ByDigestCreatedBefore(time.Now().Add(-window)))
+	if err != nil {
+		return err
+	}
+
+	for i := range digests {
+		d := &digests[i]
+		underLimit, err := qp.underRateLimit(d.Recipient)
+		if err != nil {
+			return err
+		}
+		if !underLimit {
+			evergreen.Logger.Logf(slogger.INFO, "Holding digest for %v on version %v: rate limit reached",
+				d.Recipient, d.VersionId)
+			continue
+		}
+
+		digestCtx, err := qp.loadDigestContext(d)
+		if err != nil {
+			return err
+		}
+		if len(digestCtx.Alerts) > 0 {
+			alertConf := model.AlertConfig{Provider: "email", Settings: bson.M{"recipient": d.Recipient}}
+			deliverer, err := qp.getDeliverer(alertConf)
+			if err != nil {
+				return err
+			}
+			digestDeliverer, ok := deliverer.(DigestDeliverer)
+			if !ok {
+				return fmt.Errorf("provider %v does not support digests", alertConf.Provider)
+			}
+			if err = digestDeliverer.DeliverDigest(*digestCtx, alertConf); err != nil {
+				evergreen.Logger.Logf(slogger.ERROR, "Got error delivering digest to %v: %v", d.Recipient, err)
+				continue
+			}
+			if err = alertrecord.RecordSent(d.Recipient, time.Now()); err != nil {
+				return err
+			}
+		}
+		// alerts added to the digest after it was loaded are left for the next run
+		if err = d.RemoveAlerts(d.AlertRequestIds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flushHeldAlerts delivers the alerts that were held because their recipient had
+// hit their rate limit, for every recipient that is now under it.
+func (qp *QueueProcessor) flushHeldAlerts() error {
+	held, err := alertrecord.FindHeldAlerts(alertrecord.AllHeldAlerts)
+	if err != nil {
+		return err
+	}
+
+	for i := range held {
+		h := &held[i]
+		underLimit, err := qp.underRateLimit(h.Recipient)
+		if err != nil {
+			return err
+		}
+		if !underLimit {
+			continue
+		}
+
+		requests, err := alert.Find(alert.ByIds([]bson.ObjectId{h.AlertRequestId}))
+		if err != nil {
+			return err
+		}
+		if len(requests) > 0 {
+			ctx, err := qp.loadAlertContext(&requests[0])
+			if err != nil {
+				return err
+			}
+			alertConf := model.AlertConfig{Provider: "email", Settings: bson.M{"recipient": h.Recipient}}
+			deliverer, err := qp.getDeliverer(alertConf)
+			if err != nil {
+				return err
+			}
+			if err = deliverer.Deliver(*ctx, alertConf); err != nil {
+				evergreen.Logger.Logf(slogger.ERROR, "Got error delivering held alert %v to %v: %v",
+					h.AlertRequestId.Hex(), h.Recipient, err)
+				continue
+			}
+			if err = alertrecord.RecordSent(h.Recipient, time.Now()); err != nil {
+				return err
+			}
+		}
+		if err = h.Remove(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/alert"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/model/version"
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// mockDeliverer records the alerts and digests it is asked to deliver.
+type mockDeliverer struct {
+	alerts  map[string][]AlertContext
+	digests map[string][]DigestContext
+}
+
+func newMockDeliverer() *mockDeliverer {
+	return &mockDeliverer{
+		alerts:  map[string][]AlertContext{},
+		digests: map[string][]DigestContext{},
+	}
+}
+
+func (md *mockDeliverer) Deliver(ctx AlertContext, alertConf model.AlertConfig) error {
+	rcpt, err := getEmailRecipient(alertConf)
+	if err != nil {
+		return err
+	}
+	md.alerts[rcpt] = append(md.alerts[rcpt], ctx)
+	return nil
+}
+
+func (md *mockDeliverer) DeliverDigest(ctx DigestContext, alertConf model.AlertConfig) error {
+	rcpt, err := getEmailRecipient(alertConf)
+	if err != nil {
+		return err
+	}
+	md.digests[rcpt] = append(md.digests[rcpt], ctx)
+	return nil
+}
+
+func emailTo(rcpt string) model.AlertConfig {
+	return model.AlertConfig{Provider: "email", Settings: bson.M{"recipient": rcpt}}
+}
+
+// insertDigestFixtures inserts a version with two failed tasks and returns an
+// alert request for each task.
+func insertDigestFixtures() []*alert.AlertRequest {
+	So(db.ClearCollections(task.Collection, version.Collection, alert.Collection,
+		alertrecord.Collection, alertrecord.DigestCollection, alertrecord.HeldAlertCollection), ShouldBeNil)
+	v := &version.Version{Id: "v1", Identifier: "project1"}
+	So(v.Insert(), ShouldBeNil)
+
+	requests := []*alert.AlertRequest{}
+	for _, id := range []string{"t1", "t2"} {
+		t := &task.Task{
+			Id:           id,
+			DisplayName:  id,
+			Status:       evergreen.TaskFailed,
+			BuildVariant: "bv1",
+			Version:      v.Id,
+			Project:      v.Identifier,
+		}
+		So(t.Insert(), ShouldBeNil)
+		req := &alert.AlertRequest{
+			Id:        bson.NewObjectId(),
+			Trigger:   alertrecord.TaskFailedId,
+			TaskId:    t.Id,
+			Display:   t.DisplayName,
+			CreatedAt: time.Now(),
+		}
+		So(alert.EnqueueAlertRequest(req), ShouldBeNil)
+		requests = append(requests, req)
+	}
+	return requests
+}
+
+// recordSends stores n delivery records for the recipient at the given time.
+func recordSends(rcpt string, n int, sentAt time.Time) {
+	for i := 0; i < n; i++ {
+		So(alertrecord.RecordSent(rcpt, sentAt), ShouldBeNil)
+	}
+}
+
+func TestDeliverOne(t *testing.T) {
+	Convey("With a queue processor limited to two alerts per recipient an hour", t, func() {
+		requests := insertDigestFixtures()
+		deliverer := newMockDeliverer()
+		settings := &evergreen.Settings{}
+		settings.Alerts.RecipientHourlyLimit = 2
+		qp := &QueueProcessor{config: settings, deliverer: deliverer}
+		ctx, err := qp.loadAlertContext(requests[0])
+		So(err, ShouldBeNil)
+
+		Convey("an alert to a recipient under the limit should be sent and counted", func() {
+			So(qp.deliverOne(requests[0], ctx, emailTo("a@b.com")), ShouldBeNil)
+			So(len(deliverer.alerts["a@b.com"]), ShouldEqual, 1)
+			sent, err := alertrecord.CountSentToRecipient("a@b.com", time.Now().Add(-time.Hour))
+			So(err, ShouldBeNil)
+			So(sent, ShouldEqual, 1)
+		})
+		Convey("a task alert to a recipient over the limit should be added to a digest", func() {
+			recordSends("a@b.com", 2, time.Now())
+			So(qp.deliverOne(requests[0], ctx, emailTo("a@b.com")), ShouldBeNil)
+			So(deliverer.alerts["a@b.com"], ShouldBeEmpty)
+
+			digests, err := alertrecord.FindDigests(alertrecord.ByDigestCreatedBefore(time.Now()))
+			So(err, ShouldBeNil)
+			So(len(digests), ShouldEqual, 1)
+			So(digests[0].Recipient, ShouldEqual, "a@b.com")
+			So(digests[0].AlertRequestIds, ShouldResemble, []bson.ObjectId{requests[0].Id})
+		})
+		Convey("an alert outside a version to a recipient over the limit should be held", func() {
+			recordSends("a@b.com", 2, time.Now())
+			ctx.Version = nil
+			So(qp.deliverOne(requests[0], ctx, emailTo("a@b.com")), ShouldBeNil)
+			So(deliverer.alerts["a@b.com"], ShouldBeEmpty)
+
+			held, err := alertrecord.FindHeldAlerts(alertrecord.AllHeldAlerts)
+			So(err, ShouldBeNil)
+			So(len(held), ShouldEqual, 1)
+			So(held[0].Recipient, ShouldEqual, "a@b.com")
+			So(held[0].AlertRequestId, ShouldEqual, requests[0].Id)
+		})
+		Convey("sends older than an hour should not count towards the limit", func() {
+			recordSends("a@b.com", 2, time.Now().Add(-2*time.Hour))
+			So(qp.deliverOne(requests[0], ctx, emailTo("a@b.com")), ShouldBeNil)
+			So(len(deliverer.alerts["a@b.com"]), ShouldEqual, 1)
+		})
+		Convey("with digests enabled, a task alert under the limit should be added to a digest", func() {
+			settings.Alerts.DigestWindowMinutes = 10
+			So(qp.deliverOne(requests[0], ctx, emailTo("a@b.com")), ShouldBeNil)
+			So(deliverer.alerts["a@b.com"], ShouldBeEmpty)
+			digests, err := alertrecord.FindDigests(alertrecord.ByDigestCreatedBefore(time.Now()))
+			So(err, ShouldBeNil)
+			So(len(digests), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestFlushHeldAlerts(t *testing.T) {
+	Convey("With an alert held for a recipient over their limit", t, func() {
+		requests := insertDigestFixtures()
+		deliverer := newMockDeliverer()
+		settings := &evergreen.Settings{}
+		settings.Alerts.RecipientHourlyLimit = 2
+		qp := &QueueProcessor{config: settings, deliverer: deliverer}
+		So(alertrecord.HoldAlert("a@b.com", requests[0].Id), ShouldBeNil)
+
+		Convey("the alert should stay held while the recipient is over the limit", func() {
+			recordSends("a@b.com", 2, time.Now().Add(-30*time.Minute))
+			So(qp.flushHeldAlerts(), ShouldBeNil)
+			So(deliverer.alerts["a@b.com"], ShouldBeEmpty)
+			held, err := alertrecord.FindHeldAlerts(alertrecord.AllHeldAlerts)
+			So(err, ShouldBeNil)
+			So(len(held), ShouldEqual, 1)
+		})
+		Convey("the alert should be sent once the sends fall out of the window", func() {
+			recordSends("a@b.com", 2, time.Now().Add(-61*time.Minute))
+			So(qp.flushHeldAlerts(), ShouldBeNil)
+			So(len(deliverer.alerts["a@b.com"]), ShouldEqual, 1)
+			So(deliverer.alerts["a@b.com"][0].Task.Id, ShouldEqual, "t1")
+			held, err := alertrecord.FindHeldAlerts(alertrecord.AllHeldAlerts)
+			So(err, ShouldBeNil)
+			So(held, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestFlushDigests(t *testing.T) {
+	Convey("With digests of alerts for two recipients", t, func() {
+		requests := insertDigestFixtures()
+		deliverer := newMockDeliverer()
+		settings := &evergreen.Settings{}
+		settings.Alerts.DigestWindowMinutes = 10
+		settings.Alerts.RecipientHourlyLimit = 2
+		qp := &QueueProcessor{config: settings, deliverer: deliverer}
+		for _, req := range requests {
+			ctx, err := qp.loadAlertContext(req)
+			So(err, ShouldBeNil)
+			So(qp.deliverOne(req, ctx, emailTo("a@b.com")), ShouldBeNil)
+		}
+		ctx, err := qp.loadAlertContext(requests[1])
+		So(err, ShouldBeNil)
+		So(qp.deliverOne(requests[1], ctx, emailTo("c@d.com")), ShouldBeNil)
+
+		closeWindow := func() {
+			_, err := db.UpdateAll(alertrecord.DigestCollection, bson.M{},
+				bson.M{"$set": bson.M{alertrecord.DigestCreatedAtKey: time.Now().Add(-11 * time.Minute)}})
+			So(err, ShouldBeNil)
+		}
+
+		Convey("nothing should be sent before the window passes", func() {
+			So(qp.flushDigests(), ShouldBeNil)
+			So(deliverer.digests, ShouldBeEmpty)
+			So(deliverer.alerts, ShouldBeEmpty)
+		})
+		Convey("each recipient should get one digest of their alerts once the window passes", func() {
+			closeWindow()
+			So(qp.flushDigests(), ShouldBeNil)
+			So(deliverer.alerts, ShouldBeEmpty)
+
+			So(len(deliverer.digests["a@b.com"]), ShouldEqual, 1)
+			digest := deliverer.digests["a@b.com"][0]
+			So(digest.Version.Id, ShouldEqual, "v1")
+			So(len(digest.Alerts), ShouldEqual, 2)
+
+			So(len(deliverer.digests["c@d.com"]), ShouldEqual, 1)
+			digest = deliverer.digests["c@d.com"][0]
+			So(len(digest.Alerts), ShouldEqual, 1)
+			So(digest.Alerts[0].Task.Id, ShouldEqual, "t2")
+
+			remaining, err := alertrecord.FindDigests(alertrecord.ByDigestCreatedBefore(time.Now()))
+			So(err, ShouldBeNil)
+			So(remaining, ShouldBeEmpty)
+			sent, err := alertrecord.CountSentToRecipient("a@b.com", time.Now().Add(-time.Hour))
+			So(err, ShouldBeNil)
+			So(sent, ShouldEqual, 1)
+		})
+		Convey("a digest for a recipient over the limit should be kept for a later run", func() {
+			recordSends("a@b.com", 2, time.Now())
+			closeWindow()
+			So(qp.flushDigests(), ShouldBeNil)
+			So(deliverer.digests["a@b.com"], ShouldBeEmpty)
+			So(len(deliverer.digests["c@d.com"]), ShouldEqual, 1)
+
+			remaining, err := alertrecord.FindDigests(alertrecord.ByDigestCreatedBefore(time.Now()))
+			So(err, ShouldBeNil)
+			So(len(remaining), ShouldEqual, 1)
+			So(remaining[0].Recipient, ShouldEqual, "a@b.com")
+		})
+	})
+}
@@ -0,0 +1,149 @@
+package alerts
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
+	"github.com/evergreen-ci/evergreen/model/task"
+)
+
+// DefaultDurationFactor is the multiple of a task's expected duration it must exceed to
+// be considered a regression when its project has not configured any thresholds.
+const DefaultDurationFactor = 2.0
+
+/* Task duration trigger implementations */
+
+// TaskDurationExceeded is a trigger that queues an alert when a task takes longer than its
+// historical expected duration by the thresholds configured on the project, and the previous
+// completion of the task on the same variant did not.
+type TaskDurationExceeded struct{}
+
+func (trig TaskDurationExceeded) Id() string { return alertrecord.TaskDurationExceeded }
+func (trig TaskDurationExceeded) Display() string {
+	return "a task takes much longer than its expected duration"
+}
+func (trig TaskDurationExceeded) CreateAlertRecord(ctx triggerContext) *alertrecord.AlertRecord {
+	return newAlertRecord(ctx, alertrecord.TaskDurationExceeded)
+}
+
+func (trig TaskDurationExceeded) ShouldExecute(ctx triggerContext) (bool, error) {
+	if !isFinished(ctx.task) || ctx.task.Details.TimedOut {
+		return false, nil
+	}
+	settings := durationAlertSettings(ctx)
+	expected, err := expectedDuration(ctx.task)
+	if err != nil {
+		return false, err
+	}
+	if !exceedsExpectedDuration(ctx.task.TimeTaken, expected, settings) {
+		return false, nil
+	}
+
+	// only alert when the regression starts, not on every slow run after it.
+	// The previous run is judged the same way as this one.
+	if ctx.previousCompleted != nil && !ctx.previousCompleted.Details.TimedOut {
+		previousExpected, err := expectedDuration(ctx.previousCompleted)
+		if err != nil {
+			return false, err
+		}
+		if exceedsExpectedDuration(ctx.previousCompleted.TimeTaken, previousExpected, settings) {
+			return false, nil
+		}
+	}
+
+	rec, err := alertrecord.FindOne(alertrecord.ByTaskInVersion(alertrecord.TaskDurationExceeded,
+		ctx.task.Version, ctx.task.BuildVariant, ctx.task.DisplayName))
+	if err != nil {
+		return false, err
+	}
+	return rec == nil, nil
+}
+
+// TaskTimeoutTransition is a trigger that queues an alert when a task times out and the
+// previous completion of this task on the same variant did not, or the task has never run before.
+// Like TaskFailTransition, it alerts at most once for each previously passing run.
+type TaskTimeoutTransition struct{}
+
+func (trig TaskTimeoutTransition) Id() string { return alertrecord.TaskTimeoutTransition }
+func (trig TaskTimeoutTransition) Display() string {
+	return "a task that previously finished in time times out"
+}
+
+func (trig TaskTimeoutTransition) ShouldExecute(ctx triggerContext) (bool, error) {
+	if ctx.task.Status != evergreen.TaskFailed || !ctx.task.Details.TimedOut {
+		return false, nil
+	}
+	if ctx.previousCompleted == nil {
+		return true, nil
+	}
+	if ctx.previousCompleted.Details.TimedOut {
+		return false, nil
+	}
+	q := alertrecord.ByLastTimeoutTransition(ctx.task.DisplayName, ctx.task.BuildVariant, ctx.task.Project)
+	lastAlerted, err := alertrecord.FindOne(q)
+	if err != nil {
+		return false, err
+	}
+	return lastAlerted == nil || lastAlerted.RevisionOrderNumber < ctx.previousCompleted.RevisionOrderNumber, nil
+}
+
+func (trig TaskTimeoutTransition) CreateAlertRecord(ctx triggerContext) *alertrecord.AlertRecord {
+	rec := newAlertRecord(ctx, alertrecord.TaskTimeoutTransition)
+	// As with fail transitions, store the revision order number of the previous task that
+	// finished in time rather than the one that timed out.
+	rec.RevisionOrderNumber = -1
+	if ctx.previousCompleted != nil {
+		rec.RevisionOrderNumber = ctx.previousCompleted.RevisionOrderNumber
+	}
+	return rec
+}
+
+// isFinished returns true if the task ran to completion, whether it passed or failed.
+func isFinished(t *task.Task) bool {
+	return t.Status == evergreen.TaskSucceeded || t.Status == evergreen.TaskFailed
+}
+
+// durationAlertSettings returns the duration thresholds configured for the task's project.
+func durationAlertSettings(ctx triggerContext) model.DurationAlertSettings {
+	if ctx.projectRef == nil {
+		return model.DurationAlertSettings{}
+	}
+	return ctx.projectRef.DurationAlerts
+}
+
+// expectedDuration returns the historical expected duration of the task. The duration stored on
+// the task by the scheduler is used if present; otherwise it is computed from recent runs.
+// It returns zero if there is no history for the task.
+func expectedDuration(t *task.Task) (time.Duration, error) {
+	if t.ExpectedDuration > model.DefaultTaskDuration {
+		return t.ExpectedDuration, nil
+	}
+	durations, err := task.ExpectedTaskDuration(t.Project, t.BuildVariant, model.TaskCompletionEstimateWindow)
+	if err != nil {
+		return 0, err
+	}
+	return durations[t.DisplayName], nil
+}
+
+// exceedsExpectedDuration returns true if the time taken exceeds the expected duration by
+// every threshold set in the settings. If no thresholds are set, DefaultDurationFactor is used.
+// Unknown expected durations never count as exceeded.
+func exceedsExpectedDuration(taken, expected time.Duration, settings model.DurationAlertSettings) bool {
+	// the scheduler stores DefaultTaskDuration for tasks it has no history for
+	if expected <= model.DefaultTaskDuration {
+		return false
+	}
+	factor := settings.Factor
+	if factor <= 0 && settings.ThresholdSecs <= 0 {
+		factor = DefaultDurationFactor
+	}
+	if factor > 0 && float64(taken) <= factor*float64(expected) {
+		return false
+	}
+	if settings.ThresholdSecs > 0 && taken-expected <= time.Duration(settings.ThresholdSecs)*time.Second {
+		return false
+	}
+	return true
+}
@@ -34,24 +34,52 @@ type EmailDeliverer struct {
 }
 
 func (es *EmailDeliverer) Deliver(alertCtx AlertContext, alertConf model.AlertConfig) error {
-	rcptRaw, ok := alertConf.Settings["recipient"]
-	if !ok {
-		return fmt.Errorf("missing email address")
-	}
-	evergreen.Logger.Logf(slogger.INFO, "Sending email to %v", rcptRaw)
-
-	var rcpt string
-	if rcpt, ok = rcptRaw.(string); !ok {
-		return fmt.Errorf("email address must be a string")
+	rcpt, err := getEmailRecipient(alertConf)
+	if err != nil {
+		return err
 	}
+	evergreen.Logger.Logf(slogger.INFO, "Sending email to %v", rcpt)
 
-	var err error
 	subject := getSubject(alertCtx)
 	body, err := es.getBody(alertCtx)
 	if err != nil {
 		return err
 	}
+	return es.send(rcpt, subject, body)
+}
+
+// DeliverDigest sends a single e-mail summarizing all of the alerts in the digest.
+func (es *EmailDeliverer) DeliverDigest(digestCtx DigestContext, alertConf model.AlertConfig) error {
+	rcpt, err := getEmailRecipient(alertConf)
+	if err != nil {
+		return err
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Sending digest of %v alerts to %v", len(digestCtx.Alerts), rcpt)
+
+	out := &bytes.Buffer{}
+	err = es.render.HTML(out, digestCtx, "content", "email/digest.html")
+	if err != nil {
+		return err
+	}
+	return es.send(rcpt, digestSubject(digestCtx), out.String())
+}
 
+// getEmailRecipient returns the e-mail address stored in the given alert config.
+func getEmailRecipient(alertConf model.AlertConfig) (string, error) {
+	rcptRaw, ok := alertConf.Settings["recipient"]
+	if !ok {
+		return "", fmt.Errorf("missing email address")
+	}
+	rcpt, ok := rcptRaw.(string)
+	if !ok {
+		return "", fmt.Errorf("email address must be a string")
+	}
+	return rcpt, nil
+}
+
+// send delivers an HTML e-mail with the given subject and body to the recipient.
+func (es *EmailDeliverer) send(rcpt, subject, body string) error {
+	var err error
 	var c *smtp.Client
 	if es.UseSSL {
 		tlsCon, err := tls.Dial("tcp", fmt.Sprintf("%v:%v", es.Server, es.Port), &tls.Config{})
@@ -120,6 +148,9 @@ func (es *EmailDeliverer) Deliver(alertCtx AlertContext, alertConf model.AlertCo
 }
 
 func getTemplate(alertCtx AlertContext) string {
+	if isOpsTrigger(alertCtx.AlertRequest.Trigger) {
+		return "email/ops_alert.html"
+	}
 	switch alertCtx.AlertRequest.Trigger {
 	case alertrecord.SpawnHostTwoHourWarning:
 		fallthrough
@@ -183,8 +214,62 @@ func taskFailureSubject(ctx AlertContext) string {
 	return subj.String()
 }
 
+// taskDurationSubject creates an email subject for a task duration regression in the style of
+//  Task Duration Regression: Task_name on Variant took 25m0s (expected 10m0s) // ProjectName @ githash
+// based on the given AlertContext.
+func taskDurationSubject(ctx AlertContext) string {
+	subj := &bytes.Buffer{}
+	fmt.Fprintf(subj, "Task Duration Regression: %s on %s took %v ",
+		ctx.Task.DisplayName, ctx.Build.DisplayName, ctx.Task.TimeTaken)
+	if ctx.Task.ExpectedDuration > model.DefaultTaskDuration {
+		fmt.Fprintf(subj, "(expected %v) ", ctx.Task.ExpectedDuration)
+	}
+	fmt.Fprintf(subj, "// %s @ %s", ctx.ProjectRef.DisplayName, ctx.Version.Revision[0:8])
+	return subj.String()
+}
+
+// digestSubject creates an email subject for a digest in the style of
+//  Task Alerts: compile on Linux, lint on Windows, +2 more // ProjectName @ githash
+// based on the given DigestContext. Digests can hold duration alerts for tasks that
+// passed, so the subject doesn't call the tasks failures.
+func digestSubject(ctx DigestContext) string {
+	subj := &bytes.Buffer{}
+	tasks := []string{}
+	for _, a := range ctx.Alerts {
+		variant := a.Task.BuildVariant
+		if a.Build != nil {
+			variant = a.Build.DisplayName
+		}
+		tasks = append(tasks, fmt.Sprintf("%s on %s", a.Task.DisplayName, variant))
+	}
+	if len(tasks) == 1 {
+		subj.WriteString("Task Alert: ")
+	} else {
+		subj.WriteString("Task Alerts: ")
+	}
+	if len(tasks) <= 4 {
+		subj.WriteString(strings.Join(tasks, ", "))
+	} else {
+		fmt.Fprintf(subj, "%s, %s, +%v more", tasks[0], tasks[1], len(tasks)-2)
+	}
+
+	projectName := ""
+	if ctx.ProjectRef != nil {
+		projectName = ctx.ProjectRef.DisplayName
+	}
+	revision := ""
+	if ctx.Version != nil && len(ctx.Version.Revision) >= 8 {
+		revision = ctx.Version.Revision[0:8]
+	}
+	fmt.Fprintf(subj, " // %s @ %s", projectName, revision)
+	return subj.String()
+}
+
 // getSubject generates a subject line for an e-mail for the given alert.
 func getSubject(alertCtx AlertContext) string {
+	if isOpsTrigger(alertCtx.AlertRequest.Trigger) {
+		return fmt.Sprintf("Ops Alert: %s", alertCtx.AlertRequest.Display)
+	}
 	switch alertCtx.AlertRequest.Trigger {
 	case alertrecord.FirstVersionFailureId:
 		return fmt.Sprintf("First Task Failure: %s on %s // %s @ %s",
@@ -198,6 +283,14 @@ func getSubject(alertCtx AlertContext) string {
 			alertCtx.ProjectRef.DisplayName,
 			alertCtx.Version.Revision[0:8],
 		)
+	case alertrecord.TaskDurationExceeded:
+		return taskDurationSubject(alertCtx)
+	case alertrecord.TaskTimeoutTransition:
+		return fmt.Sprintf("Task Timeout: %s on %s // %s @ %s",
+			alertCtx.Task.DisplayName,
+			alertCtx.Build.DisplayName,
+			alertCtx.ProjectRef.DisplayName,
+			alertCtx.Version.Revision[0:8])
 	case alertrecord.SpawnHostTwoHourWarning:
 		return fmt.Sprintf("Your %s host (%s) will expire in two hours.",
 			alertCtx.Host.Distro, alertCtx.Host.Id)
@@ -51,6 +51,16 @@ func TestEmailSubject(t *testing.T) {
 				So(subj, ShouldContainSubstring, ProjectName)
 			})
 		})
+		Convey("a task timeout alert should return a timeout subject", func() {
+			ctx.AlertRequest.Trigger = alertrecord.TaskTimeoutTransition
+			ctx.Task.Details.TimedOut = true
+			subj := getSubject(ctx)
+			So(subj, ShouldStartWith, "Task Timeout: ")
+			So(subj, ShouldContainSubstring, TaskName)
+			So(subj, ShouldContainSubstring, BuildName)
+			So(subj, ShouldContainSubstring, VersionRevision[0:8])
+			So(subj, ShouldContainSubstring, ProjectName)
+		})
 		Convey("a task that failed on a system command should return a subject", func() {
 			ctx.Task.Details.Type = model.SystemCommandType
 			subj := getSubject(ctx)
@@ -147,3 +157,43 @@ func TestEmailSubject(t *testing.T) {
 	})
 
 }
+
+func TestDigestSubject(t *testing.T) {
+	Convey("With a digest of failed task alerts", t, func() {
+		newAlert := func(taskName, variant string) AlertContext {
+			return AlertContext{
+				Task:  &task.Task{DisplayName: taskName},
+				Build: &build.Build{DisplayName: variant},
+			}
+		}
+		ctx := DigestContext{
+			ProjectRef: &model.ProjectRef{DisplayName: ProjectName},
+			Version:    &version.Version{Revision: VersionRevision},
+		}
+		Convey("a digest with one alert should name the task and variant", func() {
+			ctx.Alerts = []AlertContext{newAlert(TaskName, BuildName)}
+			subj := digestSubject(ctx)
+			So(subj, ShouldStartWith, "Task Alert: ")
+			So(subj, ShouldContainSubstring, TaskName+" on "+BuildName)
+			So(subj, ShouldContainSubstring, ProjectName)
+			So(subj, ShouldContainSubstring, VersionRevision[0:8])
+			So(subj, ShouldNotContainSubstring, VersionRevision[0:9])
+		})
+		Convey("a digest with many alerts should list two and count the rest", func() {
+			ctx.Alerts = []AlertContext{
+				newAlert("compile", BuildName),
+				newAlert("lint", BuildName),
+				newAlert("unit", BuildName),
+				newAlert("integration", BuildName),
+				newAlert("e2e", BuildName),
+			}
+			subj := digestSubject(ctx)
+			So(subj, ShouldStartWith, "Task Alerts: ")
+			So(subj, ShouldContainSubstring, "compile on "+BuildName)
+			So(subj, ShouldContainSubstring, "lint on "+BuildName)
+			So(subj, ShouldNotContainSubstring, "unit")
+			So(subj, ShouldContainSubstring, "+3 more")
+			So(subj, ShouldContainSubstring, ProjectName)
+		})
+	})
+}
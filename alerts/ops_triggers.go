@@ -0,0 +1,172 @@
+package alerts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
+)
+
+// defaultOpsWindow is used when no ops alert window is configured.
+const defaultOpsWindow = time.Hour
+
+// OpsTrigger is a Trigger for the health of Evergreen's own infrastructure. Ops triggers
+// are delivered to the admin channels rather than to a project's alert configuration.
+type OpsTrigger interface {
+	Trigger
+
+	// Describe returns a human-readable explanation of the condition that was detected,
+	// which is stored on the queued alert.
+	Describe(ctx triggerContext) string
+}
+
+// opsStats holds the measurements that the ops triggers are evaluated against. Stats
+// that do not apply to a distro, like the repotracker's last run, are left unset.
+type opsStats struct {
+	distroId           string
+	maxQueueWait       time.Duration
+	provisioned        int
+	provisionFailed    int
+	spawnErrors        int
+	repotrackerLastRun time.Time
+}
+
+// opsWindow returns the configured window for counting failures and suppressing
+// repeated ops alerts.
+func opsWindow(settings *evergreen.Settings) time.Duration {
+	if settings.Alerts.Ops.WindowMinutes > 0 {
+		return time.Duration(settings.Alerts.Ops.WindowMinutes) * time.Minute
+	}
+	return defaultOpsWindow
+}
+
+// newOpsAlertRecord creates an alert record for an ops trigger, timestamped so that the
+// alert is suppressed for the rest of the ops window.
+func newOpsAlertRecord(ctx triggerContext, alertType string) *alertrecord.AlertRecord {
+	rec := newAlertRecord(ctx, alertType)
+	rec.DistroId = ctx.ops.distroId
+	rec.SentAt = time.Now()
+	return rec
+}
+
+// alertedWithinWindow returns true if the ops trigger already fired for the same distro
+// within the current ops window.
+func alertedWithinWindow(ctx triggerContext, triggerId string) (bool, error) {
+	since := time.Now().Add(-opsWindow(ctx.settings))
+	rec, err := alertrecord.FindOne(alertrecord.ByDistroAlertSince(triggerId, ctx.ops.distroId, since))
+	if err != nil {
+		return false, err
+	}
+	return rec != nil, nil
+}
+
+/* Ops trigger implementations */
+
+// DistroQueueBacklog is a trigger that queues an alert when the oldest task in a distro's
+// queue has been waiting for longer than the configured number of minutes.
+type DistroQueueBacklog struct{}
+
+func (trig DistroQueueBacklog) Id() string { return alertrecord.DistroQueueBacklogId }
+func (trig DistroQueueBacklog) Display() string {
+	return "tasks wait too long in a distro's queue"
+}
+func (trig DistroQueueBacklog) Describe(ctx triggerContext) string {
+	return fmt.Sprintf("The oldest task in the queue for distro '%v' has been waiting for %v",
+		ctx.ops.distroId, ctx.ops.maxQueueWait)
+}
+func (trig DistroQueueBacklog) CreateAlertRecord(ctx triggerContext) *alertrecord.AlertRecord {
+	return newOpsAlertRecord(ctx, alertrecord.DistroQueueBacklogId)
+}
+
+func (trig DistroQueueBacklog) ShouldExecute(ctx triggerContext) (bool, error) {
+	threshold := ctx.settings.Alerts.Ops.QueueWaitMinutes
+	if threshold <= 0 || ctx.ops.maxQueueWait <= time.Duration(threshold)*time.Minute {
+		return false, nil
+	}
+	alerted, err := alertedWithinWindow(ctx, trig.Id())
+	return !alerted, err
+}
+
+// DistroProvisionFailures is a trigger that queues an alert when the fraction of a distro's
+// hosts that failed to provision within the ops window exceeds the configured rate.
+type DistroProvisionFailures struct{}
+
+func (trig DistroProvisionFailures) Id() string { return alertrecord.DistroProvisionFailuresId }
+func (trig DistroProvisionFailures) Display() string {
+	return "hosts for a distro fail to provision"
+}
+func (trig DistroProvisionFailures) Describe(ctx triggerContext) string {
+	return fmt.Sprintf("%v of %v hosts for distro '%v' failed to provision in the last %v",
+		ctx.ops.provisionFailed, ctx.ops.provisioned+ctx.ops.provisionFailed,
+		ctx.ops.distroId, opsWindow(ctx.settings))
+}
+func (trig DistroProvisionFailures) CreateAlertRecord(ctx triggerContext) *alertrecord.AlertRecord {
+	return newOpsAlertRecord(ctx, alertrecord.DistroProvisionFailuresId)
+}
+
+func (trig DistroProvisionFailures) ShouldExecute(ctx triggerContext) (bool, error) {
+	conf := ctx.settings.Alerts.Ops
+	total := ctx.ops.provisioned + ctx.ops.provisionFailed
+	if conf.ProvisionFailureRate <= 0 || total == 0 || total < conf.ProvisionMinHosts {
+		return false, nil
+	}
+	if float64(ctx.ops.provisionFailed)/float64(total) <= conf.ProvisionFailureRate {
+		return false, nil
+	}
+	alerted, err := alertedWithinWindow(ctx, trig.Id())
+	return !alerted, err
+}
+
+// DistroSpawnErrors is a trigger that queues an alert when the cloud provider returned at
+// least the configured number of errors while spawning hosts for a distro within the ops window.
+type DistroSpawnErrors struct{}
+
+func (trig DistroSpawnErrors) Id() string { return alertrecord.DistroSpawnErrorsId }
+func (trig DistroSpawnErrors) Display() string {
+	return "the cloud provider returns errors when spawning hosts"
+}
+func (trig DistroSpawnErrors) Describe(ctx triggerContext) string {
+	return fmt.Sprintf("The cloud provider returned %v errors spawning hosts for distro '%v' in the last %v",
+		ctx.ops.spawnErrors, ctx.ops.distroId, opsWindow(ctx.settings))
+}
+func (trig DistroSpawnErrors) CreateAlertRecord(ctx triggerContext) *alertrecord.AlertRecord {
+	return newOpsAlertRecord(ctx, alertrecord.DistroSpawnErrorsId)
+}
+
+func (trig DistroSpawnErrors) ShouldExecute(ctx triggerContext) (bool, error) {
+	threshold := ctx.settings.Alerts.Ops.SpawnErrorCount
+	if threshold <= 0 || ctx.ops.spawnErrors < threshold {
+		return false, nil
+	}
+	alerted, err := alertedWithinWindow(ctx, trig.Id())
+	return !alerted, err
+}
+
+// RepotrackerStuck is a trigger that queues an alert when the repotracker has not completed
+// a run for longer than the configured number of minutes.
+type RepotrackerStuck struct{}
+
+func (trig RepotrackerStuck) Id() string { return alertrecord.RepotrackerStuckId }
+func (trig RepotrackerStuck) Display() string {
+	return "the repotracker stops completing runs"
+}
+func (trig RepotrackerStuck) Describe(ctx triggerContext) string {
+	if ctx.ops.repotrackerLastRun.IsZero() {
+		return "The repotracker has never completed a run"
+	}
+	return fmt.Sprintf("The repotracker last completed a run at %v",
+		ctx.ops.repotrackerLastRun.Format(time.RFC1123))
+}
+func (trig RepotrackerStuck) CreateAlertRecord(ctx triggerContext) *alertrecord.AlertRecord {
+	return newOpsAlertRecord(ctx, alertrecord.RepotrackerStuckId)
+}
+
+func (trig RepotrackerStuck) ShouldExecute(ctx triggerContext) (bool, error) {
+	threshold := ctx.settings.Alerts.Ops.RepotrackerStuckMinutes
+	if threshold <= 0 || time.Since(ctx.ops.repotrackerLastRun) <= time.Duration(threshold)*time.Minute {
+		return false, nil
+	}
+	alerted, err := alertedWithinWindow(ctx, trig.Id())
+	return !alerted, err
+}
@@ -1,7 +1,7 @@
 package alerts
 
 import (
-	//"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/alertrecord"
 	"github.com/evergreen-ci/evergreen/model/host"
@@ -40,6 +40,8 @@ type triggerContext struct {
 	task              *task.Task
 	previousCompleted *task.Task
 	host              *host.Host
+	settings          *evergreen.Settings
+	ops               opsStats
 }
 
 var (
@@ -52,13 +54,41 @@ var (
 		TaskFailTransition{},
 	}
 
+	// AvailableTaskDurationTriggers is a list of the supported triggers for tasks that run
+	// unexpectedly long, which is also shown in the UI control panel.
+	AvailableTaskDurationTriggers = []Trigger{
+		TaskDurationExceeded{},
+		TaskTimeoutTransition{},
+	}
+
 	AvailableProjectTriggers = []Trigger{
 		LastRevisionNotFound{},
 	}
 
+	// DistroOpsTriggers is a list of the infrastructure health triggers evaluated for each distro.
+	DistroOpsTriggers = []OpsTrigger{
+		DistroQueueBacklog{},
+		DistroProvisionFailures{},
+		DistroSpawnErrors{},
+	}
+
 	SpawnWarningTriggers = []Trigger{SpawnTwoHourWarning{}, SpawnTwelveHourWarning{}}
 )
 
+// isOpsTrigger returns true if the trigger id belongs to an infrastructure health trigger,
+// or to a failure one of Evergreen's processes reported for the admins.
+func isOpsTrigger(triggerId string) bool {
+	if triggerId == (RepotrackerStuck{}).Id() || triggerId == alertrecord.OpsNotificationId {
+		return true
+	}
+	for _, trigger := range DistroOpsTriggers {
+		if trigger.Id() == triggerId {
+			return true
+		}
+	}
+	return false
+}
+
 // newAlertRecord creates an instance of an alert record for the given alert type, populating it
 // with as much data from the triggerContext as possible
 func newAlertRecord(ctx triggerContext, alertType string) *alertrecord.AlertRecord {
@@ -6,10 +6,12 @@ import (
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/alertrecord"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/task"
 	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/mgo.v2/bson"
 )
 
 var (
@@ -179,3 +181,232 @@ func TestSpawnExpireWarningTrigger(t *testing.T) {
 		So(shouldExec, ShouldBeFalse)
 	})
 }
+
+func TestExceedsExpectedDuration(t *testing.T) {
+	Convey("With a task expected to take ten minutes", t, func() {
+		expected := 10 * time.Minute
+		Convey("with no thresholds configured, the default factor should be used", func() {
+			settings := model.DurationAlertSettings{}
+			So(exceedsExpectedDuration(15*time.Minute, expected, settings), ShouldBeFalse)
+			So(exceedsExpectedDuration(21*time.Minute, expected, settings), ShouldBeTrue)
+		})
+		Convey("with only a factor, the factor alone should apply", func() {
+			settings := model.DurationAlertSettings{Factor: 1.5}
+			So(exceedsExpectedDuration(15*time.Minute, expected, settings), ShouldBeFalse)
+			So(exceedsExpectedDuration(16*time.Minute, expected, settings), ShouldBeTrue)
+		})
+		Convey("with only an absolute threshold, the threshold alone should apply", func() {
+			settings := model.DurationAlertSettings{ThresholdSecs: 300}
+			So(exceedsExpectedDuration(15*time.Minute, expected, settings), ShouldBeFalse)
+			So(exceedsExpectedDuration(16*time.Minute, expected, settings), ShouldBeTrue)
+		})
+		Convey("with both set, both thresholds must be exceeded", func() {
+			settings := model.DurationAlertSettings{Factor: 1.2, ThresholdSecs: 600}
+			So(exceedsExpectedDuration(15*time.Minute, expected, settings), ShouldBeFalse)
+			So(exceedsExpectedDuration(21*time.Minute, expected, settings), ShouldBeTrue)
+		})
+		Convey("an unknown expected duration should never be exceeded", func() {
+			So(exceedsExpectedDuration(time.Hour, 0, model.DurationAlertSettings{}), ShouldBeFalse)
+			So(exceedsExpectedDuration(time.Hour, model.DefaultTaskDuration, model.DurationAlertSettings{}), ShouldBeFalse)
+		})
+	})
+}
+
+func TestOpsTriggerThresholds(t *testing.T) {
+	Convey("With ops alert thresholds configured", t, func() {
+		So(db.Clear(alertrecord.Collection), ShouldBeNil)
+		settings := &evergreen.Settings{}
+		settings.Alerts.Ops = evergreen.OpsAlertsConfig{
+			QueueWaitMinutes:        30,
+			ProvisionFailureRate:    0.5,
+			ProvisionMinHosts:       4,
+			SpawnErrorCount:         3,
+			RepotrackerStuckMinutes: 60,
+		}
+		ctx := triggerContext{settings: settings, ops: opsStats{distroId: "d1"}}
+
+		Convey("a short queue wait should not trigger a backlog alert", func() {
+			ctx.ops.maxQueueWait = 20 * time.Minute
+			shouldExec, err := DistroQueueBacklog{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+		Convey("a queue wait at the threshold should not trigger a backlog alert", func() {
+			ctx.ops.maxQueueWait = 30 * time.Minute
+			shouldExec, err := DistroQueueBacklog{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+		Convey("a queue wait above the threshold should trigger a backlog alert", func() {
+			ctx.ops.maxQueueWait = 31 * time.Minute
+			shouldExec, err := DistroQueueBacklog{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeTrue)
+		})
+		Convey("too few provisioned hosts should not trigger a provisioning alert", func() {
+			ctx.ops.provisionFailed = 3
+			shouldExec, err := DistroProvisionFailures{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+		Convey("a low provisioning failure rate should not trigger a provisioning alert", func() {
+			ctx.ops.provisioned = 8
+			ctx.ops.provisionFailed = 2
+			shouldExec, err := DistroProvisionFailures{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+		Convey("a provisioning failure rate at the threshold should not trigger a provisioning alert", func() {
+			ctx.ops.provisioned = 2
+			ctx.ops.provisionFailed = 2
+			shouldExec, err := DistroProvisionFailures{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+		Convey("a provisioning failure rate above the threshold should trigger a provisioning alert", func() {
+			ctx.ops.provisioned = 1
+			ctx.ops.provisionFailed = 3
+			shouldExec, err := DistroProvisionFailures{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeTrue)
+
+			ctx.ops.provisioned = 2
+			ctx.ops.provisionFailed = 6
+			shouldExec, err = DistroProvisionFailures{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeTrue)
+		})
+		Convey("too few spawn errors should not trigger a spawn error alert", func() {
+			ctx.ops.spawnErrors = 2
+			shouldExec, err := DistroSpawnErrors{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+		Convey("spawn errors at or above the threshold should trigger a spawn error alert", func() {
+			ctx.ops.spawnErrors = 3
+			shouldExec, err := DistroSpawnErrors{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeTrue)
+
+			ctx.ops.spawnErrors = 4
+			shouldExec, err = DistroSpawnErrors{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeTrue)
+		})
+		Convey("a recent repotracker run should not trigger a stuck alert", func() {
+			ctx.ops.repotrackerLastRun = time.Now().Add(-59 * time.Minute)
+			shouldExec, err := RepotrackerStuck{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+		Convey("an old repotracker run should trigger a stuck alert", func() {
+			ctx.ops.repotrackerLastRun = time.Now().Add(-61 * time.Minute)
+			shouldExec, err := RepotrackerStuck{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeTrue)
+		})
+		Convey("a disabled threshold should never trigger", func() {
+			settings.Alerts.Ops.RepotrackerStuckMinutes = 0
+			ctx.ops.repotrackerLastRun = time.Now().Add(-24 * time.Hour)
+			shouldExec, err := RepotrackerStuck{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+		Convey("a trigger that already fired within the window should not fire again", func() {
+			ctx.ops.spawnErrors = 5
+			So(DistroSpawnErrors{}.CreateAlertRecord(ctx).Insert(), ShouldBeNil)
+			shouldExec, err := DistroSpawnErrors{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+		Convey("the alert description should name the distro", func() {
+			ctx.ops.provisioned = 1
+			ctx.ops.provisionFailed = 3
+			So(DistroProvisionFailures{}.Describe(ctx), ShouldContainSubstring, "3 of 4 hosts for distro 'd1'")
+		})
+	})
+}
+
+func TestTimeoutReplacesFailureTriggers(t *testing.T) {
+	db.Clear(task.Collection)
+	db.Clear(alertrecord.Collection)
+	Convey("With a newly timed out task", t, func() {
+		timedOut := *testTask
+		timedOut.Status = evergreen.TaskFailed
+		timedOut.Details.TimedOut = true
+		ctx, err := getTaskTriggerContext(&timedOut)
+		So(err, ShouldBeNil)
+
+		Convey("the failure triggers should fire if the project isn't alerted of timeouts", func() {
+			ctx.projectRef = &model.ProjectRef{}
+			triggers, err := getActiveTaskFailureTriggers(*ctx)
+			So(err, ShouldBeNil)
+			So(hasTrigger(triggers, TaskFailed{}), ShouldBeTrue)
+			So(hasTrigger(triggers, TaskFailTransition{}), ShouldBeTrue)
+		})
+		Convey("the generic failure triggers should not fire if the project is alerted of timeouts", func() {
+			ctx.projectRef = &model.ProjectRef{Alerts: map[string][]model.AlertConfig{
+				alertrecord.TaskTimeoutTransition: {{Provider: "email", Settings: bson.M{"recipient": "a@b.com"}}},
+			}}
+			triggers, err := getActiveTaskFailureTriggers(*ctx)
+			So(err, ShouldBeNil)
+			So(hasTrigger(triggers, TaskFailed{}), ShouldBeFalse)
+			So(hasTrigger(triggers, TaskFailTransition{}), ShouldBeFalse)
+			So(hasTrigger(triggers, FirstFailureInVersion{}), ShouldBeTrue)
+		})
+	})
+}
+
+func TestTaskDurationExceededAfterSlowRun(t *testing.T) {
+	Convey("With a history of ten minute runs and a previous run without a stored expected duration", t, func() {
+		So(db.Clear(task.Collection), ShouldBeNil)
+		So(db.Clear(alertrecord.Collection), ShouldBeNil)
+		now := time.Now()
+		history := &task.Task{
+			Id:           "history",
+			Status:       evergreen.TaskSucceeded,
+			DisplayName:  testTask.DisplayName,
+			Project:      testTask.Project,
+			BuildVariant: testTask.BuildVariant,
+			StartTime:    now.Add(-2 * time.Hour),
+			FinishTime:   now.Add(-2*time.Hour + 10*time.Minute),
+			TimeTaken:    10 * time.Minute,
+		}
+		So(history.Insert(), ShouldBeNil)
+
+		previous := &task.Task{
+			Id:                  "previous",
+			Status:              evergreen.TaskSucceeded,
+			DisplayName:         testTask.DisplayName,
+			Project:             testTask.Project,
+			BuildVariant:        testTask.BuildVariant,
+			RevisionOrderNumber: 1,
+			ExpectedDuration:    model.DefaultTaskDuration,
+		}
+		current := &task.Task{
+			Id:                  "current",
+			Status:              evergreen.TaskSucceeded,
+			DisplayName:         testTask.DisplayName,
+			Project:             testTask.Project,
+			BuildVariant:        testTask.BuildVariant,
+			Version:             "testVersion2",
+			RevisionOrderNumber: 2,
+			ExpectedDuration:    10 * time.Minute,
+			TimeTaken:           30 * time.Minute,
+		}
+		ctx := triggerContext{task: current, previousCompleted: previous}
+
+		Convey("a slow run after a fast one should trigger", func() {
+			previous.TimeTaken = 10 * time.Minute
+			shouldExec, err := TaskDurationExceeded{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeTrue)
+		})
+		Convey("a slow run after a slow one should not trigger, judging the previous run by its history", func() {
+			previous.TimeTaken = 30 * time.Minute
+			shouldExec, err := TaskDurationExceeded{}.ShouldExecute(ctx)
+			So(err, ShouldBeNil)
+			So(shouldExec, ShouldBeFalse)
+		})
+	})
+}
@@ -3,16 +3,22 @@ package cli
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 
+	"github.com/evergreen-ci/evergreen/command"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
 	"gopkg.in/yaml.v2"
 )
 
 // EvaluateCommand reads in a project config, expanding tags and matrix definitions,
-// then prints the expanded definitions back out as yaml.
+// then prints the expanded definitions back out as yaml. Given a variant, it
+// instead prints which commands would run on each of the variant's tasks.
 type EvaluateCommand struct {
-	Tasks    bool `short:"t" long:"tasks" description:"only show task and function definitions"`
-	Variants bool `short:"v" long:"variants" description:"only show variant definitions"`
+	Tasks      bool     `short:"t" long:"tasks" description:"only show task and function definitions"`
+	Variants   bool     `short:"v" long:"variants" description:"only show variant definitions"`
+	Variant    string   `long:"variant" description:"show which commands run on the variant's tasks"`
+	Expansions []string `short:"e" long:"expansion" description:"an expansion to check command conditions with, as key=value (may be repeated)"`
 }
 
 func (ec *EvaluateCommand) Execute(args []string) error {
@@ -30,6 +36,13 @@ func (ec *EvaluateCommand) Execute(args []string) error {
 		return fmt.Errorf("error loading project: %v", err)
 	}
 
+	if ec.Variant != "" {
+		return ec.printActiveCommands(p)
+	}
+	if len(ec.Expansions) > 0 {
+		return fmt.Errorf("expansions can only be given along with a variant")
+	}
+
 	var out interface{}
 	if ec.Tasks || ec.Variants {
 		tmp := struct {
@@ -57,3 +70,122 @@ func (ec *EvaluateCommand) Execute(args []string) error {
 
 	return nil
 }
+
+// printActiveCommands prints whether each command of the variant's tasks, and of
+// the project's pre and post, would run, given the variant and the expansions.
+func (ec *EvaluateCommand) printActiveCommands(p *model.Project) error {
+	bv := p.FindBuildVariant(ec.Variant)
+	if bv == nil {
+		return fmt.Errorf("variant '%v' not found in project", ec.Variant)
+	}
+	expansions := command.NewExpansions(map[string]string{})
+	expansions.Update(bv.Expansions)
+	expansions.Put("build_variant", bv.Name)
+	for _, e := range ec.Expansions {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("expansion '%v' must be of the form key=value", e)
+		}
+		expansions.Put(parts[0], parts[1])
+	}
+
+	// commands are printed in the order the agent runs them, since the vars of
+	// the commands that run carry forward to the commands after them. Every
+	// task starts from the expansions left by pre.
+	registry := plugin.NewSimpleRegistry()
+	if p.Pre != nil {
+		fmt.Println("pre:")
+		if err := printCommands(p, registry, bv.Name, p.Pre.List(), expansions, "  "); err != nil {
+			return err
+		}
+	}
+	for _, taskName := range p.FindTasksForVariant(bv.Name) {
+		t := p.FindProjectTask(taskName)
+		if t == nil {
+			return fmt.Errorf("task '%v' not found in project", taskName)
+		}
+		taskExpansions := expansions.Copy()
+		taskExpansions.Put("task_name", t.Name)
+		fmt.Printf("task %v:\n", t.Name)
+		if err := printCommands(p, registry, bv.Name, t.Commands, taskExpansions, "  "); err != nil {
+			return err
+		}
+	}
+	if p.Post != nil {
+		fmt.Println("post:")
+		if err := printCommands(p, registry, bv.Name, p.Post.List(), expansions.Copy(), "  "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printCommands prints, for each command, whether it would run on the variant
+// and, if not, why. The vars of the commands that would run are added to the
+// expansions, as the agent does. The commands of parallel blocks are printed
+// below them.
+func printCommands(p *model.Project, registry plugin.Registry, variant string, cmds []model.PluginCommandConf,
+	expansions *command.Expansions, indent string) error {
+	for _, commandInfo := range cmds {
+		parsedCommands, err := registry.ParseCommandConf(commandInfo, p.Functions)
+		if err != nil {
+			return fmt.Errorf("error parsing command '%v': %v", commandInfo.GetDisplayName(), err)
+		}
+		for _, parsedCommand := range parsedCommands {
+			name := parsedCommand.GetDisplayName()
+			if commandInfo.Function != "" && parsedCommand.Parallel != nil {
+				name = fmt.Sprintf(`'parallel' in "%v"`, commandInfo.Function)
+			}
+
+			if !commandInfo.RunOnVariant(variant) {
+				fmt.Printf("%vskip (not on variant %v) %v\n", indent, variant, name)
+				continue
+			}
+			run, cond, err := p.ConditionsHold(expansions, commandInfo, parsedCommand)
+			if err != nil {
+				return fmt.Errorf("error checking command %v: %v", name, err)
+			}
+			if !run {
+				fmt.Printf("%vskip (if %v) %v\n", indent, cond, name)
+				continue
+			}
+			fmt.Printf("%vrun  %v\n", indent, name)
+
+			withVars, err := p.ExpansionsWithVars(expansions, commandInfo)
+			if err != nil {
+				return fmt.Errorf("error checking command %v: %v", name, err)
+			}
+			expansions.Update(*withVars)
+			if parsedCommand.Parallel != nil {
+				if err := printParallel(p, registry, variant, parsedCommand.Parallel.Commands, expansions, indent+"  "); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// printParallel prints the commands of a parallel block. Like the agent, each
+// command gets its own copy of the expansions, and the vars they set are added
+// to the expansions in the order of the block once all of them are printed.
+func printParallel(p *model.Project, registry plugin.Registry, variant string, cmds []model.PluginCommandConf,
+	expansions *command.Expansions, indent string) error {
+	before := expansions.Copy()
+	branches := []*command.Expansions{}
+	for _, commandInfo := range cmds {
+		branch := before.Copy()
+		if err := printCommands(p, registry, variant, []model.PluginCommandConf{commandInfo}, branch, indent); err != nil {
+			return err
+		}
+		branches = append(branches, branch)
+	}
+	for _, branch := range branches {
+		for key, value := range *branch {
+			if !before.Exists(key) || before.Get(key) != value {
+				expansions.Put(key, value)
+			}
+		}
+	}
+	return nil
+}
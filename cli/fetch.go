@@ -361,8 +361,12 @@ func getUrlsChannel(rc *APIClient, seed *service.RestTask, shallow bool) (chan a
 	urls := make(chan artifactDownload)
 	go func() {
 		for _, t := range allTasks {
+			directoryName := getArtifactFolderName(t)
 			for _, f := range t.Files {
-				directoryName := getArtifactFolderName(t)
+				urls <- artifactDownload{f.URL, directoryName}
+			}
+			// outputs published for dependent tasks
+			for _, f := range t.Outputs {
 				urls <- artifactDownload{f.URL, directoryName}
 			}
 		}
@@ -1,6 +1,7 @@
 package cloud
 
 import (
+	"io"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -31,6 +32,10 @@ const (
 
 	StatusStopped
 	StatusTerminated
+
+	//StatusPreempted means the provider reclaimed the instance, as happens to
+	//preemptible and spot instances; unlike StatusFailed it is not an error
+	StatusPreempted
 )
 
 func (stat CloudStatus) String() string {
@@ -47,11 +52,50 @@ func (stat CloudStatus) String() string {
 		return "stopped"
 	case StatusTerminated:
 		return "terminated"
+	case StatusPreempted:
+		return "preempted"
 	default:
 		return "unknown"
 	}
 }
 
+// NotReadyError is returned by SpawnInstance when a host can't be spawned yet
+// because something it needs is still starting, such as the parent host of a
+// container. It isn't a failure of the provider: the spawn should be tried
+// again later.
+type NotReadyError struct {
+	Reason string
+}
+
+func (self *NotReadyError) Error() string {
+	return self.Reason
+}
+
+// IsNotReady returns whether the error is from spawning a host that can't be
+// spawned yet.
+func IsNotReady(err error) bool {
+	_, ok := err.(*NotReadyError)
+	return ok
+}
+
+// ProviderError is returned by SpawnInstance when the provider's API fails to
+// create a host, as opposed to Evergreen failing to prepare or record it.
+// Only these failures count towards a distro's spawn breaker.
+type ProviderError struct {
+	Err error
+}
+
+func (self *ProviderError) Error() string {
+	return self.Err.Error()
+}
+
+// IsProviderError returns whether the error is from the provider's API
+// failing to create a host.
+func IsProviderError(err error) bool {
+	_, ok := err.(*ProviderError)
+	return ok
+}
+
 // ProviderSettings exposes provider-specific configuration settings for a CloudManager.
 type ProviderSettings interface {
 	Validate() error
@@ -107,6 +151,24 @@ type CloudManager interface {
 	TimeTilNextPayment(host *host.Host) time.Duration
 }
 
+// Executor is implemented by cloud managers whose hosts run commands through
+// the provider's API, rather than over SSH.
+type Executor interface {
+	// Exec runs a command on the host, feeding it stdin if it isn't nil,
+	// and returns an error if it fails or exits non-zero
+	Exec(h *host.Host, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// Stopper is implemented by cloud managers that can stop idle hosts, keeping
+// their disks, and start them again faster than new hosts can be spawned.
+type Stopper interface {
+	// StopInstance stops the host and marks it as stopped
+	StopInstance(h *host.Host) error
+
+	// StartInstance starts a stopped host and marks it as restarted
+	StartInstance(h *host.Host) error
+}
+
 //CloudHost is a provider-agnostic host object that delegates methods
 //like status checks, ssh options, DNS name checks, termination, etc. to the
 //underlying provider's implementation.
@@ -139,3 +201,10 @@ func (cloudHost *CloudHost) GetDNSName() (string, error) {
 func (cloudHost *CloudHost) GetSSHOptions() ([]string, error) {
 	return cloudHost.CloudMgr.GetSSHOptions(cloudHost.Host, cloudHost.KeyPath)
 }
+
+// Executor returns the host's cloud manager as an Executor, if its commands
+// are run through the provider's API instead of SSH.
+func (cloudHost *CloudHost) Executor() (Executor, bool) {
+	executor, ok := cloudHost.CloudMgr.(Executor)
+	return executor, ok
+}
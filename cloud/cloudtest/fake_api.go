@@ -0,0 +1,80 @@
+// Package cloudtest has helpers for testing cloud providers against fakes of
+// their APIs.
+package cloudtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// FakeAPI is an HTTP server that fakes a cloud provider's API. Its handlers
+// are registered on Mux. It issues tokens, which the handlers check requests
+// against, so that tests can revoke the token a client holds.
+type FakeAPI struct {
+	*httptest.Server
+	Mux *http.ServeMux
+
+	mu     sync.Mutex
+	issued int
+	token  string
+}
+
+// NewFakeAPI starts a fake API server. Close it when done.
+func NewFakeAPI() *FakeAPI {
+	f := &FakeAPI{Mux: http.NewServeMux()}
+	f.Server = httptest.NewServer(f.Mux)
+	return f
+}
+
+// IssueToken returns a new token, which replaces the current one.
+func (f *FakeAPI) IssueToken() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.issued++
+	f.token = fmt.Sprintf("token-%v", f.issued)
+	return f.token
+}
+
+// TokensIssued returns how many tokens have been issued.
+func (f *FakeAPI) TokensIssued() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.issued
+}
+
+// RevokeToken makes the current token invalid, as if it had expired.
+func (f *FakeAPI) RevokeToken() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.token = ""
+}
+
+// Authorized returns whether the token is the current one. If not, it responds
+// with 401 Unauthorized.
+func (f *FakeAPI) Authorized(w http.ResponseWriter, token string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.token == "" || token != f.token {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// DecodeJSON decodes the JSON body of a request into v. If the request wasn't
+// made with the method or its body can't be decoded, it responds with 400 Bad
+// Request.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, method string, v interface{}) bool {
+	if r.Method != method {
+		http.Error(w, "unexpected method "+r.Method, http.StatusBadRequest)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
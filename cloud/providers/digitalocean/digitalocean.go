@@ -130,7 +130,7 @@ func (digoMgr *DigitalOceanManager) SpawnInstance(d *distro.Distro, owner string
 			return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not remove intent host "+
 				"'%v': %v", intentHost.Id, rmErr)
 		}
-		return nil, err
+		return nil, &cloud.ProviderError{Err: err}
 	}
 
 	// find old intent host
@@ -28,9 +28,16 @@ const (
 
 	ProviderName   = "docker"
 	TimeoutSeconds = 5
+
+	// CPUPeriod is the period, in microseconds, over which containers'
+	// CPU limits are enforced
+	CPUPeriod = 100000
 )
 
 type DockerManager struct {
+	// ParentManager returns the cloud manager used to start parent hosts for
+	// distros whose containers run on a pool of them.
+	ParentManager func(providerName string) (cloud.CloudManager, error)
 }
 
 type portRange struct {
@@ -51,6 +58,17 @@ type Settings struct {
 	ClientPort int        `mapstructure:"client_port" json:"client_port" bson:"client_port"`
 	PortRange  *portRange `mapstructure:"port_range" json:"port_range" bson:"port_range"`
 	Auth       *auth      `mapstructure:"auth" json:"auth" bson:"auth"`
+
+	// ParentDistro, if set, is the distro of the Evergreen hosts the
+	// containers run on, and replaces HostIp
+	ParentDistro string `mapstructure:"parent_distro" json:"parent_distro,omitempty" bson:"parent_distro,omitempty"`
+	// MaxContainers is the most containers, of any distro, a parent can
+	// be running for another of this distro's to be put on it. Zero is no limit.
+	MaxContainers int `mapstructure:"max_containers" json:"max_containers,omitempty" bson:"max_containers,omitempty"`
+
+	// resource limits for each container; zero is no limit
+	CPUs     float64 `mapstructure:"cpus" json:"cpus,omitempty" bson:"cpus,omitempty"`
+	MemoryMB int     `mapstructure:"memory_mb" json:"memory_mb,omitempty" bson:"memory_mb,omitempty"`
 }
 
 var (
@@ -62,6 +80,11 @@ var (
 	PortRange  = bsonutil.MustHaveTag(Settings{}, "PortRange")
 	Auth       = bsonutil.MustHaveTag(Settings{}, "Auth")
 
+	ParentDistro  = bsonutil.MustHaveTag(Settings{}, "ParentDistro")
+	MaxContainers = bsonutil.MustHaveTag(Settings{}, "MaxContainers")
+	CPUs          = bsonutil.MustHaveTag(Settings{}, "CPUs")
+	MemoryMB      = bsonutil.MustHaveTag(Settings{}, "MemoryMB")
+
 	// bson fields for the portRange struct
 	MinPort = bsonutil.MustHaveTag(portRange{}, "MinPort")
 	MaxPort = bsonutil.MustHaveTag(portRange{}, "MaxPort")
@@ -79,37 +102,84 @@ var (
 // Helper Functions
 //*********************************************************************************
 
-func generateClient(d *distro.Distro) (*docker.Client, *Settings, error) {
+func decodeSettings(d *distro.Distro) (*Settings, error) {
 	// Populate and validate settings
 	settings := &Settings{} // Instantiate global settings
 	if err := mapstructure.Decode(d.ProviderSettings, settings); err != nil {
-		return nil, settings, fmt.Errorf("Error decoding params for distro %v: %v", d.Id, err)
+		return settings, fmt.Errorf("Error decoding params for distro %v: %v", d.Id, err)
 	}
 
 	if err := settings.Validate(); err != nil {
-		return nil, settings, fmt.Errorf("Invalid Docker settings in distro %v: %v", d.Id, err)
+		return settings, fmt.Errorf("Invalid Docker settings in distro %v: %v", d.Id, err)
 	}
+	return settings, nil
+}
 
+// newClient returns a client for the Docker daemon on the given host.
+func newClient(settings *Settings, hostIp string) (*docker.Client, error) {
 	// Convert authentication strings to byte arrays
 	cert := bytes.NewBufferString(settings.Auth.Cert).Bytes()
 	key := bytes.NewBufferString(settings.Auth.Key).Bytes()
 	ca := bytes.NewBufferString(settings.Auth.Ca).Bytes()
 
 	// Create client
-	endpoint := fmt.Sprintf("tcp://%s:%v", settings.HostIp, settings.ClientPort)
+	endpoint := fmt.Sprintf("tcp://%s:%v", hostIp, settings.ClientPort)
 	client, err := docker.NewTLSClientFromBytes(endpoint, cert, key, ca)
 	if err != nil {
 		evergreen.Logger.Logf(slogger.ERROR, "Docker initialize client API call failed for host '%s': %v", endpoint, err)
 	}
-	return client, settings, err
+	return client, err
 }
 
-func populateHostConfig(hostConfig *docker.HostConfig, d *distro.Distro) error {
-	// Retrieve client for API call and settings
-	client, settings, err := generateClient(d)
+// generateClient returns a client for the Docker daemon running a container,
+// which is either on the distro's HostIp or on the container's parent host.
+func generateClient(h *host.Host) (*docker.Client, *Settings, error) {
+	settings, err := decodeSettings(&h.Distro)
 	if err != nil {
+		return nil, settings, err
+	}
+
+	hostIp := settings.HostIp
+	if h.ParentId != "" {
+		parent, err := host.FindOne(host.ById(h.ParentId))
+		if err != nil {
+			return nil, settings, fmt.Errorf("Error finding parent of container '%v': %v", h.Id, err)
+		}
+		if parent == nil {
+			return nil, settings, fmt.Errorf("Parent '%v' of container '%v' not found", h.ParentId, h.Id)
+		}
+		hostIp = parent.Host
+	}
+
+	client, err := newClient(settings, hostIp)
+	return client, settings, err
+}
+
+// ensureImage pulls an image onto a Docker host if it isn't there already.
+func ensureImage(client *docker.Client, image string) error {
+	_, err := client.InspectImage(image)
+	if err != docker.ErrNoSuchImage {
 		return err
 	}
+
+	repository, tag := docker.ParseRepositoryTag(image)
+	if tag == "" {
+		tag = "latest"
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Pulling image '%v'", image)
+	return client.PullImage(docker.PullImageOptions{Repository: repository, Tag: tag}, docker.AuthConfiguration{})
+}
+
+func populateHostConfig(hostConfig *docker.HostConfig, client *docker.Client, settings *Settings, bindIp string) error {
+	// Enforce the distro's resource limits
+	if settings.MemoryMB > 0 {
+		hostConfig.Memory = int64(settings.MemoryMB) * 1024 * 1024
+	}
+	if settings.CPUs > 0 {
+		hostConfig.CPUPeriod = CPUPeriod
+		hostConfig.CPUQuota = int64(settings.CPUs * CPUPeriod)
+	}
+
 	minPort := settings.PortRange.MinPort
 	maxPort := settings.PortRange.MaxPort
 
@@ -138,7 +208,7 @@ func populateHostConfig(hostConfig *docker.HostConfig, d *distro.Distro) error {
 		if !reservedPorts[i] {
 			hostConfig.PortBindings[SSHDPort] = []docker.PortBinding{
 				{
-					HostIP:   settings.BindIp,
+					HostIP:   bindIp,
 					HostPort: fmt.Sprintf("%v", i),
 				},
 			}
@@ -171,8 +241,8 @@ func retrieveOpenPortBinding(containerPtr *docker.Container) (string, error) {
 
 //Validate checks that the settings from the config file are sane.
 func (settings *Settings) Validate() error {
-	if settings.HostIp == "" {
-		return fmt.Errorf("HostIp must not be blank")
+	if settings.HostIp == "" && settings.ParentDistro == "" {
+		return fmt.Errorf("HostIp or ParentDistro must not be blank")
 	}
 
 	if settings.ImageId == "" {
@@ -192,6 +262,14 @@ func (settings *Settings) Validate() error {
 		}
 	}
 
+	if settings.MaxContainers < 0 {
+		return fmt.Errorf("Maximum containers must not be negative")
+	}
+
+	if settings.CPUs < 0 || settings.MemoryMB < 0 {
+		return fmt.Errorf("Container resource limits must not be negative")
+	}
+
 	if settings.Auth == nil {
 		return fmt.Errorf("Authentication materials must not be blank")
 	} else if settings.Auth.Cert == "" {
@@ -217,17 +295,36 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, owner string, us
 		return nil, fmt.Errorf("Can't spawn instance of %v for distro %v: provider is %v", ProviderName, d.Id, d.Provider)
 	}
 
+	settings, err := decodeSettings(d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pick the host to run the container on
+	hostIp, bindIp, parentId := settings.HostIp, settings.BindIp, ""
+	if settings.ParentDistro != "" {
+		parent, err := dockerMgr.findParent(d, settings)
+		if err != nil {
+			return nil, err
+		}
+		hostIp, bindIp, parentId = parent.Host, "", parent.Id
+	}
+
 	// Initialize client
-	dockerClient, settings, err := generateClient(d)
+	dockerClient, err := newClient(settings, hostIp)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = ensureImage(dockerClient, settings.ImageId); err != nil {
+		return nil, &cloud.ProviderError{Err: evergreen.Logger.Errorf(slogger.ERROR, "Unable to pull image '%v' onto host '%v': %v", settings.ImageId, hostIp, err)}
+	}
+
 	// Create HostConfig structure
 	hostConfig := &docker.HostConfig{}
-	err = populateHostConfig(hostConfig, d)
+	err = populateHostConfig(hostConfig, dockerClient, settings, bindIp)
 	if err != nil {
-		evergreen.Logger.Logf(slogger.ERROR, "Unable to populate docker host config for host '%s': %v", settings.HostIp, err)
+		evergreen.Logger.Logf(slogger.ERROR, "Unable to populate docker host config for host '%s': %v", hostIp, err)
 		return nil, err
 	}
 
@@ -247,8 +344,8 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, owner string, us
 		},
 	)
 	if err != nil {
-		evergreen.Logger.Logf(slogger.ERROR, "Docker create container API call failed for host '%s': %v", settings.HostIp, err)
-		return nil, err
+		evergreen.Logger.Logf(slogger.ERROR, "Docker create container API call failed for host '%s': %v", hostIp, err)
+		return nil, &cloud.ProviderError{Err: err}
 	}
 
 	// Start container
@@ -264,15 +361,15 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, owner string, us
 		if err2 != nil {
 			err = fmt.Errorf("%v. And was unable to clean up container %v: %v", err, newContainer.ID, err2)
 		}
-		evergreen.Logger.Logf(slogger.ERROR, "Docker start container API call failed for host '%s': %v", settings.HostIp, err)
-		return nil, err
+		evergreen.Logger.Logf(slogger.ERROR, "Docker start container API call failed for host '%s': %v", hostIp, err)
+		return nil, &cloud.ProviderError{Err: err}
 	}
 
 	// Retrieve container details
 	newContainer, err = dockerClient.InspectContainer(newContainer.ID)
 	if err != nil {
-		evergreen.Logger.Logf(slogger.ERROR, "Docker inspect container API call failed for host '%s': %v", settings.HostIp, err)
-		return nil, err
+		evergreen.Logger.Logf(slogger.ERROR, "Docker inspect container API call failed for host '%s': %v", hostIp, err)
+		return nil, &cloud.ProviderError{Err: err}
 	}
 
 	hostPort, err := retrieveOpenPortBinding(newContainer)
@@ -280,7 +377,10 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, owner string, us
 		evergreen.Logger.Logf(slogger.ERROR, "Error with docker container '%v': %v", newContainer.ID, err)
 		return nil, err
 	}
-	hostStr := fmt.Sprintf("%s:%s", settings.BindIp, hostPort)
+	if parentId != "" {
+		bindIp = hostIp
+	}
+	hostStr := fmt.Sprintf("%s:%s", bindIp, hostPort)
 
 	// Add host info to db
 	instanceName := "container-" +
@@ -297,6 +397,7 @@ func (dockerMgr *DockerManager) SpawnInstance(d *distro.Distro, owner string, us
 		TaskDispatchTime: util.ZeroTime,
 		Provider:         ProviderName,
 		StartedBy:        owner,
+		ParentId:         parentId,
 	}
 
 	err = host.Insert()
@@ -328,7 +429,7 @@ func getStatus(s *docker.State) int {
 // GetInstanceStatus returns a universal status code representing the state
 // of a container.
 func (dockerMgr *DockerManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
-	dockerClient, _, err := generateClient(&host.Distro)
+	dockerClient, _, err := generateClient(host)
 	if err != nil {
 		return cloud.StatusUnknown, err
 	}
@@ -366,7 +467,7 @@ func (dockerMgr *DockerManager) CanSpawn() (bool, error) {
 
 //TerminateInstance destroys a container.
 func (dockerMgr *DockerManager) TerminateInstance(host *host.Host) error {
-	dockerClient, _, err := generateClient(&host.Distro)
+	dockerClient, _, err := generateClient(host)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/fsouza/go-dockerclient"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParentCapacity(t *testing.T) {
+	Convey("With parents of different sizes", t, func() {
+		settings := &Settings{CPUs: 2, MemoryMB: 4096}
+		info := func(cpus int, memoryMB int64) *docker.Env {
+			env := &docker.Env{}
+			env.SetInt("NCPU", cpus)
+			env.SetInt64("MemTotal", memoryMB*1024*1024)
+			return env
+		}
+		container := func(cpus float64, memoryMB int) host.Host {
+			return host.Host{Distro: distro.Distro{ProviderSettings: &map[string]interface{}{
+				"cpus": cpus, "memory_mb": memoryMB,
+			}}}
+		}
+
+		Convey("free capacity should be what's left after the containers' limits", func() {
+			capacity := newParentCapacity(host.Host{Id: "p1"}, info(8, 16384),
+				[]host.Host{container(2, 4096), container(1.5, 2048), container(0, 0)})
+			So(capacity.containers, ShouldEqual, 3)
+			So(capacity.freeCPUs, ShouldEqual, 4.5)
+			So(capacity.freeMemoryMB, ShouldEqual, 10240)
+		})
+
+		Convey("the parent with the most free memory that fits should be picked", func() {
+			capacities := []parentCapacity{
+				newParentCapacity(host.Host{Id: "small"}, info(4, 8192), nil),
+				newParentCapacity(host.Host{Id: "busy"}, info(16, 65536),
+					[]host.Host{container(15, 4096)}),
+				newParentCapacity(host.Host{Id: "large"}, info(8, 16384), nil),
+			}
+			So(pickParent(capacities, settings).Id, ShouldEqual, "large")
+
+			settings.MemoryMB = 32768
+			So(pickParent(capacities, settings), ShouldBeNil)
+		})
+
+		Convey("parents should not get more than the maximum containers", func() {
+			capacities := []parentCapacity{
+				newParentCapacity(host.Host{Id: "p1"}, info(32, 65536),
+					[]host.Host{container(0, 0), container(0, 0)}),
+			}
+			settings.MaxContainers = 3
+			So(pickParent(capacities, settings).Id, ShouldEqual, "p1")
+			settings.MaxContainers = 2
+			So(pickParent(capacities, settings), ShouldBeNil)
+		})
+	})
+}
+
+func TestGarbage(t *testing.T) {
+	Convey("With containers and images on a Docker host", t, func() {
+		now := time.Now()
+
+		Convey("only stopped Evergreen containers of dead hosts should be stale", func() {
+			containers := []docker.APIContainers{
+				{ID: "running", Names: []string{"/docker-1"}, Status: "Up 3 hours"},
+				{ID: "paused", Names: []string{"/docker-2"}, Status: "Up 3 hours (Paused)"},
+				{ID: "exited", Names: []string{"/docker-3"}, Status: "Exited (0) 2 hours ago"},
+				{ID: "live", Names: []string{"/docker-4"}, Status: "Exited (137) 5 minutes ago"},
+				{ID: "other", Names: []string{"/registry"}, Status: "Exited (1) 2 days ago"},
+			}
+			So(staleContainers(containers, map[string]bool{"live": true}), ShouldResemble, []string{"exited"})
+		})
+
+		Convey("only old images that nothing uses should be stale", func() {
+			old := now.Add(-2 * StaleImageAge).Unix()
+			images := []docker.APIImages{
+				{ID: "sha256:a", RepoTags: []string{"ubuntu:14.04"}, Created: old},
+				{ID: "sha256:b", RepoTags: []string{"centos:7", "centos:latest"}, Created: old},
+				{ID: "sha256:c", RepoTags: []string{"<none>:<none>"}, Created: old},
+				{ID: "sha256:d", RepoTags: []string{"debian:8"}, Created: now.Unix()},
+				{ID: "sha256:e", RepoTags: []string{"<none>:<none>"}, Created: old},
+			}
+			inUse := map[string]bool{
+				normalizeImage("ubuntu:14.04"): true,
+				normalizeImage("centos"):       true,
+				"sha256:e":                     true,
+			}
+			So(staleImages(images, inUse, now), ShouldResemble, []string{"sha256:c"})
+		})
+	})
+}
+
+func TestSettingsValidate(t *testing.T) {
+	Convey("Docker settings should be validated", t, func() {
+		settings := &Settings{
+			ParentDistro: "docker-parents",
+			ImageId:      "evergreen/ubuntu",
+			ClientPort:   2376,
+			Auth:         &auth{Cert: "cert", Key: "key", Ca: "ca"},
+			CPUs:         2,
+			MemoryMB:     4096,
+		}
+		So(settings.Validate(), ShouldBeNil)
+
+		for _, modify := range []func(*Settings){
+			func(s *Settings) { s.ParentDistro = "" },
+			func(s *Settings) { s.MaxContainers = -1 },
+			func(s *Settings) { s.CPUs = -1 },
+			func(s *Settings) { s.MemoryMB = -1 },
+		} {
+			invalid := *settings
+			modify(&invalid)
+			So(invalid.Validate(), ShouldNotBeNil)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// images that no distro or container uses are removed once they're
+	// this old
+	StaleImageAge = 24 * time.Hour
+
+	// the prefix of the names of containers Evergreen starts
+	containerNamePrefix = "/docker-"
+)
+
+// daemon is a Docker host that Evergreen runs containers on.
+type daemon struct {
+	hostIp   string
+	settings *Settings
+}
+
+// CollectGarbage removes the stopped containers of terminated hosts, and
+// images that are no longer used, from every Docker host the given distros'
+// containers run on. Docker hosts are assumed to be dedicated to Evergreen.
+func CollectGarbage(distros []distro.Distro) []error {
+	var errs []error
+
+	// find the Docker hosts, and the images the distros use
+	daemons := map[string]daemon{}
+	inUse := map[string]bool{}
+	for _, d := range distros {
+		if d.Provider != ProviderName {
+			continue
+		}
+		settings, err := decodeSettings(&d)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		inUse[normalizeImage(settings.ImageId)] = true
+
+		if settings.ParentDistro == "" {
+			daemons[settings.HostIp] = daemon{settings.HostIp, settings}
+			continue
+		}
+		parents, err := host.Find(host.ByDistroId(settings.ParentDistro))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error finding parent hosts for distro %v: %v", d.Id, err))
+			continue
+		}
+		for _, parent := range parents {
+			if parent.Status == evergreen.HostRunning {
+				daemons[parent.Host] = daemon{parent.Host, settings}
+			}
+		}
+	}
+
+	for _, dockerHost := range daemons {
+		if err := dockerHost.collectGarbage(inUse); err != nil {
+			errs = append(errs, fmt.Errorf("error collecting garbage on Docker host %v: %v", dockerHost.hostIp, err))
+		}
+	}
+	return errs
+}
+
+func (self daemon) collectGarbage(distroImages map[string]bool) error {
+	client, err := newClient(self.settings, self.hostIp)
+	if err != nil {
+		return err
+	}
+
+	containers, err := client.ListContainers(docker.ListContainersOptions{All: true})
+	if err != nil {
+		return fmt.Errorf("Docker list containers API call failed: %v", err)
+	}
+	ids := []string{}
+	for _, container := range containers {
+		ids = append(ids, container.ID)
+	}
+	hosts, err := host.Find(host.ByIds(ids))
+	if err != nil {
+		return fmt.Errorf("error finding container hosts: %v", err)
+	}
+	live := map[string]bool{}
+	for _, h := range hosts {
+		if h.Status != evergreen.HostTerminated {
+			live[h.Id] = true
+		}
+	}
+
+	for _, id := range staleContainers(containers, live) {
+		evergreen.Logger.Logf(slogger.INFO, "Removing stopped container '%v' from %v", id, self.hostIp)
+		err = client.RemoveContainer(docker.RemoveContainerOptions{ID: id, RemoveVolumes: true})
+		if err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Failed to remove container '%v': %v", id, err)
+		}
+	}
+
+	// images that containers still use can't be removed
+	inUse := map[string]bool{}
+	for image := range distroImages {
+		inUse[image] = true
+	}
+	for _, container := range containers {
+		// containers list their image by name or by id
+		inUse[container.Image] = true
+		inUse[normalizeImage(container.Image)] = true
+	}
+
+	images, err := client.ListImages(docker.ListImagesOptions{})
+	if err != nil {
+		return fmt.Errorf("Docker list images API call failed: %v", err)
+	}
+	for _, id := range staleImages(images, inUse, time.Now()) {
+		evergreen.Logger.Logf(slogger.INFO, "Removing stale image '%v' from %v", id, self.hostIp)
+		if err = client.RemoveImage(id); err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Failed to remove image '%v': %v", id, err)
+		}
+	}
+	return nil
+}
+
+// staleContainers returns the ids of the stopped containers Evergreen started
+// whose hosts are no longer live. Containers of live hosts are left for the
+// host monitor to terminate.
+func staleContainers(containers []docker.APIContainers, live map[string]bool) []string {
+	stale := []string{}
+	for _, container := range containers {
+		if live[container.ID] || strings.HasPrefix(container.Status, "Up") {
+			continue
+		}
+		for _, name := range container.Names {
+			if strings.HasPrefix(name, containerNamePrefix) {
+				stale = append(stale, container.ID)
+				break
+			}
+		}
+	}
+	return stale
+}
+
+// staleImages returns the ids of the images older than StaleImageAge that
+// aren't in use, by any of their names or by id.
+func staleImages(images []docker.APIImages, inUse map[string]bool, now time.Time) []string {
+	stale := []string{}
+	for _, image := range images {
+		if now.Sub(time.Unix(image.Created, 0)) < StaleImageAge || inUse[image.ID] {
+			continue
+		}
+		used := false
+		for _, tag := range image.RepoTags {
+			if inUse[tag] {
+				used = true
+				break
+			}
+		}
+		if !used {
+			stale = append(stale, image.ID)
+		}
+	}
+	return stale
+}
+
+// normalizeImage returns an image's name with its tag, which is how Docker
+// lists images.
+func normalizeImage(image string) string {
+	repository, tag := docker.ParseRepositoryTag(image)
+	if tag == "" {
+		return repository + ":latest"
+	}
+	return image
+}
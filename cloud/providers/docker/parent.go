@@ -0,0 +1,149 @@
+package docker
+
+import (
+	"fmt"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/fsouza/go-dockerclient"
+	"github.com/mitchellh/mapstructure"
+)
+
+// parentCapacity is how much room a parent host has left for containers.
+type parentCapacity struct {
+	parent       host.Host
+	containers   int
+	freeCPUs     float64
+	freeMemoryMB int
+}
+
+// newParentCapacity works out a parent's free capacity from what its Docker
+// daemon reports and the limits of the containers Evergreen put on it.
+func newParentCapacity(parent host.Host, info *docker.Env, containers []host.Host) parentCapacity {
+	capacity := parentCapacity{
+		parent:       parent,
+		freeCPUs:     float64(info.GetInt("NCPU")),
+		freeMemoryMB: int(info.GetInt64("MemTotal") / (1024 * 1024)),
+	}
+	for _, container := range containers {
+		capacity.containers++
+		settings := &Settings{}
+		if err := mapstructure.Decode(container.Distro.ProviderSettings, settings); err != nil {
+			continue
+		}
+		capacity.freeCPUs -= settings.CPUs
+		capacity.freeMemoryMB -= settings.MemoryMB
+	}
+	return capacity
+}
+
+// fits returns whether a container with the given settings can be put on the
+// parent.
+func (self *parentCapacity) fits(settings *Settings) bool {
+	if settings.MaxContainers > 0 && self.containers >= settings.MaxContainers {
+		return false
+	}
+	return self.freeCPUs >= settings.CPUs && self.freeMemoryMB >= settings.MemoryMB
+}
+
+// pickParent returns the parent with room for a container that has the most
+// free memory, then the most free CPUs, or nil if none has room.
+func pickParent(capacities []parentCapacity, settings *Settings) *host.Host {
+	var best *parentCapacity
+	for i := range capacities {
+		capacity := &capacities[i]
+		if !capacity.fits(settings) {
+			continue
+		}
+		if best == nil || capacity.freeMemoryMB > best.freeMemoryMB ||
+			(capacity.freeMemoryMB == best.freeMemoryMB && capacity.freeCPUs > best.freeCPUs) {
+			best = capacity
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &best.parent
+}
+
+// findParent returns the running parent host with the most room for one of
+// the distro's containers. If none has room, it starts another parent, as
+// long as the parent distro's pool isn't full and no parent is already
+// starting, and returns a cloud.NotReadyError so the container is tried again
+// once the new parent is up.
+func (dockerMgr *DockerManager) findParent(d *distro.Distro, settings *Settings) (*host.Host, error) {
+	parents, err := host.Find(host.ByDistroId(settings.ParentDistro))
+	if err != nil {
+		return nil, fmt.Errorf("Error finding parent hosts for distro %v: %v", d.Id, err)
+	}
+
+	capacities := []parentCapacity{}
+	starting := false
+	for _, parent := range parents {
+		if parent.Status == evergreen.HostUninitialized || parent.Status == evergreen.HostInitializing {
+			starting = true
+			continue
+		}
+		if parent.Status != evergreen.HostRunning {
+			continue
+		}
+		capacity, err := getParentCapacity(parent, settings)
+		if err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Unable to get capacity of parent host '%v': %v", parent.Id, err)
+			continue
+		}
+		capacities = append(capacities, capacity)
+	}
+
+	if parent := pickParent(capacities, settings); parent != nil {
+		return parent, nil
+	}
+
+	if starting {
+		return nil, &cloud.NotReadyError{Reason: fmt.Sprintf("No parent host of distro %v has room "+
+			"for a container; waiting for starting parents", settings.ParentDistro)}
+	}
+
+	parentDistro, err := distro.FindOne(distro.ById(settings.ParentDistro))
+	if err != nil {
+		return nil, fmt.Errorf("Error finding parent distro %v: %v", settings.ParentDistro, err)
+	}
+	if parentDistro == nil {
+		return nil, fmt.Errorf("Parent distro %v not found", settings.ParentDistro)
+	}
+	if len(parents) >= parentDistro.PoolSize || dockerMgr.ParentManager == nil {
+		return nil, fmt.Errorf("No parent host of distro %v has room for a container", parentDistro.Id)
+	}
+
+	parentManager, err := dockerMgr.ParentManager(parentDistro.Provider)
+	if err != nil {
+		return nil, err
+	}
+	parent, err := parentManager.SpawnInstance(parentDistro, evergreen.User, false)
+	if err != nil {
+		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Failed to start parent host of distro %v: %v", parentDistro.Id, err)
+	}
+	return nil, &cloud.NotReadyError{Reason: fmt.Sprintf("No parent host of distro %v has room "+
+		"for a container; started parent '%v'", parentDistro.Id, parent.Id)}
+}
+
+// getParentCapacity asks a parent's Docker daemon for its resources, and
+// subtracts those reserved by its containers.
+func getParentCapacity(parent host.Host, settings *Settings) (parentCapacity, error) {
+	client, err := newClient(settings, parent.Host)
+	if err != nil {
+		return parentCapacity{}, err
+	}
+	info, err := client.Info()
+	if err != nil {
+		return parentCapacity{}, fmt.Errorf("Docker info API call failed: %v", err)
+	}
+	containers, err := host.Find(host.ByParentId(parent.Id))
+	if err != nil {
+		return parentCapacity{}, fmt.Errorf("Error finding containers: %v", err)
+	}
+	return newParentCapacity(parent, info, containers), nil
+}
@@ -189,9 +189,13 @@ func (cloudManager *EC2Manager) SpawnInstance(d *distro.Distro, owner string, us
 	newHost, resp, err := startEC2Instance(ec2Handle, &options, intentHost)
 
 	if err != nil {
-		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not start new "+
+		startErr := evergreen.Logger.Errorf(slogger.ERROR, "Could not start new "+
 			"instance for distro “%v”. Accompanying host record is “%v”: %v",
 			d.Id, intentHost.Id, err)
+		if cloud.IsProviderError(err) {
+			return nil, &cloud.ProviderError{Err: startErr}
+		}
+		return nil, startErr
 	}
 
 	instance := resp.Instances[0]
@@ -238,6 +242,8 @@ func (cloudManager *EC2Manager) GetDNSName(host *host.Host) (string, error) {
 	return instanceInfo.DNSName, nil
 }
 
+// StopInstance stops an instance, which keeps its EBS volumes, and marks the
+// host as stopped.
 func (cloudManager *EC2Manager) StopInstance(host *host.Host) error {
 	ec2Handle := getUSEast(*cloudManager.awsCredentials)
 	// stop the instance
@@ -257,7 +263,26 @@ func (cloudManager *EC2Manager) StopInstance(host *host.Host) error {
 		return err
 	}
 
-	return nil
+	return host.SetStopped()
+}
+
+// StartInstance starts a stopped instance and marks the host as restarted.
+func (cloudManager *EC2Manager) StartInstance(host *host.Host) error {
+	if host.Status != evergreen.HostStopped {
+		return fmt.Errorf("Can not start %v - it is %v, not stopped", host.Id, host.Status)
+	}
+
+	ec2Handle := getUSEast(*cloudManager.awsCredentials)
+	resp, err := ec2Handle.StartInstances(host.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, stateChange := range resp.StateChanges {
+		evergreen.Logger.Logf(slogger.INFO, "Started %v", stateChange.InstanceId)
+	}
+
+	return host.SetRestarted()
 }
 
 func (cloudManager *EC2Manager) TerminateInstance(host *host.Host) error {
@@ -301,8 +326,8 @@ func startEC2Instance(ec2Handle *ec2.EC2, options *ec2.RunInstancesOptions,
 			evergreen.Logger.Errorf(slogger.ERROR, "Could not remove intent host "+
 				"“%v”: %v", intentHost.Id, rmErr)
 		}
-		return nil, nil, evergreen.Logger.Errorf(slogger.ERROR,
-			"EC2 RunInstances API call returned error: %v", err)
+		return nil, nil, &cloud.ProviderError{Err: evergreen.Logger.Errorf(slogger.ERROR,
+			"EC2 RunInstances API call returned error: %v", err)}
 	}
 
 	evergreen.Logger.Logf(slogger.DEBUG, "Spawned %v instance", len(resp.Instances))
@@ -352,7 +377,7 @@ func startEC2Instance(ec2Handle *ec2.EC2, options *ec2.RunInstancesOptions,
 				evergreen.Logger.Errorf(slogger.ERROR, "There was an error querying for the "+
 					"instance's information and retries are exhausted. The insance may "+
 					"be up.")
-				return nil, resp, err
+				return nil, resp, &cloud.ProviderError{Err: err}
 			}
 
 			evergreen.Logger.Errorf(slogger.DEBUG, "There was an error querying for the "+
@@ -217,15 +217,21 @@ func timeTilNextEC2Payment(host *host.Host) time.Duration {
 
 	now := time.Now()
 
+	// billing starts over when a stopped host is started again
+	startTime := host.CreationTime
+	if host.RestartTime.After(startTime) {
+		startTime = host.RestartTime
+	}
+
 	// the time since the host was created
-	timeSinceCreation := now.Sub(host.CreationTime)
+	timeSinceCreation := now.Sub(startTime)
 
 	// the hours since the host was created, rounded up
 	hoursRoundedUp := time.Duration(math.Ceil(timeSinceCreation.Hours()))
 
 	// the next round number of hours the host will have been up - the time
 	// that the next payment will be due
-	nextPaymentTime := host.CreationTime.Add(hoursRoundedUp * time.Hour)
+	nextPaymentTime := startTime.Add(hoursRoundedUp * time.Hour)
 
 	return nextPaymentTime.Sub(now)
 
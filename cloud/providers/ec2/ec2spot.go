@@ -32,6 +32,7 @@ type EC2SpotManager struct {
 }
 
 type EC2SpotSettings struct {
+	// BidPrice is the most that will be paid per hour for any of the spot options
 	BidPrice float64 `mapstructure:"bid_price" json:"bid_price,omitempty" bson:"bid_price,omitempty"`
 
 	AMI          string       `mapstructure:"ami" json:"ami,omitempty" bson:"ami,omitempty"`
@@ -45,6 +46,26 @@ type EC2SpotSettings struct {
 	SubnetId string `mapstructure:"subnet_id" json:"subnet_id,omitempty" bson:"subnet_id,omitempty"`
 	// this is set to true if the security group is part of a vpc
 	IsVpc bool `mapstructure:"is_vpc" json:"is_vpc,omitempty" bson:"is_vpc,omitempty"`
+
+	// SpotOptions, if set, replace InstanceType and SubnetId with a list of
+	// instance types and subnets to fall back across when there's no capacity
+	SpotOptions []SpotOption `mapstructure:"spot_options" json:"spot_options,omitempty" bson:"spot_options,omitempty"`
+	// OptionWaitMins is how long to wait for a spot request to be fulfilled
+	// before requesting the next option. Zero waits for the first option.
+	OptionWaitMins int `mapstructure:"option_wait_mins" json:"option_wait_mins,omitempty" bson:"option_wait_mins,omitempty"`
+	// OnDemandAfterMins is how long after a host is requested to give up on
+	// spot capacity and start an on-demand instance. Zero never does.
+	OnDemandAfterMins int `mapstructure:"on_demand_after_mins" json:"on_demand_after_mins,omitempty" bson:"on_demand_after_mins,omitempty"`
+}
+
+// SpotOption is an instance type and subnet that a spot distro's hosts can run
+// on. Options with a higher weight are preferred.
+type SpotOption struct {
+	InstanceType string `mapstructure:"instance_type" json:"instance_type" bson:"instance_type"`
+	// only set in VPC; defaults to the distro's subnet
+	SubnetId string `mapstructure:"subnet_id" json:"subnet_id,omitempty" bson:"subnet_id,omitempty"`
+	// defaults to 1
+	Weight int `mapstructure:"weight" json:"weight,omitempty" bson:"weight,omitempty"`
 }
 
 func (self *EC2SpotSettings) Validate() error {
@@ -56,10 +77,23 @@ func (self *EC2SpotSettings) Validate() error {
 		return fmt.Errorf("AMI must not be blank")
 	}
 
-	if self.InstanceType == "" {
+	if self.InstanceType == "" && len(self.SpotOptions) == 0 {
 		return fmt.Errorf("Instance size must not be blank")
 	}
 
+	for _, option := range self.SpotOptions {
+		if option.InstanceType == "" {
+			return fmt.Errorf("Spot option instance sizes must not be blank")
+		}
+		if option.Weight < 0 {
+			return fmt.Errorf("Spot option weights must not be negative")
+		}
+	}
+
+	if self.OptionWaitMins < 0 || self.OnDemandAfterMins < 0 {
+		return fmt.Errorf("Spot fallback wait times must not be negative")
+	}
+
 	if self.SecurityGroup == "" {
 		return fmt.Errorf("Security group must not be blank")
 	}
@@ -117,6 +151,9 @@ func (cloudManager *EC2SpotManager) IsUp(host *host.Host) (bool, error) {
 }
 
 func (cloudManager *EC2SpotManager) OnUp(host *host.Host) error {
+	if onDemand, ok := cloudManager.onDemandManager(host); ok {
+		return onDemand.OnUp(host)
+	}
 	tags := makeTags(host)
 	tags["spot"] = "true" // mark this as a spot instance
 	spotReq, err := cloudManager.describeSpotRequest(host.Id)
@@ -145,10 +182,17 @@ func (cloudManager *EC2SpotManager) IsSSHReachable(host *host.Host, keyPath stri
 // Spot request closed or cancelled             -> StatusTerminated
 // Spot request failed due to bidding/capacity  -> StatusFailed
 //
+// Open and failed requests are replaced with the distro's next spot option,
+// or with an on-demand instance, by FallBack, which the monitor runs.
+//
 // For a *fulfilled* spot request (the spot request has an instance ID)
 // the status returned will be the status of the instance that fulfilled it,
 // matching the behavior used in cloud/providers/ec2/ec2.go
 func (cloudManager *EC2SpotManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
+	if onDemand, ok := cloudManager.onDemandManager(host); ok {
+		return onDemand.GetInstanceStatus(host)
+	}
+
 	spotDetails, err := cloudManager.describeSpotRequest(host.Id)
 	if err != nil {
 		return cloud.StatusUnknown, evergreen.Logger.Errorf(slogger.ERROR,
@@ -190,6 +234,10 @@ func (cloudManager *EC2SpotManager) CanSpawn() (bool, error) {
 }
 
 func (cloudManager *EC2SpotManager) GetDNSName(host *host.Host) (string, error) {
+	if onDemand, ok := cloudManager.onDemandManager(host); ok {
+		return onDemand.GetDNSName(host)
+	}
+
 	spotDetails, err := cloudManager.describeSpotRequest(host.Id)
 	if err != nil {
 		return "", evergreen.Logger.Errorf(slogger.ERROR, "failed to get spot request info for %v: %v", host.Id, err)
@@ -231,6 +279,7 @@ func (cloudManager *EC2SpotManager) SpawnInstance(d *distro.Distro, owner string
 	}
 
 	instanceName := generateName(d.Id)
+	option := ec2Settings.orderedOptions(instanceName)[0]
 	intentHost := &host.Host{
 		Id:               instanceName,
 		User:             d.User,
@@ -241,12 +290,15 @@ func (cloudManager *EC2SpotManager) SpawnInstance(d *distro.Distro, owner string
 		TerminationTime:  util.ZeroTime,
 		TaskDispatchTime: util.ZeroTime,
 		Provider:         SpotProviderName,
-		InstanceType:     ec2Settings.InstanceType,
+		InstanceType:     option.InstanceType,
 		RunningTask:      "",
 		Provisioned:      false,
 		StartedBy:        owner,
 		UserHost:         userHost,
 	}
+	if ec2Settings.IsVpc {
+		intentHost.SubnetId = option.SubnetId
+	}
 
 	// record this 'intent host'
 	if err := intentHost.Insert(); err != nil {
@@ -258,31 +310,14 @@ func (cloudManager *EC2SpotManager) SpawnInstance(d *distro.Distro, owner string
 		"for distro “%v” to signal cloud instance spawn intent", instanceName,
 		d.Id)
 
-	spotRequest := &ec2.RequestSpotInstances{
-		SpotPrice:      fmt.Sprintf("%v", ec2Settings.BidPrice),
-		InstanceCount:  1,
-		ImageId:        ec2Settings.AMI,
-		KeyName:        ec2Settings.KeyName,
-		InstanceType:   ec2Settings.InstanceType,
-		SecurityGroups: ec2.SecurityGroupNames(ec2Settings.SecurityGroup),
-		BlockDevices:   blockDevices,
-	}
-
-	// if the spot instance is a vpc then set the appropriate fields
-	if ec2Settings.IsVpc {
-		spotRequest.SecurityGroups = ec2.SecurityGroupIds(ec2Settings.SecurityGroup)
-		spotRequest.AssociatePublicIpAddress = true
-		spotRequest.SubnetId = ec2Settings.SubnetId
-	}
-
-	spotResp, err := ec2Handle.RequestSpotInstances(spotRequest)
+	spotResp, err := ec2Handle.RequestSpotInstances(makeSpotRequest(ec2Settings, option, blockDevices))
 	if err != nil {
 		//Remove the intent host if the API call failed
 		if err := intentHost.Remove(); err != nil {
 			evergreen.Logger.Logf(slogger.ERROR, "Failed to remove intent host %v: %v", intentHost.Id, err)
 		}
-		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Failed starting spot instance "+
-			" for distro '%v' on intent host %v: %v", d.Id, intentHost.Id, err)
+		return nil, &cloud.ProviderError{Err: evergreen.Logger.Errorf(slogger.ERROR, "Failed starting spot instance "+
+			" for distro '%v' on intent host %v: %v", d.Id, intentHost.Id, err)}
 	}
 
 	spotReqRes := spotResp.SpotRequestResults[0]
@@ -343,6 +378,10 @@ func (cloudManager *EC2SpotManager) TerminateInstance(host *host.Host) error {
 		return errMsg
 	}
 
+	if onDemand, ok := cloudManager.onDemandManager(host); ok {
+		return onDemand.TerminateInstance(host)
+	}
+
 	spotDetails, err := cloudManager.describeSpotRequest(host.Id)
 	if err != nil {
 		ec2err, ok := err.(*ec2.Error)
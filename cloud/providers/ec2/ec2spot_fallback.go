@@ -0,0 +1,278 @@
+package ec2
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/goamz/goamz/ec2"
+	"github.com/mitchellh/mapstructure"
+)
+
+// fallbackStep is what to do for a host whose spot request hasn't been
+// fulfilled: request another spot option, or start an on-demand instance.
+type fallbackStep struct {
+	option   SpotOption
+	onDemand bool
+}
+
+type byWeight []SpotOption
+
+func (s byWeight) Len() int           { return len(s) }
+func (s byWeight) Less(i, j int) bool { return s[i].Weight > s[j].Weight }
+func (s byWeight) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// orderedOptions returns the distro's spot options in the order a host should
+// try them. The first is picked in proportion to the options' weights, so
+// the distro's hosts are spread across them, and the rest are tried from the
+// highest weight to the lowest. The seed makes the order the same every
+// time it's computed for a host.
+func (self *EC2SpotSettings) orderedOptions(seed string) []SpotOption {
+	options := self.SpotOptions
+	if len(options) == 0 {
+		options = []SpotOption{{InstanceType: self.InstanceType}}
+	}
+
+	ordered := make([]SpotOption, len(options))
+	total := 0
+	for i, option := range options {
+		if option.Weight == 0 {
+			option.Weight = 1
+		}
+		if option.SubnetId == "" {
+			option.SubnetId = self.SubnetId
+		}
+		ordered[i] = option
+		total += option.Weight
+	}
+	sort.Stable(byWeight(ordered))
+
+	hash := fnv.New32a()
+	hash.Write([]byte(seed))
+	pick := int(hash.Sum32() % uint32(total))
+	for i, option := range ordered {
+		if pick < option.Weight {
+			copy(ordered[1:i+1], ordered[:i])
+			ordered[0] = option
+			break
+		}
+		pick -= option.Weight
+	}
+	return ordered
+}
+
+// optionIndex returns the position of the option a spot request was made
+// for, or -1 if it isn't one of the options.
+func (self *EC2SpotSettings) optionIndex(options []SpotOption, spec ec2.SpotLaunchSpec) int {
+	for i, option := range options {
+		if option.InstanceType == spec.InstanceType && (!self.IsVpc || option.SubnetId == spec.SubnetId) {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextFallback returns what to do for a host whose spot request hasn't been
+// fulfilled, or nil if it should keep waiting. Requests move on to the next
+// option when they fail or have waited too long, and hosts fall back to
+// on-demand instances of their most preferred option once they've waited too
+// long overall, or when the last option fails.
+func (self *EC2SpotSettings) nextFallback(h *host.Host, spotDetails *ec2.SpotRequestResult, now time.Time) *fallbackStep {
+	options := self.orderedOptions(h.Tag)
+	onDemandAfter := time.Duration(self.OnDemandAfterMins) * time.Minute
+	if onDemandAfter > 0 && now.Sub(h.CreationTime) >= onDemandAfter {
+		return &fallbackStep{option: options[0], onDemand: true}
+	}
+
+	requestedAt, err := time.Parse(time.RFC3339, spotDetails.CreateTime)
+	if err != nil {
+		requestedAt = h.CreationTime
+	}
+	failed := spotDetails.State == SpotStatusFailed
+	optionWait := time.Duration(self.OptionWaitMins) * time.Minute
+	if !failed && (optionWait == 0 || now.Sub(requestedAt) < optionWait) {
+		return nil
+	}
+
+	next := self.optionIndex(options, spotDetails.SpotLaunchSpec) + 1
+	if next < len(options) {
+		return &fallbackStep{option: options[next]}
+	}
+	if failed && onDemandAfter > 0 {
+		return &fallbackStep{option: options[0], onDemand: true}
+	}
+	return nil
+}
+
+// makeSpotRequest returns the request for a spot instance of the option.
+func makeSpotRequest(ec2Settings *EC2SpotSettings, option SpotOption,
+	blockDevices []ec2.BlockDeviceMapping) *ec2.RequestSpotInstances {
+	spotRequest := &ec2.RequestSpotInstances{
+		SpotPrice:      fmt.Sprintf("%v", ec2Settings.BidPrice),
+		InstanceCount:  1,
+		ImageId:        ec2Settings.AMI,
+		KeyName:        ec2Settings.KeyName,
+		InstanceType:   option.InstanceType,
+		SecurityGroups: ec2.SecurityGroupNames(ec2Settings.SecurityGroup),
+		BlockDevices:   blockDevices,
+	}
+
+	// if the spot instance is a vpc then set the appropriate fields
+	if ec2Settings.IsVpc {
+		spotRequest.SecurityGroups = ec2.SecurityGroupIds(ec2Settings.SecurityGroup)
+		spotRequest.AssociatePublicIpAddress = true
+		spotRequest.SubnetId = option.SubnetId
+	}
+	return spotRequest
+}
+
+// makeOnDemandRequest returns the request for an on-demand instance of the
+// option.
+func makeOnDemandRequest(ec2Settings *EC2SpotSettings, option SpotOption,
+	blockDevices []ec2.BlockDeviceMapping) *ec2.RunInstancesOptions {
+	options := &ec2.RunInstancesOptions{
+		MinCount:       1,
+		MaxCount:       1,
+		ImageId:        ec2Settings.AMI,
+		KeyName:        ec2Settings.KeyName,
+		InstanceType:   option.InstanceType,
+		SecurityGroups: ec2.SecurityGroupNames(ec2Settings.SecurityGroup),
+		BlockDevices:   blockDevices,
+	}
+
+	if ec2Settings.IsVpc {
+		options.SecurityGroups = ec2.SecurityGroupIds(ec2Settings.SecurityGroup)
+		options.AssociatePublicIpAddress = true
+		options.SubnetId = option.SubnetId
+	}
+	return options
+}
+
+// onDemandManager returns a manager for hosts of spot distros that fell back
+// to on-demand instances.
+func (cloudManager *EC2SpotManager) onDemandManager(h *host.Host) (*EC2Manager, bool) {
+	if h.Provider != OnDemandProviderName {
+		return nil, false
+	}
+	return &EC2Manager{awsCredentials: cloudManager.awsCredentials}, true
+}
+
+// FallBack replaces the host's spot request with the next step for the host,
+// if the request hasn't been fulfilled and it's time to, and returns whether
+// it did. The replacement is started before the request is cancelled, and
+// abandoned if the request turns out to have been fulfilled in the meantime.
+// Hosts that fell back to on-demand instances are left alone.
+func (cloudManager *EC2SpotManager) FallBack(h *host.Host) (bool, error) {
+	if h.Provider != SpotProviderName {
+		return false, nil
+	}
+	spotDetails, err := cloudManager.describeSpotRequest(h.Id)
+	if err != nil {
+		return false, fmt.Errorf("failed to get spot request info for %v: %v", h.Id, err)
+	}
+	switch {
+	case spotDetails.InstanceId != "":
+		return false, nil
+	case spotDetails.State != SpotStatusOpen && spotDetails.State != SpotStatusActive &&
+		spotDetails.State != SpotStatusFailed:
+		return false, nil
+	}
+
+	ec2Settings := &EC2SpotSettings{}
+	if err = mapstructure.Decode(h.Distro.ProviderSettings, ec2Settings); err != nil {
+		return false, fmt.Errorf("Error decoding params for distro %v: %v", h.Distro.Id, err)
+	}
+	step := ec2Settings.nextFallback(h, spotDetails, time.Now())
+	if step == nil {
+		return false, nil
+	}
+
+	blockDevices, err := makeBlockDeviceMappings(ec2Settings.MountPoints)
+	if err != nil {
+		return false, err
+	}
+
+	ec2Handle := getUSEast(*cloudManager.awsCredentials)
+	var newId, provider string
+	if step.onDemand {
+		evergreen.Logger.Logf(slogger.INFO, "Falling back to an on-demand %v instance "+
+			"for spot request %v", step.option.InstanceType, h.Id)
+		resp, err := ec2Handle.RunInstances(makeOnDemandRequest(ec2Settings, step.option, blockDevices))
+		if err != nil {
+			return false, evergreen.Logger.Errorf(slogger.ERROR, "Failed starting on-demand "+
+				"instance to replace spot request %v: %v", h.Id, err)
+		}
+		newId, provider = resp.Instances[0].InstanceId, OnDemandProviderName
+	} else {
+		evergreen.Logger.Logf(slogger.INFO, "Falling back to a %v spot instance in '%v' "+
+			"for spot request %v", step.option.InstanceType, step.option.SubnetId, h.Id)
+		resp, err := ec2Handle.RequestSpotInstances(makeSpotRequest(ec2Settings, step.option, blockDevices))
+		if err != nil {
+			return false, evergreen.Logger.Errorf(slogger.ERROR, "Failed requesting spot "+
+				"instance to replace spot request %v: %v", h.Id, err)
+		}
+		newId, provider = resp.SpotRequestResults[0].SpotRequestId, SpotProviderName
+	}
+
+	abandon := func() {
+		var err error
+		if step.onDemand {
+			_, err = ec2Handle.TerminateInstances([]string{newId})
+		} else {
+			_, err = ec2Handle.CancelSpotRequests([]string{newId})
+		}
+		if err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Failed to abandon %v: %v", newId, err)
+		}
+	}
+
+	if _, err = ec2Handle.CancelSpotRequests([]string{h.Id}); err != nil {
+		abandon()
+		return false, evergreen.Logger.Errorf(slogger.ERROR, "Failed to cancel spot "+
+			"request %v: %v", h.Id, err)
+	}
+	if cancelled, err := cloudManager.describeSpotRequest(h.Id); err == nil && cancelled.InstanceId != "" {
+		evergreen.Logger.Logf(slogger.INFO, "Spot request %v was fulfilled before it was "+
+			"cancelled; keeping its instance", h.Id)
+		abandon()
+		return false, nil
+	}
+
+	subnetId := ""
+	if ec2Settings.IsVpc {
+		subnetId = step.option.SubnetId
+	}
+	oldId := h.Id
+	if err = replaceHost(h, newId, provider, step.option.InstanceType, subnetId); err != nil {
+		abandon()
+		return false, evergreen.Logger.Errorf(slogger.ERROR, "Failed to replace host %v "+
+			"with %v: %v", oldId, newId, err)
+	}
+	if err = attachTags(ec2Handle, makeTags(h), newId); err != nil {
+		evergreen.Logger.Errorf(slogger.ERROR, "Unable to attach tags for %v: %v", newId, err)
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Replaced spot request %v with %v", oldId, newId)
+	return true, nil
+}
+
+// replaceHost moves a host's document to a new id, recording the provider,
+// instance type and subnet of whatever the id refers to.
+func replaceHost(h *host.Host, newId, provider, instanceType, subnetId string) error {
+	replacement := *h
+	replacement.Id = newId
+	replacement.Provider = provider
+	replacement.InstanceType = instanceType
+	replacement.SubnetId = subnetId
+	if err := replacement.Insert(); err != nil {
+		return err
+	}
+	if err := h.Remove(); err != nil {
+		return err
+	}
+	*h = replacement
+	return nil
+}
@@ -0,0 +1,136 @@
+package ec2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/goamz/goamz/ec2"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSpotOptions(t *testing.T) {
+	Convey("With a spot distro that has several options", t, func() {
+		settings := &EC2SpotSettings{
+			BidPrice:      0.5,
+			AMI:           "ami-1ecae776",
+			KeyName:       "mci",
+			SecurityGroup: "sg-1234",
+			SubnetId:      "subnet-a",
+			IsVpc:         true,
+			SpotOptions: []SpotOption{
+				{InstanceType: "c4.xlarge"},
+				{InstanceType: "m4.xlarge", SubnetId: "subnet-b", Weight: 3},
+				{InstanceType: "c3.xlarge", Weight: 2},
+			},
+			OptionWaitMins:    5,
+			OnDemandAfterMins: 20,
+		}
+
+		Convey("every option should be tried, first the weighted pick then by weight", func() {
+			picked := map[string]int{}
+			for _, seed := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+				options := settings.orderedOptions(seed)
+				So(len(options), ShouldEqual, 3)
+				So(options, ShouldResemble, settings.orderedOptions(seed))
+				picked[options[0].InstanceType]++
+
+				rest := options[1:]
+				So(rest[0].Weight, ShouldBeGreaterThanOrEqualTo, rest[1].Weight)
+			}
+			So(len(picked), ShouldBeGreaterThan, 1)
+		})
+
+		Convey("options should default their subnet and weight", func() {
+			for _, option := range settings.orderedOptions("seed") {
+				switch option.InstanceType {
+				case "c4.xlarge":
+					So(option.SubnetId, ShouldEqual, "subnet-a")
+					So(option.Weight, ShouldEqual, 1)
+				case "m4.xlarge":
+					So(option.SubnetId, ShouldEqual, "subnet-b")
+				}
+			}
+		})
+
+		Convey("distros without options should use their instance type", func() {
+			settings.SpotOptions = nil
+			settings.InstanceType = "m3.large"
+			So(settings.orderedOptions("seed"), ShouldResemble,
+				[]SpotOption{{InstanceType: "m3.large", SubnetId: "subnet-a", Weight: 1}})
+		})
+
+		Convey("spot requests should be for the option's type and subnet", func() {
+			option := SpotOption{InstanceType: "m4.xlarge", SubnetId: "subnet-b"}
+			request := makeSpotRequest(settings, option, nil)
+			So(request.SpotPrice, ShouldEqual, "0.5")
+			So(request.InstanceType, ShouldEqual, "m4.xlarge")
+			So(request.SubnetId, ShouldEqual, "subnet-b")
+			So(request.AssociatePublicIpAddress, ShouldBeTrue)
+
+			onDemand := makeOnDemandRequest(settings, option, nil)
+			So(onDemand.InstanceType, ShouldEqual, "m4.xlarge")
+			So(onDemand.SubnetId, ShouldEqual, "subnet-b")
+		})
+
+		Convey("unfulfilled requests should fall back in order", func() {
+			now := time.Now()
+			h := &host.Host{Id: "sir-1", Tag: "evg-ubuntu-1", CreationTime: now.Add(-6 * time.Minute)}
+			options := settings.orderedOptions(h.Tag)
+			spotDetails := &ec2.SpotRequestResult{
+				State:      SpotStatusOpen,
+				CreateTime: now.Add(-6 * time.Minute).Format(time.RFC3339),
+			}
+			spotDetails.SpotLaunchSpec.InstanceType = options[0].InstanceType
+			spotDetails.SpotLaunchSpec.SubnetId = options[0].SubnetId
+
+			Convey("to the next option once the request has waited long enough", func() {
+				So(settings.nextFallback(h, spotDetails, now), ShouldResemble, &fallbackStep{option: options[1]})
+
+				spotDetails.CreateTime = now.Add(-time.Minute).Format(time.RFC3339)
+				So(settings.nextFallback(h, spotDetails, now), ShouldBeNil)
+
+				settings.OptionWaitMins = 0
+				spotDetails.CreateTime = now.Add(-6 * time.Minute).Format(time.RFC3339)
+				So(settings.nextFallback(h, spotDetails, now), ShouldBeNil)
+			})
+
+			Convey("to the next option as soon as the request fails", func() {
+				spotDetails.State = SpotStatusFailed
+				spotDetails.CreateTime = now.Format(time.RFC3339)
+				So(settings.nextFallback(h, spotDetails, now), ShouldResemble, &fallbackStep{option: options[1]})
+			})
+
+			Convey("to on-demand when the last option fails", func() {
+				spotDetails.State = SpotStatusFailed
+				spotDetails.SpotLaunchSpec.InstanceType = options[2].InstanceType
+				spotDetails.SpotLaunchSpec.SubnetId = options[2].SubnetId
+				So(settings.nextFallback(h, spotDetails, now), ShouldResemble,
+					&fallbackStep{option: options[0], onDemand: true})
+
+				settings.OnDemandAfterMins = 0
+				So(settings.nextFallback(h, spotDetails, now), ShouldBeNil)
+			})
+
+			Convey("to on-demand once the host has waited long enough", func() {
+				h.CreationTime = now.Add(-21 * time.Minute)
+				So(settings.nextFallback(h, spotDetails, now), ShouldResemble,
+					&fallbackStep{option: options[0], onDemand: true})
+			})
+		})
+
+		Convey("distro settings should be validated", func() {
+			So(settings.Validate(), ShouldBeNil)
+			for _, modify := range []func(*EC2SpotSettings){
+				func(s *EC2SpotSettings) { s.SpotOptions = []SpotOption{{Weight: 1}} },
+				func(s *EC2SpotSettings) { s.SpotOptions = []SpotOption{{InstanceType: "c4.xlarge", Weight: -1}} },
+				func(s *EC2SpotSettings) { s.OptionWaitMins = -1 },
+				func(s *EC2SpotSettings) { s.SpotOptions = nil },
+			} {
+				invalid := *settings
+				modify(&invalid)
+				So(invalid.Validate(), ShouldNotBeNil)
+			}
+		})
+	})
+}
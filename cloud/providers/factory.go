@@ -8,13 +8,19 @@ import (
 	"github.com/evergreen-ci/evergreen/cloud/providers/digitalocean"
 	"github.com/evergreen-ci/evergreen/cloud/providers/docker"
 	"github.com/evergreen-ci/evergreen/cloud/providers/ec2"
+	"github.com/evergreen-ci/evergreen/cloud/providers/gce"
+	"github.com/evergreen-ci/evergreen/cloud/providers/kubernetes"
+	"github.com/evergreen-ci/evergreen/cloud/providers/libvirt"
 	"github.com/evergreen-ci/evergreen/cloud/providers/mock"
+	"github.com/evergreen-ci/evergreen/cloud/providers/openstack"
 	"github.com/evergreen-ci/evergreen/cloud/providers/static"
 	"github.com/evergreen-ci/evergreen/model/host"
 )
 
-// GetCloudManager returns an implementation of CloudManager for the given provider name.
-// It returns an error if the provider name doesn't have a known implementation.
+// GetCloudManager returns an implementation of CloudManager for the given provider name,
+// wrapped to record its calls to the provider and to stop spawning hosts for distros whose
+// spawns keep failing. It returns an error if the provider name doesn't have a known
+// implementation.
 func GetCloudManager(providerName string, settings *evergreen.Settings) (cloud.CloudManager, error) {
 
 	var provider cloud.CloudManager
@@ -30,16 +36,28 @@ func GetCloudManager(providerName string, settings *evergreen.Settings) (cloud.C
 	case ec2.SpotProviderName:
 		provider = &ec2.EC2SpotManager{}
 	case docker.ProviderName:
-		provider = &docker.DockerManager{}
+		provider = &docker.DockerManager{
+			ParentManager: func(providerName string) (cloud.CloudManager, error) {
+				return GetCloudManager(providerName, settings)
+			},
+		}
+	case openstack.ProviderName:
+		provider = &openstack.OpenStackManager{}
+	case gce.ProviderName:
+		provider = &gce.GCEManager{}
+	case libvirt.ProviderName:
+		provider = &libvirt.LibvirtManager{}
+	case kubernetes.ProviderName:
+		provider = &kubernetes.KubernetesManager{}
 	default:
 		return nil, fmt.Errorf("No known provider for '%v'", providerName)
 	}
 
 	if err := provider.Configure(settings); err != nil {
-		return nil, fmt.Errorf("Failed to configure ec2 provider: %v", err)
+		return nil, fmt.Errorf("Failed to configure %v provider: %v", providerName, err)
 	}
 
-	return provider, nil
+	return instrument(providerName, provider), nil
 }
 
 // GetCloudHost returns an instance of CloudHost wrapping the given model.Host,
@@ -4,9 +4,14 @@ import (
 	"testing"
 
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers/digitalocean"
 	"github.com/evergreen-ci/evergreen/cloud/providers/ec2"
+	"github.com/evergreen-ci/evergreen/cloud/providers/gce"
+	"github.com/evergreen-ci/evergreen/cloud/providers/kubernetes"
+	"github.com/evergreen-ci/evergreen/cloud/providers/libvirt"
 	"github.com/evergreen-ci/evergreen/cloud/providers/mock"
+	"github.com/evergreen-ci/evergreen/cloud/providers/openstack"
 	"github.com/evergreen-ci/evergreen/cloud/providers/static"
 	"github.com/evergreen-ci/evergreen/model/host"
 	. "github.com/smartystreets/goconvey/convey"
@@ -19,35 +24,86 @@ func TestGetCloudManager(t *testing.T) {
 			cloudMgr, err := GetCloudManager("ec2", evergreen.TestConfig())
 			So(cloudMgr, ShouldNotBeNil)
 			So(err, ShouldBeNil)
-			So(cloudMgr, ShouldHaveSameTypeAs, &ec2.EC2Manager{})
+			So(unwrap(cloudMgr), ShouldHaveSameTypeAs, &ec2.EC2Manager{})
 		})
 
 		Convey("EC2Spot should be returned for ec2-spot provider name", func() {
 			cloudMgr, err := GetCloudManager("ec2-spot", evergreen.TestConfig())
 			So(cloudMgr, ShouldNotBeNil)
 			So(err, ShouldBeNil)
-			So(cloudMgr, ShouldHaveSameTypeAs, &ec2.EC2SpotManager{})
+			So(unwrap(cloudMgr), ShouldHaveSameTypeAs, &ec2.EC2SpotManager{})
 		})
 
 		Convey("Static should be returned for static provider name", func() {
 			cloudMgr, err := GetCloudManager("static", evergreen.TestConfig())
 			So(cloudMgr, ShouldNotBeNil)
 			So(err, ShouldBeNil)
-			So(cloudMgr, ShouldHaveSameTypeAs, &static.StaticManager{})
+			So(unwrap(cloudMgr), ShouldHaveSameTypeAs, &static.StaticManager{})
 		})
 
 		Convey("Mock should be returned for mock provider name", func() {
 			cloudMgr, err := GetCloudManager("mock", evergreen.TestConfig())
 			So(cloudMgr, ShouldNotBeNil)
 			So(err, ShouldBeNil)
-			So(cloudMgr, ShouldHaveSameTypeAs, &mock.MockCloudManager{})
+			So(unwrap(cloudMgr), ShouldHaveSameTypeAs, &mock.MockCloudManager{})
 		})
 
 		Convey("DigitalOcean should be returned for digitalocean provider name", func() {
 			cloudMgr, err := GetCloudManager("digitalocean", evergreen.TestConfig())
 			So(cloudMgr, ShouldNotBeNil)
 			So(err, ShouldBeNil)
-			So(cloudMgr, ShouldHaveSameTypeAs, &digitalocean.DigitalOceanManager{})
+			So(unwrap(cloudMgr), ShouldHaveSameTypeAs, &digitalocean.DigitalOceanManager{})
+		})
+
+		Convey("OpenStack should be returned for openstack provider name", func() {
+			cloudMgr, err := GetCloudManager("openstack", evergreen.TestConfig())
+			So(cloudMgr, ShouldNotBeNil)
+			So(err, ShouldBeNil)
+			So(unwrap(cloudMgr), ShouldHaveSameTypeAs, &openstack.OpenStackManager{})
+		})
+
+		Convey("GCE should be returned for gce provider name", func() {
+			cloudMgr, err := GetCloudManager("gce", evergreen.TestConfig())
+			So(cloudMgr, ShouldNotBeNil)
+			So(err, ShouldBeNil)
+			So(unwrap(cloudMgr), ShouldHaveSameTypeAs, &gce.GCEManager{})
+		})
+
+		Convey("libvirt should be returned for libvirt provider name", func() {
+			cloudMgr, err := GetCloudManager("libvirt", evergreen.TestConfig())
+			So(cloudMgr, ShouldNotBeNil)
+			So(err, ShouldBeNil)
+			So(unwrap(cloudMgr), ShouldHaveSameTypeAs, &libvirt.LibvirtManager{})
+		})
+
+		Convey("Kubernetes should be returned for kubernetes provider name", func() {
+			cloudMgr, err := GetCloudManager("kubernetes", evergreen.TestConfig())
+			So(cloudMgr, ShouldNotBeNil)
+			So(err, ShouldBeNil)
+			So(unwrap(cloudMgr), ShouldHaveSameTypeAs, &kubernetes.KubernetesManager{})
+		})
+
+		Convey("Managers should keep their optional interfaces when they're wrapped", func() {
+			cloudMgr, err := GetCloudManager("ec2", evergreen.TestConfig())
+			So(err, ShouldBeNil)
+			_, ok := cloudMgr.(cloud.Stopper)
+			So(ok, ShouldBeTrue)
+			_, ok = cloudMgr.(cloud.Executor)
+			So(ok, ShouldBeFalse)
+
+			cloudMgr, err = GetCloudManager("kubernetes", evergreen.TestConfig())
+			So(err, ShouldBeNil)
+			_, ok = cloudMgr.(cloud.Executor)
+			So(ok, ShouldBeTrue)
+			_, ok = cloudMgr.(cloud.Stopper)
+			So(ok, ShouldBeFalse)
+
+			cloudMgr, err = GetCloudManager("static", evergreen.TestConfig())
+			So(err, ShouldBeNil)
+			_, ok = cloudMgr.(cloud.Executor)
+			So(ok, ShouldBeFalse)
+			_, ok = cloudMgr.(cloud.Stopper)
+			So(ok, ShouldBeFalse)
 		})
 
 		Convey("Invalid provider names should return nil with err", func() {
@@ -60,6 +116,13 @@ func TestGetCloudManager(t *testing.T) {
 
 }
 
+// unwrap returns the manager an instrumented manager wraps.
+func unwrap(cloudMgr cloud.CloudManager) cloud.CloudManager {
+	return cloudMgr.(interface {
+		Unwrap() cloud.CloudManager
+	}).Unwrap()
+}
+
 func TestIsHostReachable(t *testing.T) {
 	Convey("A reachable static host should return true", t, func() {
 		// try with a reachable static host
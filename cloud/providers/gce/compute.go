@@ -0,0 +1,362 @@
+package gce
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+// GCE instance statuses, see
+// https://cloud.google.com/compute/docs/instances/checking-instance-status
+const (
+	InstanceStatusProvisioning = "PROVISIONING"
+	InstanceStatusStaging      = "STAGING"
+	InstanceStatusRunning      = "RUNNING"
+	InstanceStatusStopping     = "STOPPING"
+	InstanceStatusStopped      = "STOPPED"
+	InstanceStatusSuspending   = "SUSPENDING"
+	InstanceStatusSuspended    = "SUSPENDED"
+	InstanceStatusTerminated   = "TERMINATED"
+
+	// the operation GCE records when it preempts an instance
+	preemptedOperationType = "compute.instances.preempted"
+
+	DefaultEndpoint = "https://www.googleapis.com/compute/v1/"
+	DefaultTokenURL = "https://www.googleapis.com/oauth2/v4/token"
+	computeScope    = "https://www.googleapis.com/auth/compute"
+
+	// tokens are renewed this long before they expire
+	tokenExpiryWindow = time.Minute
+)
+
+// errInstanceNotFound is returned when GCE doesn't know about an instance.
+var errInstanceNotFound = fmt.Errorf("instance not found")
+
+// computeClient makes requests to the Compute Engine API, authenticating as a
+// service account with a signed JWT.
+type computeClient struct {
+	config     evergreen.GCEConfig
+	httpClient *http.Client
+
+	// mu guards the token, which is set by authenticate
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+}
+
+// instance is the part of a GCE instance that Evergreen uses.
+type instance struct {
+	Id                string             `json:"id,omitempty"`
+	Name              string             `json:"name"`
+	Status            string             `json:"status,omitempty"`
+	MachineType       string             `json:"machineType"`
+	Disks             []attachedDisk     `json:"disks,omitempty"`
+	NetworkInterfaces []networkInterface `json:"networkInterfaces,omitempty"`
+	Scheduling        *scheduling        `json:"scheduling,omitempty"`
+	Labels            map[string]string  `json:"labels,omitempty"`
+	Tags              *tags              `json:"tags,omitempty"`
+}
+
+type attachedDisk struct {
+	Boot             bool                    `json:"boot"`
+	AutoDelete       bool                    `json:"autoDelete"`
+	InitializeParams *attachedDiskInitParams `json:"initializeParams,omitempty"`
+}
+
+type attachedDiskInitParams struct {
+	SourceImage string `json:"sourceImage"`
+	DiskSizeGb  int64  `json:"diskSizeGb,omitempty,string"`
+	DiskType    string `json:"diskType,omitempty"`
+}
+
+type networkInterface struct {
+	Network       string         `json:"network,omitempty"`
+	Subnetwork    string         `json:"subnetwork,omitempty"`
+	NetworkIP     string         `json:"networkIP,omitempty"`
+	AccessConfigs []accessConfig `json:"accessConfigs,omitempty"`
+}
+
+type accessConfig struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	NatIP string `json:"natIP,omitempty"`
+}
+
+type scheduling struct {
+	Preemptible       bool   `json:"preemptible"`
+	AutomaticRestart  bool   `json:"automaticRestart"`
+	OnHostMaintenance string `json:"onHostMaintenance"`
+}
+
+type tags struct {
+	Items []string `json:"items"`
+}
+
+// operation is the part of a zone operation that Evergreen uses.
+type operation struct {
+	Name          string `json:"name"`
+	OperationType string `json:"operationType"`
+	TargetLink    string `json:"targetLink"`
+	Status        string `json:"status"`
+}
+
+var (
+	// clients are shared by the managers with the same settings, so they
+	// share a token instead of each authenticating
+	clientsMu sync.Mutex
+	clients   = map[evergreen.GCEConfig]*computeClient{}
+)
+
+func newComputeClient(config evergreen.GCEConfig) *computeClient {
+	if config.Endpoint == "" {
+		config.Endpoint = DefaultEndpoint
+	}
+	if config.TokenURL == "" {
+		config.TokenURL = DefaultTokenURL
+	}
+	return &computeClient{
+		config:     config,
+		httpClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// getComputeClient returns the client for the settings, creating it the first
+// time they are used.
+func getComputeClient(config evergreen.GCEConfig) *computeClient {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	client, ok := clients[config]
+	if !ok {
+		client = newComputeClient(config)
+		clients[config] = client
+	}
+	return client
+}
+
+// parsePrivateKey reads the service account's PEM encoded RSA key, which
+// Google issues in PKCS#8 form.
+func parsePrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signedJWT returns the assertion that is exchanged for an access token.
+func (c *computeClient) signedJWT(now time.Time) (string, error) {
+	key, err := parsePrivateKey(c.config.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":   c.config.ClientEmail,
+		"scope": computeScope,
+		"aud":   c.config.TokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("error signing token request: %v", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// authenticate exchanges a signed JWT for a new access token.
+func (c *computeClient) authenticate() error {
+	now := time.Now()
+	assertion, err := c.signedJWT(now)
+	if err != nil {
+		return fmt.Errorf("error authenticating with google: %v", err)
+	}
+	resp, err := c.httpClient.PostForm(c.config.TokenURL, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return fmt.Errorf("error authenticating with google: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error authenticating with google: %v", util.ResponseError(resp))
+	}
+
+	tokenResp := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return fmt.Errorf("error reading google access token: %v", err)
+	}
+	if tokenResp.AccessToken == "" {
+		return fmt.Errorf("google returned an empty access token")
+	}
+
+	c.token = tokenResp.AccessToken
+	c.tokenExpiry = now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+	return nil
+}
+
+// getToken returns the access token, authenticating first if it is missing or
+// about to expire.
+func (c *computeClient) getToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" || time.Now().Add(tokenExpiryWindow).After(c.tokenExpiry) {
+		if err := c.authenticate(); err != nil {
+			return "", err
+		}
+	}
+	return c.token, nil
+}
+
+// expireToken forgets a token that GCE rejected, unless another request has
+// already replaced it.
+func (c *computeClient) expireToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// do makes a request to the compute API for the configured project,
+// authenticating first if the token is missing or about to expire, and once
+// more if GCE rejects it.
+func (c *computeClient) do(method, path string, body, result interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	reqURL := strings.TrimSuffix(c.config.Endpoint, "/") + "/projects/" + c.config.ProjectId + path
+
+	for attempt := 0; ; attempt++ {
+		token, err := c.getToken()
+		if err != nil {
+			return err
+		}
+
+		var bodyReader io.Reader
+		if reqBody != nil {
+			bodyReader = bytes.NewReader(reqBody)
+		}
+		req, err := http.NewRequest(method, reqURL, bodyReader)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		if reqBody != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			c.expireToken(token)
+			continue
+		case resp.StatusCode == http.StatusNotFound:
+			return errInstanceNotFound
+		case resp.StatusCode >= 300:
+			return util.ResponseError(resp)
+		}
+		if result == nil {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+}
+
+// insertInstance starts creating an instance in the zone. GCE finishes
+// creating it asynchronously.
+func (c *computeClient) insertInstance(zone string, inst *instance) error {
+	if err := c.do("POST", "/zones/"+zone+"/instances", inst, nil); err != nil {
+		return fmt.Errorf("error creating instance: %v", err)
+	}
+	return nil
+}
+
+// getInstance returns the instance with the name.
+func (c *computeClient) getInstance(zone, name string) (*instance, error) {
+	inst := &instance{}
+	if err := c.do("GET", "/zones/"+zone+"/instances/"+name, nil, inst); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+// deleteInstance deletes the instance with the name.
+func (c *computeClient) deleteInstance(zone, name string) error {
+	return c.do("DELETE", "/zones/"+zone+"/instances/"+name, nil, nil)
+}
+
+// wasPreempted returns whether GCE has recorded preempting the instance. The
+// zone's operations are filtered to the instance's preemptions, and read a page
+// at a time.
+func (c *computeClient) wasPreempted(zone, name string) (bool, error) {
+	query := url.Values{"filter": {fmt.Sprintf("(operationType eq %v) (targetLink eq .*/instances/%v)",
+		preemptedOperationType, name)}}
+	for {
+		resp := struct {
+			Items         []operation `json:"items"`
+			NextPageToken string      `json:"nextPageToken"`
+		}{}
+		if err := c.do("GET", "/zones/"+zone+"/operations?"+query.Encode(), nil, &resp); err != nil {
+			return false, fmt.Errorf("error listing operations: %v", err)
+		}
+		for _, op := range resp.Items {
+			if op.OperationType == preemptedOperationType &&
+				strings.HasSuffix(op.TargetLink, "/instances/"+name) {
+				return true, nil
+			}
+		}
+		if resp.NextPageToken == "" {
+			return false, nil
+		}
+		query.Set("pageToken", resp.NextPageToken)
+	}
+}
@@ -0,0 +1,456 @@
+package gce
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"github.com/evergreen-ci/evergreen/hostutil"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	ProviderName = "gce"
+
+	// NameTimeFormat is the format in which to log times like instance start time.
+	NameTimeFormat = "20060102150405"
+
+	// GCE bills a minimum of ten minutes for each instance
+	minimumBilledTime = 10 * time.Minute
+
+	// names and labels are limited to 63 characters
+	maxNameLength = 63
+)
+
+// GCEManager implements the CloudManager interface for Google Compute Engine
+// instances.
+type GCEManager struct {
+	client *computeClient
+}
+
+// Settings are the distro's settings for the instances it creates.
+type Settings struct {
+	// Zone is the zone to create instances in, e.g. us-east1-b
+	Zone string `mapstructure:"zone" json:"zone" bson:"zone"`
+
+	// MachineType is the name of a machine type such as n1-standard-4
+	MachineType string `mapstructure:"machine_type" json:"machine_type" bson:"machine_type"`
+
+	// Image is the boot disk's source image, e.g.
+	// projects/ubuntu-os-cloud/global/images/family/ubuntu-1604-lts
+	Image string `mapstructure:"image" json:"image" bson:"image"`
+
+	// DiskSizeGB and DiskType configure the boot disk. GCE's defaults are
+	// used if they are left blank.
+	DiskSizeGB int64  `mapstructure:"disk_size_gb" json:"disk_size_gb,omitempty" bson:"disk_size_gb,omitempty"`
+	DiskType   string `mapstructure:"disk_type" json:"disk_type,omitempty" bson:"disk_type,omitempty"`
+
+	// Network and Subnetwork are the names of the network to attach instances
+	// to. Instances use the project's default network if both are blank.
+	Network    string `mapstructure:"network" json:"network,omitempty" bson:"network,omitempty"`
+	Subnetwork string `mapstructure:"subnetwork" json:"subnetwork,omitempty" bson:"subnetwork,omitempty"`
+
+	// Preemptible instances are cheaper, but GCE may stop them at any time
+	Preemptible bool `mapstructure:"preemptible" json:"preemptible" bson:"preemptible"`
+
+	// Tags are network tags, used to apply firewall rules to instances
+	Tags []string `mapstructure:"tags" json:"tags,omitempty" bson:"tags,omitempty"`
+}
+
+var (
+	// bson fields for the Settings struct
+	ZoneKey        = bsonutil.MustHaveTag(Settings{}, "Zone")
+	MachineTypeKey = bsonutil.MustHaveTag(Settings{}, "MachineType")
+	ImageKey       = bsonutil.MustHaveTag(Settings{}, "Image")
+	DiskSizeGBKey  = bsonutil.MustHaveTag(Settings{}, "DiskSizeGB")
+	DiskTypeKey    = bsonutil.MustHaveTag(Settings{}, "DiskType")
+	NetworkKey     = bsonutil.MustHaveTag(Settings{}, "Network")
+	SubnetworkKey  = bsonutil.MustHaveTag(Settings{}, "Subnetwork")
+	PreemptibleKey = bsonutil.MustHaveTag(Settings{}, "Preemptible")
+	TagsKey        = bsonutil.MustHaveTag(Settings{}, "Tags")
+)
+
+// Validate checks that the settings from the config file are sane.
+func (self *Settings) Validate() error {
+	if self.Zone == "" {
+		return fmt.Errorf("Zone must not be blank")
+	}
+
+	if strings.LastIndex(self.Zone, "-") <= 0 {
+		return fmt.Errorf("Zone '%v' is not a valid zone name", self.Zone)
+	}
+
+	if self.MachineType == "" {
+		return fmt.Errorf("Machine type must not be blank")
+	}
+
+	if self.Image == "" {
+		return fmt.Errorf("Image must not be blank")
+	}
+
+	if self.DiskSizeGB < 0 {
+		return fmt.Errorf("Disk size must not be negative")
+	}
+
+	switch self.DiskType {
+	case "", "pd-standard", "pd-ssd":
+	default:
+		return fmt.Errorf("Disk type must be pd-standard or pd-ssd, not '%v'", self.DiskType)
+	}
+
+	for _, tag := range self.Tags {
+		if tag == "" {
+			return fmt.Errorf("Network tags must not be blank")
+		}
+	}
+
+	return nil
+}
+
+func (_ *GCEManager) GetSettings() cloud.ProviderSettings {
+	return &Settings{}
+}
+
+// Configure populates a GCEManager by reading relevant settings from the
+// config object. Managers with the same settings share a client, and Google
+// isn't contacted until the first API call.
+func (gceMgr *GCEManager) Configure(settings *evergreen.Settings) error {
+	config := settings.Providers.GCE
+	if config.ProjectId == "" {
+		return fmt.Errorf("GCE project id must not be blank")
+	}
+	if config.ClientEmail == "" || config.PrivateKey == "" {
+		return fmt.Errorf("GCE service account email and private key must not be blank")
+	}
+	gceMgr.client = getComputeClient(config)
+	return nil
+}
+
+// sanitize makes a string safe to use in instance names and label values,
+// which may only contain lowercase letters, digits and dashes.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, s)
+}
+
+// truncate shortens a string to the maximum length of a name or label.
+func truncate(s string, length int) string {
+	if len(s) > length {
+		return s[:length]
+	}
+	return s
+}
+
+// generateName creates an instance name for a host. Since GCE requires names
+// to be unique within a zone, the name is also the host's id.
+func generateName(distroId string) string {
+	suffix := fmt.Sprintf("-%v-%08x", time.Now().Format(NameTimeFormat),
+		rand.New(rand.NewSource(time.Now().UnixNano())).Uint32())
+	prefix := truncate("evg-"+sanitize(distroId), maxNameLength-len(suffix))
+	return strings.TrimRight(prefix, "-") + suffix
+}
+
+// instanceZone returns the zone a host's instance was created in.
+func instanceZone(h *host.Host) (string, error) {
+	gceSettings := &Settings{}
+	if err := mapstructure.Decode(h.Distro.ProviderSettings, gceSettings); err != nil {
+		return "", fmt.Errorf("Error decoding params for distro %v: %v", h.Distro.Id, err)
+	}
+	if gceSettings.Zone == "" {
+		return "", fmt.Errorf("Host %v's distro does not have a zone", h.Id)
+	}
+	return gceSettings.Zone, nil
+}
+
+// SpawnInstance creates a new GCE instance for the given distro.
+func (gceMgr *GCEManager) SpawnInstance(d *distro.Distro, owner string, userHost bool) (*host.Host, error) {
+	if d.Provider != ProviderName {
+		return nil, fmt.Errorf("Can't spawn instance of %v for distro %v: provider is %v", ProviderName, d.Id, d.Provider)
+	}
+
+	gceSettings := &Settings{}
+	if err := mapstructure.Decode(d.ProviderSettings, gceSettings); err != nil {
+		return nil, fmt.Errorf("Error decoding params for distro %v: %v", d.Id, err)
+	}
+
+	if err := gceSettings.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid GCE settings in distro %v: %v", d.Id, err)
+	}
+
+	instanceName := generateName(d.Id)
+	intentHost := &host.Host{
+		Id:               instanceName,
+		User:             d.User,
+		Distro:           *d,
+		Tag:              instanceName,
+		CreationTime:     time.Now(),
+		Status:           evergreen.HostUninitialized,
+		TerminationTime:  util.ZeroTime,
+		TaskDispatchTime: util.ZeroTime,
+		Provider:         ProviderName,
+		InstanceType:     gceSettings.MachineType,
+		StartedBy:        owner,
+		UserHost:         userHost,
+	}
+
+	if err := intentHost.Insert(); err != nil {
+		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not insert intent "+
+			"host '%v': %v", intentHost.Id, err)
+	}
+
+	evergreen.Logger.Logf(slogger.DEBUG, "Successfully inserted intent host '%v' "+
+		"for distro '%v' to signal cloud instance spawn intent", instanceName,
+		d.Id)
+
+	// the instance is named after the host, so unlike other providers the
+	// intent host doesn't need to be replaced once the instance exists
+	err := gceMgr.client.insertInstance(gceSettings.Zone, makeInstance(intentHost, gceSettings))
+	if err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "GCE insert instance API call failed"+
+			" for intent host '%v': %v", intentHost.Id, err)
+
+		// remove the intent host document
+		if rmErr := intentHost.Remove(); rmErr != nil {
+			return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not remove intent host "+
+				"'%v': %v", intentHost.Id, rmErr)
+		}
+		return nil, &cloud.ProviderError{Err: err}
+	}
+
+	evergreen.Logger.Logf(slogger.DEBUG, "Created GCE instance '%v' in zone %v (preemptible: %v)",
+		intentHost.Id, gceSettings.Zone, gceSettings.Preemptible)
+	return intentHost, nil
+}
+
+// makeInstance returns the instance to create for the intent host. The
+// instance's labels tie it back to the host.
+func makeInstance(intentHost *host.Host, gceSettings *Settings) *instance {
+	mode := "production"
+	if intentHost.UserHost {
+		mode = "testing"
+	}
+	inst := &instance{
+		Name:        intentHost.Id,
+		MachineType: "zones/" + gceSettings.Zone + "/machineTypes/" + gceSettings.MachineType,
+		Disks: []attachedDisk{{
+			Boot:       true,
+			AutoDelete: true,
+			InitializeParams: &attachedDiskInitParams{
+				SourceImage: gceSettings.Image,
+				DiskSizeGb:  gceSettings.DiskSizeGB,
+			},
+		}},
+		NetworkInterfaces: []networkInterface{{
+			AccessConfigs: []accessConfig{{Type: "ONE_TO_ONE_NAT", Name: "External NAT"}},
+		}},
+		Scheduling: &scheduling{
+			Preemptible:       gceSettings.Preemptible,
+			AutomaticRestart:  !gceSettings.Preemptible,
+			OnHostMaintenance: "MIGRATE",
+		},
+		Labels: map[string]string{
+			"evergreen-host-id": truncate(intentHost.Id, maxNameLength),
+			"distro":            truncate(sanitize(intentHost.Distro.Id), maxNameLength),
+			"owner":             truncate(sanitize(intentHost.StartedBy), maxNameLength),
+			"mode":              mode,
+		},
+	}
+	if gceSettings.Preemptible {
+		// preemptible instances can't be live migrated
+		inst.Scheduling.OnHostMaintenance = "TERMINATE"
+	}
+	if gceSettings.DiskType != "" {
+		inst.Disks[0].InitializeParams.DiskType = "zones/" + gceSettings.Zone + "/diskTypes/" + gceSettings.DiskType
+	}
+	switch {
+	case gceSettings.Subnetwork != "":
+		// a zone's name is its region's name with a suffix, e.g. us-east1-b
+		region := gceSettings.Zone[:strings.LastIndex(gceSettings.Zone, "-")]
+		inst.NetworkInterfaces[0].Subnetwork = "regions/" + region + "/subnetworks/" + gceSettings.Subnetwork
+	case gceSettings.Network != "":
+		inst.NetworkInterfaces[0].Network = "global/networks/" + gceSettings.Network
+	default:
+		inst.NetworkInterfaces[0].Network = "global/networks/default"
+	}
+	if len(gceSettings.Tags) > 0 {
+		inst.Tags = &tags{Items: gceSettings.Tags}
+	}
+	return inst
+}
+
+// gceStatusToEvergreenStatus returns a "universal" status code based on
+// GCE's instance statuses. Terminated instances are checked for preemption
+// separately.
+func gceStatusToEvergreenStatus(gceStatus string) cloud.CloudStatus {
+	switch gceStatus {
+	case InstanceStatusProvisioning, InstanceStatusStaging:
+		return cloud.StatusInitializing
+	case InstanceStatusRunning:
+		return cloud.StatusRunning
+	case InstanceStatusStopping, InstanceStatusStopped, InstanceStatusSuspending,
+		InstanceStatusSuspended, InstanceStatusTerminated:
+		return cloud.StatusStopped
+	default:
+		return cloud.StatusUnknown
+	}
+}
+
+// GetInstanceStatus returns a universal status code representing the state
+// of an instance. Preemptible instances that GCE has stopped are reported as
+// preempted, and instances that GCE no longer knows about are terminated.
+func (gceMgr *GCEManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
+	zone, err := instanceZone(host)
+	if err != nil {
+		return cloud.StatusUnknown, err
+	}
+	inst, err := gceMgr.client.getInstance(zone, host.Id)
+	if err == errInstanceNotFound {
+		return cloud.StatusTerminated, nil
+	}
+	if err != nil {
+		return cloud.StatusUnknown, fmt.Errorf("Failed to get instance info for '%v': %v", host.Id, err)
+	}
+
+	status := gceStatusToEvergreenStatus(inst.Status)
+	if inst.Status == InstanceStatusTerminated && inst.Scheduling != nil && inst.Scheduling.Preemptible {
+		preempted, err := gceMgr.client.wasPreempted(zone, host.Id)
+		if err != nil {
+			return cloud.StatusUnknown, fmt.Errorf("Failed to check if '%v' was preempted: %v", host.Id, err)
+		}
+		if preempted {
+			return cloud.StatusPreempted, nil
+		}
+	}
+	return status, nil
+}
+
+// GetDNSName returns the address to reach an instance at. An external
+// address is preferred over the instance's internal one.
+func (gceMgr *GCEManager) GetDNSName(host *host.Host) (string, error) {
+	zone, err := instanceZone(host)
+	if err != nil {
+		return "", err
+	}
+	inst, err := gceMgr.client.getInstance(zone, host.Id)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get instance info for '%v': %v", host.Id, err)
+	}
+	return bestAddress(inst), nil
+}
+
+// bestAddress returns the best address to reach the instance at, or the
+// empty string if it doesn't have one yet.
+func bestAddress(inst *instance) string {
+	internal := ""
+	for _, iface := range inst.NetworkInterfaces {
+		for _, config := range iface.AccessConfigs {
+			if config.NatIP != "" {
+				return config.NatIP
+			}
+		}
+		if internal == "" {
+			internal = iface.NetworkIP
+		}
+	}
+	return internal
+}
+
+// CanSpawn returns if a given cloud provider supports spawning a new host
+// dynamically. Always returns true for GCE.
+func (gceMgr *GCEManager) CanSpawn() (bool, error) {
+	return true, nil
+}
+
+// TerminateInstance deletes an instance. Instances that GCE no longer knows
+// about are already gone.
+func (gceMgr *GCEManager) TerminateInstance(host *host.Host) error {
+	if host.Status == evergreen.HostTerminated {
+		errMsg := fmt.Errorf("Can not terminate %v - already marked as "+
+			"terminated!", host.Id)
+		evergreen.Logger.Errorf(slogger.ERROR, errMsg.Error())
+		return errMsg
+	}
+
+	zone, err := instanceZone(host)
+	if err != nil {
+		return err
+	}
+	err = gceMgr.client.deleteInstance(zone, host.Id)
+	if err != nil && err != errInstanceNotFound {
+		return evergreen.Logger.Errorf(slogger.ERROR, "Failed to delete instance '%v': %v", host.Id, err)
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Terminated %v", host.Id)
+
+	return host.Terminate()
+}
+
+// IsSSHReachable checks if an instance appears to be reachable via SSH by
+// attempting to contact the host directly.
+func (gceMgr *GCEManager) IsSSHReachable(host *host.Host, keyPath string) (bool, error) {
+	sshOpts, err := gceMgr.GetSSHOptions(host, keyPath)
+	if err != nil {
+		return false, err
+	}
+	return hostutil.CheckSSHResponse(host, sshOpts)
+}
+
+// IsUp checks the instance's state by querying the GCE API and
+// returns true if the host should be available to connect with SSH.
+func (gceMgr *GCEManager) IsUp(host *host.Host) (bool, error) {
+	cloudStatus, err := gceMgr.GetInstanceStatus(host)
+	if err != nil {
+		return false, err
+	}
+	if cloudStatus == cloud.StatusRunning {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (gceMgr *GCEManager) OnUp(host *host.Host) error {
+	//Not currently needed since the labels are set when the instance is created
+	return nil
+}
+
+// GetSSHOptions returns an array of default SSH options for connecting to an
+// instance. The distro's key must be one of the project's SSH keys.
+func (gceMgr *GCEManager) GetSSHOptions(host *host.Host, keyPath string) ([]string, error) {
+	if keyPath == "" {
+		return []string{}, fmt.Errorf("No key specified for GCE host")
+	}
+	opts := []string{"-i", keyPath}
+	for _, opt := range host.Distro.SSHOptions {
+		opts = append(opts, "-o", opt)
+	}
+	return opts, nil
+}
+
+// TimeTilNextPayment returns the amount of time until the next payment is due
+// for the host. GCE bills by the minute after the first ten minutes, so
+// stopping an instance is only wasteful before then.
+func (gceMgr *GCEManager) TimeTilNextPayment(host *host.Host) time.Duration {
+	if host.CreationTime.IsZero() {
+		return time.Duration(0)
+	}
+	remaining := minimumBilledTime - time.Since(host.CreationTime)
+	if remaining < 0 {
+		return time.Duration(0)
+	}
+	return remaining
+}
@@ -0,0 +1,344 @@
+package gce
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/cloudtest"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeGCE is a Google OAuth and Compute Engine API that keeps instances and
+// preemption operations in memory.
+type fakeGCE struct {
+	*cloudtest.FakeAPI
+	key        *rsa.PrivateKey
+	mu         sync.Mutex
+	instances  map[string]*instance
+	preempted  []string
+	lastInsert instance
+
+	// emptyPages is how many pages without operations are listed before the
+	// ones that match, as GCE may do
+	emptyPages int
+}
+
+func newFakeGCE() *fakeGCE {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		panic(err)
+	}
+	f := &fakeGCE{FakeAPI: cloudtest.NewFakeAPI(), key: key, instances: map[string]*instance{}}
+	f.Mux.HandleFunc("/token", f.handleToken)
+	f.Mux.HandleFunc("/compute/v1/projects/evg/zones/us-east1-b/instances", f.handleInsert)
+	f.Mux.HandleFunc("/compute/v1/projects/evg/zones/us-east1-b/instances/", f.handleInstance)
+	f.Mux.HandleFunc("/compute/v1/projects/evg/zones/us-east1-b/operations", f.handleOperations)
+	return f
+}
+
+func (f *fakeGCE) config() evergreen.GCEConfig {
+	return evergreen.GCEConfig{
+		ProjectId:   "evg",
+		ClientEmail: "evergreen@evg.iam.gserviceaccount.com",
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(f.key),
+		})),
+		Endpoint: f.URL + "/compute/v1/",
+		TokenURL: f.URL + "/token",
+	}
+}
+
+// handleToken checks that the assertion is signed by the service account.
+func (f *fakeGCE) handleToken(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.FormValue("assertion"), ".")
+	if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || len(parts) != 3 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err != nil || rsa.VerifyPKCS1v15(&f.key.PublicKey, crypto.SHA256, hash[:], sig) != nil {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	claims := map[string]interface{}{}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if json.Unmarshal(payload, &claims) != nil || claims["iss"] != "evergreen@evg.iam.gserviceaccount.com" {
+		http.Error(w, "bad claims", http.StatusUnauthorized)
+		return
+	}
+	fmt.Fprintf(w, `{"access_token": %q, "token_type": "Bearer", "expires_in": 3600}`, f.IssueToken())
+}
+
+func (f *fakeGCE) authorized(w http.ResponseWriter, r *http.Request) bool {
+	return f.Authorized(w, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
+
+func (f *fakeGCE) handleInsert(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	inst := instance{}
+	if !cloudtest.DecodeJSON(w, r, "POST", &inst) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastInsert = inst
+	inst.Status = InstanceStatusProvisioning
+	f.instances[inst.Name] = &inst
+	fmt.Fprintf(w, `{"name": "operation-1", "operationType": "insert", "status": "PENDING"}`)
+}
+
+func (f *fakeGCE) handleInstance(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	inst, ok := f.instances[name]
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(inst)
+	case "DELETE":
+		delete(f.instances, name)
+		fmt.Fprintf(w, `{"name": "operation-2", "operationType": "delete", "status": "PENDING"}`)
+	}
+}
+
+// handleOperations lists the preemptions that match the filter's target link,
+// one per page.
+func (f *fakeGCE) handleOperations(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	match := regexp.MustCompile(`^\(operationType eq ` + regexp.QuoteMeta(preemptedOperationType) +
+		`\) \(targetLink eq (.+)\)$`).FindStringSubmatch(r.FormValue("filter"))
+	if match == nil {
+		http.Error(w, "unexpected filter", http.StatusBadRequest)
+		return
+	}
+	targetLink, err := regexp.Compile("^" + match[1] + "$")
+	if err != nil {
+		http.Error(w, "bad target link", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pages := make([][]operation, f.emptyPages)
+	for _, name := range f.preempted {
+		op := operation{
+			OperationType: preemptedOperationType,
+			TargetLink:    f.URL + "/compute/v1/projects/evg/zones/us-east1-b/instances/" + name,
+			Status:        "DONE",
+		}
+		if targetLink.MatchString(op.TargetLink) {
+			pages = append(pages, []operation{op})
+		}
+	}
+	page, _ := strconv.Atoi(r.FormValue("pageToken"))
+	resp := map[string]interface{}{}
+	if page < len(pages) {
+		resp["items"] = pages[page]
+	}
+	if page+1 < len(pages) {
+		resp["nextPageToken"] = strconv.Itoa(page + 1)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func TestComputeClient(t *testing.T) {
+	Convey("With a fake GCE project", t, func() {
+		fake := newFakeGCE()
+		defer fake.Close()
+		client := newComputeClient(fake.config())
+
+		Convey("instances should be created, inspected and deleted", func() {
+			h := &host.Host{
+				Id:        "evg-ubuntu-1",
+				Distro:    distro.Distro{Id: "Ubuntu_16.04"},
+				StartedBy: "mci",
+			}
+			So(client.insertInstance("us-east1-b", makeInstance(h, &Settings{
+				Zone:        "us-east1-b",
+				MachineType: "n1-standard-4",
+				Image:       "projects/ubuntu-os-cloud/global/images/family/ubuntu-1604-lts",
+				DiskSizeGB:  100,
+				DiskType:    "pd-ssd",
+				Subnetwork:  "ci",
+				Preemptible: true,
+				Tags:        []string{"ssh"},
+			})), ShouldBeNil)
+
+			inserted := fake.lastInsert
+			So(inserted.Name, ShouldEqual, "evg-ubuntu-1")
+			So(inserted.MachineType, ShouldEqual, "zones/us-east1-b/machineTypes/n1-standard-4")
+			So(inserted.Disks[0].InitializeParams.DiskSizeGb, ShouldEqual, 100)
+			So(inserted.Disks[0].InitializeParams.DiskType, ShouldEqual, "zones/us-east1-b/diskTypes/pd-ssd")
+			So(inserted.NetworkInterfaces[0].Subnetwork, ShouldEqual, "regions/us-east1/subnetworks/ci")
+			So(inserted.Scheduling.Preemptible, ShouldBeTrue)
+			So(inserted.Scheduling.AutomaticRestart, ShouldBeFalse)
+			So(inserted.Scheduling.OnHostMaintenance, ShouldEqual, "TERMINATE")
+			So(inserted.Tags.Items, ShouldResemble, []string{"ssh"})
+			So(inserted.Labels["evergreen-host-id"], ShouldEqual, "evg-ubuntu-1")
+			So(inserted.Labels["distro"], ShouldEqual, "ubuntu-16-04")
+			So(inserted.Labels["mode"], ShouldEqual, "production")
+
+			inst, err := client.getInstance("us-east1-b", "evg-ubuntu-1")
+			So(err, ShouldBeNil)
+			So(inst.Status, ShouldEqual, InstanceStatusProvisioning)
+
+			So(client.deleteInstance("us-east1-b", "evg-ubuntu-1"), ShouldBeNil)
+			_, err = client.getInstance("us-east1-b", "evg-ubuntu-1")
+			So(err, ShouldEqual, errInstanceNotFound)
+		})
+
+		Convey("the client should authenticate again when its token is rejected", func() {
+			_, err := client.wasPreempted("us-east1-b", "a")
+			So(err, ShouldBeNil)
+			So(fake.TokensIssued(), ShouldEqual, 1)
+			fake.RevokeToken()
+			_, err = client.wasPreempted("us-east1-b", "a")
+			So(err, ShouldBeNil)
+			So(fake.TokensIssued(), ShouldEqual, 2)
+		})
+
+		Convey("a key that isn't the service account's should be rejected", func() {
+			config := fake.config()
+			other, err := rsa.GenerateKey(rand.Reader, 1024)
+			So(err, ShouldBeNil)
+			config.PrivateKey = string(pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(other),
+			}))
+			_, err = newComputeClient(config).getInstance("us-east1-b", "a")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "google")
+		})
+	})
+}
+
+func TestGCEManager(t *testing.T) {
+	Convey("With a GCE manager for a fake project", t, func() {
+		fake := newFakeGCE()
+		defer fake.Close()
+		settings := &evergreen.Settings{}
+		settings.Providers.GCE = fake.config()
+		mgr := &GCEManager{}
+		So(mgr.Configure(settings), ShouldBeNil)
+
+		fake.instances["evg-1"] = &instance{Name: "evg-1", Status: InstanceStatusStaging,
+			Scheduling: &scheduling{Preemptible: true}}
+		h := &host.Host{Id: "evg-1", Distro: distro.Distro{
+			ProviderSettings: &map[string]interface{}{"zone": "us-east1-b"},
+		}}
+
+		Convey("instance statuses should be translated", func() {
+			status, err := mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusInitializing)
+
+			fake.instances["evg-1"].Status = InstanceStatusRunning
+			up, err := mgr.IsUp(h)
+			So(err, ShouldBeNil)
+			So(up, ShouldBeTrue)
+
+			status, err = mgr.GetInstanceStatus(&host.Host{Id: "gone", Distro: h.Distro})
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusTerminated)
+		})
+
+		Convey("preempted instances should be distinguished from stopped ones", func() {
+			fake.instances["evg-1"].Status = InstanceStatusTerminated
+			status, err := mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusStopped)
+
+			fake.preempted = []string{"evg-10"}
+			status, err = mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusStopped)
+
+			fake.preempted = []string{"evg-10", "evg-1"}
+			fake.emptyPages = 2
+			status, err = mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusPreempted)
+		})
+
+		Convey("managers with the same settings should share a client and its token", func() {
+			other := &GCEManager{}
+			So(other.Configure(settings), ShouldBeNil)
+			So(other.client, ShouldEqual, mgr.client)
+			_, err := mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			_, err = other.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(fake.TokensIssued(), ShouldEqual, 1)
+		})
+
+		Convey("external addresses should be preferred", func() {
+			fake.instances["evg-1"].NetworkInterfaces = []networkInterface{{NetworkIP: "10.0.0.5"}}
+			dns, err := mgr.GetDNSName(h)
+			So(err, ShouldBeNil)
+			So(dns, ShouldEqual, "10.0.0.5")
+
+			fake.instances["evg-1"].NetworkInterfaces[0].AccessConfigs = []accessConfig{{NatIP: "203.0.113.5"}}
+			dns, err = mgr.GetDNSName(h)
+			So(err, ShouldBeNil)
+			So(dns, ShouldEqual, "203.0.113.5")
+		})
+
+		Convey("instance names should be valid GCE names", func() {
+			name := generateName(strings.Repeat("Very_Long.Distro-", 10))
+			So(len(name), ShouldBeLessThanOrEqualTo, maxNameLength)
+			So(name, ShouldStartWith, "evg-very-long-distro-")
+			So(sanitize(name), ShouldEqual, name)
+			So(strings.Contains(name, "--"), ShouldBeFalse)
+		})
+
+		Convey("the manager should not be configured without credentials", func() {
+			settings.Providers.GCE.PrivateKey = ""
+			So((&GCEManager{}).Configure(settings), ShouldNotBeNil)
+		})
+
+		Convey("distro settings should be validated", func() {
+			valid := Settings{Zone: "us-east1-b", MachineType: "n1-standard-1", Image: "i"}
+			So(valid.Validate(), ShouldBeNil)
+			for _, s := range []Settings{
+				{MachineType: "n1-standard-1", Image: "i"},
+				{Zone: "east", MachineType: "n1-standard-1", Image: "i"},
+				{Zone: "us-east1-b", Image: "i"},
+				{Zone: "us-east1-b", MachineType: "n1-standard-1"},
+				{Zone: "us-east1-b", MachineType: "n1-standard-1", Image: "i", DiskSizeGB: -1},
+				{Zone: "us-east1-b", MachineType: "n1-standard-1", Image: "i", DiskType: "local-ssd"},
+				{Zone: "us-east1-b", MachineType: "n1-standard-1", Image: "i", Tags: []string{""}},
+			} {
+				So(s.Validate(), ShouldNotBeNil)
+			}
+		})
+	})
+}
@@ -0,0 +1,208 @@
+package providers
+
+import (
+	"io"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/model/cloudstats"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/host"
+)
+
+// instrumentedManager wraps a cloud manager to record the count, latency and
+// errors of its calls to the provider, and to stop spawning hosts for distros
+// whose spawns keep failing.
+type instrumentedManager struct {
+	cloud.CloudManager
+	provider string
+}
+
+// instrumentedExecutor, instrumentedStopper and instrumentedExecutorStopper
+// keep the optional interfaces of the managers they wrap.
+type instrumentedExecutor struct {
+	*instrumentedManager
+	executor cloud.Executor
+}
+
+type instrumentedStopper struct {
+	*instrumentedManager
+	stopper cloud.Stopper
+}
+
+type instrumentedExecutorStopper struct {
+	*instrumentedStopper
+	executor cloud.Executor
+}
+
+// instrument wraps the provider's manager in the instrumented manager that
+// implements the same optional interfaces.
+func instrument(providerName string, mgr cloud.CloudManager) cloud.CloudManager {
+	instrumented := &instrumentedManager{mgr, providerName}
+	executor, isExecutor := mgr.(cloud.Executor)
+	stopper, isStopper := mgr.(cloud.Stopper)
+	switch {
+	case isExecutor && isStopper:
+		return &instrumentedExecutorStopper{&instrumentedStopper{instrumented, stopper}, executor}
+	case isExecutor:
+		return &instrumentedExecutor{instrumented, executor}
+	case isStopper:
+		return &instrumentedStopper{instrumented, stopper}
+	default:
+		return instrumented
+	}
+}
+
+// Unwrap returns the manager that the instrumented manager wraps.
+func (self *instrumentedManager) Unwrap() cloud.CloudManager {
+	return self.CloudManager
+}
+
+// Unwrap returns the provider's own manager for a manager returned by
+// GetCloudManager, for calling methods that are specific to the provider.
+func Unwrap(mgr cloud.CloudManager) cloud.CloudManager {
+	if instrumented, ok := mgr.(interface {
+		Unwrap() cloud.CloudManager
+	}); ok {
+		return instrumented.Unwrap()
+	}
+	return mgr
+}
+
+// record adds a call to the provider's stats.
+func (self *instrumentedManager) record(method string, start time.Time, callErr error) {
+	cloudstats.RecordCall(self.provider, method, time.Since(start), callErr)
+}
+
+// SpawnInstance spawns the host unless the distro's spawn breaker is open.
+// Spawn hosts that users request don't go through the breaker, and only
+// failures of the provider's API count towards tripping it.
+func (self *instrumentedManager) SpawnInstance(d *distro.Distro, owner string, userHost bool) (*host.Host, error) {
+	if userHost {
+		start := time.Now()
+		h, err := self.CloudManager.SpawnInstance(d, owner, userHost)
+		if !cloud.IsNotReady(err) {
+			self.record("SpawnInstance", start, err)
+		}
+		return h, err
+	}
+
+	breaker, err := cloudstats.FindSpawnBreaker(d.Id)
+	if err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "Error finding spawn breaker for distro %v: %v", d.Id, err)
+	}
+	if breaker != nil && breaker.IsOpen(time.Now()) {
+		return nil, &cloudstats.BreakerOpenError{
+			DistroId:  d.Id,
+			OpenUntil: breaker.OpenUntil,
+			LastError: breaker.LastError,
+		}
+	}
+
+	start := time.Now()
+	h, spawnErr := self.CloudManager.SpawnInstance(d, owner, userHost)
+	if cloud.IsNotReady(spawnErr) {
+		// the host is waiting on another one, which isn't a failure
+		return nil, spawnErr
+	}
+	self.record("SpawnInstance", start, spawnErr)
+
+	if spawnErr != nil {
+		if !cloud.IsProviderError(spawnErr) {
+			return nil, spawnErr
+		}
+		breaker, tripped, err := cloudstats.RecordSpawnFailure(d.Id, spawnErr.Error())
+		if err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Error recording spawn failure for distro %v: %v", d.Id, err)
+		} else if tripped {
+			evergreen.Logger.Logf(slogger.WARN, "Not spawning hosts for distro %v until %v after %v",
+				d.Id, breaker.OpenUntil, spawnErr)
+			event.LogDistroSpawnBreakerTripped(d.Id, breaker)
+		}
+		return nil, spawnErr
+	}
+
+	closed, err := cloudstats.RecordSpawnSuccess(d.Id)
+	if err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "Error recording spawn for distro %v: %v", d.Id, err)
+	} else if closed {
+		evergreen.Logger.Logf(slogger.INFO, "Spawning hosts for distro %v again", d.Id)
+		event.LogDistroSpawnBreakerClosed(d.Id)
+	}
+	return h, nil
+}
+
+func (self *instrumentedManager) GetInstanceStatus(h *host.Host) (cloud.CloudStatus, error) {
+	start := time.Now()
+	status, err := self.CloudManager.GetInstanceStatus(h)
+	self.record("GetInstanceStatus", start, err)
+	return status, err
+}
+
+func (self *instrumentedManager) TerminateInstance(h *host.Host) error {
+	start := time.Now()
+	err := self.CloudManager.TerminateInstance(h)
+	self.record("TerminateInstance", start, err)
+	return err
+}
+
+func (self *instrumentedManager) IsUp(h *host.Host) (bool, error) {
+	start := time.Now()
+	up, err := self.CloudManager.IsUp(h)
+	self.record("IsUp", start, err)
+	return up, err
+}
+
+func (self *instrumentedManager) OnUp(h *host.Host) error {
+	start := time.Now()
+	err := self.CloudManager.OnUp(h)
+	self.record("OnUp", start, err)
+	return err
+}
+
+func (self *instrumentedManager) IsSSHReachable(h *host.Host, keyPath string) (bool, error) {
+	start := time.Now()
+	reachable, err := self.CloudManager.IsSSHReachable(h, keyPath)
+	self.record("IsSSHReachable", start, err)
+	return reachable, err
+}
+
+func (self *instrumentedManager) GetDNSName(h *host.Host) (string, error) {
+	start := time.Now()
+	dns, err := self.CloudManager.GetDNSName(h)
+	self.record("GetDNSName", start, err)
+	return dns, err
+}
+
+func (self *instrumentedExecutor) Exec(h *host.Host, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	return self.exec(self.executor, h, cmd, stdin, stdout, stderr)
+}
+
+func (self *instrumentedExecutorStopper) Exec(h *host.Host, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	return self.exec(self.executor, h, cmd, stdin, stdout, stderr)
+}
+
+func (self *instrumentedManager) exec(executor cloud.Executor, h *host.Host, cmd []string,
+	stdin io.Reader, stdout, stderr io.Writer) error {
+	start := time.Now()
+	err := executor.Exec(h, cmd, stdin, stdout, stderr)
+	self.record("Exec", start, err)
+	return err
+}
+
+func (self *instrumentedStopper) StopInstance(h *host.Host) error {
+	start := time.Now()
+	err := self.stopper.StopInstance(h)
+	self.record("StopInstance", start, err)
+	return err
+}
+
+func (self *instrumentedStopper) StartInstance(h *host.Host) error {
+	start := time.Now()
+	err := self.stopper.StartInstance(h)
+	self.record("StartInstance", start, err)
+	return err
+}
@@ -0,0 +1,140 @@
+package providers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers/mock"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/cloudstats"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/host"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// failingManager is a mock cloud manager whose spawns fail at the provider
+// while fail is set, fail before reaching it while failLocally is set, and
+// aren't ready while notReady is set.
+type failingManager struct {
+	mock.MockCloudManager
+	fail        bool
+	failLocally bool
+	notReady    bool
+	spawns      int
+}
+
+func (self *failingManager) SpawnInstance(d *distro.Distro, owner string, userHost bool) (*host.Host, error) {
+	self.spawns++
+	if self.notReady {
+		return nil, &cloud.NotReadyError{Reason: "waiting for starting parents"}
+	}
+	if self.failLocally {
+		return nil, fmt.Errorf("Invalid settings")
+	}
+	if self.fail {
+		return nil, &cloud.ProviderError{Err: fmt.Errorf("RequestLimitExceeded")}
+	}
+	return self.MockCloudManager.SpawnInstance(d, owner, userHost)
+}
+
+func TestInstrumentedManager(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(evergreen.TestConfig()))
+
+	Convey("With an instrumented cloud manager", t, func() {
+		So(db.ClearCollections(cloudstats.ProviderStatsCollection,
+			cloudstats.SpawnBreakersCollection, event.Collection), ShouldBeNil)
+		failing := &failingManager{fail: true}
+		cloudMgr := instrument("failing", failing)
+		d := &distro.Distro{Id: "d1"}
+
+		Convey("spawns should stop after failing repeatedly until one succeeds", func() {
+			for i := 0; i < cloudstats.BreakerThreshold; i++ {
+				_, err := cloudMgr.SpawnInstance(d, evergreen.User, false)
+				So(err, ShouldNotBeNil)
+				So(cloudstats.IsBreakerOpen(err), ShouldBeFalse)
+			}
+			_, err := cloudMgr.SpawnInstance(d, evergreen.User, false)
+			So(cloudstats.IsBreakerOpen(err), ShouldBeTrue)
+			So(failing.spawns, ShouldEqual, cloudstats.BreakerThreshold)
+
+			events, err := event.Find(event.DistroEventsOfTypeSince(d.Id,
+				event.EventDistroSpawnBreakerTripped, time.Time{}))
+			So(err, ShouldBeNil)
+			So(len(events), ShouldEqual, 1)
+
+			breaker, err := cloudstats.FindSpawnBreaker(d.Id)
+			So(err, ShouldBeNil)
+			So(breaker.Trips, ShouldEqual, 1)
+			So(breaker.LastError, ShouldEqual, "RequestLimitExceeded")
+		})
+
+		Convey("spawns that aren't ready yet should not count as failures", func() {
+			failing.notReady = true
+			for i := 0; i <= cloudstats.BreakerThreshold; i++ {
+				_, err := cloudMgr.SpawnInstance(d, evergreen.User, false)
+				So(cloud.IsNotReady(err), ShouldBeTrue)
+			}
+			So(failing.spawns, ShouldEqual, cloudstats.BreakerThreshold+1)
+			breaker, err := cloudstats.FindSpawnBreaker(d.Id)
+			So(err, ShouldBeNil)
+			So(breaker, ShouldBeNil)
+		})
+
+		Convey("spawns that fail before reaching the provider should not count as failures", func() {
+			failing.failLocally = true
+			for i := 0; i <= cloudstats.BreakerThreshold; i++ {
+				_, err := cloudMgr.SpawnInstance(d, evergreen.User, false)
+				So(err, ShouldNotBeNil)
+				So(cloudstats.IsBreakerOpen(err), ShouldBeFalse)
+			}
+			breaker, err := cloudstats.FindSpawnBreaker(d.Id)
+			So(err, ShouldBeNil)
+			So(breaker, ShouldBeNil)
+		})
+
+		Convey("spawn hosts users request should not go through the breaker", func() {
+			for i := 0; i < cloudstats.BreakerThreshold; i++ {
+				_, err := cloudMgr.SpawnInstance(d, "user", true)
+				So(err, ShouldNotBeNil)
+			}
+			breaker, err := cloudstats.FindSpawnBreaker(d.Id)
+			So(err, ShouldBeNil)
+			So(breaker, ShouldBeNil)
+
+			for i := 0; i < cloudstats.BreakerThreshold; i++ {
+				_, err = cloudMgr.SpawnInstance(d, evergreen.User, false)
+			}
+			So(cloudstats.IsBreakerOpen(err), ShouldBeFalse)
+			failing.fail = false
+			_, err = cloudMgr.SpawnInstance(d, evergreen.User, false)
+			So(cloudstats.IsBreakerOpen(err), ShouldBeTrue)
+			_, err = cloudMgr.SpawnInstance(d, "user", true)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("calls should be counted by provider and method", func() {
+			failing.fail = false
+			_, err := cloudMgr.SpawnInstance(d, evergreen.User, false)
+			So(err, ShouldBeNil)
+			failing.fail = true
+			_, err = cloudMgr.SpawnInstance(d, evergreen.User, false)
+			So(err, ShouldNotBeNil)
+			_, err = cloudMgr.GetInstanceStatus(&host.Host{})
+			So(err, ShouldBeNil)
+
+			So(cloudstats.FlushCalls(), ShouldBeNil)
+			stats, err := cloudstats.FindAllProviderStats()
+			So(err, ShouldBeNil)
+			So(len(stats), ShouldEqual, 1)
+			So(stats[0].Provider, ShouldEqual, "failing")
+			So(stats[0].Calls["SpawnInstance"].Count, ShouldEqual, 2)
+			So(stats[0].Calls["SpawnInstance"].Errors, ShouldEqual, 1)
+			So(stats[0].Calls["SpawnInstance"].LastError, ShouldEqual, "RequestLimitExceeded")
+			So(stats[0].Calls["GetInstanceStatus"].Count, ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,288 @@
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/util"
+	"golang.org/x/net/websocket"
+)
+
+// pod phases, see
+// https://kubernetes.io/docs/concepts/workloads/pods/pod-lifecycle/#pod-phase
+const (
+	PodPhasePending   = "Pending"
+	PodPhaseRunning   = "Running"
+	PodPhaseSucceeded = "Succeeded"
+	PodPhaseFailed    = "Failed"
+	PodPhaseUnknown   = "Unknown"
+
+	// the reason pods have when the kubelet evicts them
+	podReasonEvicted = "Evicted"
+
+	// the exec streaming protocol, in which each message is prefixed with the
+	// channel it belongs to, and the last is the command's status
+	execProtocol      = "v4.channel.k8s.io"
+	stdinChannel      = 0
+	stdoutChannel     = 1
+	stderrChannel     = 2
+	statusChannel     = 3
+	execStatusSuccess = "Success"
+)
+
+// errNotFound is returned when Kubernetes doesn't know about a pod, or its
+// namespace.
+var errNotFound = fmt.Errorf("not found")
+
+// apiClient makes requests to a cluster's API server, authenticating with a
+// bearer token.
+type apiClient struct {
+	config     evergreen.KubernetesConfig
+	tlsConfig  *tls.Config
+	httpClient *http.Client
+}
+
+// pod is the part of a Kubernetes pod that Evergreen uses.
+type pod struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     *podSpec   `json:"spec,omitempty"`
+	Status   *podStatus `json:"status,omitempty"`
+}
+
+type objectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	DeletionTimestamp string            `json:"deletionTimestamp,omitempty"`
+}
+
+type podSpec struct {
+	Containers         []container       `json:"containers"`
+	RestartPolicy      string            `json:"restartPolicy"`
+	NodeSelector       map[string]string `json:"nodeSelector,omitempty"`
+	ServiceAccountName string            `json:"serviceAccountName,omitempty"`
+}
+
+type container struct {
+	Name      string               `json:"name"`
+	Image     string               `json:"image"`
+	Command   []string             `json:"command,omitempty"`
+	Resources resourceRequirements `json:"resources"`
+}
+
+type resourceRequirements struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+type podStatus struct {
+	Phase   string `json:"phase"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	PodIP   string `json:"podIP,omitempty"`
+}
+
+// status is the result of an exec, or of a failed request.
+type status struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+}
+
+var (
+	// clients are shared by the managers with the same settings, so they
+	// reuse their connections to the API server
+	clientsMu sync.Mutex
+	clients   = map[evergreen.KubernetesConfig]*apiClient{}
+)
+
+func newAPIClient(config evergreen.KubernetesConfig) (*apiClient, error) {
+	tlsConfig := &tls.Config{}
+	if config.CACert != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, fmt.Errorf("Kubernetes CA certificate is not PEM encoded")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &apiClient{
+		config:    config,
+		tlsConfig: tlsConfig,
+		httpClient: &http.Client{
+			Timeout:   time.Minute,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// getAPIClient returns the client for the settings, creating it the first
+// time they are used.
+func getAPIClient(config evergreen.KubernetesConfig) (*apiClient, error) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if client, ok := clients[config]; ok {
+		return client, nil
+	}
+	client, err := newAPIClient(config)
+	if err != nil {
+		return nil, err
+	}
+	clients[config] = client
+	return client, nil
+}
+
+// do makes a request to the API server.
+func (c *apiClient) do(method, path string, body, result interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		reqBody, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		bodyReader = bytes.NewReader(reqBody)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.config.APIServer, "/")+path, bodyReader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return errNotFound
+	case resp.StatusCode >= 300:
+		return util.ResponseError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func podPath(namespace, name string) string {
+	path := "/api/v1/namespaces/" + namespace + "/pods"
+	if name != "" {
+		path += "/" + name
+	}
+	return path
+}
+
+// createPod creates a pod. Kubernetes schedules and starts it asynchronously.
+func (c *apiClient) createPod(p *pod) error {
+	if err := c.do("POST", podPath(p.Metadata.Namespace, ""), p, nil); err != nil {
+		return fmt.Errorf("error creating pod: %v", err)
+	}
+	return nil
+}
+
+// getPod returns the pod with the name.
+func (c *apiClient) getPod(namespace, name string) (*pod, error) {
+	p := &pod{}
+	if err := c.do("GET", podPath(namespace, name), nil, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// deletePod deletes the pod with the name.
+func (c *apiClient) deletePod(namespace, name string) error {
+	return c.do("DELETE", podPath(namespace, name), nil, nil)
+}
+
+// exec runs a command in a pod's container, streaming its input and output
+// over a websocket, and returns an error if the command fails.
+func (c *apiClient) exec(namespace, name, containerName string, cmd []string,
+	stdin io.Reader, stdout, stderr io.Writer) error {
+	query := url.Values{
+		"container": {containerName},
+		"command":   cmd,
+		"stdout":    {"true"},
+		"stderr":    {"true"},
+	}
+	if stdin != nil {
+		query.Set("stdin", "true")
+	}
+	location := strings.TrimSuffix(c.config.APIServer, "/") + podPath(namespace, name) + "/exec?" + query.Encode()
+	location = "ws" + strings.TrimPrefix(location, "http")
+
+	wsConfig, err := websocket.NewConfig(location, c.config.APIServer)
+	if err != nil {
+		return err
+	}
+	wsConfig.Protocol = []string{execProtocol}
+	wsConfig.TlsConfig = c.tlsConfig
+	wsConfig.Header.Set("Authorization", "Bearer "+c.config.Token)
+	ws, err := websocket.DialConfig(wsConfig)
+	if err != nil {
+		return fmt.Errorf("error connecting to pod %v: %v", name, err)
+	}
+	defer ws.Close()
+
+	if stdin != nil {
+		go func() {
+			buf := make([]byte, 32*1024)
+			for {
+				n, err := stdin.Read(buf)
+				if n > 0 {
+					msg := append([]byte{stdinChannel}, buf[:n]...)
+					if websocket.Message.Send(ws, msg) != nil {
+						return
+					}
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	for {
+		var msg []byte
+		if err = websocket.Message.Receive(ws, &msg); err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("exec in pod %v ended without a status", name)
+			}
+			return fmt.Errorf("error reading from pod %v: %v", name, err)
+		}
+		if len(msg) == 0 {
+			continue
+		}
+		switch msg[0] {
+		case stdoutChannel:
+			if _, err = stdout.Write(msg[1:]); err != nil {
+				return err
+			}
+		case stderrChannel:
+			if _, err = stderr.Write(msg[1:]); err != nil {
+				return err
+			}
+		case statusChannel:
+			result := status{}
+			if err = json.Unmarshal(msg[1:], &result); err != nil {
+				return fmt.Errorf("error reading exec status: %v", err)
+			}
+			if result.Status != execStatusSuccess {
+				return fmt.Errorf("%v", result.Message)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package kubernetes
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	ProviderName = "kubernetes"
+
+	// NameTimeFormat is the format in which to log times like pod start time.
+	NameTimeFormat = "20060102150405"
+
+	DefaultNamespace = "default"
+
+	// the name of the container in each pod that commands are run in
+	containerName = "evergreen"
+
+	// names and label values are limited to 63 characters
+	maxNameLength = 63
+
+	// how long to wait for a command checking that a pod is reachable
+	reachableTimeout = 10 * time.Second
+)
+
+// keepAliveCommand keeps a pod's container running, since commands are run
+// in it with exec, until the pod is deleted.
+var keepAliveCommand = []string{"sh", "-c", "trap 'exit 0' TERM; while true; do sleep 5; done"}
+
+// KubernetesManager implements the CloudManager interface for Kubernetes
+// pods. Commands are run in pods with exec, rather than over SSH.
+type KubernetesManager struct {
+	client *apiClient
+}
+
+// Settings are the distro's settings for the pods it creates.
+type Settings struct {
+	// Namespace is the namespace to create pods in, or the default namespace
+	// if it's blank
+	Namespace string `mapstructure:"namespace" json:"namespace,omitempty" bson:"namespace,omitempty"`
+
+	// Image is the container image to run, e.g. evergreen/ubuntu1604:latest.
+	// It must have a shell.
+	Image string `mapstructure:"image" json:"image" bson:"image"`
+
+	// CPU and Memory are the resources reserved for, and the most used by,
+	// each pod, as Kubernetes quantities such as "2" or "500m" CPUs and
+	// "4Gi" of memory. Pods aren't limited if they're blank.
+	CPU    string `mapstructure:"cpu" json:"cpu,omitempty" bson:"cpu,omitempty"`
+	Memory string `mapstructure:"memory" json:"memory,omitempty" bson:"memory,omitempty"`
+
+	// NodeSelector restricts the nodes pods run on to those with all of the
+	// labels, each written as "name=value"
+	NodeSelector []string `mapstructure:"node_selector" json:"node_selector,omitempty" bson:"node_selector,omitempty"`
+
+	// ServiceAccount is the service account pods run as, or the namespace's
+	// default if it's blank
+	ServiceAccount string `mapstructure:"service_account" json:"service_account,omitempty" bson:"service_account,omitempty"`
+}
+
+var (
+	// bson fields for the Settings struct
+	NamespaceKey      = bsonutil.MustHaveTag(Settings{}, "Namespace")
+	ImageKey          = bsonutil.MustHaveTag(Settings{}, "Image")
+	CPUKey            = bsonutil.MustHaveTag(Settings{}, "CPU")
+	MemoryKey         = bsonutil.MustHaveTag(Settings{}, "Memory")
+	NodeSelectorKey   = bsonutil.MustHaveTag(Settings{}, "NodeSelector")
+	ServiceAccountKey = bsonutil.MustHaveTag(Settings{}, "ServiceAccount")
+)
+
+// Validate checks that the settings from the config file are sane.
+func (self *Settings) Validate() error {
+	if self.Image == "" {
+		return fmt.Errorf("Image must not be blank")
+	}
+
+	for _, selector := range self.NodeSelector {
+		if parts := strings.SplitN(selector, "=", 2); len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("Node selector '%v' must be of the form name=value", selector)
+		}
+	}
+
+	return nil
+}
+
+// namespace returns the namespace pods are created in.
+func (self *Settings) namespace() string {
+	if self.Namespace == "" {
+		return DefaultNamespace
+	}
+	return self.Namespace
+}
+
+func (_ *KubernetesManager) GetSettings() cloud.ProviderSettings {
+	return &Settings{}
+}
+
+// Configure populates a KubernetesManager by reading relevant settings from
+// the config object. Managers with the same settings share a client.
+func (kubeMgr *KubernetesManager) Configure(settings *evergreen.Settings) error {
+	config := settings.Providers.Kubernetes
+	if config.APIServer == "" {
+		return fmt.Errorf("Kubernetes API server must not be blank")
+	}
+	if config.Token == "" {
+		return fmt.Errorf("Kubernetes token must not be blank")
+	}
+	client, err := getAPIClient(config)
+	if err != nil {
+		return err
+	}
+	kubeMgr.client = client
+	return nil
+}
+
+// sanitize makes a string safe to use in pod names and label values, which
+// may only contain lowercase letters, digits and dashes.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '-'
+		}
+	}, s)
+}
+
+// truncate shortens a string to the maximum length of a name or label.
+func truncate(s string, length int) string {
+	if len(s) > length {
+		return s[:length]
+	}
+	return s
+}
+
+// generateName creates a pod name for a host. Since Kubernetes requires
+// names to be unique within a namespace, the name is also the host's id.
+func generateName(distroId string) string {
+	suffix := fmt.Sprintf("-%v-%08x", time.Now().Format(NameTimeFormat),
+		rand.New(rand.NewSource(time.Now().UnixNano())).Uint32())
+	prefix := truncate("evg-"+sanitize(distroId), maxNameLength-len(suffix))
+	return strings.TrimRight(prefix, "-") + suffix
+}
+
+// podNamespace returns the namespace a host's pod was created in.
+func podNamespace(h *host.Host) (string, error) {
+	kubeSettings := &Settings{}
+	if err := mapstructure.Decode(h.Distro.ProviderSettings, kubeSettings); err != nil {
+		return "", fmt.Errorf("Error decoding params for distro %v: %v", h.Distro.Id, err)
+	}
+	return kubeSettings.namespace(), nil
+}
+
+// SpawnInstance creates a new pod for the given distro.
+func (kubeMgr *KubernetesManager) SpawnInstance(d *distro.Distro, owner string, userHost bool) (*host.Host, error) {
+	if d.Provider != ProviderName {
+		return nil, fmt.Errorf("Can't spawn instance of %v for distro %v: provider is %v", ProviderName, d.Id, d.Provider)
+	}
+
+	kubeSettings := &Settings{}
+	if err := mapstructure.Decode(d.ProviderSettings, kubeSettings); err != nil {
+		return nil, fmt.Errorf("Error decoding params for distro %v: %v", d.Id, err)
+	}
+
+	if err := kubeSettings.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid Kubernetes settings in distro %v: %v", d.Id, err)
+	}
+
+	podName := generateName(d.Id)
+	intentHost := &host.Host{
+		Id:               podName,
+		User:             d.User,
+		Distro:           *d,
+		Tag:              podName,
+		CreationTime:     time.Now(),
+		Status:           evergreen.HostUninitialized,
+		TerminationTime:  util.ZeroTime,
+		TaskDispatchTime: util.ZeroTime,
+		Provider:         ProviderName,
+		StartedBy:        owner,
+		UserHost:         userHost,
+	}
+
+	if err := intentHost.Insert(); err != nil {
+		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not insert intent "+
+			"host '%v': %v", intentHost.Id, err)
+	}
+
+	evergreen.Logger.Logf(slogger.DEBUG, "Successfully inserted intent host '%v' "+
+		"for distro '%v' to signal cloud instance spawn intent", podName, d.Id)
+
+	// the pod is named after the host, so the intent host doesn't need to be
+	// replaced once the pod exists
+	if err := kubeMgr.client.createPod(makePod(intentHost, kubeSettings)); err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "Kubernetes create pod API call failed"+
+			" for intent host '%v': %v", intentHost.Id, err)
+
+		// remove the intent host document
+		if rmErr := intentHost.Remove(); rmErr != nil {
+			return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not remove intent host "+
+				"'%v': %v", intentHost.Id, rmErr)
+		}
+		return nil, &cloud.ProviderError{Err: err}
+	}
+
+	evergreen.Logger.Logf(slogger.DEBUG, "Created pod '%v' in namespace %v",
+		intentHost.Id, kubeSettings.namespace())
+	return intentHost, nil
+}
+
+// makePod returns the pod to create for the intent host. The pod's labels
+// tie it back to the host.
+func makePod(intentHost *host.Host, kubeSettings *Settings) *pod {
+	mode := "production"
+	if intentHost.UserHost {
+		mode = "testing"
+	}
+	resources := map[string]string{}
+	if kubeSettings.CPU != "" {
+		resources["cpu"] = kubeSettings.CPU
+	}
+	if kubeSettings.Memory != "" {
+		resources["memory"] = kubeSettings.Memory
+	}
+	var nodeSelector map[string]string
+	for _, selector := range kubeSettings.NodeSelector {
+		if nodeSelector == nil {
+			nodeSelector = map[string]string{}
+		}
+		parts := strings.SplitN(selector, "=", 2)
+		nodeSelector[parts[0]] = parts[1]
+	}
+
+	return &pod{
+		Metadata: objectMeta{
+			Name:      intentHost.Id,
+			Namespace: kubeSettings.namespace(),
+			Labels: map[string]string{
+				"evergreen-host-id": truncate(intentHost.Id, maxNameLength),
+				"distro":            strings.Trim(truncate(sanitize(intentHost.Distro.Id), maxNameLength), "-"),
+				"owner":             strings.Trim(truncate(sanitize(intentHost.StartedBy), maxNameLength), "-"),
+				"mode":              mode,
+			},
+		},
+		Spec: &podSpec{
+			Containers: []container{{
+				Name:    containerName,
+				Image:   kubeSettings.Image,
+				Command: keepAliveCommand,
+				// requesting what the pod is limited to guarantees it's
+				// scheduled where it can use all of it
+				Resources: resourceRequirements{Requests: resources, Limits: resources},
+			}},
+			// the host is gone once its container stops
+			RestartPolicy:      "Never",
+			NodeSelector:       nodeSelector,
+			ServiceAccountName: kubeSettings.ServiceAccount,
+		},
+	}
+}
+
+// podToEvergreenStatus returns a "universal" status code based on the pod's
+// phase. Pods that Kubernetes evicted from their node, or is deleting, are
+// as good as gone.
+func podToEvergreenStatus(p *pod) cloud.CloudStatus {
+	if p.Metadata.DeletionTimestamp != "" {
+		return cloud.StatusTerminated
+	}
+	if p.Status == nil {
+		return cloud.StatusUnknown
+	}
+	switch p.Status.Phase {
+	case PodPhasePending:
+		return cloud.StatusInitializing
+	case PodPhaseRunning:
+		return cloud.StatusRunning
+	case PodPhaseSucceeded:
+		return cloud.StatusTerminated
+	case PodPhaseFailed:
+		if p.Status.Reason == podReasonEvicted {
+			return cloud.StatusPreempted
+		}
+		return cloud.StatusFailed
+	default:
+		return cloud.StatusUnknown
+	}
+}
+
+// GetInstanceStatus returns a universal status code representing the state
+// of a pod. Pods that Kubernetes no longer knows about are terminated.
+func (kubeMgr *KubernetesManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
+	namespace, err := podNamespace(host)
+	if err != nil {
+		return cloud.StatusUnknown, err
+	}
+	p, err := kubeMgr.client.getPod(namespace, host.Id)
+	if err == errNotFound {
+		return cloud.StatusTerminated, nil
+	}
+	if err != nil {
+		return cloud.StatusUnknown, fmt.Errorf("Failed to get pod info for '%v': %v", host.Id, err)
+	}
+	return podToEvergreenStatus(p), nil
+}
+
+// GetDNSName returns the pod's IP address. Evergreen doesn't connect to pods
+// with it, since commands are run with exec.
+func (kubeMgr *KubernetesManager) GetDNSName(host *host.Host) (string, error) {
+	namespace, err := podNamespace(host)
+	if err != nil {
+		return "", err
+	}
+	p, err := kubeMgr.client.getPod(namespace, host.Id)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get pod info for '%v': %v", host.Id, err)
+	}
+	if p.Status == nil {
+		return "", nil
+	}
+	return p.Status.PodIP, nil
+}
+
+// CanSpawn returns if a given cloud provider supports spawning a new host
+// dynamically. Always returns true for Kubernetes.
+func (kubeMgr *KubernetesManager) CanSpawn() (bool, error) {
+	return true, nil
+}
+
+// TerminateInstance deletes a pod. Pods that Kubernetes no longer knows
+// about are already gone.
+func (kubeMgr *KubernetesManager) TerminateInstance(host *host.Host) error {
+	if host.Status == evergreen.HostTerminated {
+		errMsg := fmt.Errorf("Can not terminate %v - already marked as "+
+			"terminated!", host.Id)
+		evergreen.Logger.Errorf(slogger.ERROR, errMsg.Error())
+		return errMsg
+	}
+
+	namespace, err := podNamespace(host)
+	if err != nil {
+		return err
+	}
+	err = kubeMgr.client.deletePod(namespace, host.Id)
+	if err != nil && err != errNotFound {
+		return evergreen.Logger.Errorf(slogger.ERROR, "Failed to delete pod '%v': %v", host.Id, err)
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Terminated %v", host.Id)
+
+	return host.Terminate()
+}
+
+// Exec runs a command in a pod's container through the Kubernetes API.
+func (kubeMgr *KubernetesManager) Exec(host *host.Host, cmd []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	namespace, err := podNamespace(host)
+	if err != nil {
+		return err
+	}
+	return kubeMgr.client.exec(namespace, host.Id, containerName, cmd, stdin, stdout, stderr)
+}
+
+// IsSSHReachable checks if commands can be run in a pod. Despite the name,
+// this is done with exec.
+func (kubeMgr *KubernetesManager) IsSSHReachable(host *host.Host, keyPath string) (bool, error) {
+	err := util.RunFunctionWithTimeout(func() error {
+		return kubeMgr.Exec(host, []string{"true"}, nil, ioutil.Discard, ioutil.Discard)
+	}, reachableTimeout)
+	return err == nil, nil
+}
+
+// IsUp checks the pod's phase by querying the Kubernetes API and returns
+// true if commands can be run in it.
+func (kubeMgr *KubernetesManager) IsUp(host *host.Host) (bool, error) {
+	cloudStatus, err := kubeMgr.GetInstanceStatus(host)
+	if err != nil {
+		return false, err
+	}
+	if cloudStatus == cloud.StatusRunning {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (kubeMgr *KubernetesManager) OnUp(host *host.Host) error {
+	//Not currently needed since the labels are set when the pod is created
+	return nil
+}
+
+// GetSSHOptions returns no options, since pods aren't connected to with SSH.
+func (kubeMgr *KubernetesManager) GetSSHOptions(host *host.Host, keyPath string) ([]string, error) {
+	return []string{}, nil
+}
+
+// TimeTilNextPayment returns the amount of time until the next payment is due
+// for the host. Pods run on nodes that are paid for separately.
+func (kubeMgr *KubernetesManager) TimeTilNextPayment(host *host.Host) time.Duration {
+	return time.Duration(0)
+}
@@ -0,0 +1,257 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/cloudtest"
+	"github.com/evergreen-ci/evergreen/hostutil"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/websocket"
+)
+
+var headPattern = regexp.MustCompile(`head -c (\d+) > '([^']+)'`)
+
+// fakeCluster is a Kubernetes API server that keeps pods in memory and
+// fakes exec: copies with head are recorded, "false" fails, and any other
+// command prints its arguments.
+type fakeCluster struct {
+	*cloudtest.FakeAPI
+	token  string
+	mu     sync.Mutex
+	pods   map[string]*pod
+	copied map[string][]byte
+}
+
+func newFakeCluster() *fakeCluster {
+	f := &fakeCluster{FakeAPI: cloudtest.NewFakeAPI(), pods: map[string]*pod{}, copied: map[string][]byte{}}
+	f.token = f.IssueToken()
+	exec := websocket.Server{
+		Handshake: func(c *websocket.Config, r *http.Request) error {
+			c.Protocol = []string{execProtocol}
+			return nil
+		},
+		Handler: f.handleExec,
+	}
+	f.Mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if !f.Authorized(w, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/exec") {
+			exec.ServeHTTP(w, r)
+			return
+		}
+		f.handlePods(w, r)
+	})
+	return f
+}
+
+func (f *fakeCluster) config() evergreen.KubernetesConfig {
+	return evergreen.KubernetesConfig{APIServer: f.URL, Token: f.token}
+}
+
+func (f *fakeCluster) handlePods(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Method == "POST" {
+		p := &pod{}
+		if !cloudtest.DecodeJSON(w, r, "POST", p) {
+			return
+		}
+		p.Status = &podStatus{Phase: PodPhasePending}
+		f.pods[p.Metadata.Name] = p
+		json.NewEncoder(w).Encode(p)
+		return
+	}
+	name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	p, ok := f.pods[name]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(status{Status: "Failure", Message: "pods \"" + name + "\" not found"})
+		return
+	}
+	if r.Method == "DELETE" {
+		delete(f.pods, name)
+	}
+	json.NewEncoder(w).Encode(p)
+}
+
+func (f *fakeCluster) handleExec(ws *websocket.Conn) {
+	ws.PayloadType = websocket.BinaryFrame
+	cmd := ws.Request().URL.Query()["command"]
+	send := func(channel byte, data []byte) {
+		websocket.Message.Send(ws, append([]byte{channel}, data...))
+	}
+	sendStatus := func(s status) {
+		msg, _ := json.Marshal(s)
+		send(statusChannel, msg)
+	}
+
+	full := strings.Join(cmd, " ")
+	if match := headPattern.FindStringSubmatch(full); match != nil {
+		size, _ := strconv.Atoi(match[1])
+		data := []byte{}
+		for len(data) < size {
+			var msg []byte
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				return
+			}
+			if len(msg) > 0 && msg[0] == stdinChannel {
+				data = append(data, msg[1:]...)
+			}
+		}
+		f.mu.Lock()
+		f.copied[match[2]] = data
+		f.mu.Unlock()
+		sendStatus(status{Status: execStatusSuccess})
+		return
+	}
+	if len(cmd) == 3 && cmd[2] == "false" {
+		send(stderrChannel, []byte("failing\n"))
+		sendStatus(status{Status: "Failure", Reason: "NonZeroExitCode",
+			Message: "command terminated with non-zero exit code: exit status 1"})
+		return
+	}
+	send(stdoutChannel, []byte(full+"\n"))
+	sendStatus(status{Status: execStatusSuccess})
+}
+
+func TestPods(t *testing.T) {
+	Convey("With a Kubernetes manager", t, func() {
+		f := newFakeCluster()
+		defer f.Close()
+		client, err := newAPIClient(f.config())
+		So(err, ShouldBeNil)
+		kubeMgr := &KubernetesManager{client: client}
+
+		settings := &Settings{
+			Namespace:    "evergreen",
+			Image:        "evergreen/ubuntu1604",
+			CPU:          "2",
+			Memory:       "4Gi",
+			NodeSelector: []string{"pool=evergreen"},
+		}
+		h := &host.Host{
+			Id:        generateName("Ubuntu 16.04 (Large)"),
+			StartedBy: evergreen.User,
+			Provider:  ProviderName,
+			Distro: distro.Distro{Id: "Ubuntu 16.04 (Large)", ProviderSettings: &map[string]interface{}{
+				"namespace": "evergreen",
+				"image":     "evergreen/ubuntu1604",
+			}},
+		}
+		So(client.createPod(makePod(h, settings)), ShouldBeNil)
+
+		Convey("pods should be created with the distro's settings", func() {
+			p := f.pods[h.Id]
+			So(p, ShouldNotBeNil)
+			So(p.Metadata.Namespace, ShouldEqual, "evergreen")
+			So(p.Metadata.Labels["distro"], ShouldEqual, "ubuntu-16-04--large")
+			So(p.Metadata.Labels["mode"], ShouldEqual, "production")
+			So(p.Spec.NodeSelector, ShouldResemble, map[string]string{"pool": "evergreen"})
+			So(p.Spec.Containers[0].Resources.Limits, ShouldResemble,
+				map[string]string{"cpu": "2", "memory": "4Gi"})
+			So(p.Spec.RestartPolicy, ShouldEqual, "Never")
+		})
+
+		Convey("the pod's phase should be the host's status", func() {
+			status, err := kubeMgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusInitializing)
+
+			f.pods[h.Id].Status = &podStatus{Phase: PodPhaseRunning, PodIP: "10.1.2.3"}
+			up, err := kubeMgr.IsUp(h)
+			So(err, ShouldBeNil)
+			So(up, ShouldBeTrue)
+			dns, err := kubeMgr.GetDNSName(h)
+			So(err, ShouldBeNil)
+			So(dns, ShouldEqual, "10.1.2.3")
+
+			So(client.deletePod("evergreen", h.Id), ShouldBeNil)
+			status, err = kubeMgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusTerminated)
+		})
+
+		Convey("commands should be run in the pod", func() {
+			output, err := hostutil.ExecCommand(h, "echo hello", kubeMgr, time.Minute)
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "sh -c echo hello\n")
+
+			output, err = hostutil.ExecCommand(h, "false", kubeMgr, time.Minute)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "non-zero exit code")
+			So(output, ShouldEqual, "failing\n")
+
+			reachable, err := kubeMgr.IsSSHReachable(h, "")
+			So(err, ShouldBeNil)
+			So(reachable, ShouldBeTrue)
+		})
+
+		Convey("files should be copied to the pod", func() {
+			file, err := ioutil.TempFile("", "agent")
+			So(err, ShouldBeNil)
+			defer os.Remove(file.Name())
+			contents := bytes.Repeat([]byte("evergreen"), 10000)
+			_, err = file.Write(contents)
+			So(err, ShouldBeNil)
+			So(file.Close(), ShouldBeNil)
+
+			So(hostutil.ExecCopyFile(h, file.Name(), "/data/mci/main", kubeMgr, time.Minute), ShouldBeNil)
+			So(f.copied["/data/mci/main"], ShouldResemble, contents)
+		})
+	})
+}
+
+func TestConfigure(t *testing.T) {
+	Convey("Managers with the same settings should share a client", t, func() {
+		settings := &evergreen.Settings{}
+		settings.Providers.Kubernetes = evergreen.KubernetesConfig{APIServer: "https://k8s.example.com", Token: "token"}
+		first, second := &KubernetesManager{}, &KubernetesManager{}
+		So(first.Configure(settings), ShouldBeNil)
+		So(second.Configure(settings), ShouldBeNil)
+		So(second.client, ShouldEqual, first.client)
+
+		settings.Providers.Kubernetes.Token = "other"
+		third := &KubernetesManager{}
+		So(third.Configure(settings), ShouldBeNil)
+		So(third.client, ShouldNotEqual, first.client)
+	})
+}
+
+func TestPodStatus(t *testing.T) {
+	Convey("Pods should be mapped to Evergreen statuses", t, func() {
+		So(podToEvergreenStatus(&pod{Status: &podStatus{Phase: PodPhaseFailed, Reason: podReasonEvicted}}),
+			ShouldEqual, cloud.StatusPreempted)
+		So(podToEvergreenStatus(&pod{Status: &podStatus{Phase: PodPhaseFailed}}),
+			ShouldEqual, cloud.StatusFailed)
+		So(podToEvergreenStatus(&pod{Status: &podStatus{Phase: PodPhaseSucceeded}}),
+			ShouldEqual, cloud.StatusTerminated)
+		So(podToEvergreenStatus(&pod{Metadata: objectMeta{DeletionTimestamp: "2016-10-18T00:00:00Z"},
+			Status: &podStatus{Phase: PodPhaseRunning}}), ShouldEqual, cloud.StatusTerminated)
+		So(podToEvergreenStatus(&pod{Status: &podStatus{Phase: PodPhaseUnknown}}),
+			ShouldEqual, cloud.StatusUnknown)
+	})
+}
+
+func TestGenerateName(t *testing.T) {
+	Convey("Pod names should be valid Kubernetes names", t, func() {
+		name := generateName(strings.Repeat("Windows_2012R2.", 10))
+		So(len(name), ShouldBeLessThanOrEqualTo, maxNameLength)
+		So(name, ShouldNotEqual, generateName(strings.Repeat("Windows_2012R2.", 10)))
+		So(regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`).MatchString(name), ShouldBeTrue)
+	})
+}
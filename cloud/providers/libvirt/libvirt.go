@@ -0,0 +1,444 @@
+package libvirt
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"github.com/evergreen-ci/evergreen/hostutil"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	ProviderName = "libvirt"
+
+	// NameTimeFormat is the format in which to log times like instance start time.
+	NameTimeFormat = "20060102150405"
+
+	DefaultStoragePool = "default"
+	DefaultNetwork     = "default"
+)
+
+// LibvirtManager implements the CloudManager interface for VMs on on-premise
+// hypervisors managed by libvirt. Each host boots from a copy-on-write clone
+// of its distro's base volume, which is deleted along with the VM.
+type LibvirtManager struct {
+	virsh virshRunner
+}
+
+// Settings are the distro's settings for the VMs it creates.
+type Settings struct {
+	// URI is the libvirt connection URI of the hypervisor, e.g.
+	// qemu+ssh://evergreen@hypervisor.example.com/system
+	URI string `mapstructure:"uri" json:"uri" bson:"uri"`
+
+	// StoragePool is the pool holding the base volume, which clones are
+	// created in. It must be a file-based pool such as a directory.
+	StoragePool string `mapstructure:"storage_pool" json:"storage_pool,omitempty" bson:"storage_pool,omitempty"`
+
+	// BaseVolume is the name of the volume that VMs are cloned from
+	BaseVolume string `mapstructure:"base_volume" json:"base_volume" bson:"base_volume"`
+
+	// DiskSizeGB is the size of each VM's disk, which must be at least the
+	// size of the base volume
+	DiskSizeGB int `mapstructure:"disk_size_gb" json:"disk_size_gb" bson:"disk_size_gb"`
+
+	MemoryMB int `mapstructure:"memory_mb" json:"memory_mb" bson:"memory_mb"`
+	VCPUs    int `mapstructure:"vcpus" json:"vcpus" bson:"vcpus"`
+
+	// Network is the libvirt network to attach VMs to
+	Network string `mapstructure:"network" json:"network,omitempty" bson:"network,omitempty"`
+
+	// AddressSource is where libvirt looks up VMs' addresses: "lease" for the
+	// network's DHCP leases (the default), "agent" for the guest agent, or
+	// "arp" for the hypervisor's ARP table
+	AddressSource string `mapstructure:"address_source" json:"address_source,omitempty" bson:"address_source,omitempty"`
+}
+
+var (
+	// bson fields for the Settings struct
+	URIKey           = bsonutil.MustHaveTag(Settings{}, "URI")
+	StoragePoolKey   = bsonutil.MustHaveTag(Settings{}, "StoragePool")
+	BaseVolumeKey    = bsonutil.MustHaveTag(Settings{}, "BaseVolume")
+	DiskSizeGBKey    = bsonutil.MustHaveTag(Settings{}, "DiskSizeGB")
+	MemoryMBKey      = bsonutil.MustHaveTag(Settings{}, "MemoryMB")
+	VCPUsKey         = bsonutil.MustHaveTag(Settings{}, "VCPUs")
+	NetworkKey       = bsonutil.MustHaveTag(Settings{}, "Network")
+	AddressSourceKey = bsonutil.MustHaveTag(Settings{}, "AddressSource")
+)
+
+// Validate checks that the settings from the config file are sane.
+func (self *Settings) Validate() error {
+	if self.URI == "" {
+		return fmt.Errorf("Connection URI must not be blank")
+	}
+
+	if self.BaseVolume == "" {
+		return fmt.Errorf("Base volume must not be blank")
+	}
+
+	if self.DiskSizeGB <= 0 {
+		return fmt.Errorf("Disk size must be positive")
+	}
+
+	if self.MemoryMB <= 0 {
+		return fmt.Errorf("Memory must be positive")
+	}
+
+	if self.VCPUs <= 0 {
+		return fmt.Errorf("Number of vCPUs must be positive")
+	}
+
+	switch self.AddressSource {
+	case "", "lease", "agent", "arp":
+	default:
+		return fmt.Errorf("Address source must be lease, agent or arp, not '%v'", self.AddressSource)
+	}
+
+	return nil
+}
+
+// pool returns the storage pool to create volumes in.
+func (self *Settings) pool() string {
+	if self.StoragePool == "" {
+		return DefaultStoragePool
+	}
+	return self.StoragePool
+}
+
+func (_ *LibvirtManager) GetSettings() cloud.ProviderSettings {
+	return &Settings{}
+}
+
+// Configure populates a LibvirtManager. Each distro has its own connection,
+// so there is nothing to read from the config object.
+func (lvMgr *LibvirtManager) Configure(settings *evergreen.Settings) error {
+	if lvMgr.virsh == nil {
+		lvMgr.virsh = execVirsh{}
+	}
+	return nil
+}
+
+// generateName creates a domain name for a host. Since names are unique on a
+// hypervisor, the name is also the host's id.
+func generateName(distroId string) string {
+	return "evg-" + distroId + "-" + time.Now().Format(NameTimeFormat) +
+		fmt.Sprintf("-%v", rand.New(rand.NewSource(time.Now().UnixNano())).Int())
+}
+
+// volumeName returns the name of a host's disk volume.
+func volumeName(hostId string) string {
+	return hostId + ".qcow2"
+}
+
+// hostSettings returns the settings of the distro a host was created with.
+func hostSettings(h *host.Host) (*Settings, error) {
+	lvSettings := &Settings{}
+	if err := mapstructure.Decode(h.Distro.ProviderSettings, lvSettings); err != nil {
+		return nil, fmt.Errorf("Error decoding params for distro %v: %v", h.Distro.Id, err)
+	}
+	if lvSettings.URI == "" {
+		return nil, fmt.Errorf("Host %v's distro does not have a connection URI", h.Id)
+	}
+	return lvSettings, nil
+}
+
+// SpawnInstance clones the distro's base volume and boots a new VM from it.
+func (lvMgr *LibvirtManager) SpawnInstance(d *distro.Distro, owner string, userHost bool) (*host.Host, error) {
+	if d.Provider != ProviderName {
+		return nil, fmt.Errorf("Can't spawn instance of %v for distro %v: provider is %v", ProviderName, d.Id, d.Provider)
+	}
+
+	lvSettings := &Settings{}
+	if err := mapstructure.Decode(d.ProviderSettings, lvSettings); err != nil {
+		return nil, fmt.Errorf("Error decoding params for distro %v: %v", d.Id, err)
+	}
+
+	if err := lvSettings.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid libvirt settings in distro %v: %v", d.Id, err)
+	}
+
+	instanceName := generateName(d.Id)
+	intentHost := &host.Host{
+		Id:               instanceName,
+		User:             d.User,
+		Distro:           *d,
+		Tag:              instanceName,
+		CreationTime:     time.Now(),
+		Status:           evergreen.HostUninitialized,
+		TerminationTime:  util.ZeroTime,
+		TaskDispatchTime: util.ZeroTime,
+		Provider:         ProviderName,
+		StartedBy:        owner,
+		UserHost:         userHost,
+	}
+
+	if err := intentHost.Insert(); err != nil {
+		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not insert intent "+
+			"host '%v': %v", intentHost.Id, err)
+	}
+
+	evergreen.Logger.Logf(slogger.DEBUG, "Successfully inserted intent host '%v' "+
+		"for distro '%v' to signal cloud instance spawn intent", instanceName,
+		d.Id)
+
+	// the domain is named after the host, so unlike other providers the
+	// intent host doesn't need to be replaced once the domain exists
+	if err := lvMgr.createDomain(intentHost, lvSettings); err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "Creating libvirt domain failed"+
+			" for intent host '%v': %v", intentHost.Id, err)
+
+		// clean up whatever was created before the failure
+		if cleanupErr := lvMgr.deleteDomain(intentHost.Id, lvSettings); cleanupErr != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Could not clean up domain '%v': %v",
+				intentHost.Id, cleanupErr)
+		}
+
+		// remove the intent host document
+		if rmErr := intentHost.Remove(); rmErr != nil {
+			return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not remove intent host "+
+				"'%v': %v", intentHost.Id, rmErr)
+		}
+		return nil, &cloud.ProviderError{Err: err}
+	}
+
+	return intentHost, nil
+}
+
+// createDomain clones the base volume for the host, then defines and starts
+// its domain.
+func (lvMgr *LibvirtManager) createDomain(intentHost *host.Host, lvSettings *Settings) error {
+	volume := volumeName(intentHost.Id)
+	_, err := lvMgr.virsh.run(lvSettings.URI, "vol-create-as", "--pool", lvSettings.pool(),
+		volume, fmt.Sprintf("%vG", lvSettings.DiskSizeGB),
+		"--format", "qcow2", "--backing-vol", lvSettings.BaseVolume)
+	if err != nil {
+		return fmt.Errorf("error cloning volume '%v': %v", lvSettings.BaseVolume, err)
+	}
+
+	path, err := lvMgr.virsh.run(lvSettings.URI, "vol-path", "--pool", lvSettings.pool(), volume)
+	if err != nil {
+		return fmt.Errorf("error finding volume '%v': %v", volume, err)
+	}
+
+	if err = defineDomain(lvMgr.virsh, lvSettings.URI, makeDomain(intentHost, lvSettings,
+		strings.TrimSpace(path))); err != nil {
+		return fmt.Errorf("error defining domain: %v", err)
+	}
+
+	if _, err = lvMgr.virsh.run(lvSettings.URI, "start", intentHost.Id); err != nil {
+		return fmt.Errorf("error starting domain: %v", err)
+	}
+	return nil
+}
+
+// makeDomain returns the definition of the host's domain. The domain's
+// metadata ties it back to the host.
+func makeDomain(intentHost *host.Host, lvSettings *Settings, volumePath string) *domain {
+	d := &domain{
+		Type:   "kvm",
+		Name:   intentHost.Id,
+		Memory: domainMemory{Unit: "MiB", Value: lvSettings.MemoryMB},
+		VCPUs:  lvSettings.VCPUs,
+		CPU:    domainCPU{Mode: "host-model"},
+		Metadata: domainMetadata{Instance: instanceMetadata{
+			HostId: intentHost.Id,
+			Distro: intentHost.Distro.Id,
+			Owner:  intentHost.StartedBy,
+			Mode:   "production",
+		}},
+	}
+	if intentHost.UserHost {
+		d.Metadata.Instance.Mode = "testing"
+	}
+	d.OS.Type = "hvm"
+	d.OS.Boot.Dev = "hd"
+
+	disk := &d.Devices.Disk
+	disk.Type, disk.Device = "file", "disk"
+	disk.Driver.Name, disk.Driver.Type = "qemu", "qcow2"
+	disk.Source.File = volumePath
+	disk.Target.Dev, disk.Target.Bus = "vda", "virtio"
+
+	iface := &d.Devices.Interface
+	iface.Type = "network"
+	iface.Source.Network = lvSettings.Network
+	if iface.Source.Network == "" {
+		iface.Source.Network = DefaultNetwork
+	}
+	iface.Model.Type = "virtio"
+
+	channel := &d.Devices.Channel
+	channel.Type = "unix"
+	channel.Target.Type, channel.Target.Name = "virtio", "org.qemu.guest_agent.0"
+
+	d.Devices.Serial.Type = "pty"
+	d.Devices.Console.Type = "pty"
+	return d
+}
+
+// deleteDomain stops and removes a domain and its volume. Parts that don't
+// exist are skipped.
+func (lvMgr *LibvirtManager) deleteDomain(name string, lvSettings *Settings) error {
+	if _, err := lvMgr.virsh.run(lvSettings.URI, "destroy", name); err != nil &&
+		!isNotFound(err) && !strings.Contains(err.Error(), "not running") {
+		return fmt.Errorf("error stopping domain: %v", err)
+	}
+	if _, err := lvMgr.virsh.run(lvSettings.URI, "undefine", name); err != nil && !isNotFound(err) {
+		return fmt.Errorf("error undefining domain: %v", err)
+	}
+	if _, err := lvMgr.virsh.run(lvSettings.URI, "vol-delete", "--pool", lvSettings.pool(),
+		volumeName(name)); err != nil && !isNotFound(err) {
+		return fmt.Errorf("error deleting volume: %v", err)
+	}
+	return nil
+}
+
+// domainStateToEvergreenStatus returns a "universal" status code based on
+// libvirt's domain states.
+func domainStateToEvergreenStatus(state string) cloud.CloudStatus {
+	switch state {
+	case DomainStateRunning, DomainStateBlocked:
+		return cloud.StatusRunning
+	case DomainStatePaused, DomainStateShutdown, DomainStateShutoff, DomainStatePMSuspended:
+		return cloud.StatusStopped
+	case DomainStateCrashed:
+		return cloud.StatusFailed
+	default:
+		return cloud.StatusUnknown
+	}
+}
+
+// GetInstanceStatus returns a universal status code representing the state
+// of a VM. Running VMs are still initializing until they have an address,
+// and VMs that libvirt no longer knows about are terminated.
+func (lvMgr *LibvirtManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
+	lvSettings, err := hostSettings(host)
+	if err != nil {
+		return cloud.StatusUnknown, err
+	}
+	state, err := lvMgr.virsh.run(lvSettings.URI, "domstate", host.Id)
+	if isNotFound(err) {
+		return cloud.StatusTerminated, nil
+	}
+	if err != nil {
+		return cloud.StatusUnknown, fmt.Errorf("Failed to get state of domain '%v': %v", host.Id, err)
+	}
+
+	status := domainStateToEvergreenStatus(strings.TrimSpace(state))
+	if status == cloud.StatusRunning {
+		addr, err := lvMgr.domainAddress(host.Id, lvSettings)
+		if err != nil {
+			return cloud.StatusUnknown, err
+		}
+		if addr == "" {
+			return cloud.StatusInitializing, nil
+		}
+	}
+	return status, nil
+}
+
+// domainAddress returns the VM's IPv4 address, or the empty string if it
+// doesn't have one yet.
+func (lvMgr *LibvirtManager) domainAddress(name string, lvSettings *Settings) (string, error) {
+	args := []string{"domifaddr", name}
+	if lvSettings.AddressSource != "" {
+		args = append(args, "--source", lvSettings.AddressSource)
+	}
+	output, err := lvMgr.virsh.run(lvSettings.URI, args...)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get address of domain '%v': %v", name, err)
+	}
+	return parseDomainAddress(output), nil
+}
+
+// GetDNSName returns the VM's IPv4 address.
+func (lvMgr *LibvirtManager) GetDNSName(host *host.Host) (string, error) {
+	lvSettings, err := hostSettings(host)
+	if err != nil {
+		return "", err
+	}
+	return lvMgr.domainAddress(host.Id, lvSettings)
+}
+
+// CanSpawn returns if a given cloud provider supports spawning a new host
+// dynamically. Always returns true for libvirt.
+func (lvMgr *LibvirtManager) CanSpawn() (bool, error) {
+	return true, nil
+}
+
+// TerminateInstance destroys a VM and deletes its disk.
+func (lvMgr *LibvirtManager) TerminateInstance(host *host.Host) error {
+	if host.Status == evergreen.HostTerminated {
+		errMsg := fmt.Errorf("Can not terminate %v - already marked as "+
+			"terminated!", host.Id)
+		evergreen.Logger.Errorf(slogger.ERROR, errMsg.Error())
+		return errMsg
+	}
+
+	lvSettings, err := hostSettings(host)
+	if err != nil {
+		return err
+	}
+	if err = lvMgr.deleteDomain(host.Id, lvSettings); err != nil {
+		return evergreen.Logger.Errorf(slogger.ERROR, "Failed to delete domain '%v': %v", host.Id, err)
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Terminated %v", host.Id)
+
+	return host.Terminate()
+}
+
+// IsSSHReachable checks if a VM appears to be reachable via SSH by
+// attempting to contact the host directly.
+func (lvMgr *LibvirtManager) IsSSHReachable(host *host.Host, keyPath string) (bool, error) {
+	sshOpts, err := lvMgr.GetSSHOptions(host, keyPath)
+	if err != nil {
+		return false, err
+	}
+	return hostutil.CheckSSHResponse(host, sshOpts)
+}
+
+// IsUp checks the VM's state with libvirt and returns true if the host
+// should be available to connect with SSH.
+func (lvMgr *LibvirtManager) IsUp(host *host.Host) (bool, error) {
+	cloudStatus, err := lvMgr.GetInstanceStatus(host)
+	if err != nil {
+		return false, err
+	}
+	if cloudStatus == cloud.StatusRunning {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (lvMgr *LibvirtManager) OnUp(host *host.Host) error {
+	//Not currently needed since the metadata is set when the domain is defined
+	return nil
+}
+
+// GetSSHOptions returns an array of default SSH options for connecting to a
+// VM. The base volume must already authorize the distro's key.
+func (lvMgr *LibvirtManager) GetSSHOptions(host *host.Host, keyPath string) ([]string, error) {
+	if keyPath == "" {
+		return []string{}, fmt.Errorf("No key specified for libvirt host")
+	}
+	opts := []string{"-i", keyPath}
+	for _, opt := range host.Distro.SSHOptions {
+		opts = append(opts, "-o", opt)
+	}
+	return opts, nil
+}
+
+// TimeTilNextPayment returns the amount of time until the next payment is due
+// for the host. For on-premise hardware this is not relevant.
+func (lvMgr *LibvirtManager) TimeTilNextPayment(host *host.Host) time.Duration {
+	return time.Duration(0)
+}
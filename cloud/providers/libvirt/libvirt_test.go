@@ -0,0 +1,207 @@
+package libvirt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeVirsh is a hypervisor that keeps domains and volumes in memory.
+type fakeVirsh struct {
+	domains   map[string]string
+	addresses map[string]string
+	volumes   map[string]string
+	defined   domain
+	commands  []string
+	failOn    string
+}
+
+func newFakeVirsh() *fakeVirsh {
+	return &fakeVirsh{
+		domains:   map[string]string{},
+		addresses: map[string]string{},
+		volumes:   map[string]string{"default/base.qcow2": ""},
+	}
+}
+
+func (f *fakeVirsh) run(uri string, args ...string) (string, error) {
+	f.commands = append(f.commands, args[0])
+	if uri != "qemu+ssh://evergreen@hv1/system" {
+		return "", fmt.Errorf("virsh %v: failed to connect to the hypervisor", args[0])
+	}
+	if args[0] == f.failOn {
+		return "", fmt.Errorf("virsh %v: internal error", args[0])
+	}
+	switch args[0] {
+	case "vol-create-as":
+		// vol-create-as --pool POOL NAME SIZE --format qcow2 --backing-vol BASE
+		if _, ok := f.volumes[args[2]+"/"+args[8]]; !ok {
+			return "", fmt.Errorf("virsh vol-create-as: Storage volume not found: %v", args[8])
+		}
+		f.volumes[args[2]+"/"+args[3]] = args[8]
+	case "vol-path":
+		if _, ok := f.volumes[args[2]+"/"+args[3]]; !ok {
+			return "", fmt.Errorf("virsh vol-path: failed to get vol '%v'", args[3])
+		}
+		return "/var/lib/libvirt/images/" + args[3] + "\n", nil
+	case "vol-delete":
+		if _, ok := f.volumes[args[2]+"/"+args[3]]; !ok {
+			return "", fmt.Errorf("virsh vol-delete: failed to get vol '%v'", args[3])
+		}
+		delete(f.volumes, args[2]+"/"+args[3])
+	case "define":
+		definition, err := ioutil.ReadFile(args[1])
+		if err != nil {
+			return "", err
+		}
+		if err = xml.Unmarshal(definition, &f.defined); err != nil {
+			return "", err
+		}
+		f.domains[f.defined.Name] = DomainStateShutoff
+	case "start":
+		if _, ok := f.domains[args[1]]; !ok {
+			return "", fmt.Errorf("virsh start: failed to get domain '%v'", args[1])
+		}
+		f.domains[args[1]] = DomainStateRunning
+	case "destroy":
+		state, ok := f.domains[args[1]]
+		if !ok {
+			return "", fmt.Errorf("virsh destroy: failed to get domain '%v'", args[1])
+		}
+		if state != DomainStateRunning {
+			return "", fmt.Errorf("virsh destroy: Requested operation is not valid: domain is not running")
+		}
+		f.domains[args[1]] = DomainStateShutoff
+	case "undefine":
+		if _, ok := f.domains[args[1]]; !ok {
+			return "", fmt.Errorf("virsh undefine: failed to get domain '%v'", args[1])
+		}
+		delete(f.domains, args[1])
+	case "domstate":
+		state, ok := f.domains[args[1]]
+		if !ok {
+			return "", fmt.Errorf("virsh domstate: failed to get domain '%v'", args[1])
+		}
+		return state + "\n\n", nil
+	case "domifaddr":
+		output := " Name       MAC address          Protocol     Address\n" +
+			"-------------------------------------------------------------------------------\n"
+		if addr, ok := f.addresses[args[1]]; ok {
+			output += " lo         00:00:00:00:00:00    ipv4         127.0.0.1/8\n"
+			output += " vnet0      52:54:00:6b:3c:58    ipv6         fe80::5054:ff:fe6b:3c58/64\n"
+			output += " -          -                    ipv4         " + addr + "/24\n"
+		}
+		return output, nil
+	}
+	return "", nil
+}
+
+func TestLibvirtManager(t *testing.T) {
+	Convey("With a libvirt manager for a fake hypervisor", t, func() {
+		fake := newFakeVirsh()
+		mgr := &LibvirtManager{virsh: fake}
+		lvSettings := &Settings{
+			URI:        "qemu+ssh://evergreen@hv1/system",
+			BaseVolume: "base.qcow2",
+			DiskSizeGB: 40,
+			MemoryMB:   4096,
+			VCPUs:      2,
+			Network:    "ci",
+		}
+		h := &host.Host{
+			Id:        "evg-ubuntu-1",
+			Distro:    distro.Distro{Id: "ubuntu", ProviderSettings: &map[string]interface{}{"uri": lvSettings.URI}},
+			StartedBy: "mci",
+		}
+
+		Convey("domains should be cloned from the base volume and started", func() {
+			So(mgr.createDomain(h, lvSettings), ShouldBeNil)
+			So(fake.volumes["default/evg-ubuntu-1.qcow2"], ShouldEqual, "base.qcow2")
+			So(fake.domains["evg-ubuntu-1"], ShouldEqual, DomainStateRunning)
+
+			So(fake.defined.Memory.Value, ShouldEqual, 4096)
+			So(fake.defined.VCPUs, ShouldEqual, 2)
+			So(fake.defined.Devices.Disk.Source.File, ShouldEqual, "/var/lib/libvirt/images/evg-ubuntu-1.qcow2")
+			So(fake.defined.Devices.Disk.Driver.Type, ShouldEqual, "qcow2")
+			So(fake.defined.Devices.Interface.Source.Network, ShouldEqual, "ci")
+			So(fake.defined.Metadata.Instance.HostId, ShouldEqual, "evg-ubuntu-1")
+			So(fake.defined.Metadata.Instance.Distro, ShouldEqual, "ubuntu")
+			So(fake.defined.Metadata.Instance.Mode, ShouldEqual, "production")
+		})
+
+		Convey("a missing base volume should fail before anything is defined", func() {
+			lvSettings.BaseVolume = "missing.qcow2"
+			err := mgr.createDomain(h, lvSettings)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "missing.qcow2")
+			So(fake.commands, ShouldResemble, []string{"vol-create-as"})
+		})
+
+		Convey("partly created domains should be cleaned up", func() {
+			fake.failOn = "start"
+			So(mgr.createDomain(h, lvSettings), ShouldNotBeNil)
+			fake.failOn = ""
+			So(mgr.deleteDomain(h.Id, lvSettings), ShouldBeNil)
+			So(fake.domains, ShouldBeEmpty)
+			So(len(fake.volumes), ShouldEqual, 1)
+
+			// deleting again should find nothing left to delete
+			So(mgr.deleteDomain(h.Id, lvSettings), ShouldBeNil)
+		})
+
+		Convey("running domains should be initializing until they have an address", func() {
+			So(mgr.createDomain(h, lvSettings), ShouldBeNil)
+			status, err := mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusInitializing)
+
+			fake.addresses["evg-ubuntu-1"] = "192.168.122.45"
+			up, err := mgr.IsUp(h)
+			So(err, ShouldBeNil)
+			So(up, ShouldBeTrue)
+			dns, err := mgr.GetDNSName(h)
+			So(err, ShouldBeNil)
+			So(dns, ShouldEqual, "192.168.122.45")
+
+			fake.domains["evg-ubuntu-1"] = DomainStateCrashed
+			status, err = mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusFailed)
+
+			delete(fake.domains, "evg-ubuntu-1")
+			status, err = mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusTerminated)
+		})
+
+		Convey("connection errors should not be mistaken for missing domains", func() {
+			h.Distro.ProviderSettings = &map[string]interface{}{"uri": "qemu:///elsewhere"}
+			_, err := mgr.GetInstanceStatus(h)
+			So(err, ShouldNotBeNil)
+			So(strings.Contains(err.Error(), "failed to connect"), ShouldBeTrue)
+		})
+
+		Convey("distro settings should be validated", func() {
+			So(lvSettings.Validate(), ShouldBeNil)
+			for _, modify := range []func(*Settings){
+				func(s *Settings) { s.URI = "" },
+				func(s *Settings) { s.BaseVolume = "" },
+				func(s *Settings) { s.DiskSizeGB = 0 },
+				func(s *Settings) { s.MemoryMB = -1 },
+				func(s *Settings) { s.VCPUs = 0 },
+				func(s *Settings) { s.AddressSource = "dns" },
+			} {
+				invalid := *lvSettings
+				modify(&invalid)
+				So(invalid.Validate(), ShouldNotBeNil)
+			}
+		})
+	})
+}
@@ -0,0 +1,188 @@
+package libvirt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// libvirt domain states, as printed by virsh domstate
+const (
+	DomainStateRunning     = "running"
+	DomainStateBlocked     = "idle"
+	DomainStatePaused      = "paused"
+	DomainStateShutdown    = "in shutdown"
+	DomainStateShutoff     = "shut off"
+	DomainStateCrashed     = "crashed"
+	DomainStatePMSuspended = "pmsuspended"
+
+	virshBinary = "virsh"
+)
+
+// virshRunner runs virsh commands against a libvirt connection and returns
+// their output.
+type virshRunner interface {
+	run(uri string, args ...string) (string, error)
+}
+
+// execVirsh runs the virsh binary.
+type execVirsh struct{}
+
+func (_ execVirsh) run(uri string, args ...string) (string, error) {
+	cmd := exec.Command(virshBinary, append([]string{"--connect", uri, "--quiet"}, args...)...)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("virsh %v: %v", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// isNotFound returns whether a virsh command failed because the domain or
+// volume it refers to doesn't exist.
+func isNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "failed to get")
+}
+
+// domain is the libvirt XML definition of a VM.
+type domain struct {
+	XMLName  xml.Name       `xml:"domain"`
+	Type     string         `xml:"type,attr"`
+	Name     string         `xml:"name"`
+	Metadata domainMetadata `xml:"metadata"`
+	Memory   domainMemory   `xml:"memory"`
+	VCPUs    int            `xml:"vcpu"`
+	OS       domainOS       `xml:"os"`
+	Features domainFeatures `xml:"features"`
+	CPU      domainCPU      `xml:"cpu"`
+	Devices  domainDevices  `xml:"devices"`
+}
+
+type domainMetadata struct {
+	Instance instanceMetadata `xml:"instance"`
+}
+
+// instanceMetadata ties a domain back to its host. libvirt requires custom
+// metadata to have its own namespace.
+type instanceMetadata struct {
+	XMLName xml.Name `xml:"https://github.com/evergreen-ci/evergreen/libvirt instance"`
+	HostId  string   `xml:"host-id"`
+	Distro  string   `xml:"distro"`
+	Owner   string   `xml:"owner"`
+	Mode    string   `xml:"mode"`
+}
+
+type domainMemory struct {
+	Unit  string `xml:"unit,attr"`
+	Value int    `xml:",chardata"`
+}
+
+type domainOS struct {
+	Type string `xml:"type"`
+	Boot struct {
+		Dev string `xml:"dev,attr"`
+	} `xml:"boot"`
+}
+
+type domainFeatures struct {
+	ACPI struct{} `xml:"acpi"`
+	APIC struct{} `xml:"apic"`
+}
+
+type domainCPU struct {
+	Mode string `xml:"mode,attr"`
+}
+
+type domainDevices struct {
+	Disk struct {
+		Type   string `xml:"type,attr"`
+		Device string `xml:"device,attr"`
+		Driver struct {
+			Name string `xml:"name,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"driver"`
+		Source struct {
+			File string `xml:"file,attr"`
+		} `xml:"source"`
+		Target struct {
+			Dev string `xml:"dev,attr"`
+			Bus string `xml:"bus,attr"`
+		} `xml:"target"`
+	} `xml:"disk"`
+	Interface struct {
+		Type   string `xml:"type,attr"`
+		Source struct {
+			Network string `xml:"network,attr"`
+		} `xml:"source"`
+		Model struct {
+			Type string `xml:"type,attr"`
+		} `xml:"model"`
+	} `xml:"interface"`
+	// the guest agent channel lets addresses be read from inside the VM
+	Channel struct {
+		Type   string `xml:"type,attr"`
+		Target struct {
+			Type string `xml:"type,attr"`
+			Name string `xml:"name,attr"`
+		} `xml:"target"`
+	} `xml:"channel"`
+	Serial struct {
+		Type string `xml:"type,attr"`
+	} `xml:"serial"`
+	Console struct {
+		Type string `xml:"type,attr"`
+	} `xml:"console"`
+}
+
+// defineDomain defines a domain from its XML. virsh reads the definition
+// from a file on the machine it runs on, even for remote connections.
+func defineDomain(virsh virshRunner, uri string, d *domain) error {
+	definition, err := xml.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error creating domain definition: %v", err)
+	}
+	file, err := ioutil.TempFile("", "evg-domain-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(definition)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing domain definition: %v", err)
+	}
+	_, err = virsh.run(uri, "define", file.Name())
+	return err
+}
+
+// parseDomainAddress returns the first non-loopback IPv4 address in the
+// output of virsh domifaddr, or the empty string if there isn't one.
+func parseDomainAddress(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		// each line is: name, MAC address, protocol, address/prefix
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[len(fields)-2] != "ipv4" {
+			continue
+		}
+		addr := strings.Split(fields[len(fields)-1], "/")[0]
+		if !strings.HasPrefix(addr, "127.") {
+			return addr
+		}
+	}
+	return ""
+}
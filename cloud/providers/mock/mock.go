@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
@@ -73,3 +74,19 @@ func (staticMgr *MockCloudManager) GetSSHOptions(host *host.Host, keyPath string
 func (staticMgr *MockCloudManager) TimeTilNextPayment(host *host.Host) time.Duration {
 	return time.Duration(0)
 }
+
+// stop an instance
+func (staticMgr *MockCloudManager) StopInstance(host *host.Host) error {
+	if err := host.ClearRunningTask(); err != nil {
+		return err
+	}
+	return host.SetStopped()
+}
+
+// start a stopped instance
+func (staticMgr *MockCloudManager) StartInstance(host *host.Host) error {
+	if host.Status != evergreen.HostStopped {
+		return fmt.Errorf("Can not start %v - it is %v, not stopped", host.Id, host.Status)
+	}
+	return host.SetRestarted()
+}
@@ -0,0 +1,290 @@
+package openstack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+// Nova server statuses, see
+// http://developer.openstack.org/api-guide/compute/server_concepts.html
+const (
+	NovaStatusBuild       = "BUILD"
+	NovaStatusActive      = "ACTIVE"
+	NovaStatusReboot      = "REBOOT"
+	NovaStatusHardReboot  = "HARD_REBOOT"
+	NovaStatusShutoff     = "SHUTOFF"
+	NovaStatusSuspended   = "SUSPENDED"
+	NovaStatusPaused      = "PAUSED"
+	NovaStatusError       = "ERROR"
+	NovaStatusDeleted     = "DELETED"
+	NovaStatusSoftDeleted = "SOFT_DELETED"
+
+	// tokens are renewed this long before they expire
+	tokenExpiryWindow = time.Minute
+)
+
+// errServerNotFound is returned when Nova doesn't know about a server.
+var errServerNotFound = fmt.Errorf("server not found")
+
+// novaClient makes requests to the Nova compute API, authenticating with
+// Keystone's v3 password authentication.
+type novaClient struct {
+	config     evergreen.OpenStackConfig
+	httpClient *http.Client
+
+	// mu guards the token and compute endpoint, which are set by authenticate
+	mu          sync.Mutex
+	token       string
+	tokenExpiry time.Time
+	computeURL  string
+}
+
+// server is the part of a Nova server that Evergreen uses.
+type server struct {
+	Id         string                     `json:"id"`
+	Name       string                     `json:"name"`
+	Status     string                     `json:"status"`
+	AccessIPv4 string                     `json:"accessIPv4"`
+	Addresses  map[string][]serverAddress `json:"addresses"`
+	Metadata   map[string]string          `json:"metadata"`
+}
+
+type serverAddress struct {
+	Addr    string `json:"addr"`
+	Version int    `json:"version"`
+	Type    string `json:"OS-EXT-IPS:type"`
+}
+
+// createServerRequest is the request body for booting a server.
+type createServerRequest struct {
+	Name           string             `json:"name"`
+	ImageRef       string             `json:"imageRef"`
+	FlavorRef      string             `json:"flavorRef"`
+	KeyName        string             `json:"key_name,omitempty"`
+	SecurityGroups []securityGroupRef `json:"security_groups,omitempty"`
+	Networks       []networkRef       `json:"networks,omitempty"`
+	Metadata       map[string]string  `json:"metadata,omitempty"`
+}
+
+type securityGroupRef struct {
+	Name string `json:"name"`
+}
+
+type networkRef struct {
+	UUID string `json:"uuid"`
+}
+
+var (
+	// clients are shared by the managers with the same settings, so they
+	// share a token instead of each authenticating
+	clientsMu sync.Mutex
+	clients   = map[evergreen.OpenStackConfig]*novaClient{}
+)
+
+func newNovaClient(config evergreen.OpenStackConfig) *novaClient {
+	return &novaClient{
+		config:     config,
+		httpClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// getNovaClient returns the client for the settings, creating it the first
+// time they are used.
+func getNovaClient(config evergreen.OpenStackConfig) *novaClient {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	client, ok := clients[config]
+	if !ok {
+		client = newNovaClient(config)
+		clients[config] = client
+	}
+	return client
+}
+
+// authenticate gets a new token from Keystone, along with the URL of the
+// compute service from its catalog.
+func (c *novaClient) authenticate() error {
+	domain := map[string]string{"name": c.config.DomainName}
+	body := map[string]interface{}{
+		"auth": map[string]interface{}{
+			"identity": map[string]interface{}{
+				"methods": []string{"password"},
+				"password": map[string]interface{}{
+					"user": map[string]interface{}{
+						"name":     c.config.Username,
+						"password": c.config.Password,
+						"domain":   domain,
+					},
+				},
+			},
+			"scope": map[string]interface{}{
+				"project": map[string]interface{}{
+					"name":   c.config.ProjectName,
+					"domain": domain,
+				},
+			},
+		},
+	}
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(c.config.IdentityEndpoint, "/") + "/auth/tokens"
+	resp, err := c.httpClient.Post(url, "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return fmt.Errorf("error authenticating with keystone: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("error authenticating with keystone: %v", util.ResponseError(resp))
+	}
+
+	tokenResp := struct {
+		Token struct {
+			ExpiresAt time.Time `json:"expires_at"`
+			Catalog   []struct {
+				Type      string `json:"type"`
+				Endpoints []struct {
+					Interface string `json:"interface"`
+					Region    string `json:"region"`
+					URL       string `json:"url"`
+				} `json:"endpoints"`
+			} `json:"catalog"`
+		} `json:"token"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return fmt.Errorf("error reading keystone token: %v", err)
+	}
+
+	computeURL := ""
+	for _, service := range tokenResp.Token.Catalog {
+		if service.Type != "compute" {
+			continue
+		}
+		for _, endpoint := range service.Endpoints {
+			if endpoint.Interface == "public" && (c.config.Region == "" || endpoint.Region == c.config.Region) {
+				computeURL = endpoint.URL
+				break
+			}
+		}
+	}
+	if computeURL == "" {
+		return fmt.Errorf("no public compute endpoint in region '%v' in the keystone catalog", c.config.Region)
+	}
+
+	c.token = resp.Header.Get("X-Subject-Token")
+	c.tokenExpiry = tokenResp.Token.ExpiresAt
+	c.computeURL = strings.TrimSuffix(computeURL, "/")
+	return nil
+}
+
+// getToken returns the token and the compute endpoint, authenticating first if
+// the token is missing or about to expire.
+func (c *novaClient) getToken() (string, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" || time.Now().Add(tokenExpiryWindow).After(c.tokenExpiry) {
+		if err := c.authenticate(); err != nil {
+			return "", "", err
+		}
+	}
+	return c.token, c.computeURL, nil
+}
+
+// expireToken forgets a token that Nova rejected, unless another request has
+// already replaced it.
+func (c *novaClient) expireToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// do makes a request to the compute API, authenticating first if the token is
+// missing or about to expire, and once more if Nova rejects it.
+func (c *novaClient) do(method, path string, body, result interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, computeURL, err := c.getToken()
+		if err != nil {
+			return err
+		}
+
+		var bodyReader io.Reader
+		if reqBody != nil {
+			bodyReader = bytes.NewReader(reqBody)
+		}
+		req, err := http.NewRequest(method, computeURL+path, bodyReader)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Auth-Token", token)
+		req.Header.Set("Accept", "application/json")
+		if reqBody != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			c.expireToken(token)
+			continue
+		case resp.StatusCode == http.StatusNotFound:
+			return errServerNotFound
+		case resp.StatusCode >= 300:
+			return util.ResponseError(resp)
+		}
+		if result == nil {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	}
+}
+
+// createServer boots a server and returns its id.
+func (c *novaClient) createServer(opts createServerRequest) (string, error) {
+	resp := struct {
+		Server server `json:"server"`
+	}{}
+	if err := c.do("POST", "/servers", map[string]interface{}{"server": opts}, &resp); err != nil {
+		return "", fmt.Errorf("error creating server: %v", err)
+	}
+	return resp.Server.Id, nil
+}
+
+// getServer returns the server with the id.
+func (c *novaClient) getServer(id string) (*server, error) {
+	resp := struct {
+		Server server `json:"server"`
+	}{}
+	if err := c.do("GET", "/servers/"+id, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Server, nil
+}
+
+// deleteServer deletes the server with the id.
+func (c *novaClient) deleteServer(id string) error {
+	return c.do("DELETE", "/servers/"+id, nil, nil)
+}
@@ -0,0 +1,352 @@
+package openstack
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"github.com/evergreen-ci/evergreen/hostutil"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	ProviderName = "openstack"
+
+	// NameTimeFormat is the format in which to log times like instance start time.
+	NameTimeFormat = "20060102150405"
+)
+
+// OpenStackManager implements the CloudManager interface for Nova instances
+// on an OpenStack cloud.
+type OpenStackManager struct {
+	client *novaClient
+}
+
+// Settings are the distro's settings for the instances it boots.
+type Settings struct {
+	// ImageId and FlavorId are the ids of the image and flavor to boot
+	ImageId  string `mapstructure:"image_id" json:"image_id" bson:"image_id"`
+	FlavorId string `mapstructure:"flavor_id" json:"flavor_id" bson:"flavor_id"`
+
+	// NetworkId is the id of the network to attach instances to. It can be
+	// left blank if the project has only one network.
+	NetworkId string `mapstructure:"network_id" json:"network_id,omitempty" bson:"network_id,omitempty"`
+
+	// SecurityGroups are the names of the security groups to add instances to
+	SecurityGroups []string `mapstructure:"security_groups" json:"security_groups,omitempty" bson:"security_groups,omitempty"`
+
+	// KeyName is the name of the keypair whose public key is added to instances
+	KeyName string `mapstructure:"key_name" json:"key_name" bson:"key_name"`
+}
+
+var (
+	// bson fields for the Settings struct
+	ImageIdKey        = bsonutil.MustHaveTag(Settings{}, "ImageId")
+	FlavorIdKey       = bsonutil.MustHaveTag(Settings{}, "FlavorId")
+	NetworkIdKey      = bsonutil.MustHaveTag(Settings{}, "NetworkId")
+	SecurityGroupsKey = bsonutil.MustHaveTag(Settings{}, "SecurityGroups")
+	KeyNameKey        = bsonutil.MustHaveTag(Settings{}, "KeyName")
+)
+
+// Validate checks that the settings from the config file are sane.
+func (self *Settings) Validate() error {
+	if self.ImageId == "" {
+		return fmt.Errorf("Image ID must not be blank")
+	}
+
+	if self.FlavorId == "" {
+		return fmt.Errorf("Flavor ID must not be blank")
+	}
+
+	if self.KeyName == "" {
+		return fmt.Errorf("Key name must not be blank")
+	}
+
+	for _, group := range self.SecurityGroups {
+		if group == "" {
+			return fmt.Errorf("Security group names must not be blank")
+		}
+	}
+
+	return nil
+}
+
+func (_ *OpenStackManager) GetSettings() cloud.ProviderSettings {
+	return &Settings{}
+}
+
+// Configure populates an OpenStackManager by reading relevant settings from the
+// config object. Managers with the same settings share a client, and Keystone
+// isn't contacted until the first API call.
+func (osMgr *OpenStackManager) Configure(settings *evergreen.Settings) error {
+	config := settings.Providers.OpenStack
+	if config.IdentityEndpoint == "" || config.Username == "" || config.Password == "" {
+		return fmt.Errorf("OpenStack identity endpoint, username and password must not be blank")
+	}
+	if config.ProjectName == "" {
+		return fmt.Errorf("OpenStack project name must not be blank")
+	}
+	if config.DomainName == "" {
+		config.DomainName = "Default"
+	}
+	osMgr.client = getNovaClient(config)
+	return nil
+}
+
+// generateName creates a temporary instance name for a host
+func generateName(distroId string) string {
+	return "evg-" + distroId + "-" + time.Now().Format(NameTimeFormat) +
+		fmt.Sprintf("-%v", rand.New(rand.NewSource(time.Now().UnixNano())).Int())
+}
+
+// SpawnInstance boots a new Nova instance for the given distro.
+func (osMgr *OpenStackManager) SpawnInstance(d *distro.Distro, owner string, userHost bool) (*host.Host, error) {
+	if d.Provider != ProviderName {
+		return nil, fmt.Errorf("Can't spawn instance of %v for distro %v: provider is %v", ProviderName, d.Id, d.Provider)
+	}
+
+	osSettings := &Settings{}
+	if err := mapstructure.Decode(d.ProviderSettings, osSettings); err != nil {
+		return nil, fmt.Errorf("Error decoding params for distro %v: %v", d.Id, err)
+	}
+
+	if err := osSettings.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid OpenStack settings in distro %v: %v", d.Id, err)
+	}
+
+	instanceName := generateName(d.Id)
+	intentHost := &host.Host{
+		Id:               instanceName,
+		User:             d.User,
+		Distro:           *d,
+		Tag:              instanceName,
+		CreationTime:     time.Now(),
+		Status:           evergreen.HostUninitialized,
+		TerminationTime:  util.ZeroTime,
+		TaskDispatchTime: util.ZeroTime,
+		Provider:         ProviderName,
+		InstanceType:     osSettings.FlavorId,
+		StartedBy:        owner,
+		UserHost:         userHost,
+	}
+
+	if err := intentHost.Insert(); err != nil {
+		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not insert intent "+
+			"host '%v': %v", intentHost.Id, err)
+	}
+
+	evergreen.Logger.Logf(slogger.DEBUG, "Successfully inserted intent host '%v' "+
+		"for distro '%v' to signal cloud instance spawn intent", instanceName,
+		d.Id)
+
+	serverId, err := osMgr.client.createServer(makeServerRequest(intentHost, osSettings))
+	if err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "OpenStack create server API call failed"+
+			" for intent host '%v': %v", intentHost.Id, err)
+
+		// remove the intent host document
+		if rmErr := intentHost.Remove(); rmErr != nil {
+			return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not remove intent host "+
+				"'%v': %v", intentHost.Id, rmErr)
+		}
+		return nil, &cloud.ProviderError{Err: err}
+	}
+
+	// find old intent host
+	host, err := host.FindOne(host.ById(intentHost.Id))
+	if host == nil {
+		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Can't locate "+
+			"record inserted for intended host '%v'", intentHost.Id)
+	}
+	if err != nil {
+		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Failed to look up intent host %v: %v",
+			intentHost.Id, err)
+	}
+
+	host.Id = serverId
+	if err = host.Insert(); err != nil {
+		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Failed to insert new host %v "+
+			"for intent host %v: %v", host.Id, intentHost.Id, err)
+	}
+
+	// remove the intent host document
+	if err = intentHost.Remove(); err != nil {
+		return nil, evergreen.Logger.Errorf(slogger.ERROR, "Could not remove "+
+			"insert host '%v' (replaced by '%v'): %v", intentHost.Id, host.Id,
+			err)
+	}
+	return host, nil
+}
+
+// makeServerRequest returns the request for booting the intent host's
+// instance. The instance's metadata ties it back to the host.
+func makeServerRequest(intentHost *host.Host, osSettings *Settings) createServerRequest {
+	req := createServerRequest{
+		Name:      intentHost.Id,
+		ImageRef:  osSettings.ImageId,
+		FlavorRef: osSettings.FlavorId,
+		KeyName:   osSettings.KeyName,
+		Metadata: map[string]string{
+			"evergreen-host": intentHost.Id,
+			"distro":         intentHost.Distro.Id,
+			"owner":          intentHost.StartedBy,
+			"mode":           "production",
+			"start-time":     intentHost.CreationTime.Format(NameTimeFormat),
+		},
+	}
+	if intentHost.UserHost {
+		req.Metadata["mode"] = "testing"
+	}
+	if osSettings.NetworkId != "" {
+		req.Networks = []networkRef{{UUID: osSettings.NetworkId}}
+	}
+	for _, group := range osSettings.SecurityGroups {
+		req.SecurityGroups = append(req.SecurityGroups, securityGroupRef{Name: group})
+	}
+	return req
+}
+
+// novaStatusToEvergreenStatus returns a "universal" status code based on
+// Nova's server statuses.
+func novaStatusToEvergreenStatus(novaStatus string) cloud.CloudStatus {
+	switch novaStatus {
+	case NovaStatusBuild, NovaStatusReboot, NovaStatusHardReboot:
+		return cloud.StatusInitializing
+	case NovaStatusActive:
+		return cloud.StatusRunning
+	case NovaStatusShutoff, NovaStatusSuspended, NovaStatusPaused:
+		return cloud.StatusStopped
+	case NovaStatusError:
+		return cloud.StatusFailed
+	case NovaStatusDeleted, NovaStatusSoftDeleted:
+		return cloud.StatusTerminated
+	default:
+		return cloud.StatusUnknown
+	}
+}
+
+// GetInstanceStatus returns a universal status code representing the state
+// of an instance. Instances that Nova no longer knows about are terminated.
+func (osMgr *OpenStackManager) GetInstanceStatus(host *host.Host) (cloud.CloudStatus, error) {
+	server, err := osMgr.client.getServer(host.Id)
+	if err == errServerNotFound {
+		return cloud.StatusTerminated, nil
+	}
+	if err != nil {
+		return cloud.StatusUnknown, fmt.Errorf("Failed to get server info for '%v': %v", host.Id, err)
+	}
+	return novaStatusToEvergreenStatus(server.Status), nil
+}
+
+// GetDNSName returns the address to reach an instance at. A floating IP is
+// preferred over the instance's fixed IPs, and IPv4 over IPv6.
+func (osMgr *OpenStackManager) GetDNSName(host *host.Host) (string, error) {
+	server, err := osMgr.client.getServer(host.Id)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get server info for '%v': %v", host.Id, err)
+	}
+	return bestAddress(server), nil
+}
+
+// bestAddress returns the best address to reach the server at, or the empty
+// string if it doesn't have one yet.
+func bestAddress(s *server) string {
+	if s.AccessIPv4 != "" {
+		return s.AccessIPv4
+	}
+	best, bestRank := "", -1
+	for _, addresses := range s.Addresses {
+		for _, addr := range addresses {
+			rank := 0
+			if addr.Version == 4 {
+				rank++
+			}
+			if addr.Type == "floating" {
+				rank += 2
+			}
+			if rank > bestRank {
+				best, bestRank = addr.Addr, rank
+			}
+		}
+	}
+	return best
+}
+
+// CanSpawn returns if a given cloud provider supports spawning a new host
+// dynamically. Always returns true for OpenStack.
+func (osMgr *OpenStackManager) CanSpawn() (bool, error) {
+	return true, nil
+}
+
+// TerminateInstance deletes an instance. Instances that Nova no longer knows
+// about are already gone.
+func (osMgr *OpenStackManager) TerminateInstance(host *host.Host) error {
+	if host.Status == evergreen.HostTerminated {
+		errMsg := fmt.Errorf("Can not terminate %v - already marked as "+
+			"terminated!", host.Id)
+		evergreen.Logger.Errorf(slogger.ERROR, errMsg.Error())
+		return errMsg
+	}
+
+	err := osMgr.client.deleteServer(host.Id)
+	if err != nil && err != errServerNotFound {
+		return evergreen.Logger.Errorf(slogger.ERROR, "Failed to delete server '%v': %v", host.Id, err)
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Terminated %v", host.Id)
+
+	return host.Terminate()
+}
+
+// IsSSHReachable checks if an instance appears to be reachable via SSH by
+// attempting to contact the host directly.
+func (osMgr *OpenStackManager) IsSSHReachable(host *host.Host, keyPath string) (bool, error) {
+	sshOpts, err := osMgr.GetSSHOptions(host, keyPath)
+	if err != nil {
+		return false, err
+	}
+	return hostutil.CheckSSHResponse(host, sshOpts)
+}
+
+// IsUp checks the instance's state by querying the Nova API and
+// returns true if the host should be available to connect with SSH.
+func (osMgr *OpenStackManager) IsUp(host *host.Host) (bool, error) {
+	cloudStatus, err := osMgr.GetInstanceStatus(host)
+	if err != nil {
+		return false, err
+	}
+	if cloudStatus == cloud.StatusRunning {
+		return true, nil
+	}
+	return false, nil
+}
+
+func (osMgr *OpenStackManager) OnUp(host *host.Host) error {
+	//Not currently needed since the metadata is set when the instance boots
+	return nil
+}
+
+// GetSSHOptions returns an array of default SSH options for connecting to an
+// instance.
+func (osMgr *OpenStackManager) GetSSHOptions(host *host.Host, keyPath string) ([]string, error) {
+	if keyPath == "" {
+		return []string{}, fmt.Errorf("No key specified for OpenStack host")
+	}
+	opts := []string{"-i", keyPath}
+	for _, opt := range host.Distro.SSHOptions {
+		opts = append(opts, "-o", opt)
+	}
+	return opts, nil
+}
+
+// TimeTilNextPayment returns the amount of time until the next payment is due
+// for the host. For a private OpenStack cloud this is not relevant.
+func (osMgr *OpenStackManager) TimeTilNextPayment(host *host.Host) time.Duration {
+	return time.Duration(0)
+}
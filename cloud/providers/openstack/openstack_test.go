@@ -0,0 +1,255 @@
+package openstack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/cloudtest"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeOpenStack is a Keystone and Nova API that keeps servers in memory.
+type fakeOpenStack struct {
+	*cloudtest.FakeAPI
+	mu          sync.Mutex
+	servers     map[string]*server
+	lastRequest createServerRequest
+}
+
+func newFakeOpenStack() *fakeOpenStack {
+	f := &fakeOpenStack{FakeAPI: cloudtest.NewFakeAPI(), servers: map[string]*server{}}
+	f.Mux.HandleFunc("/identity/v3/auth/tokens", f.handleAuth)
+	f.Mux.HandleFunc("/compute/v2.1/servers", f.handleCreate)
+	f.Mux.HandleFunc("/compute/v2.1/servers/", f.handleServer)
+	return f
+}
+
+func (f *fakeOpenStack) config() evergreen.OpenStackConfig {
+	return evergreen.OpenStackConfig{
+		IdentityEndpoint: f.URL + "/identity/v3",
+		Username:         "evergreen",
+		Password:         "secret",
+		ProjectName:      "ci",
+		DomainName:       "Default",
+		Region:           "RegionOne",
+	}
+}
+
+func (f *fakeOpenStack) handleAuth(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		Auth struct {
+			Identity struct {
+				Password struct {
+					User struct {
+						Name     string `json:"name"`
+						Password string `json:"password"`
+					} `json:"user"`
+				} `json:"password"`
+			} `json:"identity"`
+		} `json:"auth"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Auth.Identity.Password.User.Password != "secret" {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("X-Subject-Token", f.IssueToken())
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"token": {"expires_at": %q, "catalog": [
+		{"type": "identity", "endpoints": [{"interface": "public", "region": "RegionOne", "url": %q}]},
+		{"type": "compute", "endpoints": [
+			{"interface": "internal", "region": "RegionOne", "url": "http://internal"},
+			{"interface": "public", "region": "RegionTwo", "url": "http://elsewhere"},
+			{"interface": "public", "region": "RegionOne", "url": %q}
+		]}
+	]}}`, time.Now().Add(time.Hour).Format(time.RFC3339), f.URL+"/identity/v3", f.URL+"/compute/v2.1/")
+}
+
+func (f *fakeOpenStack) authorized(w http.ResponseWriter, r *http.Request) bool {
+	return f.Authorized(w, r.Header.Get("X-Auth-Token"))
+}
+
+func (f *fakeOpenStack) handleCreate(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	body := struct {
+		Server createServerRequest `json:"server"`
+	}{}
+	if !cloudtest.DecodeJSON(w, r, "POST", &body) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastRequest = body.Server
+	id := fmt.Sprintf("server-%v", len(f.servers)+1)
+	f.servers[id] = &server{Id: id, Name: body.Server.Name, Status: NovaStatusBuild, Metadata: body.Server.Metadata}
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, `{"server": {"id": %q}}`, id)
+}
+
+func (f *fakeOpenStack) handleServer(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(w, r) {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := strings.TrimPrefix(r.URL.Path, "/compute/v2.1/servers/")
+	s, ok := f.servers[id]
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case "GET":
+		json.NewEncoder(w).Encode(map[string]interface{}{"server": s})
+	case "DELETE":
+		delete(f.servers, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestNovaClient(t *testing.T) {
+	Convey("With a fake OpenStack cloud", t, func() {
+		fake := newFakeOpenStack()
+		defer fake.Close()
+		client := newNovaClient(fake.config())
+
+		Convey("servers should be created, inspected and deleted", func() {
+			id, err := client.createServer(makeServerRequest(&host.Host{
+				Id:        "evg-ubuntu-1",
+				Distro:    distro.Distro{Id: "ubuntu"},
+				StartedBy: "mci",
+			}, &Settings{
+				ImageId:        "image-1",
+				FlavorId:       "m1.large",
+				NetworkId:      "net-1",
+				SecurityGroups: []string{"default", "ssh"},
+				KeyName:        "evergreen",
+			}))
+			So(err, ShouldBeNil)
+			So(id, ShouldEqual, "server-1")
+			So(fake.lastRequest.ImageRef, ShouldEqual, "image-1")
+			So(fake.lastRequest.FlavorRef, ShouldEqual, "m1.large")
+			So(fake.lastRequest.KeyName, ShouldEqual, "evergreen")
+			So(fake.lastRequest.Networks, ShouldResemble, []networkRef{{UUID: "net-1"}})
+			So(fake.lastRequest.SecurityGroups, ShouldResemble, []securityGroupRef{{Name: "default"}, {Name: "ssh"}})
+			So(fake.lastRequest.Metadata["evergreen-host"], ShouldEqual, "evg-ubuntu-1")
+			So(fake.lastRequest.Metadata["distro"], ShouldEqual, "ubuntu")
+
+			s, err := client.getServer(id)
+			So(err, ShouldBeNil)
+			So(s.Status, ShouldEqual, NovaStatusBuild)
+
+			So(client.deleteServer(id), ShouldBeNil)
+			_, err = client.getServer(id)
+			So(err, ShouldEqual, errServerNotFound)
+		})
+
+		Convey("the client should authenticate again when its token is rejected", func() {
+			_, err := client.createServer(createServerRequest{Name: "a"})
+			So(err, ShouldBeNil)
+			So(fake.TokensIssued(), ShouldEqual, 1)
+			fake.RevokeToken()
+			_, err = client.createServer(createServerRequest{Name: "b"})
+			So(err, ShouldBeNil)
+			So(fake.TokensIssued(), ShouldEqual, 2)
+		})
+
+		Convey("bad credentials should be an error", func() {
+			config := fake.config()
+			config.Password = "wrong"
+			_, err := newNovaClient(config).getServer("server-1")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "keystone")
+		})
+	})
+}
+
+func TestOpenStackManager(t *testing.T) {
+	Convey("With an OpenStack manager for a fake cloud", t, func() {
+		fake := newFakeOpenStack()
+		defer fake.Close()
+		settings := &evergreen.Settings{}
+		settings.Providers.OpenStack = fake.config()
+		mgr := &OpenStackManager{}
+		So(mgr.Configure(settings), ShouldBeNil)
+
+		fake.servers["s1"] = &server{Id: "s1", Status: NovaStatusBuild}
+		h := &host.Host{Id: "s1"}
+
+		Convey("instance statuses should be translated", func() {
+			status, err := mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusInitializing)
+
+			fake.servers["s1"].Status = NovaStatusActive
+			up, err := mgr.IsUp(h)
+			So(err, ShouldBeNil)
+			So(up, ShouldBeTrue)
+
+			fake.servers["s1"].Status = NovaStatusError
+			status, err = mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusFailed)
+
+			status, err = mgr.GetInstanceStatus(&host.Host{Id: "gone"})
+			So(err, ShouldBeNil)
+			So(status, ShouldEqual, cloud.StatusTerminated)
+		})
+
+		Convey("floating IPv4 addresses should be preferred", func() {
+			fake.servers["s1"].Addresses = map[string][]serverAddress{
+				"private": {
+					{Addr: "fd00::5", Version: 6, Type: "fixed"},
+					{Addr: "10.0.0.5", Version: 4, Type: "fixed"},
+				},
+			}
+			dns, err := mgr.GetDNSName(h)
+			So(err, ShouldBeNil)
+			So(dns, ShouldEqual, "10.0.0.5")
+
+			fake.servers["s1"].Addresses["public"] = []serverAddress{{Addr: "203.0.113.5", Version: 4, Type: "floating"}}
+			dns, err = mgr.GetDNSName(h)
+			So(err, ShouldBeNil)
+			So(dns, ShouldEqual, "203.0.113.5")
+		})
+
+		Convey("managers with the same settings should share a client and its token", func() {
+			other := &OpenStackManager{}
+			So(other.Configure(settings), ShouldBeNil)
+			So(other.client, ShouldEqual, mgr.client)
+			_, err := mgr.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			_, err = other.GetInstanceStatus(h)
+			So(err, ShouldBeNil)
+			So(fake.TokensIssued(), ShouldEqual, 1)
+		})
+
+		Convey("the manager should not be configured without credentials", func() {
+			settings.Providers.OpenStack.Password = ""
+			So((&OpenStackManager{}).Configure(settings), ShouldNotBeNil)
+		})
+
+		Convey("distro settings should be validated", func() {
+			valid := Settings{ImageId: "i", FlavorId: "f", KeyName: "k"}
+			So(valid.Validate(), ShouldBeNil)
+			for _, s := range []Settings{
+				{FlavorId: "f", KeyName: "k"},
+				{ImageId: "i", KeyName: "k"},
+				{ImageId: "i", FlavorId: "f"},
+				{ImageId: "i", FlavorId: "f", KeyName: "k", SecurityGroups: []string{""}},
+			} {
+				So(s.Validate(), ShouldNotBeNil)
+			}
+		})
+	})
+}
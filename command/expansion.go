@@ -23,6 +23,12 @@ func NewExpansions(initMap map[string]string) *Expansions {
 	return &exp
 }
 
+// Copy returns a new Expansions object with the same expansions, which can be
+// updated without changing these.
+func (self *Expansions) Copy() *Expansions {
+	return NewExpansions(*self)
+}
+
 // Update all of the specified keys in the expansions to point to the specified
 // values.
 func (self *Expansions) Update(newItems map[string]string) {
@@ -153,6 +153,9 @@ type TaskRunnerConfig struct {
 type CloudProviders struct {
 	AWS          AWSConfig          `yaml:"aws"`
 	DigitalOcean DigitalOceanConfig `yaml:"digitalocean"`
+	OpenStack    OpenStackConfig    `yaml:"openstack"`
+	GCE          GCEConfig          `yaml:"gce"`
+	Kubernetes   KubernetesConfig   `yaml:"kubernetes"`
 }
 
 // AWSConfig stores auth info for Amazon Web Services.
@@ -167,6 +170,38 @@ type DigitalOceanConfig struct {
 	Key      string `yaml:"key"`
 }
 
+// OpenStackConfig stores auth info for an OpenStack cloud's Keystone identity
+// service, and the region whose compute service hosts are spawned in.
+type OpenStackConfig struct {
+	IdentityEndpoint string `yaml:"identity_endpoint"`
+	Username         string `yaml:"username"`
+	Password         string `yaml:"password"`
+	ProjectName      string `yaml:"project_name"`
+	DomainName       string `yaml:"domain_name"`
+	Region           string `yaml:"region"`
+}
+
+// GCEConfig stores the service account Evergreen uses to manage Google
+// Compute Engine instances. The endpoints only need to be set to use
+// something other than Google's public APIs.
+type GCEConfig struct {
+	ProjectId   string `yaml:"project_id"`
+	ClientEmail string `yaml:"client_email"`
+	PrivateKey  string `yaml:"private_key"`
+	Endpoint    string `yaml:"endpoint"`
+	TokenURL    string `yaml:"token_url"`
+}
+
+// KubernetesConfig stores the API server of the Kubernetes cluster Evergreen
+// runs pods in, and the service account token it authenticates with. CACert
+// is the PEM encoded certificate of the cluster's CA, if it isn't trusted by
+// the system.
+type KubernetesConfig struct {
+	APIServer string `yaml:"api_server"`
+	Token     string `yaml:"token"`
+	CACert    string `yaml:"ca_cert"`
+}
+
 // JiraConfig stores auth info for interacting with Atlassian Jira.
 type JiraConfig struct {
 	Host     string
@@ -174,6 +209,33 @@ type JiraConfig struct {
 	Password string
 }
 
+// ArtifactsConfig holds the settings for serving private task files.
+type ArtifactsConfig struct {
+	// LinkExpirationMinutes is how long signed links to private files are
+	// valid for. Defaults to 60.
+	LinkExpirationMinutes int `yaml:"link_expiration_minutes"`
+
+	// Buckets holds the credentials used to sign links to private files, by
+	// bucket name. Private files in other buckets can't be downloaded.
+	Buckets map[string]ArtifactBucketConfig `yaml:"buckets"`
+}
+
+// ArtifactBucketConfig holds the credentials and storage settings of a bucket
+// that private task files are stored in.
+type ArtifactBucketConfig struct {
+	AwsKey    string `yaml:"aws_key"`
+	AwsSecret string `yaml:"aws_secret"`
+
+	// Driver, Endpoint, Region, PathStyle and Root select the storage driver,
+	// as for the storage settings of commands. Files in buckets of the local
+	// driver are streamed by the server instead of linked to.
+	Driver    string `yaml:"driver"`
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	PathStyle bool   `yaml:"path_style"`
+	Root      string `yaml:"root"`
+}
+
 // PluginConfig holds plugin-specific settings, which are handled.
 // manually by their respective plugins
 type PluginConfig map[string]map[string]interface{}
@@ -181,6 +243,40 @@ type PluginConfig map[string]map[string]interface{}
 type AlertsConfig struct {
 	LogFile string
 	SMTP    *SMTPConfig `yaml:"smtp"`
+
+	// DigestWindowMinutes, when set, holds task alerts for the same recipient
+	// and version for this many minutes and delivers them as a single digest.
+	DigestWindowMinutes int `yaml:"digest_window_minutes"`
+
+	// RecipientHourlyLimit caps the number of alert e-mails sent to any one
+	// recipient per hour. Zero disables the limit.
+	RecipientHourlyLimit int `yaml:"recipient_hourly_limit"`
+
+	Ops OpsAlertsConfig `yaml:"ops"`
+}
+
+// OpsAlertsConfig holds the thresholds for infrastructure health alerts and the
+// channels they are delivered to. A threshold of zero disables its alert.
+type OpsAlertsConfig struct {
+	// Channels are the deliveries used for ops alerts. If empty, ops alerts are
+	// e-mailed to the superusers.
+	Channels []AlertChannel `yaml:"channels"`
+
+	// WindowMinutes is the period over which failures are counted, and the minimum
+	// time between repeated alerts for the same condition. Defaults to 60.
+	WindowMinutes int `yaml:"window_minutes"`
+
+	QueueWaitMinutes        int     `yaml:"queue_wait_minutes"`
+	ProvisionFailureRate    float64 `yaml:"provision_failure_rate"`
+	ProvisionMinHosts       int     `yaml:"provision_min_hosts"`
+	SpawnErrorCount         int     `yaml:"spawn_error_count"`
+	RepotrackerStuckMinutes int     `yaml:"repotracker_stuck_minutes"`
+}
+
+// AlertChannel describes a single alert delivery, such as an e-mail address.
+type AlertChannel struct {
+	Provider string                 `yaml:"provider"`
+	Settings map[string]interface{} `yaml:"settings"`
 }
 type WriteConcern struct {
 	W        int    `yaml:"w"`
@@ -215,6 +311,7 @@ type Settings struct {
 	Monitor             MonitorConfig     `yaml:"monitor"`
 	Api                 APIConfig         `yaml:"api"`
 	Alerts              AlertsConfig      `yaml:"alerts"`
+	Artifacts           ArtifactsConfig   `yaml:"artifacts"`
 	Ui                  UIConfig          `yaml:"ui"`
 	HostInit            HostInitConfig    `yaml:"hostinit"`
 	Notify              NotifyConfig      `yaml:"notify"`
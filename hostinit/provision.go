@@ -0,0 +1,290 @@
+package hostinit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/cloud/providers"
+	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/hostutil"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+const (
+	readyCheckScriptName = "ready_check.sh"
+
+	// files that sudo steps put outside the user's home directory are
+	// uploaded with this prefix, then installed by the install script
+	uploadPrefix      = "provision_upload_"
+	installScriptName = "provision_install.sh"
+
+	// the most output kept of each provisioning step
+	maxProvisionOutput = 64 * 1024 // 64KB
+)
+
+var (
+	// how long to wait before retrying a failed provisioning step, and between
+	// runs of a distro's ready check
+	ProvisionRetryDelay = 10 * time.Second
+	ReadyCheckInterval  = 10 * time.Second
+)
+
+// provisionStepError is returned when a step of a host's provisioning fails.
+type provisionStepError struct {
+	step string
+	err  error
+}
+
+func (self *provisionStepError) Error() string {
+	return fmt.Sprintf("error running provisioning step '%v': %v", self.step, self.err)
+}
+
+// remoteHost is a host being provisioned, which files are copied to and scripts
+// run on over SSH or through its provider's API.
+type remoteHost interface {
+	// copyFile copies a local file to the destination path on the host
+	copyFile(source, dest string) error
+
+	// runScript runs a shell script that's on the host, returning its output
+	runScript(script string, sudo bool, timeout time.Duration) (string, error)
+}
+
+type sshHost struct {
+	host       *host.Host
+	sshOptions []string
+}
+
+type executorHost struct {
+	host     *host.Host
+	executor cloud.Executor
+}
+
+// getRemoteHost returns the host to provision, which runs commands through its
+// provider's API if the provider supports it, or over SSH.
+func (init *HostInit) getRemoteHost(h *host.Host) (remoteHost, error) {
+	cloudHost, err := providers.GetCloudHost(h, init.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cloud host for %v: %v", h.Id, err)
+	}
+	if executor, ok := cloudHost.Executor(); ok {
+		return &executorHost{h, executor}, nil
+	}
+	sshOptions, err := cloudHost.GetSSHOptions()
+	if err != nil {
+		return nil, fmt.Errorf("error getting ssh options for host %v: %v", h.Id, err)
+	}
+	return &sshHost{h, sshOptions}, nil
+}
+
+func (self *sshHost) copyFile(source, dest string) error {
+	// parse the hostname into the user, host and port
+	hostInfo, err := util.ParseSSHInfo(self.host.Host)
+	if err != nil {
+		return err
+	}
+	user := self.host.Distro.User
+	if hostInfo.User != "" {
+		user = hostInfo.User
+	}
+
+	var scpCmdStderr bytes.Buffer
+	scpCmd := &command.ScpCommand{
+		Source:         source,
+		Dest:           dest,
+		Stdout:         &scpCmdStderr,
+		Stderr:         &scpCmdStderr,
+		RemoteHostName: hostInfo.Hostname,
+		User:           user,
+		Options:        append([]string{"-P", hostInfo.Port}, self.sshOptions...),
+	}
+	err = util.RunFunctionWithTimeout(scpCmd.Run, SCPTimeout)
+	if err != nil {
+		if err == util.ErrTimedOut {
+			scpCmd.Stop()
+			return fmt.Errorf("scp-ing file timed out")
+		}
+		return fmt.Errorf("error (%v) copying file to remote machine: %v",
+			err, scpCmdStderr.String())
+	}
+	return nil
+}
+
+func (self *sshHost) runScript(script string, sudo bool, timeout time.Duration) (string, error) {
+	return hostutil.RunRemoteScriptWithTimeout(self.host, script, self.sshOptions, sudo, timeout)
+}
+
+func (self *executorHost) copyFile(source, dest string) error {
+	return hostutil.ExecCopyFile(self.host, source, dest, self.executor, SCPTimeout)
+}
+
+func (self *executorHost) runScript(script string, sudo bool, timeout time.Duration) (string, error) {
+	return hostutil.ExecRemoteScriptWithTimeout(self.host, script, self.executor, sudo, timeout)
+}
+
+// provision runs the distro's provisioning steps on the host in order, then its
+// ready check, passing the result of each to record. It stops at the first step
+// that fails, returning its output and a *provisionStepError.
+func (init *HostInit) provision(remote remoteHost, d *distro.Distro,
+	record func(host.ProvisionStepResult)) (string, error) {
+	output := ""
+	for i, step := range d.ProvisionSteps() {
+		scriptName := fmt.Sprintf("provision_%v.sh", i)
+		if step.Name == distro.SetupStepName {
+			scriptName = setupScriptName
+		}
+		result := init.runProvisionStep(remote, step, scriptName)
+		record(result)
+		output = result.Output
+		if !result.Successful {
+			return output, &provisionStepError{step.Name, errors.New(result.Error)}
+		}
+	}
+
+	if d.Provisioning.ReadyCheck == "" {
+		return output, nil
+	}
+	result := init.runReadyCheck(remote, &d.Provisioning)
+	record(result)
+	if !result.Successful {
+		return result.Output, &provisionStepError{result.Step, errors.New(result.Error)}
+	}
+	return result.Output, nil
+}
+
+// runProvisionStep uploads a step's files and runs its script, retrying the step
+// if it fails up to the number of times it allows.
+func (init *HostInit) runProvisionStep(remote remoteHost, step distro.ProvisionStep,
+	scriptName string) host.ProvisionStepResult {
+	result := host.ProvisionStepResult{Step: step.Name, StartTime: time.Now()}
+	timeout := stepTimeout(step.TimeoutSecs)
+	for {
+		result.Attempts++
+		output, err := init.runProvisionStepOnce(remote, step, scriptName, timeout)
+		setAttemptResult(&result, output, err, timeout)
+		if result.Successful || result.Attempts > step.Retries {
+			break
+		}
+		time.Sleep(ProvisionRetryDelay)
+	}
+	result.Duration = time.Since(result.StartTime)
+	return result
+}
+
+func (init *HostInit) runProvisionStepOnce(remote remoteHost, step distro.ProvisionStep,
+	scriptName string, timeout time.Duration) (string, error) {
+	for _, file := range step.Files {
+		if err := init.uploadFile(remote, file, step.Sudo); err != nil {
+			return "", fmt.Errorf("error copying file %v: %v", file.Path, err)
+		}
+	}
+	if step.Script == "" {
+		return "", nil
+	}
+	if err := init.copyScript(remote, scriptName, step.Script); err != nil {
+		return "", fmt.Errorf("error copying script: %v", err)
+	}
+	return remote.runScript(scriptName, step.Sudo, timeout)
+}
+
+// runReadyCheck runs the distro's ready check until it succeeds or its timeout
+// passes.
+func (init *HostInit) runReadyCheck(remote remoteHost, p *distro.Provisioning) host.ProvisionStepResult {
+	result := host.ProvisionStepResult{Step: distro.ReadyCheckStepName, StartTime: time.Now()}
+	deadline := result.StartTime.Add(time.Duration(p.ReadyTimeoutSecs) * time.Second)
+	timeout := stepTimeout(0)
+	if err := init.copyScript(remote, readyCheckScriptName, p.ReadyCheck); err != nil {
+		result.Attempts = 1
+		result.Error = fmt.Sprintf("error copying script: %v", err)
+		result.Duration = time.Since(result.StartTime)
+		return result
+	}
+	for {
+		result.Attempts++
+		output, err := remote.runScript(readyCheckScriptName, false, timeout)
+		setAttemptResult(&result, output, err, timeout)
+		if result.Successful || !time.Now().Add(ReadyCheckInterval).Before(deadline) {
+			break
+		}
+		time.Sleep(ReadyCheckInterval)
+	}
+	result.Duration = time.Since(result.StartTime)
+	return result
+}
+
+// setAttemptResult sets a step's result to the output and error of its latest
+// attempt.
+func setAttemptResult(result *host.ProvisionStepResult, output string, err error, timeout time.Duration) {
+	if len(output) > maxProvisionOutput {
+		output = output[len(output)-maxProvisionOutput:]
+	}
+	result.Output = output
+	result.Successful = err == nil
+	result.Error = ""
+	if err == util.ErrTimedOut {
+		result.Error = fmt.Sprintf("timed out after %v", timeout)
+	} else if err != nil {
+		result.Error = err.Error()
+	}
+}
+
+// stepTimeout returns how long a step's script can run for, given the timeout
+// the step sets.
+func stepTimeout(timeoutSecs int) time.Duration {
+	if timeoutSecs > 0 {
+		return time.Duration(timeoutSecs) * time.Second
+	}
+	return time.Duration(SSHTimeoutSeconds) * time.Second
+}
+
+// copyScript writes a given script, after expanding it, as file "name" on the
+// target host. This works by creating a local copy of the script on the runner's
+// machine, copying it over then removing the local copy.
+func (init *HostInit) copyScript(remote remoteHost, name, script string) error {
+	expanded, err := init.expandScript(script)
+	if err != nil {
+		return fmt.Errorf("error expanding script: %v", err)
+	}
+	return copyContent(remote, name, expanded)
+}
+
+// copyContent writes the content as file "name" on the target host, as is.
+func copyContent(remote remoteHost, name, content string) error {
+	file, err := util.WriteTempFile(filepath.Base(name), []byte(content))
+	if err != nil {
+		return fmt.Errorf("error writing local script: %v", err)
+	}
+	defer os.Remove(file)
+	return remote.copyFile(file, name)
+}
+
+// uploadFile copies one of a step's files to the host. Files that a sudo step
+// puts outside the home directory of the distro's user are uploaded to the home
+// directory first, then installed at their path as root, since the user can't
+// usually write there.
+func (init *HostInit) uploadFile(remote remoteHost, file distro.ProvisionFile, sudo bool) error {
+	if !sudo || !path.IsAbs(file.Path) {
+		return init.copyScript(remote, file.Path, file.Content)
+	}
+
+	upload := uploadPrefix + path.Base(file.Path)
+	if err := init.copyScript(remote, upload, file.Content); err != nil {
+		return err
+	}
+	install := fmt.Sprintf("install -D -m 644 %v %v && rm -f %v",
+		hostutil.ShellQuote(upload), hostutil.ShellQuote(file.Path), hostutil.ShellQuote(upload))
+	if err := copyContent(remote, installScriptName, install); err != nil {
+		return err
+	}
+	if output, err := remote.runScript(installScriptName, true, SCPTimeout); err != nil {
+		return fmt.Errorf("error installing file: %v: %v", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package hostinit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeRemoteHost records the files copied to it and the scripts run on it, and
+// fails the runs of scripts with the given contents a number of times.
+type fakeRemoteHost struct {
+	files    map[string]string
+	runs     []string
+	sudoRuns []string
+	failures map[string]int
+}
+
+func (self *fakeRemoteHost) copyFile(source, dest string) error {
+	content, err := ioutil.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	self.files[dest] = string(content)
+	return nil
+}
+
+func (self *fakeRemoteHost) runScript(script string, sudo bool, timeout time.Duration) (string, error) {
+	content := self.files[script]
+	self.runs = append(self.runs, content)
+	if sudo {
+		self.sudoRuns = append(self.sudoRuns, content)
+	}
+	if self.failures[content] > 0 {
+		self.failures[content]--
+		return "output of " + content, fmt.Errorf("exit code 1")
+	}
+	return "output of " + content, nil
+}
+
+func TestProvision(t *testing.T) {
+	ProvisionRetryDelay = 0
+	ReadyCheckInterval = 100 * time.Millisecond
+	init := &HostInit{&evergreen.Settings{Expansions: map[string]string{"url": "http://evergreen"}}}
+
+	Convey("With a distro with a setup script and provisioning steps", t, func() {
+		remote := &fakeRemoteHost{files: map[string]string{}, failures: map[string]int{}}
+		d := &distro.Distro{
+			Setup: "setup",
+			Provisioning: distro.Provisioning{
+				Steps: []distro.ProvisionStep{
+					{
+						Name:   "config",
+						Files:  []distro.ProvisionFile{{Path: "/etc/agent.conf", Content: "url: ${url}"}},
+						Script: "install",
+						Sudo:   true,
+					},
+					{
+						Name:    "start",
+						Files:   []distro.ProvisionFile{{Path: "start.conf", Content: "url: ${url}"}},
+						Script:  "start",
+						Retries: 1,
+					},
+				},
+			},
+		}
+		results := []host.ProvisionStepResult{}
+		record := func(result host.ProvisionStepResult) {
+			results = append(results, result)
+		}
+
+		installFile := "install -D -m 644 'provision_upload_agent.conf' '/etc/agent.conf'" +
+			" && rm -f 'provision_upload_agent.conf'"
+
+		Convey("the setup script and steps should be run in order", func() {
+			output, err := init.provision(remote, d, record)
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "output of start")
+			So(remote.runs, ShouldResemble, []string{"setup", installFile, "install", "start"})
+			So(remote.sudoRuns, ShouldResemble, []string{installFile, "install"})
+			So(remote.files["start.conf"], ShouldEqual, "url: http://evergreen")
+			So(len(results), ShouldEqual, 3)
+			So(results[0].Step, ShouldEqual, distro.SetupStepName)
+			for _, result := range results {
+				So(result.Successful, ShouldBeTrue)
+				So(result.Attempts, ShouldEqual, 1)
+			}
+		})
+
+		Convey("files a sudo step puts outside the home directory should be installed as root", func() {
+			_, err := init.provision(remote, d, record)
+			So(err, ShouldBeNil)
+			So(remote.files["provision_upload_agent.conf"], ShouldEqual, "url: http://evergreen")
+			So(remote.files, ShouldNotContainKey, "/etc/agent.conf")
+		})
+
+		Convey("a failed step should be retried as many times as it allows", func() {
+			remote.failures["start"] = 1
+			_, err := init.provision(remote, d, record)
+			So(err, ShouldBeNil)
+			So(results[2].Attempts, ShouldEqual, 2)
+			So(results[2].Successful, ShouldBeTrue)
+			So(results[2].Error, ShouldEqual, "")
+		})
+
+		Convey("a step that keeps failing should stop provisioning", func() {
+			remote.failures["install"] = 1
+			output, err := init.provision(remote, d, record)
+			So(err, ShouldNotBeNil)
+			So(err.(*provisionStepError).step, ShouldEqual, "config")
+			So(output, ShouldEqual, "output of install")
+			So(remote.runs, ShouldResemble, []string{"setup", installFile, "install"})
+			So(len(results), ShouldEqual, 2)
+			So(results[1].Successful, ShouldBeFalse)
+			So(results[1].Error, ShouldEqual, "exit code 1")
+		})
+
+		Convey("the ready check should be run until it succeeds", func() {
+			d.Provisioning.ReadyCheck = "check"
+			d.Provisioning.ReadyTimeoutSecs = 10
+			remote.failures["check"] = 2
+			_, err := init.provision(remote, d, record)
+			So(err, ShouldBeNil)
+			So(len(results), ShouldEqual, 4)
+			So(results[3].Step, ShouldEqual, distro.ReadyCheckStepName)
+			So(results[3].Attempts, ShouldEqual, 3)
+			So(results[3].Successful, ShouldBeTrue)
+		})
+
+		Convey("a ready check that doesn't succeed before its timeout should fail", func() {
+			d.Provisioning.ReadyCheck = "check"
+			d.Provisioning.ReadyTimeoutSecs = 1
+			remote.failures["check"] = 100
+			_, err := init.provision(remote, d, record)
+			So(err, ShouldNotBeNil)
+			So(err.(*provisionStepError).step, ShouldEqual, distro.ReadyCheckStepName)
+			So(results[3].Attempts, ShouldBeGreaterThan, 1)
+			So(results[3].Successful, ShouldBeFalse)
+		})
+	})
+}
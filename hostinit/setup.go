@@ -5,8 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -19,8 +17,8 @@ import (
 	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/command"
-	"github.com/evergreen-ci/evergreen/hostutil"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/alert"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/user"
@@ -87,6 +85,15 @@ func (init *HostInit) setupReadyHosts() error {
 			continue
 		}
 
+		// hosts that were stopped and started again are already set up
+		if h.Provisioned {
+			evergreen.Logger.Logf(slogger.INFO, "Resuming restarted host %v", h.Id)
+			if err := resumeHost(&h); err != nil {
+				evergreen.Logger.Logf(slogger.ERROR, "Error resuming host %v: %v", h.Id, err)
+			}
+			continue
+		}
+
 		evergreen.Logger.Logf(slogger.INFO, "Running setup script for host %v", h.Id)
 
 		// kick off the setup, in its own goroutine, so pending setups don't have
@@ -103,8 +110,8 @@ func (init *HostInit) setupReadyHosts() error {
 				hostLink := fmt.Sprintf("%v/host/%v", init.Settings.Ui.Url, h.Id)
 				message := fmt.Sprintf("Provisioning failed on %v host -- %v: see %v",
 					h.Distro.Id, h.Id, hostLink)
-				if err := notify.NotifyAdmins(subject, message, init.Settings); err != nil {
-					evergreen.Logger.Errorf(slogger.ERROR, "Error sending email: %v", err)
+				if err := alert.EnqueueOpsAlert(subject, message); err != nil {
+					evergreen.Logger.Errorf(slogger.ERROR, "Error queueing ops alert: %v", err)
 				}
 			}
 
@@ -120,6 +127,19 @@ func (init *HostInit) setupReadyHosts() error {
 	return nil
 }
 
+// resumeHost marks a host that was set up before it was stopped as running
+// again, without running its setup script.
+func resumeHost(h *host.Host) error {
+	if err := h.SetInitializing(); err != nil {
+		// another hostinit process beat us there
+		if err == mgo.ErrNotFound {
+			return nil
+		}
+		return fmt.Errorf("database error: %v", err)
+	}
+	return h.MarkAsProvisioned()
+}
+
 // IsHostReady returns whether or not the specified host is ready for its setup script
 // to be run.
 func (init *HostInit) IsHostReady(host *host.Host) (bool, error) {
@@ -178,9 +198,9 @@ func (init *HostInit) IsHostReady(host *host.Host) (bool, error) {
 	return reachable, nil
 }
 
-// setupHost runs the specified setup script for an individual host. Returns
-// the output from running the script remotely, as well as any error that
-// occurs. If the script exits with a non-zero exit code, the error will be non-nil.
+// setupHost runs the provisioning steps of the host's distro on it, saving the
+// result of each on the host. Returns the output of the last step run, as well as
+// any error that occurs. If a step fails, the error is a *provisionStepError.
 func (init *HostInit) setupHost(targetHost *host.Host) (string, error) {
 
 	// fetch the appropriate cloud provider for the host
@@ -210,99 +230,30 @@ func (init *HostInit) setupHost(targetHost *host.Host) (string, error) {
 		// if this fails it is probably due to an API hiccup, so we keep going.
 		evergreen.Logger.Logf(slogger.WARN, "OnUp callback failed for host '%v': '%v'", targetHost.Id, err)
 	}
-	cloudHost, err := providers.GetCloudHost(targetHost, init.Settings)
+	remote, err := init.getRemoteHost(targetHost)
 	if err != nil {
-		return "", fmt.Errorf("failed to get cloud host for %v: %v", targetHost.Id, err)
-	}
-	sshOptions, err := cloudHost.GetSSHOptions()
-	if err != nil {
-		return "", fmt.Errorf("error getting ssh options for host %v: %v", targetHost.Id, err)
+		return "", err
 	}
 
 	if targetHost.Distro.Teardown != "" {
-		err = init.copyScript(targetHost, teardownScriptName, targetHost.Distro.Teardown)
+		err = init.copyScript(remote, teardownScriptName, targetHost.Distro.Teardown)
 		if err != nil {
 			return "", fmt.Errorf("error copying script %v to host %v: %v",
 				teardownScriptName, targetHost.Id, err)
 		}
 	}
 
-	if targetHost.Distro.Setup != "" {
-		err = init.copyScript(targetHost, setupScriptName, targetHost.Distro.Setup)
-		if err != nil {
-			return "", fmt.Errorf("error copying script %v to host %v: %v",
-				setupScriptName, targetHost.Id, err)
-		}
-		logs, err := hostutil.RunRemoteScript(targetHost, setupScriptName, sshOptions)
-		if err != nil {
-			return logs, fmt.Errorf("error running setup script over ssh: %v", err)
-		}
-		return logs, nil
-	}
-	return "", nil
-}
-
-// copyScript writes a given script as file "name" to the target host. This works
-// by creating a local copy of the script on the runner's machine, scping it over
-// then removing the local copy.
-func (init *HostInit) copyScript(target *host.Host, name, script string) error {
-	// parse the hostname into the user, host and port
-	hostInfo, err := util.ParseSSHInfo(target.Host)
-	if err != nil {
-		return err
-	}
-	user := target.Distro.User
-	if hostInfo.User != "" {
-		user = hostInfo.User
-	}
-
-	// create a temp file for the script
-	file, err := ioutil.TempFile("", name)
-	if err != nil {
-		return fmt.Errorf("error creating temporary script file: %v", err)
-	}
-	defer func() {
-		file.Close()
-		os.Remove(file.Name())
-	}()
-
-	expanded, err := init.expandScript(script)
-	if err != nil {
-		return fmt.Errorf("error expanding script for host %v: %v", target.Id, err)
-	}
-	if _, err := io.WriteString(file, expanded); err != nil {
-		return fmt.Errorf("error writing local script: %v", err)
-	}
-
-	cloudHost, err := providers.GetCloudHost(target, init.Settings)
-	if err != nil {
-		return fmt.Errorf("failed to get cloud host for %v: %v", target.Id, err)
+	if err = targetHost.ClearProvisionResults(); err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "Error clearing provisioning results of host %v: %v", targetHost.Id, err)
 	}
-	sshOptions, err := cloudHost.GetSSHOptions()
-	if err != nil {
-		return fmt.Errorf("error getting ssh options for host %v: %v", target.Id, err)
-	}
-
-	var scpCmdStderr bytes.Buffer
-	scpCmd := &command.ScpCommand{
-		Source:         file.Name(),
-		Dest:           name,
-		Stdout:         &scpCmdStderr,
-		Stderr:         &scpCmdStderr,
-		RemoteHostName: hostInfo.Hostname,
-		User:           user,
-		Options:        append([]string{"-P", hostInfo.Port}, sshOptions...),
-	}
-	err = util.RunFunctionWithTimeout(scpCmd.Run, SCPTimeout)
-	if err != nil {
-		if err == util.ErrTimedOut {
-			scpCmd.Stop()
-			return fmt.Errorf("scp-ing script timed out")
+	return init.provision(remote, &targetHost.Distro, func(result host.ProvisionStepResult) {
+		evergreen.Logger.Logf(slogger.INFO, "Provisioning step %v on host %v finished after %v attempts (successful: %v)",
+			result.Step, targetHost.Id, result.Attempts, result.Successful)
+		if err := targetHost.AddProvisionResult(result); err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Error saving result of provisioning step %v of host %v: %v",
+				result.Step, targetHost.Id, err)
 		}
-		return fmt.Errorf("error (%v) copying script to remote machine: %v",
-			err, scpCmdStderr.String())
-	}
-	return nil
+	})
 }
 
 // Build the setup script that will need to be run on the specified host.
@@ -339,7 +290,7 @@ func (init *HostInit) ProvisionHost(h *host.Host, opts ProvisionOptions) error {
 
 	// deal with any errors that occured while running the setup
 	if err != nil {
-		evergreen.Logger.Logf(slogger.ERROR, "Error running setup script: %v", err)
+		evergreen.Logger.Logf(slogger.ERROR, "Error provisioning host: %v", err)
 
 		// another hostinit process beat us there
 		if err == ErrHostAlreadyInitializing {
@@ -348,7 +299,11 @@ func (init *HostInit) ProvisionHost(h *host.Host, opts ProvisionOptions) error {
 		}
 
 		alerts.RunHostProvisionFailTriggers(h)
-		event.LogProvisionFailed(h.Id, output)
+		if stepErr, ok := err.(*provisionStepError); ok {
+			event.LogProvisionStepFailed(h.Id, stepErr.step, output)
+		} else {
+			event.LogProvisionFailed(h.Id, output)
+		}
 
 		// setup script failed, mark the host's provisioning as failed
 		if err := h.SetUnprovisioned(); err != nil {
@@ -435,6 +390,11 @@ func (init *HostInit) LoadClient(target *host.Host, user *user.DBUser) (*LoadCli
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get cloud host for %v: %v", target.Id, err)
 	}
+	// users can't log in to hosts that don't have SSH
+	if _, ok := cloudHost.Executor(); ok {
+		return nil, fmt.Errorf("Can't load client onto host %v: provider %v doesn't support SSH",
+			target.Id, target.Provider)
+	}
 	sshOptions, err := cloudHost.GetSSHOptions()
 	if err != nil {
 		return nil, fmt.Errorf("Error getting ssh options for host %v: %v", target.Id, err)
@@ -0,0 +1,44 @@
+package hostinit
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResumeHost(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(evergreen.TestConfig()))
+
+	Convey("With a restarted host that was provisioned before it was stopped", t, func() {
+		testutil.HandleTestingErr(db.Clear(host.Collection), t, "error clearing hosts collection")
+
+		h := &host.Host{
+			Id:          "h1",
+			Status:      evergreen.HostUninitialized,
+			Provisioned: true,
+		}
+		testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+
+		Convey("resuming it should mark it as running", func() {
+			So(resumeHost(h), ShouldBeNil)
+
+			resumed, err := host.FindOne(host.ById("h1"))
+			So(err, ShouldBeNil)
+			So(resumed.Status, ShouldEqual, evergreen.HostRunning)
+			So(resumed.Provisioned, ShouldBeTrue)
+		})
+
+		Convey("hosts another process is resuming should be left alone", func() {
+			So(h.SetStatus(evergreen.HostInitializing), ShouldBeNil)
+			So(resumeHost(h), ShouldBeNil)
+
+			resumed, err := host.FindOne(host.ById("h1"))
+			So(err, ShouldBeNil)
+			So(resumed.Status, ShouldEqual, evergreen.HostInitializing)
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package hostutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+// ExecCommand runs a shell command on a host whose provider runs commands
+// through its API, returning the command's output and any errors that occur.
+func ExecCommand(h *host.Host, cmd string, executor cloud.Executor, timeout time.Duration) (string, error) {
+	output := &util.CappedWriter{
+		Buffer:   &bytes.Buffer{},
+		MaxBytes: 1024 * 1024, // 1MB
+	}
+	err := util.RunFunctionWithTimeout(func() error {
+		return executor.Exec(h, []string{"sh", "-c", cmd}, nil, output, output)
+	}, timeout)
+	return output.String(), err
+}
+
+// ExecRemoteScript is RunRemoteScript for hosts whose provider runs commands
+// through its API.
+func ExecRemoteScript(h *host.Host, script string, executor cloud.Executor) (string, error) {
+	return ExecRemoteScriptWithTimeout(h, script, executor, h.Distro.SetupAsSudo, SSHTimeout)
+}
+
+// ExecRemoteScriptWithTimeout is RunRemoteScriptWithTimeout for hosts whose
+// provider runs commands through its API.
+func ExecRemoteScriptWithTimeout(h *host.Host, script string, executor cloud.Executor,
+	sudo bool, timeout time.Duration) (string, error) {
+	sudoStr := ""
+	if sudo {
+		sudoStr = "sudo "
+	}
+	return ExecCommand(h, sudoStr+"sh "+script, executor, timeout)
+}
+
+// ExecCopyFile copies a local file to the destination path on a host whose
+// provider runs commands through its API, keeping the file's permissions.
+// The file is streamed to a command that reads exactly its size, since
+// the remote end can't always tell when the input ends.
+func ExecCopyFile(h *host.Host, source, dest string, executor cloud.Executor, timeout time.Duration) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	cmd := fmt.Sprintf("mkdir -p %v && head -c %v > %v && chmod %o %v",
+		ShellQuote(path.Dir(dest)), info.Size(), ShellQuote(dest), info.Mode().Perm(), ShellQuote(dest))
+	output := &util.CappedWriter{
+		Buffer:   &bytes.Buffer{},
+		MaxBytes: 1024 * 1024, // 1MB
+	}
+	err = util.RunFunctionWithTimeout(func() error {
+		return executor.Exec(h, []string{"sh", "-c", cmd}, file, output, output)
+	}, timeout)
+	if err != nil {
+		return fmt.Errorf("error copying %v to %v: %v: %v", source, dest, err, output.String())
+	}
+	return nil
+}
+
+// ShellQuote quotes a string, such as a path, so that the shell passes it to a
+// command as one argument, unexpanded.
+func ShellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,15 @@
+package hostutil
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestShellQuote(t *testing.T) {
+	Convey("Quoted strings should be passed to commands as one argument, unexpanded", t, func() {
+		So(ShellQuote("/etc/agent.conf"), ShouldEqual, "'/etc/agent.conf'")
+		So(ShellQuote("my dir/$HOME; rm -rf x"), ShouldEqual, "'my dir/$HOME; rm -rf x'")
+		So(ShellQuote("it's"), ShouldEqual, `'it'\''s'`)
+	})
+}
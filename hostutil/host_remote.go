@@ -14,6 +14,13 @@ const SSHTimeout = time.Minute * 5
 // RunRemoteScript executes a shell script that already exists on the remote host,
 // returning logs and any errors that occur. Logs may still be returned for some errors.
 func RunRemoteScript(h *host.Host, script string, sshOptions []string) (string, error) {
+	return RunRemoteScriptWithTimeout(h, script, sshOptions, h.Distro.SetupAsSudo, SSHTimeout)
+}
+
+// RunRemoteScriptWithTimeout is RunRemoteScript, running the script as sudo if
+// specified and for at most the given timeout.
+func RunRemoteScriptWithTimeout(h *host.Host, script string, sshOptions []string,
+	sudo bool, timeout time.Duration) (string, error) {
 	// parse the hostname into the user, host and port
 	hostInfo, err := util.ParseSSHInfo(h.Host)
 	if err != nil {
@@ -26,7 +33,7 @@ func RunRemoteScript(h *host.Host, script string, sshOptions []string) (string,
 
 	// run the remote script as sudo, if appropriate
 	sudoStr := ""
-	if h.Distro.SetupAsSudo {
+	if sudo {
 		sudoStr = "sudo "
 	}
 	// run command to ssh into remote machine and execute script
@@ -44,7 +51,7 @@ func RunRemoteScript(h *host.Host, script string, sshOptions []string) (string,
 		Background:     false,
 	}
 	// force creation of a tty if sudo
-	if h.Distro.SetupAsSudo {
+	if sudo {
 		cmd.Options = []string{"-t", "-t", "-p", hostInfo.Port}
 	}
 	cmd.Options = append(cmd.Options, sshOptions...)
@@ -52,7 +59,7 @@ func RunRemoteScript(h *host.Host, script string, sshOptions []string) (string,
 	// run the ssh command with given timeout
 	err = util.RunFunctionWithTimeout(
 		cmd.Run,
-		SSHTimeout,
+		timeout,
 	)
 	return sshCmdStd.String(), err
 }
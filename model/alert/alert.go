@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/alertrecord"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -24,12 +25,14 @@ type AlertRequest struct {
 	Trigger     string        `bson:"trigger"`
 	TaskId      string        `bson:"task_id,omitempty"`
 	HostId      string        `bson:"host_id,omitempty"`
+	DistroId    string        `bson:"distro_id,omitempty"`
 	Execution   int           `bson:"execution,omitempty"`
 	BuildId     string        `bson:"build_id,omitempty"`
 	VersionId   string        `bson:"version_id,omitempty"`
 	ProjectId   string        `bson:"project_id,omitempty"`
 	PatchId     string        `bson:"patch_id,omitempty"`
 	Display     string        `bson:"display"`
+	Details     string        `bson:"details,omitempty"`
 	CreatedAt   time.Time     `bson:"created_at"`
 	ProcessedAt time.Time     `bson:"processed_at"`
 }
@@ -59,3 +62,15 @@ func EnqueueAlertRequest(a *AlertRequest) error {
 	a.QueueStatus = Pending
 	return db.Insert(Collection, a)
 }
+
+// EnqueueOpsAlert queues an alert for the admins' ops channels about a failure
+// in one of Evergreen's own processes, with a one-line summary and its details.
+func EnqueueOpsAlert(summary, details string) error {
+	return EnqueueAlertRequest(&AlertRequest{
+		Id:        bson.NewObjectId(),
+		Trigger:   alertrecord.OpsNotificationId,
+		Display:   summary,
+		Details:   details,
+		CreatedAt: time.Now(),
+	})
+}
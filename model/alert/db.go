@@ -1,7 +1,9 @@
 package alert
 
 import (
+	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2/bson"
 )
 
 const (
@@ -21,3 +23,15 @@ var (
 	DisplayKey     = bsonutil.MustHaveTag(AlertRequest{}, "Display")
 	CreatedAtKey   = bsonutil.MustHaveTag(AlertRequest{}, "CreatedAt")
 )
+
+// ByIds returns a query for the alert requests with the given ids.
+func ByIds(ids []bson.ObjectId) db.Q {
+	return db.Query(bson.M{IdKey: bson.M{"$in": ids}}).Sort([]string{CreatedAtKey})
+}
+
+// Find gets all AlertRequests for the given query.
+func Find(query db.Q) ([]AlertRequest, error) {
+	alerts := []AlertRequest{}
+	err := db.FindAllQ(Collection, query, &alerts)
+	return alerts, err
+}
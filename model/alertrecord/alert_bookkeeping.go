@@ -1,6 +1,8 @@
 package alertrecord
 
 import (
+	"time"
+
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/db/bsonutil"
 	"gopkg.in/mgo.v2"
@@ -19,6 +21,8 @@ var (
 	FirstTaskTypeFailureId = "first_tasktype_failure"
 	TaskFailTransitionId   = "task_transition_failure"
 	LastRevisionNotFound   = "last_revision_not_found"
+	TaskDurationExceeded   = "task_duration_exceeded"
+	TaskTimeoutTransition  = "task_timeout_transition"
 )
 
 // Host triggers
@@ -30,16 +34,33 @@ var (
 	ProvisionFailed            = "provision_failed"
 )
 
+// Ops triggers
+var (
+	DistroQueueBacklogId      = "distro_queue_backlog"
+	DistroProvisionFailuresId = "distro_provision_failures"
+	DistroSpawnErrorsId       = "distro_spawn_errors"
+	RepotrackerStuckId        = "repotracker_stuck"
+	OpsNotificationId         = "ops_notification"
+)
+
+// Delivery bookkeeping
+var (
+	AlertSentId = "alert_sent"
+)
+
 type AlertRecord struct {
 	Id                  bson.ObjectId `bson:"_id"`
 	Type                string        `bson:"type"`
 	HostId              string        `bson:"host_id,omitempty"`
+	DistroId            string        `bson:"distro_id,omitempty"`
 	TaskId              string        `bson:"task_id,omitempty"`
 	ProjectId           string        `bson:"project_id,omitempty"`
 	VersionId           string        `bson:"version_id,omitempty"`
 	TaskName            string        `bson:"task_name,omitempty"`
 	Variant             string        `bson:"variant,omitempty"`
 	RevisionOrderNumber int           `bson:"order,omitempty"`
+	Recipient           string        `bson:"recipient,omitempty"`
+	SentAt              time.Time     `bson:"sent_at,omitempty"`
 }
 
 var (
@@ -47,11 +68,14 @@ var (
 	TypeKey                = bsonutil.MustHaveTag(AlertRecord{}, "Type")
 	TaskIdKey              = bsonutil.MustHaveTag(AlertRecord{}, "TaskId")
 	HostIdKey              = bsonutil.MustHaveTag(AlertRecord{}, "HostId")
+	DistroIdKey            = bsonutil.MustHaveTag(AlertRecord{}, "DistroId")
 	TaskNameKey            = bsonutil.MustHaveTag(AlertRecord{}, "TaskName")
 	VariantKey             = bsonutil.MustHaveTag(AlertRecord{}, "Variant")
 	ProjectIdKey           = bsonutil.MustHaveTag(AlertRecord{}, "ProjectId")
 	VersionIdKey           = bsonutil.MustHaveTag(AlertRecord{}, "VersionId")
 	RevisionOrderNumberKey = bsonutil.MustHaveTag(AlertRecord{}, "RevisionOrderNumber")
+	RecipientKey           = bsonutil.MustHaveTag(AlertRecord{}, "Recipient")
+	SentAtKey              = bsonutil.MustHaveTag(AlertRecord{}, "SentAt")
 )
 
 // FindOne gets one AlertRecord for the given query.
@@ -106,6 +130,38 @@ func ByHostAlertRecordType(hostId, triggerId string) db.Q {
 	}).Limit(1)
 }
 
+// ByTaskInVersion finds the alert record of the given type stored for a task
+// (by display name and variant) within a version.
+func ByTaskInVersion(triggerId, versionId, variant, taskName string) db.Q {
+	return db.Query(bson.M{
+		TypeKey:      triggerId,
+		VersionIdKey: versionId,
+		VariantKey:   variant,
+		TaskNameKey:  taskName,
+	}).Limit(1)
+}
+
+// ByLastTimeoutTransition finds the last timeout transition alert record stored
+// for a task/variant within a given project.
+func ByLastTimeoutTransition(taskName, variant, projectId string) db.Q {
+	return db.Query(bson.M{
+		TypeKey:      TaskTimeoutTransition,
+		TaskNameKey:  taskName,
+		VariantKey:   variant,
+		ProjectIdKey: projectId,
+	}).Sort([]string{"-" + RevisionOrderNumberKey}).Limit(1)
+}
+
+// ByDistroAlertSince finds an alert record of the given type stored for the distro at or
+// after the given time. Ops alerts not tied to a distro use an empty distro id.
+func ByDistroAlertSince(triggerId, distroId string, since time.Time) db.Q {
+	return db.Query(bson.M{
+		TypeKey:     triggerId,
+		DistroIdKey: distroId,
+		SentAtKey:   bson.M{"$gte": since},
+	}).Limit(1)
+}
+
 func ByLastRevNotFound(projectId, versionId string) db.Q {
 	return db.Query(bson.M{
 		TypeKey:      LastRevisionNotFound,
@@ -114,6 +170,34 @@ func ByLastRevNotFound(projectId, versionId string) db.Q {
 	}).Limit(1)
 }
 
+// BySentToRecipient finds the delivery records for alerts sent to the given
+// recipient at or after the given time.
+func BySentToRecipient(recipient string, since time.Time) db.Q {
+	return db.Query(bson.M{
+		TypeKey:      AlertSentId,
+		RecipientKey: recipient,
+		SentAtKey:    bson.M{"$gte": since},
+	})
+}
+
+// CountSentToRecipient returns the number of alerts delivered to the given
+// recipient since the given time.
+func CountSentToRecipient(recipient string, since time.Time) (int, error) {
+	return db.CountQ(Collection, BySentToRecipient(recipient, since))
+}
+
+// RecordSent stores a delivery record for an alert sent to the given recipient,
+// which is used for per-recipient rate limiting.
+func RecordSent(recipient string, sentAt time.Time) error {
+	rec := &AlertRecord{
+		Id:        bson.NewObjectId(),
+		Type:      AlertSentId,
+		Recipient: recipient,
+		SentAt:    sentAt,
+	}
+	return rec.Insert()
+}
+
 func (ar *AlertRecord) Insert() error {
 	return db.Insert(Collection, ar)
 }
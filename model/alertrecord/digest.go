@@ -0,0 +1,92 @@
+package alertrecord
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	DigestCollection = "alertdigests"
+)
+
+// Digest holds the alert requests that are waiting to be delivered together to a
+// single recipient for a single version.
+type Digest struct {
+	Id              bson.ObjectId   `bson:"_id"`
+	Recipient       string          `bson:"recipient"`
+	ProjectId       string          `bson:"project_id"`
+	VersionId       string          `bson:"version_id"`
+	AlertRequestIds []bson.ObjectId `bson:"alert_request_ids"`
+	CreatedAt       time.Time       `bson:"created_at"`
+}
+
+var (
+	DigestIdKey              = bsonutil.MustHaveTag(Digest{}, "Id")
+	DigestRecipientKey       = bsonutil.MustHaveTag(Digest{}, "Recipient")
+	DigestProjectIdKey       = bsonutil.MustHaveTag(Digest{}, "ProjectId")
+	DigestVersionIdKey       = bsonutil.MustHaveTag(Digest{}, "VersionId")
+	DigestAlertRequestIdsKey = bsonutil.MustHaveTag(Digest{}, "AlertRequestIds")
+	DigestCreatedAtKey       = bsonutil.MustHaveTag(Digest{}, "CreatedAt")
+)
+
+// AddToDigest appends the alert request to the pending digest for the recipient and
+// version, creating the digest if one does not exist yet.
+func AddToDigest(recipient, projectId, versionId string, alertRequestId bson.ObjectId) error {
+	_, err := db.Upsert(DigestCollection,
+		bson.M{
+			DigestRecipientKey: recipient,
+			DigestVersionIdKey: versionId,
+		},
+		bson.M{
+			"$addToSet": bson.M{DigestAlertRequestIdsKey: alertRequestId},
+			"$setOnInsert": bson.M{
+				DigestProjectIdKey: projectId,
+				DigestCreatedAtKey: time.Now(),
+			},
+		},
+	)
+	return err
+}
+
+// ByDigestCreatedBefore returns all pending digests whose window opened before
+// the given time, oldest first.
+func ByDigestCreatedBefore(cutoff time.Time) db.Q {
+	return db.Query(bson.M{
+		DigestCreatedAtKey: bson.M{"$lte": cutoff},
+	}).Sort([]string{DigestCreatedAtKey})
+}
+
+// FindDigests gets all Digests for the given query.
+func FindDigests(query db.Q) ([]Digest, error) {
+	digests := []Digest{}
+	err := db.FindAllQ(DigestCollection, query, &digests)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return digests, err
+}
+
+// RemoveAlerts takes the given alert requests out of the digest once they have
+// been delivered, and deletes the digest if no alerts were added to it since it
+// was loaded.
+func (d *Digest) RemoveAlerts(alertRequestIds []bson.ObjectId) error {
+	err := db.Update(DigestCollection,
+		bson.M{DigestIdKey: d.Id},
+		bson.M{"$pullAll": bson.M{DigestAlertRequestIdsKey: alertRequestIds}},
+	)
+	if err != nil {
+		return err
+	}
+	err = db.Remove(DigestCollection, bson.M{
+		DigestIdKey:              d.Id,
+		DigestAlertRequestIdsKey: bson.M{"$size": 0},
+	})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package alertrecord
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	HeldAlertCollection = "heldalerts"
+)
+
+// HeldAlert is an alert request that could not be sent to a recipient because they
+// had hit their rate limit, and that is delivered once they are under it again.
+type HeldAlert struct {
+	Id             bson.ObjectId `bson:"_id"`
+	Recipient      string        `bson:"recipient"`
+	AlertRequestId bson.ObjectId `bson:"alert_request_id"`
+	CreatedAt      time.Time     `bson:"created_at"`
+}
+
+var (
+	HeldAlertIdKey             = bsonutil.MustHaveTag(HeldAlert{}, "Id")
+	HeldAlertRecipientKey      = bsonutil.MustHaveTag(HeldAlert{}, "Recipient")
+	HeldAlertAlertRequestIdKey = bsonutil.MustHaveTag(HeldAlert{}, "AlertRequestId")
+	HeldAlertCreatedAtKey      = bsonutil.MustHaveTag(HeldAlert{}, "CreatedAt")
+)
+
+// HoldAlert stores the alert request to be delivered to the recipient later.
+func HoldAlert(recipient string, alertRequestId bson.ObjectId) error {
+	return db.Insert(HeldAlertCollection, &HeldAlert{
+		Id:             bson.NewObjectId(),
+		Recipient:      recipient,
+		AlertRequestId: alertRequestId,
+		CreatedAt:      time.Now(),
+	})
+}
+
+// AllHeldAlerts returns every held alert, oldest first.
+var AllHeldAlerts = db.Query(bson.M{}).Sort([]string{HeldAlertCreatedAtKey})
+
+// FindHeldAlerts gets all HeldAlerts for the given query.
+func FindHeldAlerts(query db.Q) ([]HeldAlert, error) {
+	held := []HeldAlert{}
+	err := db.FindAllQ(HeldAlertCollection, query, &held)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return held, err
+}
+
+// Remove deletes the held alert once it has been delivered.
+func (h *HeldAlert) Remove() error {
+	return db.Remove(HeldAlertCollection, bson.M{HeldAlertIdKey: h.Id})
+}
@@ -35,6 +35,10 @@ type File struct {
 	Link string `json:"link" bson:"link"`
 	// Visibility determines who can see the file in the UI
 	Visibility string `json:"visibility" bson:"visibility"`
+	// Bucket and FileKey locate the file in storage, so the server can sign
+	// expiring links to private files. They are empty for files linked by URL only.
+	Bucket  string `json:"bucket,omitempty" bson:"bucket,omitempty"`
+	FileKey string `json:"file_key,omitempty" bson:"file_key,omitempty"`
 }
 
 // Array turns the parameter map into an array of File structs.
@@ -42,7 +46,7 @@ type File struct {
 func (params Params) Array() []File {
 	var files []File
 	for name, link := range params {
-		files = append(files, File{name, link, ""})
+		files = append(files, File{Name: name, Link: link})
 	}
 	return files
 }
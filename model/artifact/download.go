@@ -0,0 +1,65 @@
+package artifact
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const DownloadsCollection = "artifact_downloads"
+
+const (
+	// sources of downloads
+	DownloadSourceUI   = "ui"
+	DownloadSourceREST = "rest"
+)
+
+// Download records a user being given access to a private task file, either
+// by downloading it through the UI or by being handed a signed link by the
+// REST API.
+type Download struct {
+	Id         bson.ObjectId `json:"-" bson:"_id,omitempty"`
+	TaskId     string        `json:"task_id" bson:"task_id"`
+	FileName   string        `json:"file_name" bson:"file_name"`
+	Bucket     string        `json:"bucket" bson:"bucket"`
+	FileKey    string        `json:"file_key" bson:"file_key"`
+	User       string        `json:"user" bson:"user"`
+	RemoteAddr string        `json:"remote_addr" bson:"remote_addr"`
+	Source     string        `json:"source" bson:"source"`
+	Time       time.Time     `json:"time" bson:"time"`
+}
+
+var (
+	// BSON fields for download structs
+	DownloadTaskIdKey = bsonutil.MustHaveTag(Download{}, "TaskId")
+	DownloadUserKey   = bsonutil.MustHaveTag(Download{}, "User")
+	DownloadTimeKey   = bsonutil.MustHaveTag(Download{}, "Time")
+)
+
+// === Queries ===
+
+// DownloadsByTaskId returns a query for the downloads of a task's files, newest first.
+func DownloadsByTaskId(taskId string) db.Q {
+	return db.Query(bson.M{DownloadTaskIdKey: taskId}).Sort([]string{"-" + DownloadTimeKey})
+}
+
+// DownloadsByUser returns a query for the downloads by a user, newest first.
+func DownloadsByUser(userId string) db.Q {
+	return db.Query(bson.M{DownloadUserKey: userId}).Sort([]string{"-" + DownloadTimeKey})
+}
+
+// === DB Logic ===
+
+// Insert records the download.
+func (d *Download) Insert() error {
+	return db.Insert(DownloadsCollection, d)
+}
+
+// FindDownloads gets every Download for the given query.
+func FindDownloads(query db.Q) ([]Download, error) {
+	downloads := []Download{}
+	err := db.FindAllQ(DownloadsCollection, query, &downloads)
+	return downloads, err
+}
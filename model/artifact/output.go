@@ -0,0 +1,81 @@
+package artifact
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const OutputsCollection = "task_outputs"
+
+// Output is a named file published by a task for its dependent tasks to fetch.
+// The server chooses where outputs are stored, so tasks refer to them only by
+// the publishing task and the output's name.
+type Output struct {
+	// Id is the publishing task's id and the output's name, so that publishing
+	// an output again, e.g. from a restarted task, replaces it
+	Id           string    `json:"-" bson:"_id"`
+	TaskId       string    `json:"task_id" bson:"task_id"`
+	Execution    int       `json:"execution" bson:"execution"`
+	TaskName     string    `json:"task_name" bson:"task_name"`
+	BuildVariant string    `json:"build_variant" bson:"build_variant"`
+	Name         string    `json:"name" bson:"name"`
+	FileName     string    `json:"file_name" bson:"file_name"`
+	Size         int64     `json:"size" bson:"size"`
+	Bucket       string    `json:"-" bson:"bucket"`
+	Path         string    `json:"-" bson:"path"`
+	CreateTime   time.Time `json:"create_time" bson:"create_time"`
+}
+
+var (
+	// BSON fields for task output structs
+	OutputIdKey     = bsonutil.MustHaveTag(Output{}, "Id")
+	OutputTaskIdKey = bsonutil.MustHaveTag(Output{}, "TaskId")
+	OutputNameKey   = bsonutil.MustHaveTag(Output{}, "Name")
+)
+
+// OutputId returns the id of the task's output with the given name.
+func OutputId(taskId, name string) string {
+	return taskId + "/" + name
+}
+
+// === Queries ===
+
+// OutputsByTaskId returns a query for the outputs published by a task, sorted by name.
+func OutputsByTaskId(taskId string) db.Q {
+	return db.Query(bson.M{OutputTaskIdKey: taskId}).Sort([]string{OutputNameKey})
+}
+
+// OutputByTaskIdAndName returns a query for one of a task's outputs.
+func OutputByTaskIdAndName(taskId, name string) db.Q {
+	return db.Query(bson.M{OutputIdKey: OutputId(taskId, name)})
+}
+
+// === DB Logic ===
+
+// Upsert saves the output, replacing an output of the same name from the same task.
+func (o *Output) Upsert() error {
+	o.Id = OutputId(o.TaskId, o.Name)
+	_, err := db.Upsert(OutputsCollection, bson.M{OutputIdKey: o.Id}, o)
+	return err
+}
+
+// FindOneOutput gets one Output for the given query.
+func FindOneOutput(query db.Q) (*Output, error) {
+	output := &Output{}
+	err := db.FindOneQ(OutputsCollection, query, output)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return output, err
+}
+
+// FindOutputs gets every Output for the given query.
+func FindOutputs(query db.Q) ([]Output, error) {
+	outputs := []Output{}
+	err := db.FindAllQ(OutputsCollection, query, &outputs)
+	return outputs, err
+}
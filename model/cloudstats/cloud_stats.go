@@ -0,0 +1,180 @@
+package cloudstats
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	ProviderStatsCollection = "cloud_provider_stats"
+)
+
+// ProviderStats are the calls Evergreen has made to a cloud provider's manager,
+// by method, since the stats were last reset.
+type ProviderStats struct {
+	Provider string               `bson:"_id" json:"provider"`
+	Since    time.Time            `bson:"since" json:"since"`
+	Calls    map[string]CallStats `bson:"calls" json:"calls"`
+}
+
+// CallStats are the number of calls to one method of a cloud manager, how
+// many failed, and how long they took.
+type CallStats struct {
+	Count         int           `bson:"count" json:"count"`
+	Errors        int           `bson:"errors" json:"errors"`
+	TotalLatency  time.Duration `bson:"total_latency" json:"total_latency_ns"`
+	MaxLatency    time.Duration `bson:"max_latency" json:"max_latency_ns"`
+	LastError     string        `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LastErrorTime time.Time     `bson:"last_error_time,omitempty" json:"last_error_time,omitempty"`
+}
+
+var (
+	// bson fields for the provider stats struct
+	ProviderStatsIdKey    = bsonutil.MustHaveTag(ProviderStats{}, "Provider")
+	ProviderStatsSinceKey = bsonutil.MustHaveTag(ProviderStats{}, "Since")
+	ProviderStatsCallsKey = bsonutil.MustHaveTag(ProviderStats{}, "Calls")
+
+	// bson fields for the call stats struct
+	CallStatsCountKey         = bsonutil.MustHaveTag(CallStats{}, "Count")
+	CallStatsErrorsKey        = bsonutil.MustHaveTag(CallStats{}, "Errors")
+	CallStatsTotalLatencyKey  = bsonutil.MustHaveTag(CallStats{}, "TotalLatency")
+	CallStatsMaxLatencyKey    = bsonutil.MustHaveTag(CallStats{}, "MaxLatency")
+	CallStatsLastErrorKey     = bsonutil.MustHaveTag(CallStats{}, "LastError")
+	CallStatsLastErrorTimeKey = bsonutil.MustHaveTag(CallStats{}, "LastErrorTime")
+)
+
+// MeanLatency returns the average time a call took.
+func (self CallStats) MeanLatency() time.Duration {
+	if self.Count == 0 {
+		return 0
+	}
+	return self.TotalLatency / time.Duration(self.Count)
+}
+
+// ErrorRate returns the fraction of calls that failed.
+func (self CallStats) ErrorRate() float64 {
+	if self.Count == 0 {
+		return 0
+	}
+	return float64(self.Errors) / float64(self.Count)
+}
+
+// CallStatsFlushInterval is how often the calls recorded in memory are added
+// to the stats in the database.
+var CallStatsFlushInterval = time.Minute
+
+// pendingCalls are the calls recorded since the last flush, by provider and
+// then by method.
+var pendingCalls = struct {
+	sync.Mutex
+	calls    map[string]map[string]CallStats
+	flushing sync.Once
+}{calls: map[string]map[string]CallStats{}}
+
+// RecordCall adds a call to the method of the provider's manager to its stats.
+// Calls are kept in memory and flushed to the database every
+// CallStatsFlushInterval, so that recording them doesn't add a write to every
+// call to the provider.
+func RecordCall(provider, method string, latency time.Duration, callErr error) {
+	pendingCalls.flushing.Do(func() {
+		go flushPeriodically()
+	})
+
+	pendingCalls.Lock()
+	defer pendingCalls.Unlock()
+	methods, ok := pendingCalls.calls[provider]
+	if !ok {
+		methods = map[string]CallStats{}
+		pendingCalls.calls[provider] = methods
+	}
+	stats := methods[method]
+	stats.Count++
+	stats.TotalLatency += latency
+	if latency > stats.MaxLatency {
+		stats.MaxLatency = latency
+	}
+	if callErr != nil {
+		stats.Errors++
+		stats.LastError = callErr.Error()
+		stats.LastErrorTime = time.Now()
+	}
+	methods[method] = stats
+}
+
+func flushPeriodically() {
+	for range time.Tick(CallStatsFlushInterval) {
+		if err := FlushCalls(); err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Error flushing cloud provider stats: %v", err)
+		}
+	}
+}
+
+// FlushCalls adds the calls recorded since the last flush to the stats in the
+// database. Processes should flush before exiting so no calls are lost.
+func FlushCalls() error {
+	pendingCalls.Lock()
+	calls := pendingCalls.calls
+	pendingCalls.calls = map[string]map[string]CallStats{}
+	pendingCalls.Unlock()
+
+	errs := []string{}
+	for provider, methods := range calls {
+		if err := addCalls(provider, methods); err != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", provider, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("error flushing provider stats: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// addCalls adds the calls to the provider's methods to its stats.
+func addCalls(provider string, methods map[string]CallStats) error {
+	inc := bson.M{}
+	max := bson.M{}
+	set := bson.M{}
+	for method, stats := range methods {
+		key := func(field string) string {
+			return fmt.Sprintf("%v.%v.%v", ProviderStatsCallsKey, method, field)
+		}
+		inc[key(CallStatsCountKey)] = stats.Count
+		inc[key(CallStatsErrorsKey)] = stats.Errors
+		inc[key(CallStatsTotalLatencyKey)] = stats.TotalLatency
+		max[key(CallStatsMaxLatencyKey)] = stats.MaxLatency
+		if stats.LastError != "" {
+			set[key(CallStatsLastErrorKey)] = stats.LastError
+			set[key(CallStatsLastErrorTimeKey)] = stats.LastErrorTime
+		}
+	}
+	update := bson.M{
+		"$setOnInsert": bson.M{ProviderStatsSinceKey: time.Now()},
+		"$inc":         inc,
+		"$max":         max,
+	}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	_, err := db.Upsert(ProviderStatsCollection, bson.M{ProviderStatsIdKey: provider}, update)
+	return err
+}
+
+// FindAllProviderStats returns the stats of every provider that has been called,
+// sorted by provider.
+func FindAllProviderStats() ([]ProviderStats, error) {
+	stats := []ProviderStats{}
+	err := db.FindAllQ(ProviderStatsCollection, db.Query(bson.M{}).Sort([]string{ProviderStatsIdKey}), &stats)
+	if err == mgo.ErrNotFound {
+		return stats, nil
+	}
+	return stats, err
+}
@@ -0,0 +1,163 @@
+package cloudstats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	SpawnBreakersCollection = "spawn_breakers"
+
+	// BreakerThreshold is the number of spawns in a row that must fail for
+	// a distro's breaker to trip
+	BreakerThreshold = 3
+
+	// BreakerBaseBackoff is how long a breaker stays open the first time it
+	// trips. It doubles each time the breaker trips again, up to
+	// BreakerMaxBackoff.
+	BreakerBaseBackoff = time.Minute
+	BreakerMaxBackoff  = 30 * time.Minute
+)
+
+// SpawnBreaker is the circuit breaker that stops hosts being spawned for a
+// distro after its spawns fail repeatedly, so that a provider that's throttling
+// or failing isn't called every scheduler run. Once it has been open for its
+// backoff, one spawn is let through: if it succeeds the breaker closes, and if
+// it fails the breaker opens again for twice as long.
+type SpawnBreaker struct {
+	DistroId            string    `bson:"_id" json:"distro"`
+	ConsecutiveFailures int       `bson:"failures" json:"consecutive_failures"`
+	Trips               int       `bson:"trips" json:"trips"`
+	OpenUntil           time.Time `bson:"open_until,omitempty" json:"open_until,omitempty"`
+	LastError           string    `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LastFailureTime     time.Time `bson:"last_failure_time,omitempty" json:"last_failure_time,omitempty"`
+}
+
+var (
+	// bson fields for the spawn breaker struct
+	SpawnBreakerIdKey = bsonutil.MustHaveTag(SpawnBreaker{}, "DistroId")
+)
+
+// BreakerBackoff returns how long a breaker stays open after tripping for the
+// nth time.
+func BreakerBackoff(trips int) time.Duration {
+	backoff := BreakerBaseBackoff
+	for i := 1; i < trips && backoff < BreakerMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > BreakerMaxBackoff {
+		return BreakerMaxBackoff
+	}
+	return backoff
+}
+
+// IsOpen returns whether spawns for the distro are blocked at the given time.
+func (self *SpawnBreaker) IsOpen(now time.Time) bool {
+	return now.Before(self.OpenUntil)
+}
+
+// Failed records a failed spawn, and returns whether it tripped the breaker.
+func (self *SpawnBreaker) Failed(errMsg string, now time.Time) bool {
+	self.ConsecutiveFailures++
+	self.LastError = errMsg
+	self.LastFailureTime = now
+	// a breaker that has tripped only lets one spawn through before
+	// opening again
+	if self.ConsecutiveFailures < BreakerThreshold && self.Trips == 0 {
+		return false
+	}
+	self.Trips++
+	self.ConsecutiveFailures = 0
+	self.OpenUntil = now.Add(BreakerBackoff(self.Trips))
+	return true
+}
+
+// Succeeded records a successful spawn, and returns whether it closed a breaker
+// that had tripped.
+func (self *SpawnBreaker) Succeeded() bool {
+	closed := self.Trips > 0
+	self.ConsecutiveFailures = 0
+	self.Trips = 0
+	self.OpenUntil = time.Time{}
+	return closed
+}
+
+// FindSpawnBreaker returns the distro's breaker, or nil if its spawns haven't
+// failed.
+func FindSpawnBreaker(distroId string) (*SpawnBreaker, error) {
+	breaker := &SpawnBreaker{}
+	err := db.FindOneQ(SpawnBreakersCollection, db.Query(bson.M{SpawnBreakerIdKey: distroId}), breaker)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return breaker, err
+}
+
+// FindAllSpawnBreakers returns the breakers of every distro whose spawns have
+// failed, sorted by distro.
+func FindAllSpawnBreakers() ([]SpawnBreaker, error) {
+	breakers := []SpawnBreaker{}
+	err := db.FindAllQ(SpawnBreakersCollection, db.Query(bson.M{}).Sort([]string{SpawnBreakerIdKey}), &breakers)
+	if err == mgo.ErrNotFound {
+		return breakers, nil
+	}
+	return breakers, err
+}
+
+// RecordSpawnFailure records a failed spawn for the distro, and returns its
+// breaker and whether the failure tripped it.
+func RecordSpawnFailure(distroId, errMsg string) (*SpawnBreaker, bool, error) {
+	breaker, err := FindSpawnBreaker(distroId)
+	if err != nil {
+		return nil, false, err
+	}
+	if breaker == nil {
+		breaker = &SpawnBreaker{DistroId: distroId}
+	}
+	tripped := breaker.Failed(errMsg, time.Now())
+	return breaker, tripped, breaker.upsert()
+}
+
+// RecordSpawnSuccess records a successful spawn for the distro, and returns
+// whether it closed the distro's breaker.
+func RecordSpawnSuccess(distroId string) (bool, error) {
+	breaker, err := FindSpawnBreaker(distroId)
+	if err != nil || breaker == nil {
+		return false, err
+	}
+	if breaker.ConsecutiveFailures == 0 && breaker.Trips == 0 {
+		return false, nil
+	}
+	closed := breaker.Succeeded()
+	return closed, breaker.upsert()
+}
+
+func (self *SpawnBreaker) upsert() error {
+	_, err := db.Upsert(SpawnBreakersCollection, bson.M{SpawnBreakerIdKey: self.DistroId}, self)
+	return err
+}
+
+// BreakerOpenError is returned instead of spawning a host for a distro whose
+// breaker is open.
+type BreakerOpenError struct {
+	DistroId  string
+	OpenUntil time.Time
+	LastError string
+}
+
+func (self *BreakerOpenError) Error() string {
+	return fmt.Sprintf("not spawning hosts for distro '%v' until %v after repeated failures (last error: %v)",
+		self.DistroId, self.OpenUntil.Format(time.RFC3339), self.LastError)
+}
+
+// IsBreakerOpen returns whether the error is from spawning a host for a distro
+// whose breaker is open.
+func IsBreakerOpen(err error) bool {
+	_, ok := err.(*BreakerOpenError)
+	return ok
+}
@@ -0,0 +1,52 @@
+package cloudstats
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSpawnBreaker(t *testing.T) {
+	Convey("With a distro's spawn breaker", t, func() {
+		now := time.Now()
+		breaker := &SpawnBreaker{DistroId: "d1"}
+
+		Convey("it should trip after repeated failures", func() {
+			So(breaker.Failed("throttled", now), ShouldBeFalse)
+			So(breaker.Failed("throttled", now), ShouldBeFalse)
+			So(breaker.IsOpen(now), ShouldBeFalse)
+			So(breaker.Failed("throttled", now), ShouldBeTrue)
+			So(breaker.IsOpen(now), ShouldBeTrue)
+			So(breaker.IsOpen(now.Add(BreakerBaseBackoff)), ShouldBeFalse)
+			So(breaker.LastError, ShouldEqual, "throttled")
+		})
+
+		Convey("a success should reset the failures", func() {
+			breaker.Failed("throttled", now)
+			breaker.Failed("throttled", now)
+			So(breaker.Succeeded(), ShouldBeFalse)
+			So(breaker.Failed("throttled", now), ShouldBeFalse)
+		})
+
+		Convey("once tripped, one failure should trip it again for longer", func() {
+			for i := 0; i < BreakerThreshold; i++ {
+				breaker.Failed("throttled", now)
+			}
+			later := now.Add(BreakerBaseBackoff)
+			So(breaker.Failed("throttled", later), ShouldBeTrue)
+			So(breaker.OpenUntil, ShouldResemble, later.Add(2*BreakerBaseBackoff))
+
+			So(breaker.Succeeded(), ShouldBeTrue)
+			So(breaker.IsOpen(later), ShouldBeFalse)
+			So(breaker.Failed("throttled", later), ShouldBeFalse)
+		})
+
+		Convey("the backoff should double up to its maximum", func() {
+			So(BreakerBackoff(1), ShouldEqual, BreakerBaseBackoff)
+			So(BreakerBackoff(2), ShouldEqual, 2*BreakerBaseBackoff)
+			So(BreakerBackoff(3), ShouldEqual, 4*BreakerBaseBackoff)
+			So(BreakerBackoff(100), ShouldEqual, BreakerMaxBackoff)
+		})
+	})
+}
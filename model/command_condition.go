@@ -0,0 +1,368 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/command"
+)
+
+// Conditions are the "if" clauses of commands and function calls in a project
+// file. A command only runs if its condition holds for the task's expansions.
+// Formally, we define the syntax as:
+//   Condition  := Or
+//   Or         := And ("||" And)*
+//   And        := Unary ("&&" Unary)*
+//   Unary      := "!" Unary | "(" Condition ")" | "exists" Expansion | Comparison
+//   Comparison := Operand (("==" | "!=" | "=~" | "!~") Operand)?
+//   Operand    := Expansion | <quoted string> | <word>
+//   Expansion  := "${" <name> ("|" <default>)? "}"
+//
+// "=~" and "!~" match the left operand against the regular expression on the
+// right. An operand on its own holds if its value is neither empty nor "false",
+// and "exists" holds if the expansion is set, even to an empty value.
+//
+// For example:
+//   `${is_patch} == "true"` holds for patches
+//   `!exists ${skip_upload} && ${build_variant} =~ "^linux-"` holds on linux
+//      variants unless skip_upload is set
+//   `${run_slow_tests}` holds if run_slow_tests is set to anything but "false"
+
+// Condition is a parsed "if" clause.
+type Condition interface {
+	// Eval returns whether the condition holds for the expansions.
+	Eval(expansions *command.Expansions) (bool, error)
+}
+
+// ParseCondition parses a command's "if" clause. This function only parses;
+// it does not evaluate.
+func ParseCondition(s string) (Condition, error) {
+	tokens, err := tokenizeCondition(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("condition is empty")
+	}
+	p := &conditionParser{tokens: tokens}
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%v' in condition", p.tokens[p.pos].text)
+	}
+	return cond, nil
+}
+
+// EvalCondition parses and evaluates a condition. The empty condition holds.
+func EvalCondition(s string, expansions *command.Expansions) (bool, error) {
+	if strings.TrimSpace(s) == "" {
+		return true, nil
+	}
+	cond, err := ParseCondition(s)
+	if err != nil {
+		return false, err
+	}
+	return cond.Eval(expansions)
+}
+
+type conditionTokenType int
+
+const (
+	tokenOperator conditionTokenType = iota
+	tokenExpansion
+	tokenString
+	tokenWord
+)
+
+type conditionToken struct {
+	tokenType conditionTokenType
+	text      string
+}
+
+// conditionOperators are checked in order, so longer operators come first.
+var conditionOperators = []string{"&&", "||", "==", "!=", "=~", "!~", "!", "(", ")"}
+
+func tokenizeCondition(s string) ([]conditionToken, error) {
+	tokens := []conditionToken{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case strings.HasPrefix(s[i:], "${"):
+			end := strings.Index(s[i:], "}")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated expansion in condition: %v", s[i:])
+			}
+			tokens = append(tokens, conditionToken{tokenExpansion, s[i : i+end+1]})
+			i += end + 1
+		case c == '"' || c == '\'':
+			literal, n, err := readQuoted(s[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, conditionToken{tokenString, literal})
+			i += n
+		default:
+			operator := ""
+			for _, op := range conditionOperators {
+				if strings.HasPrefix(s[i:], op) {
+					operator = op
+					break
+				}
+			}
+			if operator != "" {
+				tokens = append(tokens, conditionToken{tokenOperator, operator})
+				i += len(operator)
+				continue
+			}
+			start := i
+			for i < len(s) && isWordByte(s[i]) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected character '%c' in condition", c)
+			}
+			tokens = append(tokens, conditionToken{tokenWord, s[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		strings.IndexByte("_-./", c) >= 0
+}
+
+// readQuoted reads a quoted string from the start of s, returning its contents
+// and the number of bytes read. A backslash escapes the next character.
+func readQuoted(s string) (string, int, error) {
+	quote := s[0]
+	contents := []byte{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				contents = append(contents, s[i])
+			}
+		case quote:
+			return string(contents), i + 1, nil
+		default:
+			contents = append(contents, s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string in condition: %v", s)
+}
+
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+// peekOperator returns whether the next token is the operator.
+func (p *conditionParser) peekOperator(op string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].tokenType == tokenOperator && p.tokens[p.pos].text == op
+}
+
+func (p *conditionParser) parseOr() (Condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("||") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCondition{left, right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (Condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekOperator("&&") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andCondition{left, right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseUnary() (Condition, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("condition ends unexpectedly")
+	}
+	switch {
+	case p.peekOperator("!"):
+		p.pos++
+		cond, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{cond}, nil
+	case p.peekOperator("("):
+		p.pos++
+		cond, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekOperator(")") {
+			return nil, fmt.Errorf("missing ')' in condition")
+		}
+		p.pos++
+		return cond, nil
+	case p.tokens[p.pos] == conditionToken{tokenWord, "exists"}:
+		p.pos++
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].tokenType != tokenExpansion {
+			return nil, fmt.Errorf("'exists' must be followed by an expansion")
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(p.tokens[p.pos].text, "${"), "}")
+		if idx := strings.Index(name, "|"); idx >= 0 {
+			name = name[:idx]
+		}
+		p.pos++
+		return existsCondition{name}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (Condition, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "=~", "!~"} {
+		if !p.peekOperator(op) {
+			continue
+		}
+		p.pos++
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		cond := comparisonCondition{left: left, op: op, right: right}
+		// check regular expressions up front, if they don't depend on expansions
+		if (op == "=~" || op == "!~") && !right.expansion {
+			if cond.regex, err = regexp.Compile(right.text); err != nil {
+				return nil, fmt.Errorf("invalid regular expression '%v' in condition: %v", right.text, err)
+			}
+		}
+		return cond, nil
+	}
+	return truthyCondition{left}, nil
+}
+
+func (p *conditionParser) parseOperand() (conditionOperand, error) {
+	if p.pos >= len(p.tokens) {
+		return conditionOperand{}, fmt.Errorf("condition ends unexpectedly")
+	}
+	token := p.tokens[p.pos]
+	if token.tokenType == tokenOperator {
+		return conditionOperand{}, fmt.Errorf("unexpected '%v' in condition", token.text)
+	}
+	p.pos++
+	return conditionOperand{text: token.text, expansion: token.tokenType == tokenExpansion}, nil
+}
+
+// conditionOperand is a literal or an expansion.
+type conditionOperand struct {
+	text      string
+	expansion bool
+}
+
+func (o conditionOperand) value(expansions *command.Expansions) (string, error) {
+	if !o.expansion {
+		return o.text, nil
+	}
+	return expansions.ExpandString(o.text)
+}
+
+type orCondition struct{ left, right Condition }
+
+func (c orCondition) Eval(expansions *command.Expansions) (bool, error) {
+	left, err := c.left.Eval(expansions)
+	if err != nil || left {
+		return left, err
+	}
+	return c.right.Eval(expansions)
+}
+
+type andCondition struct{ left, right Condition }
+
+func (c andCondition) Eval(expansions *command.Expansions) (bool, error) {
+	left, err := c.left.Eval(expansions)
+	if err != nil || !left {
+		return false, err
+	}
+	return c.right.Eval(expansions)
+}
+
+type notCondition struct{ cond Condition }
+
+func (c notCondition) Eval(expansions *command.Expansions) (bool, error) {
+	result, err := c.cond.Eval(expansions)
+	return !result, err
+}
+
+type existsCondition struct{ name string }
+
+func (c existsCondition) Eval(expansions *command.Expansions) (bool, error) {
+	return expansions.Exists(c.name), nil
+}
+
+type truthyCondition struct{ operand conditionOperand }
+
+func (c truthyCondition) Eval(expansions *command.Expansions) (bool, error) {
+	value, err := c.operand.value(expansions)
+	if err != nil {
+		return false, err
+	}
+	return value != "" && value != "false", nil
+}
+
+type comparisonCondition struct {
+	left  conditionOperand
+	op    string
+	right conditionOperand
+	regex *regexp.Regexp
+}
+
+func (c comparisonCondition) Eval(expansions *command.Expansions) (bool, error) {
+	left, err := c.left.value(expansions)
+	if err != nil {
+		return false, err
+	}
+	right, err := c.right.value(expansions)
+	if err != nil {
+		return false, err
+	}
+	switch c.op {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	}
+	regex := c.regex
+	if regex == nil {
+		if regex, err = regexp.Compile(right); err != nil {
+			return false, fmt.Errorf("invalid regular expression '%v' in condition: %v", right, err)
+		}
+	}
+	matched := regex.MatchString(left)
+	if c.op == "!~" {
+		return !matched, nil
+	}
+	return matched, nil
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/command"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// helper for checking whether a condition holds for the expansions
+func conditionShouldEval(s string, expansions *command.Expansions, expected bool) {
+	Convey(fmt.Sprintf(`condition "%v" should evaluate to %v`, s, expected), func() {
+		result, err := EvalCondition(s, expansions)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, expected)
+	})
+}
+
+func TestConditionParsing(t *testing.T) {
+	Convey("With a set of malformed conditions", t, func() {
+		for _, s := range []string{
+			`${is_patch} ==`,
+			`== "true"`,
+			`(${a} == "b"`,
+			`${a} == "b")`,
+			`${a} && || ${b}`,
+			`exists "a"`,
+			`${a} == "b`,
+			`${a == "b"`,
+			`${a} =~ "["`,
+			`${a} $ ${b}`,
+		} {
+			Convey(fmt.Sprintf(`condition "%v" should not parse`, s), func() {
+				_, err := ParseCondition(s)
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestConditionEvaluation(t *testing.T) {
+	Convey("With a set of expansions", t, func() {
+		expansions := command.NewExpansions(map[string]string{
+			"is_patch":      "true",
+			"build_variant": "linux-64",
+			"empty":         "",
+			"disabled":      "false",
+		})
+
+		Convey("the empty condition should hold", func() {
+			conditionShouldEval("", expansions, true)
+			conditionShouldEval("  ", expansions, true)
+		})
+
+		Convey("comparisons should compare expanded values", func() {
+			conditionShouldEval(`${is_patch} == "true"`, expansions, true)
+			conditionShouldEval(`${is_patch} != true`, expansions, false)
+			conditionShouldEval(`${missing|default} == 'default'`, expansions, true)
+			conditionShouldEval(`${build_variant} =~ "^linux-"`, expansions, true)
+			conditionShouldEval(`${build_variant} !~ "^windows-"`, expansions, true)
+			conditionShouldEval(`"linux" =~ ${build_variant}`, expansions, false)
+		})
+
+		Convey("exists should hold for set expansions, even empty ones", func() {
+			conditionShouldEval(`exists ${empty}`, expansions, true)
+			conditionShouldEval(`exists ${missing}`, expansions, false)
+			conditionShouldEval(`!exists ${missing}`, expansions, true)
+			conditionShouldEval(`"exists" == exists`, expansions, true)
+		})
+
+		Convey("operands on their own should hold unless empty or false", func() {
+			conditionShouldEval(`${is_patch}`, expansions, true)
+			conditionShouldEval(`${empty}`, expansions, false)
+			conditionShouldEval(`${disabled}`, expansions, false)
+			conditionShouldEval(`${missing}`, expansions, false)
+		})
+
+		Convey("boolean operators should follow precedence and parentheses", func() {
+			conditionShouldEval(`${is_patch} && ${empty}`, expansions, false)
+			conditionShouldEval(`${is_patch} || ${empty}`, expansions, true)
+			conditionShouldEval(`${empty} && ${empty} || ${is_patch}`, expansions, true)
+			conditionShouldEval(`${empty} && (${empty} || ${is_patch})`, expansions, false)
+			conditionShouldEval(`!(${is_patch} == "true") || !${disabled}`, expansions, true)
+		})
+	})
+}
+
+func TestConditionsHold(t *testing.T) {
+	Convey("With a project with a function that takes a param", t, func() {
+		p := &Project{Functions: map[string]*YAMLCommandSet{
+			"deploy": {Params: []FunctionParam{{Name: "target", Default: "staging"}}},
+		}}
+		expansions := command.NewExpansions(map[string]string{"is_patch": "true"})
+		parsed := PluginCommandConf{Command: "shell.exec", If: "${target} == prod"}
+
+		Convey("the commands of a call should see its vars", func() {
+			call := PluginCommandConf{Function: "deploy", Vars: map[string]string{"target": "prod"}}
+			run, _, err := p.ConditionsHold(expansions, call, parsed)
+			So(err, ShouldBeNil)
+			So(run, ShouldBeTrue)
+			So(expansions.Exists("target"), ShouldBeFalse)
+		})
+
+		Convey("the defaults of params that the call doesn't pass should be used", func() {
+			run, cond, err := p.ConditionsHold(expansions, PluginCommandConf{Function: "deploy"}, parsed)
+			So(err, ShouldBeNil)
+			So(run, ShouldBeFalse)
+			So(cond, ShouldEqual, "${target} == prod")
+		})
+
+		Convey("the call's own condition should be checked first", func() {
+			call := PluginCommandConf{Function: "deploy", Vars: map[string]string{"target": "prod"}, If: "!${is_patch}"}
+			run, cond, err := p.ConditionsHold(expansions, call, parsed)
+			So(err, ShouldBeNil)
+			So(run, ShouldBeFalse)
+			So(cond, ShouldEqual, "!${is_patch}")
+		})
+	})
+}
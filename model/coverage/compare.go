@@ -0,0 +1,129 @@
+package coverage
+
+import (
+	"sort"
+)
+
+// Delta compares the coverage of a task in a base version and in a patch. Percentages
+// are nil when the task did not report coverage on that side.
+type Delta struct {
+	TaskName     string      `json:"task_name"`
+	BuildVariant string      `json:"build_variant"`
+	BasePercent  *float64    `json:"base_percent"`
+	PatchPercent *float64    `json:"patch_percent"`
+	Delta        float64     `json:"delta"`
+	Files        []FileDelta `json:"files,omitempty"`
+}
+
+// FileDelta compares the coverage of one file. Only files whose coverage changed are
+// included in a Delta.
+type FileDelta struct {
+	Name         string   `json:"name"`
+	BasePercent  *float64 `json:"base_percent"`
+	PatchPercent *float64 `json:"patch_percent"`
+	Delta        float64  `json:"delta"`
+}
+
+// Compare matches the coverage of each task in the patch with the same task on the same
+// variant in the base version, and returns the differences sorted by variant and task.
+func Compare(base, patch []TaskCoverage) []Delta {
+	type taskKey struct{ buildVariant, taskName string }
+	baseByTask := map[taskKey]*TaskCoverage{}
+	for i := range base {
+		baseByTask[taskKey{base[i].BuildVariant, base[i].TaskName}] = &base[i]
+	}
+	patchByTask := map[taskKey]*TaskCoverage{}
+	for i := range patch {
+		patchByTask[taskKey{patch[i].BuildVariant, patch[i].TaskName}] = &patch[i]
+	}
+
+	deltas := []Delta{}
+	for key, patchCov := range patchByTask {
+		deltas = append(deltas, compareTask(baseByTask[key], patchCov))
+	}
+	for key, baseCov := range baseByTask {
+		if patchByTask[key] == nil {
+			deltas = append(deltas, compareTask(baseCov, nil))
+		}
+	}
+	sort.Sort(deltasByTask(deltas))
+	return deltas
+}
+
+// compareTask compares the coverage of one task, either side of which may be nil.
+func compareTask(base, patch *TaskCoverage) Delta {
+	delta := Delta{}
+	baseFiles, patchFiles := map[string]FileCoverage{}, map[string]FileCoverage{}
+	if base != nil {
+		delta.TaskName, delta.BuildVariant = base.TaskName, base.BuildVariant
+		delta.BasePercent = percentPtr(base.Covered, base.Total)
+		for _, f := range base.Files {
+			baseFiles[f.Name] = f
+		}
+	}
+	if patch != nil {
+		delta.TaskName, delta.BuildVariant = patch.TaskName, patch.BuildVariant
+		delta.PatchPercent = percentPtr(patch.Covered, patch.Total)
+		for _, f := range patch.Files {
+			patchFiles[f.Name] = f
+		}
+	}
+	delta.Delta = difference(delta.BasePercent, delta.PatchPercent)
+
+	names := map[string]bool{}
+	for name := range baseFiles {
+		names[name] = true
+	}
+	for name := range patchFiles {
+		names[name] = true
+	}
+	for name := range names {
+		fd := FileDelta{Name: name}
+		if f, ok := baseFiles[name]; ok {
+			fd.BasePercent = percentPtr(f.Covered, f.Total)
+		}
+		if f, ok := patchFiles[name]; ok {
+			fd.PatchPercent = percentPtr(f.Covered, f.Total)
+		}
+		fd.Delta = difference(fd.BasePercent, fd.PatchPercent)
+		if fd.BasePercent == nil || fd.PatchPercent == nil || fd.Delta != 0 {
+			delta.Files = append(delta.Files, fd)
+		}
+	}
+	sort.Sort(fileDeltasByName(delta.Files))
+	return delta
+}
+
+func percentPtr(covered, total int) *float64 {
+	p := Percent(covered, total)
+	return &p
+}
+
+// difference returns patch - base, treating a missing side as 0% covered.
+func difference(base, patch *float64) float64 {
+	var b, p float64
+	if base != nil {
+		b = *base
+	}
+	if patch != nil {
+		p = *patch
+	}
+	return p - b
+}
+
+type deltasByTask []Delta
+
+func (d deltasByTask) Len() int      { return len(d) }
+func (d deltasByTask) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d deltasByTask) Less(i, j int) bool {
+	if d[i].BuildVariant != d[j].BuildVariant {
+		return d[i].BuildVariant < d[j].BuildVariant
+	}
+	return d[i].TaskName < d[j].TaskName
+}
+
+type fileDeltasByName []FileDelta
+
+func (f fileDeltasByName) Len() int           { return len(f) }
+func (f fileDeltasByName) Less(i, j int) bool { return f[i].Name < f[j].Name }
+func (f fileDeltasByName) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
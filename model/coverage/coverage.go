@@ -0,0 +1,154 @@
+package coverage
+
+import (
+	"sort"
+)
+
+const Collection = "task_coverage"
+
+// FileCoverage is the coverage of a single source file. Covered and Total count lines,
+// or statements for Go coverage profiles.
+type FileCoverage struct {
+	Name    string `json:"name" bson:"name"`
+	Package string `json:"package" bson:"package"`
+	Covered int    `json:"covered" bson:"covered"`
+	Total   int    `json:"total" bson:"total"`
+
+	// Lines is the coverage of each line of the file, which is kept so reports that
+	// cover the same file can be merged. It's left out when showing coverage.
+	Lines []LineCoverage `json:"lines,omitempty" bson:"lines,omitempty"`
+}
+
+// LineCoverage is the coverage of a line, or of a block of statements in Go coverage
+// profiles. Weight is the number of lines or statements it counts for.
+type LineCoverage struct {
+	Line   string `json:"line" bson:"line"`
+	Weight int    `json:"weight" bson:"weight"`
+	Hits   int    `json:"hits" bson:"hits"`
+}
+
+// NewFileCoverage returns the coverage of a file from the coverage of its lines.
+func NewFileCoverage(name, pkg string, lines []LineCoverage) FileCoverage {
+	f := FileCoverage{Name: name, Package: pkg, Lines: lines}
+	sort.Sort(linesByLine(f.Lines))
+	for _, line := range f.Lines {
+		f.Total += line.Weight
+		if line.Hits > 0 {
+			f.Covered += line.Weight
+		}
+	}
+	return f
+}
+
+// merge returns the coverage of the file reported by both f and other. The hits of lines
+// in both are added together. If either doesn't have the coverage of its lines, other
+// replaces f.
+func (f FileCoverage) merge(other FileCoverage) FileCoverage {
+	if len(f.Lines) == 0 || len(other.Lines) == 0 {
+		return other
+	}
+	byLine := map[string]LineCoverage{}
+	for _, line := range f.Lines {
+		byLine[line.Line] = line
+	}
+	for _, line := range other.Lines {
+		existing := byLine[line.Line]
+		line.Hits += existing.Hits
+		byLine[line.Line] = line
+	}
+	lines := make([]LineCoverage, 0, len(byLine))
+	for _, line := range byLine {
+		lines = append(lines, line)
+	}
+	return NewFileCoverage(other.Name, other.Package, lines)
+}
+
+// PackageCoverage is the coverage of all the files in a package or directory.
+type PackageCoverage struct {
+	Name    string `json:"name" bson:"name"`
+	Covered int    `json:"covered" bson:"covered"`
+	Total   int    `json:"total" bson:"total"`
+}
+
+// TaskCoverage stores the coverage reported by the latest execution of a task.
+// Coverage reported by several commands in the same task is merged by file.
+type TaskCoverage struct {
+	TaskId       string            `json:"task_id" bson:"_id"`
+	Execution    int               `json:"execution" bson:"execution"`
+	TaskName     string            `json:"task_name" bson:"task_name"`
+	BuildVariant string            `json:"build_variant" bson:"build_variant"`
+	BuildId      string            `json:"build_id" bson:"build_id"`
+	Version      string            `json:"version" bson:"version"`
+	Project      string            `json:"project" bson:"project"`
+	Covered      int               `json:"covered" bson:"covered"`
+	Total        int               `json:"total" bson:"total"`
+	Percent      float64           `json:"percent" bson:"percent"`
+	Packages     []PackageCoverage `json:"packages" bson:"packages"`
+	Files        []FileCoverage    `json:"files" bson:"files"`
+}
+
+// Percent returns the percentage of covered lines, or 0 if there are none.
+func Percent(covered, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(covered) / float64(total)
+}
+
+// AddFiles merges the coverage of the given files into the task's coverage, adding up
+// the hits of each line of files that were already covered, and recomputes the package
+// and task totals.
+func (tc *TaskCoverage) AddFiles(files []FileCoverage) {
+	byName := map[string]FileCoverage{}
+	for _, f := range tc.Files {
+		byName[f.Name] = f
+	}
+	for _, f := range files {
+		if existing, ok := byName[f.Name]; ok {
+			f = existing.merge(f)
+		}
+		byName[f.Name] = f
+	}
+
+	tc.Files = make([]FileCoverage, 0, len(byName))
+	packages := map[string]*PackageCoverage{}
+	tc.Covered, tc.Total = 0, 0
+	for _, f := range byName {
+		tc.Files = append(tc.Files, f)
+		pkg := packages[f.Package]
+		if pkg == nil {
+			pkg = &PackageCoverage{Name: f.Package}
+			packages[f.Package] = pkg
+		}
+		pkg.Covered += f.Covered
+		pkg.Total += f.Total
+		tc.Covered += f.Covered
+		tc.Total += f.Total
+	}
+	sort.Sort(filesByName(tc.Files))
+
+	tc.Packages = make([]PackageCoverage, 0, len(packages))
+	for _, pkg := range packages {
+		tc.Packages = append(tc.Packages, *pkg)
+	}
+	sort.Sort(packagesByName(tc.Packages))
+	tc.Percent = Percent(tc.Covered, tc.Total)
+}
+
+type filesByName []FileCoverage
+
+func (f filesByName) Len() int           { return len(f) }
+func (f filesByName) Less(i, j int) bool { return f[i].Name < f[j].Name }
+func (f filesByName) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+type linesByLine []LineCoverage
+
+func (l linesByLine) Len() int           { return len(l) }
+func (l linesByLine) Less(i, j int) bool { return l[i].Line < l[j].Line }
+func (l linesByLine) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+type packagesByName []PackageCoverage
+
+func (p packagesByName) Len() int           { return len(p) }
+func (p packagesByName) Less(i, j int) bool { return p[i].Name < p[j].Name }
+func (p packagesByName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
@@ -0,0 +1,113 @@
+package coverage
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAddFiles(t *testing.T) {
+	Convey("With a task's coverage", t, func() {
+		tc := &TaskCoverage{}
+		tc.AddFiles([]FileCoverage{
+			{Name: "a/x.go", Package: "a", Covered: 1, Total: 4},
+			{Name: "a/y.go", Package: "a", Covered: 3, Total: 4},
+			{Name: "b/z.go", Package: "b", Covered: 0, Total: 2},
+		})
+
+		Convey("totals should be computed by package and for the task", func() {
+			So(tc.Covered, ShouldEqual, 4)
+			So(tc.Total, ShouldEqual, 10)
+			So(tc.Percent, ShouldEqual, 40)
+			So(tc.Packages, ShouldResemble, []PackageCoverage{
+				{Name: "a", Covered: 4, Total: 8},
+				{Name: "b", Covered: 0, Total: 2},
+			})
+		})
+
+		Convey("later reports without line coverage should replace the coverage of the same files", func() {
+			tc.AddFiles([]FileCoverage{{Name: "b/z.go", Package: "b", Covered: 2, Total: 2}})
+			So(len(tc.Files), ShouldEqual, 3)
+			So(tc.Covered, ShouldEqual, 6)
+			So(tc.Percent, ShouldEqual, 60)
+		})
+	})
+
+	Convey("With two reports that cover the same file", t, func() {
+		tc := &TaskCoverage{}
+		tc.AddFiles([]FileCoverage{
+			NewFileCoverage("a/x.go", "a", []LineCoverage{
+				{Line: "1", Weight: 1, Hits: 2},
+				{Line: "2", Weight: 1, Hits: 0},
+				{Line: "3", Weight: 2, Hits: 0},
+			}),
+			NewFileCoverage("a/y.go", "a", []LineCoverage{{Line: "1", Weight: 1, Hits: 0}}),
+		})
+		So(tc.Covered, ShouldEqual, 1)
+		So(tc.Total, ShouldEqual, 5)
+
+		tc.AddFiles([]FileCoverage{
+			NewFileCoverage("a/x.go", "a", []LineCoverage{
+				{Line: "2", Weight: 1, Hits: 0},
+				{Line: "3", Weight: 2, Hits: 1},
+				{Line: "4", Weight: 1, Hits: 1},
+			}),
+		})
+
+		Convey("the hits of each line should be added up", func() {
+			So(len(tc.Files), ShouldEqual, 2)
+			So(tc.Files[0].Lines, ShouldResemble, []LineCoverage{
+				{Line: "1", Weight: 1, Hits: 2},
+				{Line: "2", Weight: 1, Hits: 0},
+				{Line: "3", Weight: 2, Hits: 1},
+				{Line: "4", Weight: 1, Hits: 1},
+			})
+		})
+
+		Convey("a line covered by either report should count as covered", func() {
+			So(tc.Files[0].Covered, ShouldEqual, 4)
+			So(tc.Files[0].Total, ShouldEqual, 5)
+			So(tc.Packages, ShouldResemble, []PackageCoverage{{Name: "a", Covered: 4, Total: 6}})
+			So(tc.Covered, ShouldEqual, 4)
+			So(tc.Total, ShouldEqual, 6)
+		})
+	})
+}
+
+func TestCompare(t *testing.T) {
+	Convey("When comparing a patch's coverage with its base version", t, func() {
+		base := []TaskCoverage{
+			{TaskName: "unit", BuildVariant: "linux", Covered: 5, Total: 10, Files: []FileCoverage{
+				{Name: "x.go", Covered: 5, Total: 5},
+				{Name: "y.go", Covered: 0, Total: 5},
+			}},
+			{TaskName: "lint", BuildVariant: "linux", Covered: 1, Total: 1},
+		}
+		patch := []TaskCoverage{
+			{TaskName: "unit", BuildVariant: "linux", Covered: 8, Total: 12, Files: []FileCoverage{
+				{Name: "x.go", Covered: 5, Total: 5},
+				{Name: "y.go", Covered: 2, Total: 5},
+				{Name: "z.go", Covered: 1, Total: 2},
+			}},
+		}
+		deltas := Compare(base, patch)
+		So(len(deltas), ShouldEqual, 2)
+
+		Convey("tasks only in the base version should be reported without patch coverage", func() {
+			So(deltas[0].TaskName, ShouldEqual, "lint")
+			So(deltas[0].PatchPercent, ShouldBeNil)
+			So(deltas[0].Delta, ShouldEqual, -100)
+		})
+
+		Convey("only files whose coverage changed should be reported", func() {
+			unit := deltas[1]
+			So(*unit.BasePercent, ShouldEqual, 50)
+			So(unit.Delta, ShouldAlmostEqual, 100.0*8/12-50)
+			So(len(unit.Files), ShouldEqual, 2)
+			So(unit.Files[0].Name, ShouldEqual, "y.go")
+			So(unit.Files[0].Delta, ShouldEqual, 40)
+			So(unit.Files[1].Name, ShouldEqual, "z.go")
+			So(unit.Files[1].BasePercent, ShouldBeNil)
+		})
+	})
+}
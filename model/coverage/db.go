@@ -0,0 +1,63 @@
+package coverage
+
+import (
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	// BSON fields for task coverage structs
+	TaskIdKey       = bsonutil.MustHaveTag(TaskCoverage{}, "TaskId")
+	ExecutionKey    = bsonutil.MustHaveTag(TaskCoverage{}, "Execution")
+	TaskNameKey     = bsonutil.MustHaveTag(TaskCoverage{}, "TaskName")
+	BuildVariantKey = bsonutil.MustHaveTag(TaskCoverage{}, "BuildVariant")
+	BuildIdKey      = bsonutil.MustHaveTag(TaskCoverage{}, "BuildId")
+	VersionKey      = bsonutil.MustHaveTag(TaskCoverage{}, "Version")
+	ProjectKey      = bsonutil.MustHaveTag(TaskCoverage{}, "Project")
+	FilesKey        = bsonutil.MustHaveTag(TaskCoverage{}, "Files")
+
+	// BSON fields for file coverage structs
+	FileLinesKey = bsonutil.MustHaveTag(FileCoverage{}, "Lines")
+)
+
+// === Queries ===
+
+// ByTaskId returns a query for the coverage of the given task.
+func ByTaskId(id string) db.Q {
+	return db.Query(bson.M{TaskIdKey: id})
+}
+
+// ByVersion returns a query for the coverage of all tasks in a version, sorted by
+// variant and task name. The coverage of the files' lines is left out.
+func ByVersion(versionId string) db.Q {
+	return db.Query(bson.M{VersionKey: versionId}).
+		Sort([]string{BuildVariantKey, TaskNameKey}).
+		WithoutFields(FilesKey + "." + FileLinesKey)
+}
+
+// === DB Logic ===
+
+// Upsert saves the task's coverage, overwriting any coverage stored for the task.
+func (tc *TaskCoverage) Upsert() error {
+	_, err := db.Upsert(Collection, bson.M{TaskIdKey: tc.TaskId}, tc)
+	return err
+}
+
+// FindOne gets one TaskCoverage for the given query.
+func FindOne(query db.Q) (*TaskCoverage, error) {
+	tc := &TaskCoverage{}
+	err := db.FindOneQ(Collection, query, tc)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return tc, err
+}
+
+// Find gets every TaskCoverage for the given query.
+func Find(query db.Q) ([]TaskCoverage, error) {
+	coverages := []TaskCoverage{}
+	err := db.FindAllQ(Collection, query, &coverages)
+	return coverages, err
+}
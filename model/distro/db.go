@@ -1,6 +1,8 @@
 package distro
 
 import (
+	"fmt"
+
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/db/bsonutil"
 	"gopkg.in/mgo.v2/bson"
@@ -20,10 +22,15 @@ var (
 	SSHOptionsKey       = bsonutil.MustHaveTag(Distro{}, "SSHOptions")
 	WorkDirKey          = bsonutil.MustHaveTag(Distro{}, "WorkDir")
 
-	UserDataKey = bsonutil.MustHaveTag(Distro{}, "UserData")
+	UserDataKey     = bsonutil.MustHaveTag(Distro{}, "UserData")
+	ProvisioningKey = bsonutil.MustHaveTag(Distro{}, "Provisioning")
 
-	SpawnAllowedKey = bsonutil.MustHaveTag(Distro{}, "SpawnAllowed")
-	ExpansionsKey   = bsonutil.MustHaveTag(Distro{}, "Expansions")
+	SpawnAllowedKey       = bsonutil.MustHaveTag(Distro{}, "SpawnAllowed")
+	ExpansionsKey         = bsonutil.MustHaveTag(Distro{}, "Expansions")
+	WarmPoolKey           = bsonutil.MustHaveTag(Distro{}, "WarmPool")
+	ImageVersionKey       = bsonutil.MustHaveTag(Distro{}, "ImageVersion")
+	LatestImageVersionKey = bsonutil.MustHaveTag(Distro{}, "LatestImageVersion")
+	ImageRolloutKey       = bsonutil.MustHaveTag(Distro{}, "ImageRollout")
 
 	// bson fields for the UserData struct
 	UserDataFileKey     = bsonutil.MustHaveTag(UserData{}, "File")
@@ -58,6 +65,28 @@ func (d *Distro) Update() error {
 	return db.UpdateId(Collection, d.Id, d)
 }
 
+// UpdateImage updates the distro's image version and the image being rolled
+// out to it. If setting is set, the distro's value of that provider setting,
+// which holds its image, is updated too, leaving its other settings as they
+// are, so that edits made to them since the distro was read aren't lost.
+func (d *Distro) UpdateImage(setting string) error {
+	update := bson.M{
+		"$set": bson.M{
+			ImageVersionKey: d.ImageVersion,
+		},
+	}
+	if setting != "" {
+		settingKey := fmt.Sprintf("%v.%v", ProviderSettingsKey, setting)
+		update["$set"].(bson.M)[settingKey] = (*d.ProviderSettings)[setting]
+	}
+	if d.ImageRollout == nil {
+		update["$unset"] = bson.M{ImageRolloutKey: 1}
+	} else {
+		update["$set"].(bson.M)[ImageRolloutKey] = d.ImageRollout
+	}
+	return db.Update(Collection, bson.M{IdKey: d.Id}, update)
+}
+
 // Remove removes one distro.
 func Remove(id string) error {
 	return db.Remove(Collection, bson.D{{IdKey, id}})
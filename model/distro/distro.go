@@ -23,8 +23,19 @@ type Distro struct {
 	SSHOptions  []string `bson:"ssh_options,omitempty" json:"ssh_options,omitempty" mapstructure:"ssh_options,omitempty"`
 	UserData    UserData `bson:"user_data,omitempty" json:"user_data,omitempty" mapstructure:"user_data,omitempty"`
 
+	Provisioning Provisioning `bson:"provisioning,omitempty" json:"provisioning,omitempty" mapstructure:"provisioning,omitempty"`
+
 	SpawnAllowed bool        `bson:"spawn_allowed" json:"spawn_allowed,omitempty" mapstructure:"spawn_allowed,omitempty"`
 	Expansions   []Expansion `bson:"expansions,omitempty" json:"expansions,omitempty" mapstructure:"expansions,omitempty"`
+
+	WarmPool WarmPool `bson:"warm_pool,omitempty" json:"warm_pool,omitempty" mapstructure:"warm_pool,omitempty"`
+
+	// ImageVersion is the version of the image in the provider settings, which
+	// changes each time a rolled out image is promoted, and LatestImageVersion
+	// is the last version given to a rolled out image, even if it was rolled back
+	ImageVersion       int           `bson:"image_version,omitempty" json:"image_version,omitempty" mapstructure:"image_version,omitempty"`
+	LatestImageVersion int           `bson:"latest_image_version,omitempty" json:"latest_image_version,omitempty" mapstructure:"latest_image_version,omitempty"`
+	ImageRollout       *ImageRollout `bson:"image_rollout,omitempty" json:"image_rollout,omitempty" mapstructure:"image_rollout,omitempty"`
 }
 
 type ValidateFormat string
@@ -0,0 +1,111 @@
+package distro
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ImageRollout is a new image being rolled out to a distro's hosts. A fraction
+// of the distro's new hosts are spawned from it, and once enough tasks have run
+// on them it's promoted to the distro's image, or rolled back if its hosts fail
+// tasks more often than hosts spawned from the distro's image.
+type ImageRollout struct {
+	// Setting is the provider setting the image is in, e.g. "ami"
+	Setting string `bson:"setting" json:"setting" mapstructure:"setting"`
+
+	// Image is the new image
+	Image string `bson:"image" json:"image" mapstructure:"image"`
+
+	// Percent is the percentage of the distro's hosts to spawn from the new image
+	Percent int `bson:"percent" json:"percent" mapstructure:"percent"`
+
+	// MinTasks is the number of tasks that must finish on hosts spawned from
+	// the new image before it's promoted or rolled back
+	MinTasks int `bson:"min_tasks" json:"min_tasks" mapstructure:"min_tasks"`
+
+	// MaxFailureRateIncrease is how much higher, from 0 to 1, the failure rate
+	// of tasks on hosts spawned from the new image can be than on other
+	// hosts of the distro before it's rolled back
+	MaxFailureRateIncrease float64 `bson:"max_failure_rate_increase" json:"max_failure_rate_increase" mapstructure:"max_failure_rate_increase"`
+
+	// Version is the image version the new image will have, and StartTime is
+	// when the rollout started. They're set when the rollout starts.
+	Version   int       `bson:"version" json:"version" mapstructure:"version"`
+	StartTime time.Time `bson:"start_time" json:"start_time" mapstructure:"start_time"`
+}
+
+// Validate returns an error if the rollout's settings are invalid.
+func (self *ImageRollout) Validate() error {
+	if self.Setting == "" {
+		return fmt.Errorf("the provider setting of the image must be set")
+	}
+	if strings.ContainsAny(self.Setting, ".$") {
+		return fmt.Errorf("the provider setting of the image can't contain '.' or '$'")
+	}
+	if self.Image == "" {
+		return fmt.Errorf("the new image must be set")
+	}
+	if self.Percent <= 0 || self.Percent > 100 {
+		return fmt.Errorf("percentage of hosts must be between 1 and 100")
+	}
+	if self.MinTasks <= 0 {
+		return fmt.Errorf("minimum tasks must be positive")
+	}
+	if self.MaxFailureRateIncrease < 0 || self.MaxFailureRateIncrease > 1 {
+		return fmt.Errorf("maximum failure rate increase must be between 0 and 1")
+	}
+	return nil
+}
+
+// Start sets the version and start time of a rollout to the distro, if it's a
+// new rollout rather than the distro's existing one, and returns whether it is.
+// New rollouts get a version no earlier rollout has had, so that hosts spawned
+// from a rolled back image aren't counted as spawned from the new one.
+func (self *ImageRollout) Start(d *Distro, existing *ImageRollout) bool {
+	if existing != nil && existing.Setting == self.Setting && existing.Image == self.Image {
+		self.Version = existing.Version
+		self.StartTime = existing.StartTime
+		return false
+	}
+	self.Version = d.ImageVersion + 1
+	if d.LatestImageVersion >= self.Version {
+		self.Version = d.LatestImageVersion + 1
+	}
+	self.StartTime = time.Now()
+	d.LatestImageVersion = self.Version
+	return true
+}
+
+// SpawnFromRollout returns whether the distro's next host should be spawned
+// from the image being rolled out, given how many of its hosts are. Hosts are
+// spawned from it while fewer than the rollout's percentage of the distro's
+// hosts, including the new host, are, so at least one host is.
+func (self *Distro) SpawnFromRollout(rolloutHosts, totalHosts int) bool {
+	if self.ImageRollout == nil {
+		return false
+	}
+	return rolloutHosts*100 < self.ImageRollout.Percent*(totalHosts+1)
+}
+
+// WithRolloutImage returns a copy of the distro with the image being rolled out
+// in its provider settings, to spawn hosts from.
+func (self *Distro) WithRolloutImage() *Distro {
+	d := *self
+	settings := map[string]interface{}{}
+	if self.ProviderSettings != nil {
+		for k, v := range *self.ProviderSettings {
+			settings[k] = v
+		}
+	}
+	settings[self.ImageRollout.Setting] = self.ImageRollout.Image
+	d.ProviderSettings = &settings
+	d.ImageVersion = self.ImageRollout.Version
+	return &d
+}
+
+// PromoteImage makes the image being rolled out the distro's image.
+func (self *Distro) PromoteImage() {
+	*self = *self.WithRolloutImage()
+	self.ImageRollout = nil
+}
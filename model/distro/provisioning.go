@@ -0,0 +1,117 @@
+package distro
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// SetupStepName is the name of the step that runs the distro's setup
+	// script, and ReadyCheckStepName of the one that runs its ready check
+	SetupStepName      = "setup"
+	ReadyCheckStepName = "ready_check"
+)
+
+// Provisioning is the steps run, in order, to set up a distro's new hosts after
+// its setup script, and the command that checks the hosts are then ready to
+// run tasks.
+type Provisioning struct {
+	Steps []ProvisionStep `bson:"steps,omitempty" json:"steps,omitempty" mapstructure:"steps,omitempty"`
+
+	// ReadyCheck is a command that must succeed once the steps have run for
+	// hosts to be provisioned. It's run until it succeeds or ReadyTimeoutSecs
+	// have passed.
+	ReadyCheck       string `bson:"ready_check,omitempty" json:"ready_check,omitempty" mapstructure:"ready_check,omitempty"`
+	ReadyTimeoutSecs int    `bson:"ready_timeout_secs,omitempty" json:"ready_timeout_secs,omitempty" mapstructure:"ready_timeout_secs,omitempty"`
+}
+
+// ProvisionStep is one step of setting up a host: files to upload to it, then a
+// script to run on it.
+type ProvisionStep struct {
+	Name   string          `bson:"name" json:"name" mapstructure:"name"`
+	Files  []ProvisionFile `bson:"files,omitempty" json:"files,omitempty" mapstructure:"files,omitempty"`
+	Script string          `bson:"script,omitempty" json:"script,omitempty" mapstructure:"script,omitempty"`
+	Sudo   bool            `bson:"sudo,omitempty" json:"sudo,omitempty" mapstructure:"sudo,omitempty"`
+
+	// TimeoutSecs is how long the script can run for, or the default SSH
+	// timeout if it's 0
+	TimeoutSecs int `bson:"timeout_secs,omitempty" json:"timeout_secs,omitempty" mapstructure:"timeout_secs,omitempty"`
+
+	// Retries is how many more times the step is run if it fails
+	Retries int `bson:"retries,omitempty" json:"retries,omitempty" mapstructure:"retries,omitempty"`
+}
+
+// ProvisionFile is a file uploaded to hosts. Its content can use the
+// expansions in the Evergreen settings.
+type ProvisionFile struct {
+	// Path is where the file is put on the host, relative to the home
+	// directory of the distro's user if it isn't absolute
+	Path    string `bson:"path" json:"path" mapstructure:"path"`
+	Content string `bson:"content" json:"content" mapstructure:"content"`
+}
+
+// Validate returns an error if the provisioning's settings are invalid.
+func (self *Provisioning) Validate() error {
+	names := map[string]bool{}
+	for i, step := range self.Steps {
+		if step.Name == "" {
+			return fmt.Errorf("step %v: name must be set", i+1)
+		}
+		if step.Name == SetupStepName || step.Name == ReadyCheckStepName {
+			return fmt.Errorf("step %v: name '%v' is reserved", i+1, step.Name)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("step %v: name '%v' is used by another step", i+1, step.Name)
+		}
+		names[step.Name] = true
+		if step.Script == "" && len(step.Files) == 0 {
+			return fmt.Errorf("step '%v': must have a script or files", step.Name)
+		}
+		if step.TimeoutSecs < 0 || step.Retries < 0 {
+			return fmt.Errorf("step '%v': timeout and retries must not be negative", step.Name)
+		}
+		for _, file := range step.Files {
+			if file.Path == "" {
+				return fmt.Errorf("step '%v': file paths must be set", step.Name)
+			}
+		}
+	}
+	if self.ReadyTimeoutSecs < 0 {
+		return fmt.Errorf("ready check timeout must not be negative")
+	}
+	return nil
+}
+
+// ProvisionSteps returns the steps run to provision the distro's hosts: its
+// setup script, if it has one, then its provisioning steps.
+func (self *Distro) ProvisionSteps() []ProvisionStep {
+	steps := make([]ProvisionStep, 0, len(self.Provisioning.Steps)+1)
+	if self.Setup != "" {
+		steps = append(steps, ProvisionStep{
+			Name:   SetupStepName,
+			Script: self.Setup,
+			Sudo:   self.SetupAsSudo,
+		})
+	}
+	return append(steps, self.Provisioning.Steps...)
+}
+
+// ProvisioningBudget returns the longest the provision steps' scripts,
+// including the setup script, and the ready check can run for on a host, given
+// how long scripts that don't set a timeout can run for. Uploads and the delays
+// between retries aren't counted.
+func (self *Distro) ProvisioningBudget(defaultTimeout time.Duration) time.Duration {
+	budget := time.Duration(0)
+	for _, step := range self.ProvisionSteps() {
+		timeout := defaultTimeout
+		if step.TimeoutSecs > 0 {
+			timeout = time.Duration(step.TimeoutSecs) * time.Second
+		}
+		budget += time.Duration(step.Retries+1) * timeout
+	}
+	if self.Provisioning.ReadyCheck != "" {
+		// the last run of the check can start just before its timeout
+		budget += time.Duration(self.Provisioning.ReadyTimeoutSecs)*time.Second + defaultTimeout
+	}
+	return budget
+}
@@ -0,0 +1,37 @@
+package distro
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProvisioningBudget(t *testing.T) {
+	Convey("With provisioning steps and a ready check", t, func() {
+		d := Distro{
+			Provisioning: Provisioning{
+				Steps: []ProvisionStep{
+					{Name: "install", Script: "install", TimeoutSecs: 600, Retries: 2},
+					{Name: "start", Script: "start"},
+				},
+				ReadyCheck:       "check",
+				ReadyTimeoutSecs: 300,
+			},
+		}
+
+		Convey("the budget should cover every attempt of each step and the ready check", func() {
+			So(d.ProvisioningBudget(time.Minute), ShouldEqual, 30*time.Minute+time.Minute+5*time.Minute+time.Minute)
+		})
+
+		Convey("the budget should cover the setup script", func() {
+			d.Setup = "setup"
+			So(d.ProvisioningBudget(time.Minute), ShouldEqual, time.Minute+30*time.Minute+time.Minute+5*time.Minute+time.Minute)
+			So((&Distro{Setup: "setup"}).ProvisioningBudget(time.Minute), ShouldEqual, time.Minute)
+		})
+
+		Convey("there should be no budget without a setup script, steps or a ready check", func() {
+			So((&Distro{}).ProvisioningBudget(time.Minute), ShouldEqual, 0)
+		})
+	})
+}
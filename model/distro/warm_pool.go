@@ -0,0 +1,120 @@
+package distro
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WarmPool is the number of idle hosts the scheduler keeps running for a
+// distro, even when no tasks are queued, so that tasks don't wait for new
+// hosts to boot and be set up.
+type WarmPool struct {
+	// MinIdle is the number of idle hosts to keep when no schedule applies
+	MinIdle int `bson:"min_idle,omitempty" json:"min_idle,omitempty" mapstructure:"min_idle,omitempty"`
+
+	// Schedules override MinIdle at certain times of day. The first that
+	// applies is used.
+	Schedules []WarmPoolSchedule `bson:"schedules,omitempty" json:"schedules,omitempty" mapstructure:"schedules,omitempty"`
+
+	// TimeZone is the IANA time zone the schedules are in, e.g.
+	// America/New_York, or UTC if it's blank
+	TimeZone string `bson:"time_zone,omitempty" json:"time_zone,omitempty" mapstructure:"time_zone,omitempty"`
+
+	// StopIdle stops idle hosts instead of terminating them, if the
+	// provider supports it, so they can be started again faster than new
+	// hosts can be spawned
+	StopIdle bool `bson:"stop_idle,omitempty" json:"stop_idle,omitempty" mapstructure:"stop_idle,omitempty"`
+}
+
+// WarmPoolSchedule is a minimum number of idle hosts for some hours of some
+// days of the week.
+type WarmPoolSchedule struct {
+	// Days are the days the schedule applies on, as "mon" through "sun", or
+	// every day if there are none. A schedule that runs past midnight
+	// applies on the days it starts on.
+	Days []string `bson:"days,omitempty" json:"days,omitempty" mapstructure:"days,omitempty"`
+
+	// StartHour and EndHour are the hours, from 0 to 24, the schedule
+	// starts and ends at. The end hour isn't included.
+	StartHour int `bson:"start_hour" json:"start_hour" mapstructure:"start_hour"`
+	EndHour   int `bson:"end_hour" json:"end_hour" mapstructure:"end_hour"`
+
+	MinIdle int `bson:"min_idle" json:"min_idle" mapstructure:"min_idle"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Validate returns an error if the warm pool's settings are invalid.
+func (self *WarmPool) Validate() error {
+	if self.MinIdle < 0 {
+		return fmt.Errorf("minimum idle hosts must not be negative")
+	}
+	if _, err := time.LoadLocation(self.TimeZone); err != nil {
+		return fmt.Errorf("unknown time zone '%v'", self.TimeZone)
+	}
+	for i, schedule := range self.Schedules {
+		if schedule.MinIdle < 0 {
+			return fmt.Errorf("schedule %v: minimum idle hosts must not be negative", i+1)
+		}
+		if schedule.StartHour < 0 || schedule.StartHour > 24 || schedule.EndHour < 0 || schedule.EndHour > 24 {
+			return fmt.Errorf("schedule %v: hours must be between 0 and 24", i+1)
+		}
+		if schedule.StartHour == schedule.EndHour {
+			return fmt.Errorf("schedule %v: start and end hours must differ", i+1)
+		}
+		for _, day := range schedule.Days {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("schedule %v: unknown day '%v'", i+1, day)
+			}
+		}
+	}
+	return nil
+}
+
+// MinIdleAt returns the number of idle hosts to keep at the given time.
+func (self *WarmPool) MinIdleAt(now time.Time) int {
+	if location, err := time.LoadLocation(self.TimeZone); err == nil {
+		now = now.In(location)
+	}
+	for _, schedule := range self.Schedules {
+		if schedule.appliesAt(now) {
+			return schedule.MinIdle
+		}
+	}
+	return self.MinIdle
+}
+
+// appliesAt returns whether the time is in the schedule.
+func (self *WarmPoolSchedule) appliesAt(now time.Time) bool {
+	day := now.Weekday()
+	hour := now.Hour()
+	if self.StartHour > self.EndHour {
+		// after midnight is part of the previous day's schedule
+		if hour < self.EndHour {
+			return self.onDay((day + 6) % 7)
+		}
+		return hour >= self.StartHour && self.onDay(day)
+	}
+	return hour >= self.StartHour && hour < self.EndHour && self.onDay(day)
+}
+
+func (self *WarmPoolSchedule) onDay(day time.Weekday) bool {
+	if len(self.Days) == 0 {
+		return true
+	}
+	for _, d := range self.Days {
+		if weekday, ok := weekdays[strings.ToLower(d)]; ok && weekday == day {
+			return true
+		}
+	}
+	return false
+}
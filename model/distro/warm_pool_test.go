@@ -0,0 +1,52 @@
+package distro
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWarmPoolSchedules(t *testing.T) {
+	Convey("With a warm pool that's larger on weekdays and overnight", t, func() {
+		pool := WarmPool{
+			MinIdle:  1,
+			TimeZone: "America/New_York",
+			Schedules: []WarmPoolSchedule{
+				{Days: []string{"mon", "tue", "wed", "thu", "fri"}, StartHour: 9, EndHour: 18, MinIdle: 10},
+				{Days: []string{"fri"}, StartHour: 22, EndHour: 6, MinIdle: 4},
+			},
+		}
+		So(pool.Validate(), ShouldBeNil)
+		newYork, err := time.LoadLocation("America/New_York")
+		So(err, ShouldBeNil)
+
+		Convey("schedules should apply on their days and hours in the time zone", func() {
+			// Monday
+			So(pool.MinIdleAt(time.Date(2016, 10, 17, 9, 0, 0, 0, newYork)), ShouldEqual, 10)
+			So(pool.MinIdleAt(time.Date(2016, 10, 17, 18, 0, 0, 0, newYork)), ShouldEqual, 1)
+			So(pool.MinIdleAt(time.Date(2016, 10, 17, 13, 0, 0, 0, time.UTC)), ShouldEqual, 10)
+			So(pool.MinIdleAt(time.Date(2016, 10, 17, 23, 0, 0, 0, time.UTC)), ShouldEqual, 1)
+			// Sunday
+			So(pool.MinIdleAt(time.Date(2016, 10, 16, 12, 0, 0, 0, newYork)), ShouldEqual, 1)
+		})
+
+		Convey("schedules past midnight should apply on the day after they start", func() {
+			So(pool.MinIdleAt(time.Date(2016, 10, 21, 23, 0, 0, 0, newYork)), ShouldEqual, 4)
+			So(pool.MinIdleAt(time.Date(2016, 10, 22, 5, 0, 0, 0, newYork)), ShouldEqual, 4)
+			So(pool.MinIdleAt(time.Date(2016, 10, 22, 6, 0, 0, 0, newYork)), ShouldEqual, 1)
+			So(pool.MinIdleAt(time.Date(2016, 10, 21, 5, 0, 0, 0, newYork)), ShouldEqual, 1)
+		})
+
+		Convey("invalid schedules should be rejected", func() {
+			invalid := pool
+			invalid.Schedules = []WarmPoolSchedule{{Days: []string{"someday"}, StartHour: 1, EndHour: 2}}
+			So(invalid.Validate(), ShouldNotBeNil)
+			invalid.Schedules = []WarmPoolSchedule{{StartHour: 1, EndHour: 25}}
+			So(invalid.Validate(), ShouldNotBeNil)
+			invalid.Schedules = nil
+			invalid.TimeZone = "Nowhere/Special"
+			So(invalid.Validate(), ShouldNotBeNil)
+		})
+	})
+}
@@ -12,9 +12,15 @@ const (
 	ResourceTypeDistro = "DISTRO"
 
 	// event types
-	EventDistroAdded    = "DISTRO_ADDED"
-	EventDistroModified = "DISTRO_MODIFIED"
-	EventDistroRemoved  = "DISTRO_REMOVED"
+	EventDistroAdded               = "DISTRO_ADDED"
+	EventDistroModified            = "DISTRO_MODIFIED"
+	EventDistroRemoved             = "DISTRO_REMOVED"
+	EventDistroSpawnFailed         = "DISTRO_SPAWN_FAILED"
+	EventDistroSpawnBreakerTripped = "DISTRO_SPAWN_BREAKER_TRIPPED"
+	EventDistroSpawnBreakerClosed  = "DISTRO_SPAWN_BREAKER_CLOSED"
+	EventDistroImageRolloutStarted = "DISTRO_IMAGE_ROLLOUT_STARTED"
+	EventDistroImagePromoted       = "DISTRO_IMAGE_PROMOTED"
+	EventDistroImageRolledBack     = "DISTRO_IMAGE_ROLLED_BACK"
 )
 
 // DistroEventData implements EventData.
@@ -55,3 +61,39 @@ func LogDistroModified(distroId, userId string, data interface{}) {
 func LogDistroRemoved(distroId, userId string, data interface{}) {
 	LogDistroEvent(distroId, EventDistroRemoved, DistroEventData{UserId: userId, Data: data})
 }
+
+// LogDistroSpawnFailed records an error returned by the cloud provider while spawning
+// a host for the distro.
+func LogDistroSpawnFailed(distroId, errMsg string) {
+	LogDistroEvent(distroId, EventDistroSpawnFailed, DistroEventData{Data: errMsg})
+}
+
+// LogDistroSpawnBreakerTripped records that spawns for the distro are blocked
+// after failing repeatedly. The data is the distro's breaker.
+func LogDistroSpawnBreakerTripped(distroId string, data interface{}) {
+	LogDistroEvent(distroId, EventDistroSpawnBreakerTripped, DistroEventData{Data: data})
+}
+
+// LogDistroSpawnBreakerClosed records that a spawn for the distro succeeded
+// after its breaker had tripped.
+func LogDistroSpawnBreakerClosed(distroId string) {
+	LogDistroEvent(distroId, EventDistroSpawnBreakerClosed, DistroEventData{})
+}
+
+// LogDistroImageRolloutStarted records that a user started rolling out a new
+// image to the distro. The data is the rollout.
+func LogDistroImageRolloutStarted(distroId, userId string, data interface{}) {
+	LogDistroEvent(distroId, EventDistroImageRolloutStarted, DistroEventData{UserId: userId, Data: data})
+}
+
+// LogDistroImagePromoted records that the image rolled out to the distro became
+// its image. The data is the rollout and the tasks run on each image.
+func LogDistroImagePromoted(distroId string, data interface{}) {
+	LogDistroEvent(distroId, EventDistroImagePromoted, DistroEventData{Data: data})
+}
+
+// LogDistroImageRolledBack records that the image rolled out to the distro was
+// rolled back. The data is the rollout and the tasks run on each image.
+func LogDistroImageRolledBack(distroId string, data interface{}) {
+	LogDistroEvent(distroId, EventDistroImageRolledBack, DistroEventData{Data: data})
+}
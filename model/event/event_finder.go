@@ -1,6 +1,8 @@
 package event
 
 import (
+	"time"
+
 	"github.com/evergreen-ci/evergreen/db"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -31,6 +33,16 @@ func HostEventsInOrder(id string) db.Q {
 	return HostEventsForId(id).Sort([]string{TimestampKey})
 }
 
+// HostEventsOfTypesSince finds the events of any of the given types logged for any
+// host at or after the given time.
+func HostEventsOfTypesSince(eventTypes []string, since time.Time) db.Q {
+	return db.Query(bson.D{
+		{DataKey + "." + ResourceTypeKey, ResourceTypeHost},
+		{TypeKey, bson.M{"$in": eventTypes}},
+		{TimestampKey, bson.M{"$gte": since}},
+	})
+}
+
 // Task Events
 func TaskEventsForId(id string) db.Q {
 	return db.Query(bson.D{
@@ -63,6 +75,17 @@ func DistroEventsInOrder(id string) db.Q {
 	return DistroEventsForId(id).Sort([]string{TimestampKey})
 }
 
+// DistroEventsOfTypeSince finds the events of the given type logged for the distro
+// at or after the given time.
+func DistroEventsOfTypeSince(id, eventType string, since time.Time) db.Q {
+	return db.Query(bson.D{
+		{DataKey + "." + ResourceTypeKey, ResourceTypeDistro},
+		{ResourceIdKey, id},
+		{TypeKey, eventType},
+		{TimestampKey, bson.M{"$gte": since}},
+	})
+}
+
 // Scheduler Events
 func SchedulerEventsForId(distroId string) db.Q {
 	return db.Query(bson.D{
@@ -23,6 +23,7 @@ const (
 	EventHostMonitorFlag        = "HOST_MONITOR_FLAG"
 	EventTaskFinished           = "HOST_TASK_FINISHED"
 	EventHostTeardown           = "HOST_TEARDOWN"
+	EventHostPreempted          = "HOST_PREEMPTED"
 )
 
 // implements EventData
@@ -33,6 +34,7 @@ type HostEventData struct {
 	OldStatus  string        `bson:"o_s,omitempty" json:"old_status,omitempty"`
 	NewStatus  string        `bson:"n_s,omitempty" json:"new_status,omitempty"`
 	Logs       string        `bson:"log,omitempty" json:"logs,omitempty"`
+	Step       string        `bson:"step,omitempty" json:"step,omitempty"`
 	Hostname   string        `bson:"hn,omitempty" json:"hostname,omitempty"`
 	TaskId     string        `bson:"t_id,omitempty" json:"task_id,omitempty"`
 	TaskPid    string        `bson:"t_pid,omitempty" json:"task_pid,omitempty"`
@@ -100,6 +102,12 @@ func LogProvisionFailed(hostId string, setupLogs string) {
 	LogHostEvent(hostId, EventHostProvisionFailed, HostEventData{Logs: setupLogs})
 }
 
+// LogProvisionStepFailed logs that provisioning failed at the given step of the
+// distro's provisioning.
+func LogProvisionStepFailed(hostId, step, stepLogs string) {
+	LogHostEvent(hostId, EventHostProvisionFailed, HostEventData{Step: step, Logs: stepLogs})
+}
+
 func LogHostTeardown(hostId, teardownLogs string, success bool, duration time.Duration) {
 	LogHostEvent(hostId, EventHostTeardown,
 		HostEventData{Logs: teardownLogs, Successful: success, Duration: duration})
@@ -108,3 +116,7 @@ func LogHostTeardown(hostId, teardownLogs string, success bool, duration time.Du
 func LogMonitorOperation(hostId string, op string) {
 	LogHostEvent(hostId, EventHostMonitorFlag, HostEventData{MonitorOp: op})
 }
+
+func LogHostPreempted(hostId string, taskId string) {
+	LogHostEvent(hostId, EventHostPreempted, HostEventData{TaskId: taskId})
+}
@@ -19,6 +19,7 @@ type TaskQueueInfo struct {
 	TaskQueueLength  int           `bson:"tq_l" json:"task_queue_length"`
 	NumHostsRunning  int           `bson:"n_h" json:"num_hosts_running"`
 	ExpectedDuration time.Duration `bson:"ex_d" json:"expected_duration,"`
+	MaxWaitTime      time.Duration `bson:"mw_t" json:"max_wait_time"`
 }
 
 // implements EventData
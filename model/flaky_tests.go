@@ -0,0 +1,192 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/model/task"
+)
+
+// FlakyTestWindow is how far back test results are analyzed when no window is given.
+const FlakyTestWindow = 14 * 24 * time.Hour
+
+// FlakyTest summarizes how consistently a test passed or failed across the runs of one
+// task on one build variant.
+type FlakyTest struct {
+	TestFile     string `json:"test_file"`
+	TaskName     string `json:"task_name"`
+	BuildVariant string `json:"build_variant"`
+	Runs         int    `json:"runs"`
+	Failures     int    `json:"failures"`
+	Revisions    int    `json:"revisions"`
+
+	// FlakyRevisions is the number of revisions where the test both passed and failed,
+	// such as a failure that passed when the task was restarted.
+	FlakyRevisions int `json:"flaky_revisions"`
+
+	// Transitions is the number of times the test's outcome flipped between consecutive
+	// revisions that it ran on.
+	Transitions int `json:"transitions"`
+
+	// Score is between 0 and 1, where higher scores mean the test is more likely flaky.
+	Score       float64 `json:"score"`
+	Quarantined bool    `json:"quarantined"`
+}
+
+// FindFlakyTests analyzes the test results of the project's mainline tasks, including earlier
+// executions of restarted tasks, that finished since the given time. Only tests that failed at
+// least once are returned, most flaky first. If buildVariant is empty, all variants are analyzed.
+func FindFlakyTests(projectRef *ProjectRef, buildVariant string, since time.Time) ([]FlakyTest, error) {
+	query := task.ByFinishedWithTestResultsSince(projectRef.Identifier, buildVariant, since)
+	tasks, err := task.Find(query)
+	if err != nil {
+		return nil, err
+	}
+	oldTasks, err := task.FindOld(query)
+	if err != nil {
+		return nil, err
+	}
+
+	flaky := computeFlakyTests(append(tasks, oldTasks...))
+	for i := range flaky {
+		flaky[i].Quarantined = projectRef.IsQuarantined(flaky[i].TestFile)
+	}
+	return flaky, nil
+}
+
+// revisionOutcome records whether a test passed and failed on one revision.
+type revisionOutcome struct {
+	order          int
+	passed, failed bool
+}
+
+// computeFlakyTests computes the flakiness of each test in the given task runs. A revision
+// where a test both passed and failed counts fully towards its score, and each flip between
+// revisions with consistent outcomes counts half, since a real regression and its fix also flip.
+func computeFlakyTests(tasks []task.Task) []FlakyTest {
+	type testKey struct{ buildVariant, taskName, testFile string }
+	stats := map[testKey]*FlakyTest{}
+	outcomes := map[testKey]map[string]*revisionOutcome{}
+
+	for _, t := range tasks {
+		for _, result := range t.TestResults {
+			if result.Status != evergreen.TestFailedStatus && result.Status != evergreen.TestSucceededStatus {
+				continue
+			}
+			key := testKey{t.BuildVariant, t.DisplayName, result.TestFile}
+			if stats[key] == nil {
+				stats[key] = &FlakyTest{TestFile: result.TestFile, TaskName: t.DisplayName, BuildVariant: t.BuildVariant}
+				outcomes[key] = map[string]*revisionOutcome{}
+			}
+			outcome := outcomes[key][t.Revision]
+			if outcome == nil {
+				outcome = &revisionOutcome{order: t.RevisionOrderNumber}
+				outcomes[key][t.Revision] = outcome
+			}
+
+			stats[key].Runs++
+			if result.Status == evergreen.TestFailedStatus {
+				stats[key].Failures++
+				outcome.failed = true
+			} else {
+				outcome.passed = true
+			}
+		}
+	}
+
+	flaky := []FlakyTest{}
+	for key, ft := range stats {
+		if ft.Failures == 0 {
+			continue
+		}
+		ordered := make([]*revisionOutcome, 0, len(outcomes[key]))
+		for _, outcome := range outcomes[key] {
+			ordered = append(ordered, outcome)
+		}
+		sort.Sort(revisionOutcomesByOrder(ordered))
+
+		ft.Revisions = len(ordered)
+		var last *revisionOutcome
+		for _, outcome := range ordered {
+			if outcome.passed && outcome.failed {
+				ft.FlakyRevisions++
+				continue
+			}
+			if last != nil && last.failed != outcome.failed {
+				ft.Transitions++
+			}
+			last = outcome
+		}
+
+		ft.Score = (float64(ft.FlakyRevisions) + float64(ft.Transitions)/2) / float64(ft.Revisions)
+		if ft.Score > 1 {
+			ft.Score = 1
+		}
+		if ft.Score > 0 {
+			flaky = append(flaky, *ft)
+		}
+	}
+	sort.Sort(flakyTestsByScore(flaky))
+	return flaky
+}
+
+type revisionOutcomesByOrder []*revisionOutcome
+
+func (o revisionOutcomesByOrder) Len() int           { return len(o) }
+func (o revisionOutcomesByOrder) Less(i, j int) bool { return o[i].order < o[j].order }
+func (o revisionOutcomesByOrder) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+
+type flakyTestsByScore []FlakyTest
+
+func (f flakyTestsByScore) Len() int      { return len(f) }
+func (f flakyTestsByScore) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f flakyTestsByScore) Less(i, j int) bool {
+	if f[i].Score != f[j].Score {
+		return f[i].Score > f[j].Score
+	}
+	if f[i].TestFile != f[j].TestFile {
+		return f[i].TestFile < f[j].TestFile
+	}
+	if f[i].BuildVariant != f[j].BuildVariant {
+		return f[i].BuildVariant < f[j].BuildVariant
+	}
+	return f[i].TaskName < f[j].TaskName
+}
+
+// ApplyTestQuarantine marks a failed task as successful if all of its failed tests are
+// quarantined for the project. The test results are left as they are so the quarantined
+// failures are still recorded. Only failures of test commands are changed, so tasks that
+// failed from a system error, a timeout or an untyped failure, or that have no failed tests,
+// keep failing. It returns true if the end details were changed.
+func ApplyTestQuarantine(t *task.Task, projectRef *ProjectRef, details *apimodels.TaskEndDetail) bool {
+	if details.Status != evergreen.TaskFailed || details.Type != TestCommandType || details.TimedOut {
+		return false
+	}
+	if len(projectRef.QuarantinedTests) == 0 {
+		return false
+	}
+
+	quarantined := []string{}
+	for _, result := range t.TestResults {
+		if result.Status != evergreen.TestFailedStatus {
+			continue
+		}
+		if !projectRef.IsQuarantined(result.TestFile) {
+			return false
+		}
+		quarantined = append(quarantined, result.TestFile)
+	}
+	if len(quarantined) == 0 {
+		return false
+	}
+
+	details.Status = evergreen.TaskSucceeded
+	details.Type = ""
+	details.Description = fmt.Sprintf("ignored failures of quarantined tests: %v",
+		strings.Join(quarantined, ", "))
+	return true
+}
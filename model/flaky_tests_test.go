@@ -0,0 +1,97 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/apimodels"
+	"github.com/evergreen-ci/evergreen/model/task"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func testRun(revision string, order int, results map[string]string) task.Task {
+	t := task.Task{DisplayName: "t", BuildVariant: "bv", Revision: revision, RevisionOrderNumber: order}
+	for file, status := range results {
+		t.TestResults = append(t.TestResults, task.TestResult{TestFile: file, Status: status})
+	}
+	return t
+}
+
+func TestComputeFlakyTests(t *testing.T) {
+	Convey("When computing flaky tests", t, func() {
+		pass, fail := evergreen.TestSucceededStatus, evergreen.TestFailedStatus
+
+		Convey("tests that never fail should not be reported", func() {
+			flaky := computeFlakyTests([]task.Task{
+				testRun("a", 1, map[string]string{"x": pass}),
+				testRun("b", 2, map[string]string{"x": pass}),
+			})
+			So(len(flaky), ShouldEqual, 0)
+		})
+
+		Convey("a test that passes on restart should count as flaky", func() {
+			flaky := computeFlakyTests([]task.Task{
+				testRun("a", 1, map[string]string{"x": fail}),
+				testRun("a", 1, map[string]string{"x": pass}),
+				testRun("b", 2, map[string]string{"x": pass}),
+			})
+			So(len(flaky), ShouldEqual, 1)
+			So(flaky[0].Runs, ShouldEqual, 3)
+			So(flaky[0].Failures, ShouldEqual, 1)
+			So(flaky[0].Revisions, ShouldEqual, 2)
+			So(flaky[0].FlakyRevisions, ShouldEqual, 1)
+			So(flaky[0].Score, ShouldEqual, 0.5)
+		})
+
+		Convey("tests that flip more often should score higher", func() {
+			flaky := computeFlakyTests([]task.Task{
+				testRun("a", 1, map[string]string{"flips": fail, "breaks": pass}),
+				testRun("b", 2, map[string]string{"flips": pass, "breaks": pass}),
+				testRun("c", 3, map[string]string{"flips": fail, "breaks": fail}),
+				testRun("d", 4, map[string]string{"flips": pass, "breaks": fail}),
+			})
+			So(len(flaky), ShouldEqual, 2)
+			So(flaky[0].TestFile, ShouldEqual, "flips")
+			So(flaky[0].Transitions, ShouldEqual, 3)
+			So(flaky[1].TestFile, ShouldEqual, "breaks")
+			So(flaky[1].Transitions, ShouldEqual, 1)
+		})
+	})
+}
+
+func TestApplyTestQuarantine(t *testing.T) {
+	Convey("With a project that quarantines a test", t, func() {
+		ref := &ProjectRef{QuarantinedTests: []string{"flaky"}}
+		failedTask := testRun("a", 1, map[string]string{"flaky": evergreen.TestFailedStatus})
+
+		Convey("a task failing only the quarantined test should succeed", func() {
+			details := &apimodels.TaskEndDetail{Status: evergreen.TaskFailed, Type: TestCommandType}
+			So(ApplyTestQuarantine(&failedTask, ref, details), ShouldBeTrue)
+			So(details.Status, ShouldEqual, evergreen.TaskSucceeded)
+			So(failedTask.TestResults[0].Status, ShouldEqual, evergreen.TestFailedStatus)
+		})
+
+		Convey("a task failing other tests should still fail", func() {
+			failedTask.TestResults = append(failedTask.TestResults,
+				task.TestResult{TestFile: "other", Status: evergreen.TestFailedStatus})
+			details := &apimodels.TaskEndDetail{Status: evergreen.TaskFailed, Type: TestCommandType}
+			So(ApplyTestQuarantine(&failedTask, ref, details), ShouldBeFalse)
+			So(details.Status, ShouldEqual, evergreen.TaskFailed)
+		})
+
+		Convey("a task that timed out should still fail", func() {
+			details := &apimodels.TaskEndDetail{Status: evergreen.TaskFailed, Type: TestCommandType, TimedOut: true}
+			So(ApplyTestQuarantine(&failedTask, ref, details), ShouldBeFalse)
+		})
+
+		Convey("a task failing in a non-test command should still fail", func() {
+			details := &apimodels.TaskEndDetail{Status: evergreen.TaskFailed, Type: SystemCommandType}
+			So(ApplyTestQuarantine(&failedTask, ref, details), ShouldBeFalse)
+			So(details.Status, ShouldEqual, evergreen.TaskFailed)
+
+			details = &apimodels.TaskEndDetail{Status: evergreen.TaskFailed}
+			So(ApplyTestQuarantine(&failedTask, ref, details), ShouldBeFalse)
+			So(details.Status, ShouldEqual, evergreen.TaskFailed)
+		})
+	})
+}
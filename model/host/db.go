@@ -43,10 +43,14 @@ var (
 	AgentRevisionKey         = bsonutil.MustHaveTag(Host{}, "AgentRevision")
 	StartedByKey             = bsonutil.MustHaveTag(Host{}, "StartedBy")
 	InstanceTypeKey          = bsonutil.MustHaveTag(Host{}, "InstanceType")
+	SubnetIdKey              = bsonutil.MustHaveTag(Host{}, "SubnetId")
+	ParentIdKey              = bsonutil.MustHaveTag(Host{}, "ParentId")
 	NotificationsKey         = bsonutil.MustHaveTag(Host{}, "Notifications")
 	UserDataKey              = bsonutil.MustHaveTag(Host{}, "UserData")
 	LastReachabilityCheckKey = bsonutil.MustHaveTag(Host{}, "LastReachabilityCheck")
 	UnreachableSinceKey      = bsonutil.MustHaveTag(Host{}, "UnreachableSince")
+	StopTimeKey              = bsonutil.MustHaveTag(Host{}, "StopTime")
+	RestartTimeKey           = bsonutil.MustHaveTag(Host{}, "RestartTime")
 )
 
 // === Queries ===
@@ -119,11 +123,32 @@ func ByUnprovisionedSince(threshold time.Time) db.Q {
 	})
 }
 
+// ByRestartingSince produces a query that returns all hosts that were
+// started again after being stopped before the given time, but aren't
+// running yet.
+func ByRestartingSince(threshold time.Time) db.Q {
+	return db.Query(bson.M{
+		ProvisionedKey: true,
+		RestartTimeKey: bson.M{"$lte": threshold},
+		StatusKey:      bson.M{"$in": []string{evergreen.HostUninitialized, evergreen.HostInitializing}},
+		StartedByKey:   evergreen.User,
+	})
+}
+
 // IsUninitialized is a query that returns all uninitialized Evergreen hosts.
 var IsUninitialized = db.Query(
 	bson.M{StatusKey: evergreen.HostUninitialized, StartedByKey: evergreen.User},
 )
 
+// UninitializedByProvider produces a query that returns the uninitialized
+// hosts of the provider, including spawn hosts.
+func UninitializedByProvider(provider string) db.Q {
+	return db.Query(bson.M{
+		ProviderKey: provider,
+		StatusKey:   evergreen.HostUninitialized,
+	})
+}
+
 // ByUnproductiveSince produces a query that returns all hosts that
 // are not doign work and were created before the given time.
 func ByUnproductiveSince(threshold time.Time) db.Q {
@@ -173,6 +198,78 @@ func ByDistroId(distroId string) db.Q {
 	})
 }
 
+// StoppedByDistroIdAndImageVersion produces a query that returns the stopped
+// hosts of the given distro spawned from the given version of its image, most
+// recently stopped first.
+func StoppedByDistroIdAndImageVersion(distroId string, version int) db.Q {
+	dId := fmt.Sprintf("%v.%v", DistroKey, distro.IdKey)
+	dVersion := fmt.Sprintf("%v.%v", DistroKey, distro.ImageVersionKey)
+	return db.Query(bson.M{
+		dId:          distroId,
+		dVersion:     imageVersionQuery(version),
+		StartedByKey: evergreen.User,
+		StatusKey:    evergreen.HostStopped,
+	}).Sort([]string{"-" + StopTimeKey})
+}
+
+// imageVersionQuery matches hosts spawned from the distro image version, which
+// is unset on hosts spawned before distros had versions.
+func imageVersionQuery(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": []interface{}{0, nil}}
+	}
+	return version
+}
+
+// ByDistroIdAndImageVersion produces a query that returns all working hosts of
+// the given distro spawned from the given version of its image.
+func ByDistroIdAndImageVersion(distroId string, version int) db.Q {
+	dId := fmt.Sprintf("%v.%v", DistroKey, distro.IdKey)
+	dVersion := fmt.Sprintf("%v.%v", DistroKey, distro.ImageVersionKey)
+	return db.Query(bson.M{
+		dId:          distroId,
+		dVersion:     imageVersionQuery(version),
+		StartedByKey: evergreen.User,
+		StatusKey:    bson.M{"$in": evergreen.UphostStatus},
+	})
+}
+
+// ByImageVersionRunningSince produces a query that returns all hosts, including
+// terminated ones, of the given distro spawned from the given version of its
+// image that haven't been terminated since before the given time.
+func ByImageVersionRunningSince(distroId string, version int, since time.Time) db.Q {
+	dId := fmt.Sprintf("%v.%v", DistroKey, distro.IdKey)
+	dVersion := fmt.Sprintf("%v.%v", DistroKey, distro.ImageVersionKey)
+	return db.Query(bson.M{
+		dId:          distroId,
+		dVersion:     imageVersionQuery(version),
+		StartedByKey: evergreen.User,
+		"$or": []bson.M{
+			{StatusKey: bson.M{"$ne": evergreen.HostTerminated}},
+			{TerminationTimeKey: bson.M{"$gte": since}},
+		},
+	})
+}
+
+// ByStoppedBefore produces a query that returns all hosts that have been
+// stopped since before the given time.
+func ByStoppedBefore(threshold time.Time) db.Q {
+	return db.Query(bson.M{
+		StartedByKey: evergreen.User,
+		StatusKey:    evergreen.HostStopped,
+		StopTimeKey:  bson.M{"$lte": threshold},
+	})
+}
+
+// ByParentId produces a query that returns all containers, that aren't
+// terminated, running on the host with the given id.
+func ByParentId(parentId string) db.Q {
+	return db.Query(bson.M{
+		ParentIdKey: parentId,
+		StatusKey:   bson.M{"$ne": evergreen.HostTerminated},
+	})
+}
+
 // ById produces a query that returns a host with the given id.
 func ById(id string) db.Q {
 	return db.Query(bson.D{{IdKey, id}})
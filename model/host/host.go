@@ -46,6 +46,10 @@ type Host struct {
 	AgentRevision string `bson:"agent_revision" json:"agent_revision"`
 	// for ec2 dynamic hosts, the instance type requested
 	InstanceType string `bson:"instance_type" json:"instance_type,omitempty"`
+	// for ec2 dynamic hosts in a vpc, the subnet requested
+	SubnetId string `bson:"subnet_id,omitempty" json:"subnet_id,omitempty"`
+	// for containers, the id of the host the container runs on
+	ParentId string `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
 	// stores information on expiration notifications for spawn hosts
 	Notifications map[string]bool `bson:"notifications,omitempty" json:"notifications,omitempty"`
 
@@ -57,6 +61,11 @@ type Host struct {
 
 	// if set, the time at which the host first became unreachable
 	UnreachableSince time.Time `bson:"unreachable_since,omitempty" json:"unreachable_since"`
+
+	// for hosts that were stopped while idle, when they were last stopped,
+	// and started again
+	StopTime    time.Time `bson:"stop_time,omitempty" json:"stop_time,omitempty"`
+	RestartTime time.Time `bson:"restart_time,omitempty" json:"restart_time,omitempty"`
 }
 
 // IdleTime returns how long has this host been idle
@@ -68,14 +77,19 @@ func (self *Host) IdleTime() time.Duration {
 	}
 
 	// if the host has run a task before, then the idle time is just the time
-	// passed since the last task finished
+	// passed since the last task finished. if the host has not run a task
+	// before, the idle time is just how long is has been since the host was
+	// created
+	idleSince := self.CreationTime
 	if self.LastTaskCompleted != "" {
-		return time.Now().Sub(self.LastTaskCompletedTime)
+		idleSince = self.LastTaskCompletedTime
 	}
 
-	// if the host has not run a task before, the idle time is just
-	// how long is has been since the host was created
-	return time.Now().Sub(self.CreationTime)
+	// a host that was stopped has been idle since it was started again
+	if self.RestartTime.After(idleSince) {
+		idleSince = self.RestartTime
+	}
+	return time.Now().Sub(idleSince)
 }
 
 func (self *Host) SetStatus(status string) error {
@@ -129,14 +143,65 @@ func (self *Host) SetRunning() error {
 	return self.SetStatus(evergreen.HostRunning)
 }
 
+// SetTerminated marks the host as terminated and removes the results of its
+// provisioning, which are only kept while the host is up.
 func (self *Host) SetTerminated() error {
-	return self.SetStatus(evergreen.HostTerminated)
+	if err := self.SetStatus(evergreen.HostTerminated); err != nil {
+		return err
+	}
+	return self.ClearProvisionResults()
 }
 
 func (self *Host) SetUnreachable() error {
 	return self.SetStatus(evergreen.HostUnreachable)
 }
 
+// SetStopped marks an idle host as stopped. Its DNS name is cleared, since
+// providers may give it a new one when it's started again.
+func (self *Host) SetStopped() error {
+	if err := self.SetStatus(evergreen.HostStopped); err != nil {
+		return err
+	}
+	self.StopTime = time.Now()
+	self.Host = ""
+	return UpdateOne(
+		bson.M{
+			IdKey: self.Id,
+		},
+		bson.M{
+			"$set": bson.M{
+				StopTimeKey: self.StopTime,
+				DNSKey:      "",
+			},
+		},
+	)
+}
+
+// SetRestarted marks a stopped host as uninitialized, so that hostinit
+// waits for it to be up again. Hosts that were provisioned before they were
+// stopped aren't set up again.
+func (self *Host) SetRestarted() error {
+	self.RestartTime = time.Now()
+	err := UpdateOne(
+		bson.M{
+			IdKey:     self.Id,
+			StatusKey: evergreen.HostStopped,
+		},
+		bson.M{
+			"$set": bson.M{
+				StatusKey:      evergreen.HostUninitialized,
+				RestartTimeKey: self.RestartTime,
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	event.LogHostStatusChanged(self.Id, self.Status, evergreen.HostUninitialized)
+	self.Status = evergreen.HostUninitialized
+	return nil
+}
+
 func (self *Host) SetUnprovisioned() error {
 	return UpdateOne(
 		bson.M{
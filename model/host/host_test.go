@@ -549,3 +549,52 @@ func TestUpsert(t *testing.T) {
 			})
 	})
 }
+
+func TestProvisionResults(t *testing.T) {
+
+	Convey("With a host being provisioned", t, func() {
+
+		testutil.HandleTestingErr(db.ClearCollections(Collection, ProvisionResultsCollection),
+			t, "error clearing collections")
+
+		h := &Host{Id: "h1"}
+		So(h.Insert(), ShouldBeNil)
+
+		Convey("the results of its steps should be kept in order, apart from the host", func() {
+			So(h.AddProvisionResult(ProvisionStepResult{Step: "setup", Successful: true}), ShouldBeNil)
+			So(h.AddProvisionResult(ProvisionStepResult{Step: "install", Output: "installed"}), ShouldBeNil)
+
+			results, err := FindProvisionResults(h.Id)
+			So(err, ShouldBeNil)
+			So(len(results), ShouldEqual, 2)
+			So(results[0].Step, ShouldEqual, "setup")
+			So(results[1].Output, ShouldEqual, "installed")
+
+			raw := bson.M{}
+			So(db.FindOne(Collection, bson.M{IdKey: h.Id}, db.NoProjection, db.NoSort, &raw), ShouldBeNil)
+			So(raw, ShouldNotContainKey, "provision_results")
+		})
+
+		Convey("clearing its results should remove them", func() {
+			So(h.ClearProvisionResults(), ShouldBeNil)
+			So(h.AddProvisionResult(ProvisionStepResult{Step: "setup"}), ShouldBeNil)
+			So(h.ClearProvisionResults(), ShouldBeNil)
+
+			results, err := FindProvisionResults(h.Id)
+			So(err, ShouldBeNil)
+			So(results, ShouldBeEmpty)
+		})
+
+		Convey("terminating it should remove its results", func() {
+			So(h.AddProvisionResult(ProvisionStepResult{Step: "setup"}), ShouldBeNil)
+			So(h.Terminate(), ShouldBeNil)
+
+			results, err := FindProvisionResults(h.Id)
+			So(err, ShouldBeNil)
+			So(results, ShouldBeEmpty)
+			count, err := db.Count(ProvisionResultsCollection, bson.M{ProvisionResultsHostIdKey: h.Id})
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package host
+
+import (
+	"time"
+
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// ProvisionResultsCollection holds the results of the hosts' provisioning,
+	// which are kept out of the hosts collection since their output is large
+	// and only the host page needs it.
+	ProvisionResultsCollection = "host_provision_results"
+)
+
+// ProvisionResults are the results of the steps of a host's last
+// provisioning, in the order they were run.
+type ProvisionResults struct {
+	HostId  string                `bson:"_id" json:"host_id"`
+	Results []ProvisionStepResult `bson:"results" json:"results"`
+}
+
+// ProvisionStepResult is the result of running a step of a host's provisioning.
+type ProvisionStepResult struct {
+	Step       string        `bson:"step" json:"step"`
+	Attempts   int           `bson:"attempts" json:"attempts"`
+	Successful bool          `bson:"successful" json:"successful"`
+	StartTime  time.Time     `bson:"start_time" json:"start_time"`
+	Duration   time.Duration `bson:"duration" json:"duration"`
+
+	// the output and error of the step's last attempt
+	Output string `bson:"output,omitempty" json:"output,omitempty"`
+	Error  string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+var (
+	// bson fields for the provision results struct
+	ProvisionResultsHostIdKey  = bsonutil.MustHaveTag(ProvisionResults{}, "HostId")
+	ProvisionResultsResultsKey = bsonutil.MustHaveTag(ProvisionResults{}, "Results")
+)
+
+// FindProvisionResults returns the results of the host's last provisioning, or
+// none if it hasn't been provisioned.
+func FindProvisionResults(hostId string) ([]ProvisionStepResult, error) {
+	results := &ProvisionResults{}
+	err := db.FindOne(
+		ProvisionResultsCollection,
+		bson.M{ProvisionResultsHostIdKey: hostId},
+		db.NoProjection,
+		db.NoSort,
+		results,
+	)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return results.Results, err
+}
+
+// ClearProvisionResults removes the results of the host's last provisioning.
+func (self *Host) ClearProvisionResults() error {
+	err := db.Remove(ProvisionResultsCollection, bson.M{ProvisionResultsHostIdKey: self.Id})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// AddProvisionResult adds the result of a step of the host's provisioning.
+func (self *Host) AddProvisionResult(result ProvisionStepResult) error {
+	_, err := db.Upsert(
+		ProvisionResultsCollection,
+		bson.M{ProvisionResultsHostIdKey: self.Id},
+		bson.M{
+			"$push": bson.M{
+				ProvisionResultsResultsKey: result,
+			},
+		},
+	)
+	return err
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/evergreen-ci/evergreen"
@@ -26,6 +27,15 @@ const (
 	DefaultCommandType = TestCommandType
 )
 
+const (
+	// ParallelFailFast stops the rest of a parallel block's commands as soon
+	// as one of them fails. It is the default.
+	ParallelFailFast = "fail_fast"
+	// ParallelWaitAll lets all of a parallel block's commands finish before
+	// failing the block.
+	ParallelWaitAll = "wait_all"
+)
+
 type Project struct {
 	Enabled         bool                       `yaml:"enabled,omitempty" bson:"enabled"`
 	Stepback        bool                       `yaml:"stepback,omitempty" bson:"stepback"`
@@ -188,6 +198,51 @@ type PluginCommandConf struct {
 
 	// Vars defines variables that can be used within commands.
 	Vars map[string]string `yaml:"vars,omitempty" bson:"vars"`
+
+	// If is a condition on the task's expansions that must hold for the command
+	// or function to run. See ParseCondition for its syntax.
+	If string `yaml:"if,omitempty" bson:"if,omitempty"`
+
+	// Parallel, if set, makes this a block of commands that are run at the same
+	// time, instead of a single command or function.
+	Parallel *ParallelBlock `yaml:"parallel,omitempty" bson:"parallel,omitempty"`
+}
+
+// ParallelBlock is a group of commands run concurrently within a task. The
+// commands share the task's expansions, so they should not update them.
+type ParallelBlock struct {
+	// OnFailure is the block's failure policy, either ParallelFailFast or
+	// ParallelWaitAll. Defaults to ParallelFailFast.
+	OnFailure string `yaml:"on_failure,omitempty" bson:"on_failure"`
+
+	// Commands are the commands or functions to run. The commands of each
+	// function are run in order, at the same time as the block's other
+	// commands.
+	Commands []PluginCommandConf `yaml:"commands,omitempty" bson:"commands"`
+}
+
+// FailFast returns whether the block should stop its other commands as soon
+// as one of them fails.
+func (b *ParallelBlock) FailFast() bool {
+	return b.OnFailure != ParallelWaitAll
+}
+
+// Validate checks that the block has commands and a known failure policy, and
+// that none of its commands are parallel blocks themselves.
+func (b *ParallelBlock) Validate() error {
+	if len(b.Commands) == 0 {
+		return fmt.Errorf("parallel block must have commands")
+	}
+	if b.OnFailure != "" && b.OnFailure != ParallelFailFast && b.OnFailure != ParallelWaitAll {
+		return fmt.Errorf("invalid parallel on_failure policy '%v': must be '%v' or '%v'",
+			b.OnFailure, ParallelFailFast, ParallelWaitAll)
+	}
+	for _, cmd := range b.Commands {
+		if cmd.Parallel != nil {
+			return fmt.Errorf("parallel blocks can not be nested")
+		}
+	}
+	return nil
 }
 
 type ArtifactInstructions struct {
@@ -195,16 +250,35 @@ type ArtifactInstructions struct {
 	ExcludeFiles []string `yaml:"excludefiles,omitempty" bson:"exclude_files"`
 }
 
+// YAMLCommandSet is a list of commands, or a single command, as in a
+// project's pre, post and timeout sections and its functions. Functions may
+// also be written as a map of their params and commands, in order to declare
+// the vars they take.
 type YAMLCommandSet struct {
 	SingleCommand *PluginCommandConf
 	MultiCommand  []PluginCommandConf
+	Params        []FunctionParam `bson:"params,omitempty"`
+}
+
+// FunctionParam is a var that a function takes.
+type FunctionParam struct {
+	Name        string `yaml:"name" bson:"name"`
+	Required    bool   `yaml:"required,omitempty" bson:"required,omitempty"`
+	Default     string `yaml:"default,omitempty" bson:"default,omitempty"`
+	Description string `yaml:"description,omitempty" bson:"description,omitempty"`
+}
+
+// functionWithParams is the YAML form of a function that declares its params.
+type functionWithParams struct {
+	Params   []FunctionParam     `yaml:"params"`
+	Commands []PluginCommandConf `yaml:"commands"`
 }
 
 func (c *YAMLCommandSet) List() []PluginCommandConf {
 	if len(c.MultiCommand) > 0 {
 		return c.MultiCommand
 	}
-	if c.SingleCommand != nil && (c.SingleCommand.Command != "" || c.SingleCommand.Function != "") {
+	if c.SingleCommand != nil && (c.SingleCommand.Command != "" || c.SingleCommand.Function != "" || c.SingleCommand.Parallel != nil) {
 		return []PluginCommandConf{*c.SingleCommand}
 	}
 	return []PluginCommandConf{}
@@ -214,10 +288,21 @@ func (c *YAMLCommandSet) MarshalYAML() (interface{}, error) {
 	if c == nil {
 		return nil, nil
 	}
+	if len(c.Params) > 0 {
+		return functionWithParams{Params: c.Params, Commands: c.List()}, nil
+	}
 	return c.List(), nil
 }
 
 func (c *YAMLCommandSet) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// a single command's params are a map, so it can't be mistaken for a
+	// function with a list of params
+	withParams := functionWithParams{}
+	if err := unmarshal(&withParams); err == nil && len(withParams.Commands) > 0 {
+		c.Params = withParams.Params
+		c.MultiCommand = withParams.Commands
+		return nil
+	}
 	err1 := unmarshal(&(c.MultiCommand))
 	err2 := unmarshal(&(c.SingleCommand))
 	if err1 == nil || err2 == nil {
@@ -226,6 +311,107 @@ func (c *YAMLCommandSet) UnmarshalYAML(unmarshal func(interface{}) error) error
 	return err1
 }
 
+// ValidateParams checks that the declared params are named, and named once, and
+// that required params don't have defaults.
+func (c *YAMLCommandSet) ValidateParams() error {
+	seen := map[string]bool{}
+	for _, param := range c.Params {
+		if param.Name == "" {
+			return fmt.Errorf("params must have a name")
+		}
+		if seen[param.Name] {
+			return fmt.Errorf("param '%v' is declared more than once", param.Name)
+		}
+		seen[param.Name] = true
+		if param.Required && param.Default != "" {
+			return fmt.Errorf("required param '%v' can not have a default", param.Name)
+		}
+	}
+	return nil
+}
+
+// ResolveVars checks the vars passed to a function against its params and
+// returns them along with the defaults of the params that weren't passed.
+// Functions that don't declare params take any vars.
+func (c *YAMLCommandSet) ResolveVars(vars map[string]string) (map[string]string, error) {
+	if len(c.Params) == 0 {
+		return vars, nil
+	}
+	declared := map[string]bool{}
+	resolved := map[string]string{}
+	missing := []string{}
+	for _, param := range c.Params {
+		declared[param.Name] = true
+		if val, ok := vars[param.Name]; ok {
+			resolved[param.Name] = val
+		} else if param.Required {
+			missing = append(missing, param.Name)
+		} else if param.Default != "" {
+			resolved[param.Name] = param.Default
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required vars: %v", strings.Join(missing, ", "))
+	}
+	unknown := []string{}
+	for name := range vars {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown vars: %v", strings.Join(unknown, ", "))
+	}
+	return resolved, nil
+}
+
+// ExpansionsWithVars returns a copy of the expansions with the vars of a command
+// or function call added, along with the defaults of the params that a function
+// call doesn't pass. The vars' values are expanded with the expansions.
+func (p *Project) ExpansionsWithVars(expansions *command.Expansions, commandInfo PluginCommandConf) (*command.Expansions, error) {
+	vars := commandInfo.Vars
+	if f, ok := p.Functions[commandInfo.Function]; ok {
+		var err error
+		if vars, err = f.ResolveVars(commandInfo.Vars); err != nil {
+			return nil, fmt.Errorf("invalid vars for function '%v': %v", commandInfo.Function, err)
+		}
+	}
+	withVars := expansions.Copy()
+	for key, val := range vars {
+		newVal, err := expansions.ExpandString(val)
+		if err != nil {
+			return nil, fmt.Errorf("Can't expand '%v': %v", val, err)
+		}
+		withVars.Put(key, newVal)
+	}
+	return withVars, nil
+}
+
+// ConditionsHold returns whether the condition of a command, and the condition
+// of the function call it was parsed from, hold for the expansions with the
+// call's vars added. If not, the condition that doesn't hold is returned.
+func (p *Project) ConditionsHold(expansions *command.Expansions, commandInfo, parsedCommand PluginCommandConf) (bool, string, error) {
+	withVars, err := p.ExpansionsWithVars(expansions, commandInfo)
+	if err != nil {
+		return false, "", err
+	}
+	confs := []PluginCommandConf{commandInfo}
+	if commandInfo.Function != "" {
+		confs = append(confs, parsedCommand)
+	}
+	for _, conf := range confs {
+		run, err := conf.RunOnCondition(withVars)
+		if err != nil {
+			return false, "", err
+		}
+		if !run {
+			return false, conf.If, nil
+		}
+	}
+	return true, "", nil
+}
+
 // TaskDependency holds configuraiton information about a task that must finish before
 // the task that contains the dependency can run.
 type TaskDependency struct {
@@ -519,12 +705,25 @@ func (p PluginCommandConf) RunOnVariant(variant string) bool {
 	return len(p.Variants) == 0 || util.SliceContains(p.Variants, variant)
 }
 
+// RunOnCondition returns true if the plugin command has no condition, or if its
+// condition holds for the expansions; returns false otherwise
+func (p PluginCommandConf) RunOnCondition(expansions *command.Expansions) (bool, error) {
+	run, err := EvalCondition(p.If, expansions)
+	if err != nil {
+		return false, fmt.Errorf("error evaluating condition '%v': %v", p.If, err)
+	}
+	return run, nil
+}
+
 // GetDisplayName returns the  display name of the plugin command. If none is
 // defined, it returns the command's identifier.
 func (p PluginCommandConf) GetDisplayName() string {
 	if p.DisplayName != "" {
 		return p.DisplayName
 	}
+	if p.Parallel != nil {
+		return "parallel"
+	}
 	return p.Command
 }
 
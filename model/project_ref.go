@@ -5,6 +5,8 @@ import (
 
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/db/bsonutil"
+	"github.com/evergreen-ci/evergreen/storage"
+	"github.com/evergreen-ci/evergreen/util"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -34,6 +36,22 @@ type ProjectRef struct {
 	// the set of alert deliveries to be processed for that trigger.
 	Alerts map[string][]AlertConfig `bson:"alert_settings" json:"alert_config,omitempty"`
 
+	// DurationAlerts holds the thresholds used by the task duration alert triggers.
+	DurationAlerts DurationAlertSettings `bson:"duration_alerts" json:"duration_alerts"`
+
+	// QuarantinedTests lists the test files whose failures do not fail their task.
+	// Failures of quarantined tests are still recorded in the task's test results.
+	QuarantinedTests []string `bson:"quarantined_tests" json:"quarantined_tests"`
+
+	// PrivateFileUsers lists the users, besides the project's admins, who may
+	// download its tasks' private files. If it's empty, any user who can see the
+	// project may.
+	PrivateFileUsers []string `bson:"private_file_users" json:"private_file_users"`
+
+	// Storage selects the blob storage used by the project's s3, s3Copy and cache
+	// commands that don't select their own. If unset, they use Amazon S3.
+	Storage storage.Options `bson:"storage" json:"storage"`
+
 	// RepoDetails contain the details of the status of the consistency
 	// between what is in GitHub and what is in Evergreen
 	RepotrackerError *RepositoryErrorDetails `bson:"repotracker_error" json:"repotracker_error"`
@@ -55,6 +73,16 @@ type AlertConfig struct {
 	Settings bson.M `bson:"settings" json:"settings"`
 }
 
+// DurationAlertSettings configures when a task's run time is considered a regression.
+// A task regresses when it exceeds its expected duration by every threshold that is set.
+type DurationAlertSettings struct {
+	// Factor is the multiple of the expected duration a task must exceed.
+	Factor float64 `bson:"factor" json:"factor"`
+
+	// ThresholdSecs is the number of seconds beyond the expected duration a task must exceed.
+	ThresholdSecs int `bson:"threshold_secs" json:"threshold_secs"`
+}
+
 type EmailAlertData struct {
 	Recipients []string `bson:"recipients"`
 }
@@ -77,6 +105,10 @@ var (
 	ProjectRefAlertsKey             = bsonutil.MustHaveTag(ProjectRef{}, "Alerts")
 	ProjectRefRepotrackerError      = bsonutil.MustHaveTag(ProjectRef{}, "RepotrackerError")
 	ProjectRefAdminsKey             = bsonutil.MustHaveTag(ProjectRef{}, "Admins")
+	ProjectRefDurationAlertsKey     = bsonutil.MustHaveTag(ProjectRef{}, "DurationAlerts")
+	ProjectRefQuarantinedTestsKey   = bsonutil.MustHaveTag(ProjectRef{}, "QuarantinedTests")
+	ProjectRefPrivateFileUsersKey   = bsonutil.MustHaveTag(ProjectRef{}, "PrivateFileUsers")
+	ProjectRefStorageKey            = bsonutil.MustHaveTag(ProjectRef{}, "Storage")
 )
 
 const (
@@ -179,6 +211,10 @@ func (projectRef *ProjectRef) Upsert() error {
 				ProjectRefAlertsKey:             projectRef.Alerts,
 				ProjectRefRepotrackerError:      projectRef.RepotrackerError,
 				ProjectRefAdminsKey:             projectRef.Admins,
+				ProjectRefDurationAlertsKey:     projectRef.DurationAlerts,
+				ProjectRefQuarantinedTestsKey:   projectRef.QuarantinedTests,
+				ProjectRefPrivateFileUsersKey:   projectRef.PrivateFileUsers,
+				ProjectRefStorageKey:            projectRef.Storage,
 			},
 		},
 	)
@@ -198,6 +234,11 @@ func (p *ProjectRef) GetBatchTime(variant *BuildVariant) int {
 	return p.BatchTime
 }
 
+// IsQuarantined returns true if failures of the given test file are ignored for the project.
+func (projectRef *ProjectRef) IsQuarantined(testFile string) bool {
+	return util.SliceContains(projectRef.QuarantinedTests, testFile)
+}
+
 // Location generates and returns the ssh hostname and path to the repo.
 func (projectRef *ProjectRef) Location() (string, error) {
 	if projectRef.Owner == "" {
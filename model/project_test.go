@@ -8,6 +8,7 @@ import (
 	"github.com/evergreen-ci/evergreen/testutil"
 	"github.com/evergreen-ci/evergreen/util"
 	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/yaml.v2"
 )
 
 var (
@@ -272,6 +273,83 @@ func TestIgnoresAllFiles(t *testing.T) {
 	})
 }
 
+func TestFunctionParams(t *testing.T) {
+	Convey("With a set of function definitions", t, func() {
+		functions := map[string]*YAMLCommandSet{}
+		So(yaml.Unmarshal([]byte(`
+single:
+  command: shell.exec
+  params:
+    script: make
+list:
+  - command: shell.exec
+  - command: s3.put
+upload:
+  params:
+    - name: bucket
+      required: true
+      description: the bucket to upload to
+    - name: local_file
+      default: build.tgz
+    - name: remote_file
+  commands:
+    - command: s3.put
+      params:
+        bucket: ${bucket}
+`), &functions), ShouldBeNil)
+
+		Convey("functions should be parsed from every form", func() {
+			So(functions["single"].List(), ShouldHaveLength, 1)
+			So(functions["single"].Params, ShouldBeEmpty)
+			So(functions["list"].List(), ShouldHaveLength, 2)
+			So(functions["upload"].List(), ShouldHaveLength, 1)
+			So(functions["upload"].List()[0].Params["bucket"], ShouldEqual, "${bucket}")
+			So(functions["upload"].Params, ShouldResemble, []FunctionParam{
+				{Name: "bucket", Required: true, Description: "the bucket to upload to"},
+				{Name: "local_file", Default: "build.tgz"},
+				{Name: "remote_file"},
+			})
+			So(functions["upload"].ValidateParams(), ShouldBeNil)
+		})
+
+		Convey("functions with params should marshal back to the same form", func() {
+			out, err := yaml.Marshal(functions)
+			So(err, ShouldBeNil)
+			reparsed := map[string]*YAMLCommandSet{}
+			So(yaml.Unmarshal(out, &reparsed), ShouldBeNil)
+			So(reparsed["upload"].Params, ShouldResemble, functions["upload"].Params)
+			So(reparsed["single"].Params, ShouldBeEmpty)
+		})
+
+		Convey("functions without params should take any vars", func() {
+			vars, err := functions["list"].ResolveVars(map[string]string{"a": "b"})
+			So(err, ShouldBeNil)
+			So(vars, ShouldResemble, map[string]string{"a": "b"})
+		})
+
+		Convey("defaults should be added for params that aren't passed", func() {
+			vars, err := functions["upload"].ResolveVars(map[string]string{"bucket": "mciuploads"})
+			So(err, ShouldBeNil)
+			So(vars, ShouldResemble, map[string]string{"bucket": "mciuploads", "local_file": "build.tgz"})
+		})
+
+		Convey("missing required vars and unknown vars should be errors", func() {
+			_, err := functions["upload"].ResolveVars(map[string]string{"local_file": "a.tgz"})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "bucket")
+			_, err = functions["upload"].ResolveVars(map[string]string{"bucket": "b", "bukcet": "b"})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "bukcet")
+		})
+
+		Convey("malformed params should not be valid", func() {
+			So((&YAMLCommandSet{Params: []FunctionParam{{Name: ""}}}).ValidateParams(), ShouldNotBeNil)
+			So((&YAMLCommandSet{Params: []FunctionParam{{Name: "a"}, {Name: "a"}}}).ValidateParams(), ShouldNotBeNil)
+			So((&YAMLCommandSet{Params: []FunctionParam{{Name: "a", Required: true, Default: "b"}}}).ValidateParams(), ShouldNotBeNil)
+		})
+	})
+}
+
 func boolPtr(b bool) *bool {
 	return &b
 }
@@ -61,6 +61,7 @@ var (
 	TestResultTestFileKey  = bsonutil.MustHaveTag(TestResult{}, "TestFile")
 	TestResultURLKey       = bsonutil.MustHaveTag(TestResult{}, "URL")
 	TestResultURLRawKey    = bsonutil.MustHaveTag(TestResult{}, "URLRaw")
+	TestResultLogIdKey     = bsonutil.MustHaveTag(TestResult{}, "LogId")
 	TestResultExitCodeKey  = bsonutil.MustHaveTag(TestResult{}, "ExitCode")
 	TestResultStartTimeKey = bsonutil.MustHaveTag(TestResult{}, "StartTime")
 	TestResultEndTimeKey   = bsonutil.MustHaveTag(TestResult{}, "EndTime")
@@ -268,6 +269,16 @@ func ByTimeRun(startTime, endTime time.Time) db.Q {
 		})
 }
 
+// ByHostIdsFinishedSince returns all tasks with one of the given statuses that
+// finished on one of the given hosts since the given time.
+func ByHostIdsFinishedSince(hostIds []string, statuses []string, since time.Time) db.Q {
+	return db.Query(bson.M{
+		HostIdKey:     bson.M{"$in": hostIds},
+		StatusKey:     bson.M{"$in": statuses},
+		FinishTimeKey: bson.M{"$gte": since},
+	})
+}
+
 func ByStatuses(statuses []string, buildVariant, displayName, project, requester string) db.Q {
 	return db.Query(bson.M{
 		BuildVariantKey: buildVariant,
@@ -330,6 +341,24 @@ func ByRecentlyFinished(finishTime time.Time, project string, requester string)
 	return db.Query(query)
 }
 
+// ByFinishedWithTestResultsSince returns the mainline tasks of a project that finished
+// with test results after the given time, optionally limited to one build variant.
+// Only the fields needed to compare test results across runs are returned.
+func ByFinishedWithTestResultsSince(project, buildVariant string, since time.Time) db.Q {
+	query := bson.M{
+		ProjectKey:            project,
+		RequesterKey:          evergreen.RepotrackerVersionRequester,
+		StatusKey:             bson.M{"$in": []string{evergreen.TaskFailed, evergreen.TaskSucceeded}},
+		FinishTimeKey:         bson.M{"$gte": since},
+		TestResultsKey + ".0": bson.M{"$exists": true},
+	}
+	if buildVariant != "" {
+		query[BuildVariantKey] = buildVariant
+	}
+	return db.Query(query).WithFields(IdKey, DisplayNameKey, BuildVariantKey, RevisionKey,
+		RevisionOrderNumberKey, ExecutionKey, TestResultsKey)
+}
+
 func ByDispatchedWithIdsVersionAndStatus(taskIds []string, versionId string, statuses []string) db.Q {
 	return db.Query(bson.M{
 		IdKey: bson.M{
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// DefaultTestHistoryLimit is the number of test results returned when no limit is given.
+	DefaultTestHistoryLimit = 100
+	// MaxTestHistoryLimit is the largest number of test results returned by one query.
+	MaxTestHistoryLimit = 1000
+)
+
+// TestHistoryParameters are the filters for a test history query. Only the project is
+// required; empty filters match everything.
+type TestHistoryParameters struct {
+	ProjectId     string
+	BuildVariants []string
+	TaskNames     []string
+	TestNameRegex string
+	TestStatuses  []string
+	Requester     string
+	After         time.Time
+	Before        time.Time
+	Limit         int
+	Offset        int
+}
+
+// TestHistoryResult is a single test result along with the task run that produced it.
+type TestHistoryResult struct {
+	TestFile            string    `bson:"test_file" json:"test_file"`
+	TestStatus          string    `bson:"test_status" json:"test_status"`
+	URL                 string    `bson:"url" json:"url,omitempty"`
+	URLRaw              string    `bson:"url_raw" json:"url_raw,omitempty"`
+	LogId               string    `bson:"log_id" json:"log_id,omitempty"`
+	StartTime           float64   `bson:"start" json:"start"`
+	EndTime             float64   `bson:"end" json:"end"`
+	TaskId              string    `bson:"task_id" json:"task_id"`
+	TaskName            string    `bson:"task_name" json:"task_name"`
+	TaskStatus          string    `bson:"task_status" json:"task_status"`
+	Execution           int       `bson:"execution" json:"execution"`
+	BuildVariant        string    `bson:"build_variant" json:"build_variant"`
+	Revision            string    `bson:"revision" json:"revision"`
+	RevisionOrderNumber int       `bson:"order" json:"order"`
+	Requester           string    `bson:"requester" json:"requester"`
+	TaskFinishTime      time.Time `bson:"finish_time" json:"finish_time"`
+}
+
+// Validate checks the parameters and fills in the default requester and limit.
+func (p *TestHistoryParameters) Validate() error {
+	if p.ProjectId == "" {
+		return fmt.Errorf("a project is required")
+	}
+	if p.TestNameRegex != "" {
+		if _, err := regexp.Compile(p.TestNameRegex); err != nil {
+			return fmt.Errorf("invalid test name regex '%v': %v", p.TestNameRegex, err)
+		}
+	}
+	for _, status := range p.TestStatuses {
+		switch status {
+		case evergreen.TestFailedStatus, evergreen.TestSkippedStatus, evergreen.TestSucceededStatus:
+		default:
+			return fmt.Errorf("invalid test status '%v'", status)
+		}
+	}
+	if !p.After.IsZero() && !p.Before.IsZero() && !p.After.Before(p.Before) {
+		return fmt.Errorf("the start of the time range must be before its end")
+	}
+	if p.Requester == "" {
+		p.Requester = evergreen.RepotrackerVersionRequester
+	}
+	if p.Limit <= 0 {
+		p.Limit = DefaultTestHistoryLimit
+	}
+	if p.Limit > MaxTestHistoryLimit {
+		return fmt.Errorf("limit must be at most %v", MaxTestHistoryLimit)
+	}
+	if p.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	return nil
+}
+
+// testHistoryPipeline builds the aggregation that unwinds the test results of the matching
+// tasks, newest revision first. The test filters are applied both before unwinding, so the
+// indexes on the test results can be used, and after, to drop the other tests of each task.
+func testHistoryPipeline(p *TestHistoryParameters) []bson.M {
+	taskMatch := bson.M{
+		task.ProjectKey:   p.ProjectId,
+		task.RequesterKey: p.Requester,
+		task.StatusKey:    bson.M{"$in": []string{evergreen.TaskFailed, evergreen.TaskSucceeded}},
+	}
+	if len(p.BuildVariants) > 0 {
+		taskMatch[task.BuildVariantKey] = bson.M{"$in": p.BuildVariants}
+	}
+	if len(p.TaskNames) > 0 {
+		taskMatch[task.DisplayNameKey] = bson.M{"$in": p.TaskNames}
+	}
+	finishTime := bson.M{}
+	if !p.After.IsZero() {
+		finishTime["$gte"] = p.After
+	}
+	if !p.Before.IsZero() {
+		finishTime["$lt"] = p.Before
+	}
+	if len(finishTime) > 0 {
+		taskMatch[task.FinishTimeKey] = finishTime
+	}
+
+	testMatch := bson.M{}
+	if p.TestNameRegex != "" {
+		testMatch[task.TestResultTestFileKey] = bson.RegEx{Pattern: p.TestNameRegex}
+	}
+	if len(p.TestStatuses) > 0 {
+		testMatch[task.TestResultStatusKey] = bson.M{"$in": p.TestStatuses}
+	}
+	if len(testMatch) > 0 {
+		taskMatch[task.TestResultsKey] = bson.M{"$elemMatch": testMatch}
+	} else {
+		taskMatch[task.TestResultsKey+".0"] = bson.M{"$exists": true}
+	}
+
+	unwoundMatch := bson.M{}
+	for key, value := range testMatch {
+		unwoundMatch[fmt.Sprintf("%v.%v", task.TestResultsKey, key)] = value
+	}
+
+	testField := func(key string) string {
+		return fmt.Sprintf("$%v.%v", task.TestResultsKey, key)
+	}
+	return []bson.M{
+		{"$match": taskMatch},
+		{"$project": bson.M{
+			task.IdKey:                  1,
+			task.DisplayNameKey:         1,
+			task.StatusKey:              1,
+			task.ExecutionKey:           1,
+			task.BuildVariantKey:        1,
+			task.RevisionKey:            1,
+			task.RevisionOrderNumberKey: 1,
+			task.RequesterKey:           1,
+			task.FinishTimeKey:          1,
+			task.TestResultsKey:         1,
+		}},
+		{"$unwind": "$" + task.TestResultsKey},
+		{"$match": unwoundMatch},
+		{"$project": bson.M{
+			"_id":           0,
+			"test_file":     testField(task.TestResultTestFileKey),
+			"test_status":   testField(task.TestResultStatusKey),
+			"url":           testField(task.TestResultURLKey),
+			"url_raw":       testField(task.TestResultURLRawKey),
+			"log_id":        testField(task.TestResultLogIdKey),
+			"start":         testField(task.TestResultStartTimeKey),
+			"end":           testField(task.TestResultEndTimeKey),
+			"task_id":       "$" + task.IdKey,
+			"task_name":     "$" + task.DisplayNameKey,
+			"task_status":   "$" + task.StatusKey,
+			"execution":     "$" + task.ExecutionKey,
+			"build_variant": "$" + task.BuildVariantKey,
+			"revision":      "$" + task.RevisionKey,
+			"order":         "$" + task.RevisionOrderNumberKey,
+			"requester":     "$" + task.RequesterKey,
+			"finish_time":   "$" + task.FinishTimeKey,
+		}},
+		{"$sort": bson.D{{"order", -1}, {"build_variant", 1}, {"task_name", 1}, {"test_file", 1}}},
+		{"$skip": p.Offset},
+		{"$limit": p.Limit},
+	}
+}
+
+// GetTestHistory returns the test results matching the parameters, newest revision first.
+// The parameters must have been validated.
+func GetTestHistory(p *TestHistoryParameters) ([]TestHistoryResult, error) {
+	results := []TestHistoryResult{}
+	err := db.Aggregate(task.Collection, testHistoryPipeline(p), &results)
+	if err != nil {
+		return nil, fmt.Errorf("Error aggregating test history: %v", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/task"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// testHistoryIds returns "<task id>/<test file>" for each result, in order.
+func testHistoryIds(results []TestHistoryResult) []string {
+	ids := []string{}
+	for _, r := range results {
+		ids = append(ids, fmt.Sprintf("%v/%v", r.TaskId, r.TestFile))
+	}
+	return ids
+}
+
+func TestGetTestHistory(t *testing.T) {
+	Convey("With tasks and test results from several revisions", t, func() {
+		So(db.Clear(task.Collection), ShouldBeNil)
+
+		tasks := []task.Task{
+			{Id: "t1", DisplayName: "compile", BuildVariant: "bv1", RevisionOrderNumber: 1,
+				Status: evergreen.TaskSucceeded, TestResults: []task.TestResult{
+					{TestFile: "a.js", Status: evergreen.TestSucceededStatus},
+					{TestFile: "b.js", Status: evergreen.TestFailedStatus},
+				}},
+			{Id: "t2", DisplayName: "test", BuildVariant: "bv1", RevisionOrderNumber: 2,
+				Status: evergreen.TaskFailed, TestResults: []task.TestResult{
+					{TestFile: "c.js", Status: evergreen.TestFailedStatus},
+					{TestFile: "a.js", Status: evergreen.TestSucceededStatus},
+				}},
+			{Id: "t3", DisplayName: "compile", BuildVariant: "bv2", RevisionOrderNumber: 2,
+				Status: evergreen.TaskSucceeded, TestResults: []task.TestResult{
+					{TestFile: "a.js", Status: evergreen.TestSkippedStatus},
+				}},
+			// not returned: a patch, an unfinished task and another project
+			{Id: "patch", DisplayName: "compile", BuildVariant: "bv1", RevisionOrderNumber: 3,
+				Status: evergreen.TaskFailed, Requester: evergreen.PatchVersionRequester,
+				TestResults: []task.TestResult{{TestFile: "a.js", Status: evergreen.TestFailedStatus}}},
+			{Id: "started", DisplayName: "compile", BuildVariant: "bv1", RevisionOrderNumber: 3,
+				Status: evergreen.TaskStarted, TestResults: []task.TestResult{
+					{TestFile: "a.js", Status: evergreen.TestFailedStatus},
+				}},
+			{Id: "other", DisplayName: "compile", BuildVariant: "bv1", RevisionOrderNumber: 3,
+				Project: "other", Status: evergreen.TaskFailed, TestResults: []task.TestResult{
+					{TestFile: "a.js", Status: evergreen.TestFailedStatus},
+				}},
+		}
+		for i := range tasks {
+			if tasks[i].Project == "" {
+				tasks[i].Project = "project"
+			}
+			if tasks[i].Requester == "" {
+				tasks[i].Requester = evergreen.RepotrackerVersionRequester
+			}
+			So(tasks[i].Insert(), ShouldBeNil)
+		}
+
+		getHistory := func(p TestHistoryParameters) []string {
+			p.ProjectId = "project"
+			So(p.Validate(), ShouldBeNil)
+			results, err := GetTestHistory(&p)
+			So(err, ShouldBeNil)
+			return testHistoryIds(results)
+		}
+
+		Convey("every test of finished mainline tasks should be returned, newest revision first", func() {
+			So(getHistory(TestHistoryParameters{}), ShouldResemble, []string{
+				"t2/a.js", "t2/c.js", "t3/a.js", "t1/a.js", "t1/b.js",
+			})
+		})
+		Convey("each row should hold the test and the task that ran it", func() {
+			results, err := GetTestHistory(&TestHistoryParameters{
+				ProjectId:     "project",
+				Requester:     evergreen.RepotrackerVersionRequester,
+				BuildVariants: []string{"bv2"},
+				Limit:         DefaultTestHistoryLimit,
+			})
+			So(err, ShouldBeNil)
+			So(len(results), ShouldEqual, 1)
+			So(results[0].TestFile, ShouldEqual, "a.js")
+			So(results[0].TestStatus, ShouldEqual, evergreen.TestSkippedStatus)
+			So(results[0].TaskId, ShouldEqual, "t3")
+			So(results[0].TaskName, ShouldEqual, "compile")
+			So(results[0].TaskStatus, ShouldEqual, evergreen.TaskSucceeded)
+			So(results[0].BuildVariant, ShouldEqual, "bv2")
+			So(results[0].RevisionOrderNumber, ShouldEqual, 2)
+			So(results[0].Requester, ShouldEqual, evergreen.RepotrackerVersionRequester)
+		})
+		Convey("filtering by test status should only return tests with that status", func() {
+			So(getHistory(TestHistoryParameters{TestStatuses: []string{evergreen.TestFailedStatus}}),
+				ShouldResemble, []string{"t2/c.js", "t1/b.js"})
+		})
+		Convey("filtering by task should only return tests of tasks with that name", func() {
+			So(getHistory(TestHistoryParameters{TaskNames: []string{"compile"}}),
+				ShouldResemble, []string{"t3/a.js", "t1/a.js", "t1/b.js"})
+		})
+		Convey("filtering by variant should only return tests of tasks on that variant", func() {
+			So(getHistory(TestHistoryParameters{BuildVariants: []string{"bv2"}}),
+				ShouldResemble, []string{"t3/a.js"})
+		})
+		Convey("filtering by test name should only return matching tests", func() {
+			So(getHistory(TestHistoryParameters{TestNameRegex: "^a"}),
+				ShouldResemble, []string{"t2/a.js", "t3/a.js", "t1/a.js"})
+		})
+		Convey("filters should combine", func() {
+			So(getHistory(TestHistoryParameters{
+				BuildVariants: []string{"bv1"},
+				TaskNames:     []string{"compile"},
+				TestStatuses:  []string{evergreen.TestSucceededStatus},
+			}), ShouldResemble, []string{"t1/a.js"})
+		})
+		Convey("the limit and offset should page through the sorted results", func() {
+			So(getHistory(TestHistoryParameters{Limit: 2}),
+				ShouldResemble, []string{"t2/a.js", "t2/c.js"})
+			So(getHistory(TestHistoryParameters{Limit: 2, Offset: 2}),
+				ShouldResemble, []string{"t3/a.js", "t1/a.js"})
+			So(getHistory(TestHistoryParameters{Limit: 2, Offset: 4}),
+				ShouldResemble, []string{"t1/b.js"})
+		})
+	})
+}
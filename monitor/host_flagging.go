@@ -6,7 +6,9 @@ import (
 
 	"github.com/10gen-labs/slogger/v1"
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
+	"github.com/evergreen-ci/evergreen/hostinit"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/host"
 )
@@ -18,6 +20,10 @@ const (
 	// UnreachableCutoff is the threshold to wait for an unreachable host to become marked
 	// as reachable again before giving up and terminating it.
 	UnreachableCutoff = 10 * time.Minute
+
+	// StoppedCutoff is how long a host stopped while idle is kept, in case
+	// it's needed again, before it's terminated.
+	StoppedCutoff = 7 * 24 * time.Hour
 )
 
 type hostFlagger struct {
@@ -52,10 +58,35 @@ func flagUnreachableHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host
 }
 
 // flagIdleHosts is a hostFlaggingFunc to get all hosts which have spent too
-// long without running a task
+// long without running a task. Hosts that are stopped when idle instead are
+// left for stopIdleHosts.
 func flagIdleHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error) {
-	// will ultimately contain all of the hosts determined to be idle
+	idle, err := findIdleHosts(d, s)
+	if err != nil {
+		return nil, err
+	}
+
 	idleHosts := []host.Host{}
+	for _, h := range idle {
+		if !h.stop {
+			idleHosts = append(idleHosts, h.Host)
+		}
+	}
+	return idleHosts, nil
+}
+
+// idleHost is a host that has idled long enough to be terminated or stopped.
+type idleHost struct {
+	host.Host
+	stop bool
+}
+
+// findIdleHosts returns the hosts which have spent too long without running
+// a task, leaving enough free hosts for each distro's warm pool, and whether
+// each should be stopped rather than terminated.
+func findIdleHosts(distros []distro.Distro, s *evergreen.Settings) ([]idleHost, error) {
+	// will ultimately contain all of the hosts determined to be idle
+	idleHosts := []idleHost{}
 
 	// fetch all hosts not currently running a task
 	freeHosts, err := host.Find(host.IsFree)
@@ -63,6 +94,19 @@ func flagIdleHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error
 		return nil, fmt.Errorf("error finding free hosts: %v", err)
 	}
 
+	// the number of free hosts each distro can lose before its warm pool
+	// is too small
+	now := time.Now()
+	distrosById := map[string]distro.Distro{}
+	excessFree := map[string]int{}
+	for _, d := range distros {
+		distrosById[d.Id] = d
+		excessFree[d.Id] = -d.WarmPool.MinIdleAt(now)
+	}
+	for _, h := range freeHosts {
+		excessFree[h.Distro.Id]++
+	}
+
 	// go through the hosts, and see if they have idled long enough to
 	// be terminated
 	for _, host := range freeHosts {
@@ -92,14 +136,31 @@ func flagIdleHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error
 		// current determinants for idle:
 		//  idle for at least 15 minutes and
 		//  less than 5 minutes til next payment
-		if idleTime >= 15*time.Minute && tilNextPayment <= 5*time.Minute {
-			idleHosts = append(idleHosts, host)
+		//  and not needed for the distro's warm pool
+		if idleTime >= 15*time.Minute && tilNextPayment <= 5*time.Minute && excessFree[host.Distro.Id] > 0 {
+			_, canStop := cloudManager.(cloud.Stopper)
+			idleHosts = append(idleHosts, idleHost{
+				Host: host,
+				stop: canStop && distrosById[host.Distro.Id].WarmPool.StopIdle,
+			})
+			excessFree[host.Distro.Id]--
 		}
 	}
 
 	return idleHosts, nil
 }
 
+// flagStoppedHosts is a hostFlaggingFunc to get all hosts that have been
+// stopped for too long to be worth keeping
+func flagStoppedHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error) {
+	threshold := time.Now().Add(-StoppedCutoff)
+	hosts, err := host.Find(host.ByStoppedBefore(threshold))
+	if err != nil {
+		return nil, fmt.Errorf("error finding hosts stopped since before %v: %v", threshold, err)
+	}
+	return hosts, nil
+}
+
 // flagExcessHosts is a hostFlaggingFunc to get all hosts that push their
 // distros over the specified max hosts
 func flagExcessHosts(distros []distro.Distro, s *evergreen.Settings) ([]host.Host, error) {
@@ -157,15 +218,31 @@ func flagExcessHosts(distros []distro.Distro, s *evergreen.Settings) ([]host.Hos
 }
 
 // flagUnprovisionedHosts is a hostFlaggingFunc to get all hosts that are
-// taking too long to provision
+// taking too long to provision. Hosts of distros with a setup script or
+// provisioning steps get as long as they can take on top of ProvisioningCutoff.
 func flagUnprovisionedHosts(d []distro.Distro, s *evergreen.Settings) ([]host.Host, error) {
 	// fetch all hosts that are taking too long to provision
-	threshold := time.Now().Add(-ProvisioningCutoff)
-	hosts, err := host.Find(host.ByUnprovisionedSince(threshold))
+	now := time.Now()
+	threshold := now.Add(-ProvisioningCutoff)
+	unprovisioned, err := host.Find(host.ByUnprovisionedSince(threshold))
 	if err != nil {
 		return nil, fmt.Errorf("error finding unprovisioned hosts: %v", err)
 	}
-	return hosts, err
+	stepTimeout := defaultStepTimeout(s)
+	hosts := []host.Host{}
+	for _, h := range unprovisioned {
+		budget := h.Distro.ProvisioningBudget(stepTimeout)
+		if h.CreationTime.Add(ProvisioningCutoff + budget).Before(now) {
+			hosts = append(hosts, h)
+		}
+	}
+
+	// hosts that were stopped and started again are already provisioned
+	restarting, err := host.Find(host.ByRestartingSince(threshold))
+	if err != nil {
+		return nil, fmt.Errorf("error finding restarting hosts: %v", err)
+	}
+	return append(hosts, restarting...), nil
 }
 
 // flagProvisioningFailedHosts is a hostFlaggingFunc to get all hosts
@@ -207,7 +284,25 @@ func hostCanBeTerminated(h host.Host, s *evergreen.Settings) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("error checking if cloud manager for host %v supports spawning: %v", h.Id, err)
 	}
+	if !canSpawn {
+		return false, nil
+	}
+
+	// hosts that are running containers are still in use
+	containers, err := host.Count(host.ByParentId(h.Id))
+	if err != nil {
+		return false, fmt.Errorf("error counting containers on host %v: %v", h.Id, err)
+	}
+
+	return containers == 0, nil
 
-	return canSpawn, nil
+}
 
+// defaultStepTimeout returns how long hostinit lets provisioning steps that
+// don't set a timeout run for.
+func defaultStepTimeout(s *evergreen.Settings) time.Duration {
+	if s != nil && s.HostInit.SSHTimeoutSeconds > 0 {
+		return time.Duration(s.HostInit.SSHTimeoutSeconds) * time.Second
+	}
+	return time.Duration(hostinit.SSHTimeoutSeconds) * time.Second
 }
@@ -146,6 +146,70 @@ func TestFlaggingIdleHosts(t *testing.T) {
 
 		})
 
+		Convey("hosts running containers should not be flagged", func() {
+
+			// insert an idle parent host with a container on it
+			parent := host.Host{
+				Id:           "h1",
+				Provider:     mock.ProviderName,
+				CreationTime: time.Now().Add(-30 * time.Minute),
+				Status:       evergreen.HostRunning,
+				StartedBy:    evergreen.User,
+			}
+			testutil.HandleTestingErr(parent.Insert(), t, "error inserting host")
+
+			container := host.Host{
+				Id:          "c1",
+				Provider:    mock.ProviderName,
+				RunningTask: "t1",
+				ParentId:    "h1",
+				Status:      evergreen.HostRunning,
+				StartedBy:   evergreen.User,
+			}
+			testutil.HandleTestingErr(container.Insert(), t, "error inserting host")
+
+			idle, err := flagIdleHosts(nil, nil)
+			So(err, ShouldBeNil)
+			So(len(idle), ShouldEqual, 0)
+
+			// once the container is terminated, the parent is idle
+			testutil.HandleTestingErr(container.SetTerminated(), t, "error terminating host")
+			idle, err = flagIdleHosts(nil, nil)
+			So(err, ShouldBeNil)
+			So(len(idle), ShouldEqual, 1)
+			So(idle[0].Id, ShouldEqual, "h1")
+
+		})
+
+		Convey("hosts needed for their distro's warm pool should not be"+
+			" flagged", func() {
+
+			distros := []distro.Distro{{Id: "d1", WarmPool: distro.WarmPool{MinIdle: 2}}}
+			for _, id := range []string{"h1", "h2", "h3"} {
+				h := host.Host{
+					Id:           id,
+					Distro:       distro.Distro{Id: "d1"},
+					Provider:     mock.ProviderName,
+					CreationTime: time.Now().Add(-30 * time.Minute),
+					Status:       evergreen.HostRunning,
+					StartedBy:    evergreen.User,
+				}
+				testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+			}
+
+			// only the host above the floor should be flagged
+			idle, err := flagIdleHosts(distros, nil)
+			So(err, ShouldBeNil)
+			So(len(idle), ShouldEqual, 1)
+
+			// nothing should be flagged once the floor is raised
+			distros[0].WarmPool.MinIdle = 3
+			idle, err = flagIdleHosts(distros, nil)
+			So(err, ShouldBeNil)
+			So(len(idle), ShouldEqual, 0)
+
+		})
+
 	})
 
 }
@@ -474,6 +538,34 @@ func TestFlaggingUnprovisionedHosts(t *testing.T) {
 
 		})
 
+		Convey("hosts should get as long as their provisioning steps can"+
+			" take on top of the limit", func() {
+
+			provisioning := distro.Provisioning{
+				Steps: []distro.ProvisionStep{{Name: "install", Script: "install", TimeoutSecs: 600, Retries: 1}},
+			}
+			host1 := &host.Host{
+				Id:           "h1",
+				StartedBy:    evergreen.User,
+				CreationTime: time.Now().Add(-time.Minute * 40),
+				Distro:       distro.Distro{Provisioning: provisioning},
+			}
+			testutil.HandleTestingErr(host1.Insert(), t, "error inserting host")
+			host2 := &host.Host{
+				Id:           "h2",
+				StartedBy:    evergreen.User,
+				CreationTime: time.Now().Add(-time.Minute * 60),
+				Distro:       distro.Distro{Provisioning: provisioning},
+			}
+			testutil.HandleTestingErr(host2.Insert(), t, "error inserting host")
+
+			unprovisioned, err := flagUnprovisionedHosts(nil, nil)
+			So(err, ShouldBeNil)
+			So(len(unprovisioned), ShouldEqual, 1)
+			So(unprovisioned[0].Id, ShouldEqual, "h2")
+
+		})
+
 	})
 }
 
@@ -8,6 +8,10 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
+	"github.com/evergreen-ci/evergreen/cloud/providers/docker"
+	"github.com/evergreen-ci/evergreen/cloud/providers/ec2"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
 )
 
@@ -95,9 +99,111 @@ func checkHostReachability(host host.Host, settings *evergreen.Settings) error {
 		if err := host.SetTerminated(); err != nil {
 			return fmt.Errorf("error setting host %v terminated: %v", host.Id, err)
 		}
+	case cloud.StatusPreempted:
+		evergreen.Logger.Logf(slogger.INFO, "Host %v was preempted by its provider; terminating it", host.Id)
+		event.LogHostPreempted(host.Id, host.RunningTask)
+
+		// the instance is gone, so skip teardown. any task it was running is
+		// reset once its heartbeat times out
+		if err := cloudHost.TerminateInstance(); err != nil {
+			return fmt.Errorf("error terminating preempted host %v: %v", host.Id, err)
+		}
 	}
 
 	// success
 	return nil
 
 }
+
+// collectContainerGarbage is a hostMonitoringFunc responsible for removing
+// stopped containers and stale images from the hosts Docker distros run on.
+func collectContainerGarbage(settings *evergreen.Settings) []error {
+	evergreen.Logger.Logf(slogger.INFO, "Collecting container garbage...")
+
+	distros, err := distro.Find(distro.ByProvider(docker.ProviderName))
+	if err != nil {
+		return []error{fmt.Errorf("error finding docker distros: %v", err)}
+	}
+	errs := docker.CollectGarbage(distros)
+
+	evergreen.Logger.Logf(slogger.INFO, "Finished collecting container garbage")
+
+	return errs
+}
+
+// fallBackSpotRequests is a hostMonitoringFunc responsible for replacing the
+// unfulfilled spot requests of hosts with the distro's next spot option, or
+// with an on-demand instance, once it's time to.
+func fallBackSpotRequests(settings *evergreen.Settings) []error {
+	evergreen.Logger.Logf(slogger.INFO, "Falling back from unfulfilled spot requests...")
+
+	hosts, err := host.Find(host.UninitializedByProvider(ec2.SpotProviderName))
+	if err != nil {
+		return []error{fmt.Errorf("error finding unfulfilled spot requests: %v", err)}
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	cloudManager, err := providers.GetCloudManager(ec2.SpotProviderName, settings)
+	if err != nil {
+		return []error{fmt.Errorf("error getting cloud manager for spot hosts: %v", err)}
+	}
+	spotManager, ok := providers.Unwrap(cloudManager).(*ec2.EC2SpotManager)
+	if !ok {
+		return []error{fmt.Errorf("unexpected cloud manager for spot hosts: %T", cloudManager)}
+	}
+
+	errs := []error{}
+	for i := range hosts {
+		oldId := hosts[i].Id
+		replaced, err := spotManager.FallBack(&hosts[i])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error falling back from spot request %v: %v", oldId, err))
+			continue
+		}
+		if replaced {
+			event.LogMonitorOperation(hosts[i].Id, "spot_fallback")
+		}
+	}
+
+	evergreen.Logger.Logf(slogger.INFO, "Finished falling back from unfulfilled spot requests")
+
+	return errs
+}
+
+// stopIdleHosts is a hostMonitoringFunc responsible for stopping the idle
+// hosts of distros that stop their hosts rather than terminating them, so
+// the scheduler can start them again when they're needed.
+func stopIdleHosts(settings *evergreen.Settings) []error {
+	evergreen.Logger.Logf(slogger.INFO, "Stopping idle hosts...")
+
+	distros, err := distro.Find(distro.All)
+	if err != nil {
+		return []error{fmt.Errorf("error finding distros: %v", err)}
+	}
+	idleHosts, err := findIdleHosts(distros, settings)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := []error{}
+	for _, h := range idleHosts {
+		if !h.stop {
+			continue
+		}
+		cloudManager, err := providers.GetCloudManager(h.Provider, settings)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error getting cloud manager for host %v: %v", h.Id, err))
+			continue
+		}
+		evergreen.Logger.Logf(slogger.INFO, "Stopping idle host %v", h.Id)
+		event.LogMonitorOperation(h.Id, "stop_idle")
+		if err := cloudManager.(cloud.Stopper).StopInstance(&h.Host); err != nil {
+			errs = append(errs, fmt.Errorf("error stopping host %v: %v", h.Id, err))
+		}
+	}
+
+	evergreen.Logger.Logf(slogger.INFO, "Finished stopping idle hosts")
+
+	return errs
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/cloud/providers/mock"
 	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/testutil"
 	. "github.com/smartystreets/goconvey/convey"
@@ -85,3 +86,78 @@ func TestMonitorReachability(t *testing.T) {
 	})
 
 }
+
+func TestStopIdleHosts(t *testing.T) {
+
+	testConfig := evergreen.TestConfig()
+
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(testConfig))
+
+	Convey("When stopping idle hosts", t, func() {
+
+		// reset the db
+		testutil.HandleTestingErr(db.ClearCollections(host.Collection, distro.Collection),
+			t, "error clearing collections")
+
+		stopping := &distro.Distro{Id: "stopping", WarmPool: distro.WarmPool{StopIdle: true}}
+		testutil.HandleTestingErr(stopping.Insert(), t, "error inserting distro")
+		terminating := &distro.Distro{Id: "terminating"}
+		testutil.HandleTestingErr(terminating.Insert(), t, "error inserting distro")
+
+		idleHost := func(id, distroId string) *host.Host {
+			return &host.Host{
+				Id:                    id,
+				Distro:                distro.Distro{Id: distroId},
+				Provider:              mock.ProviderName,
+				LastTaskCompleted:     "t1",
+				LastTaskCompletedTime: time.Now().Add(-time.Minute * 20),
+				Status:                evergreen.HostRunning,
+				StartedBy:             evergreen.User,
+			}
+		}
+
+		Convey("idle hosts of distros that stop their hosts should be"+
+			" stopped", func() {
+
+			h := idleHost("h1", stopping.Id)
+			testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+
+			So(stopIdleHosts(testConfig), ShouldBeEmpty)
+
+			stopped, err := host.FindOne(host.ById("h1"))
+			So(err, ShouldBeNil)
+			So(stopped.Status, ShouldEqual, evergreen.HostStopped)
+			So(stopped.StopTime.IsZero(), ShouldBeFalse)
+
+		})
+
+		Convey("idle hosts of other distros should be left to be"+
+			" terminated", func() {
+
+			h := idleHost("h1", terminating.Id)
+			testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+
+			So(stopIdleHosts(testConfig), ShouldBeEmpty)
+
+			running, err := host.FindOne(host.ById("h1"))
+			So(err, ShouldBeNil)
+			So(running.Status, ShouldEqual, evergreen.HostRunning)
+
+		})
+
+		Convey("hosts that aren't idle should not be stopped", func() {
+
+			h := idleHost("h1", stopping.Id)
+			h.LastTaskCompletedTime = time.Now()
+			testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+
+			So(stopIdleHosts(testConfig), ShouldBeEmpty)
+
+			running, err := host.FindOne(host.ById("h1"))
+			So(err, ShouldBeNil)
+			So(running.Status, ShouldEqual, evergreen.HostRunning)
+
+		})
+
+	})
+}
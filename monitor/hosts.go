@@ -9,6 +9,7 @@ import (
 	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/hostutil"
+	"github.com/evergreen-ci/evergreen/model/alert"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
@@ -134,14 +135,15 @@ func terminateHost(host *host.Host, settings *evergreen.Settings) error {
 	}
 
 	// run teardown script if we have one, sending notifications if things go awry
-	if host.Distro.Teardown != "" && host.Provisioned {
+	// stopped hosts can't run it
+	if host.Distro.Teardown != "" && host.Provisioned && host.Status != evergreen.HostStopped {
 		evergreen.Logger.Logf(slogger.ERROR, "Running teardown script for host %v", host.Id)
 		if err := runHostTeardown(host, cloudHost); err != nil {
 			evergreen.Logger.Logf(slogger.ERROR, "Error running teardown script for %v: %v", host.Id, err)
 			subj := fmt.Sprintf("%v Error running teardown for host %v",
 				notify.TeardownFailurePreface, host.Id)
-			if err := notify.NotifyAdmins(subj, err.Error(), settings); err != nil {
-				evergreen.Logger.Errorf(slogger.ERROR, "Error sending email: %v", err)
+			if err := alert.EnqueueOpsAlert(subj, err.Error()); err != nil {
+				evergreen.Logger.Errorf(slogger.ERROR, "Error queueing ops alert: %v", err)
 			}
 		}
 	}
@@ -155,6 +157,16 @@ func terminateHost(host *host.Host, settings *evergreen.Settings) error {
 }
 
 func runHostTeardown(h *host.Host, cloudHost *cloud.CloudHost) error {
+	if executor, ok := cloudHost.Executor(); ok {
+		startTime := time.Now()
+		logs, err := hostutil.ExecRemoteScript(h, "teardown.sh", executor)
+		event.LogHostTeardown(h.Id, logs, err == nil, time.Since(startTime))
+		if err != nil {
+			return fmt.Errorf("error (%v) running teardown.sh through %v: %v", err, h.Provider, logs)
+		}
+		return nil
+	}
+
 	sshOptions, err := cloudHost.GetSSHOptions()
 	if err != nil {
 		return fmt.Errorf("error getting ssh options for host %v: %v", h.Id, err)
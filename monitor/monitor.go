@@ -30,11 +30,15 @@ var (
 		{flagUnprovisionedHosts, "provision_timeout"},
 		{flagProvisioningFailedHosts, "provision_failed"},
 		{flagExpiredHosts, "expired"},
+		{flagStoppedHosts, "stopped"},
 	}
 
 	// the functions the host monitor will run through to do simpler checks
 	defaultHostMonitoringFuncs = []hostMonitoringFunc{
 		monitorReachability,
+		collectContainerGarbage,
+		fallBackSpotRequests,
+		stopIdleHosts,
 	}
 
 	// the functions the notifier will use to build notifications that need
@@ -144,6 +148,11 @@ func RunAllMonitoring(settings *evergreen.Settings) error {
 		}
 	}
 
+	// Queue alerts for the admins about the health of the infrastructure
+	if err = alerts.RunOpsTriggers(settings); err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "Error queueing ops alerts: %v", err)
+	}
+
 	return nil
 
 }
@@ -1,7 +1,14 @@
 package archive
 
 import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/storage"
 )
 
 func init() {
@@ -12,6 +19,8 @@ const (
 	TarGzPackCmdName   = "targz_pack"
 	TarGzUnpackCmdName = "targz_unpack"
 	ArchivePluginName  = "archive"
+
+	archiveContentType = "application/x-gzip"
 )
 
 // ArchivePlugin holds commands for creating archives and extracting
@@ -34,3 +43,59 @@ func (self *ArchivePlugin) NewCommand(cmdName string) (plugin.Command, error) {
 	}
 	return nil, &plugin.ErrUnknownCommand{cmdName}
 }
+
+// validateRemote checks the params of an archive kept with a storage driver.
+func validateRemote(bucket string, opts storage.Options, awsKey, awsSecret string) error {
+	if bucket == "" {
+		return fmt.Errorf("bucket cannot be blank")
+	}
+	return plugin.ValidateStorage(opts, awsKey, awsSecret)
+}
+
+// chooseStorage returns the command's storage options, falling back to the
+// project's storage settings.
+func chooseStorage(opts storage.Options, conf *model.TaskConfig) storage.Options {
+	if conf.ProjectRef == nil {
+		return opts
+	}
+	return storage.Choose(opts, conf.ProjectRef.Storage)
+}
+
+// uploadArchive stores the local archive at the remote path in the bucket.
+func uploadArchive(localFile, bucket, remoteFile string, opts storage.Options,
+	awsKey, awsSecret string) error {
+	driver, err := storage.GetDriver(opts, awsKey, awsSecret)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return driver.Put(bucket, remoteFile, f, fi.Size(), storage.PutOptions{ContentType: archiveContentType})
+}
+
+// openRemoteArchive returns a reader for the archive at the remote path in the
+// bucket, and a gzip reader for its contents. Both must be closed.
+func openRemoteArchive(bucket, remoteFile string, opts storage.Options,
+	awsKey, awsSecret string) (io.ReadCloser, *gzip.Reader, error) {
+	driver, err := storage.GetDriver(opts, awsKey, awsSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := driver.Get(bucket, remoteFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		r.Close()
+		return nil, nil, err
+	}
+	return r, gz, nil
+}
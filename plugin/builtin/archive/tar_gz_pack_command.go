@@ -9,6 +9,7 @@ import (
 	"github.com/evergreen-ci/evergreen/archive"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -27,6 +28,15 @@ type TarGzPackCommand struct {
 	// a list of filename blobs to exclude,
 	// e.g. "*.zip", "results.out", "ignore/**"
 	ExcludeFiles []string `mapstructure:"exclude_files" plugin:"expand"`
+
+	// RemoteFile, if set, is the path within Bucket that the archive is
+	// uploaded to once it's built, using the storage driver selected by
+	// Storage. AwsKey and AwsSecret are the credentials for the bucket.
+	RemoteFile string          `mapstructure:"remote_file" plugin:"expand"`
+	Bucket     string          `mapstructure:"bucket" plugin:"expand"`
+	AwsKey     string          `mapstructure:"aws_key" plugin:"expand"`
+	AwsSecret  string          `mapstructure:"aws_secret" plugin:"expand"`
+	Storage    storage.Options `mapstructure:"storage" plugin:"expand"`
 }
 
 func (self *TarGzPackCommand) Name() string {
@@ -60,7 +70,9 @@ func (self *TarGzPackCommand) validateParams() error {
 	if len(self.Include) == 0 {
 		return fmt.Errorf("include cannot be empty")
 	}
-
+	if self.RemoteFile != "" {
+		return validateRemote(self.Bucket, self.Storage, self.AwsKey, self.AwsSecret)
+	}
 	return nil
 }
 
@@ -73,6 +85,12 @@ func (self *TarGzPackCommand) Execute(pluginLogger plugin.Logger,
 	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
 		return fmt.Errorf("error expanding params: %v", err)
 	}
+	if self.RemoteFile != "" {
+		self.Storage = chooseStorage(self.Storage, conf)
+		if err := self.validateParams(); err != nil {
+			return fmt.Errorf("expanded params are not valid: %v", err)
+		}
+	}
 
 	// if the source dir is a relative path, join it to the working dir
 	if !filepath.IsAbs(self.SourceDir) {
@@ -102,8 +120,15 @@ func (self *TarGzPackCommand) Execute(pluginLogger plugin.Logger,
 			if deleteErr != nil {
 				pluginLogger.LogExecution(slogger.INFO, "Error deleting empty archive: %v", deleteErr)
 			}
+			return nil
 		}
-		return nil
+		if self.RemoteFile == "" {
+			return nil
+		}
+		pluginLogger.LogTask(slogger.INFO, "Uploading archive %v to %v in bucket %v",
+			self.Target, self.RemoteFile, self.Bucket)
+		return uploadArchive(self.Target, self.Bucket, self.RemoteFile,
+			self.Storage, self.AwsKey, self.AwsSecret)
 	case <-stop:
 		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
 			" execution of targz pack command")
@@ -1,6 +1,7 @@
 package archive
 
 import (
+	"archive/tar"
 	"fmt"
 	"os"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/evergreen-ci/evergreen/archive"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -17,6 +19,15 @@ type TarGzUnpackCommand struct {
 	Source string `mapstructure:"source" plugin:"expand"`
 	// the directory that the unpacked contents should be put into
 	DestDir string `mapstructure:"dest_dir" plugin:"expand"`
+
+	// RemoteFile, if set instead of Source, is the path within Bucket of the
+	// archive to unpack, which is read with the storage driver selected by
+	// Storage. AwsKey and AwsSecret are the credentials for the bucket.
+	RemoteFile string          `mapstructure:"remote_file" plugin:"expand"`
+	Bucket     string          `mapstructure:"bucket" plugin:"expand"`
+	AwsKey     string          `mapstructure:"aws_key" plugin:"expand"`
+	AwsSecret  string          `mapstructure:"aws_secret" plugin:"expand"`
+	Storage    storage.Options `mapstructure:"storage" plugin:"expand"`
 }
 
 func (self *TarGzUnpackCommand) Name() string {
@@ -38,15 +49,22 @@ func (self *TarGzUnpackCommand) ParseParams(params map[string]interface{}) error
 	return nil
 }
 
-// Make sure both source and dest dir are speciifed.
+// Make sure exactly one of source and remote_file, and the dest dir, are
+// specified.
 func (self *TarGzUnpackCommand) validateParams() error {
 
-	if self.Source == "" {
-		return fmt.Errorf("source cannot be blank")
+	if self.Source == "" && self.RemoteFile == "" {
+		return fmt.Errorf("must specify either source or remote_file")
+	}
+	if self.Source != "" && self.RemoteFile != "" {
+		return fmt.Errorf("cannot specify both source and remote_file")
 	}
 	if self.DestDir == "" {
 		return fmt.Errorf("dest_dir cannot be blank")
 	}
+	if self.RemoteFile != "" {
+		return validateRemote(self.Bucket, self.Storage, self.AwsKey, self.AwsSecret)
+	}
 
 	return nil
 }
@@ -60,6 +78,12 @@ func (self *TarGzUnpackCommand) Execute(pluginLogger plugin.Logger,
 	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
 		return fmt.Errorf("error expanding params: %v", err)
 	}
+	if self.RemoteFile != "" {
+		self.Storage = chooseStorage(self.Storage, conf)
+		if err := self.validateParams(); err != nil {
+			return fmt.Errorf("expanded params are not valid: %v", err)
+		}
+	}
 
 	errChan := make(chan error)
 	go func() {
@@ -80,13 +104,27 @@ func (self *TarGzUnpackCommand) Execute(pluginLogger plugin.Logger,
 // set for the command during parameter parsing.
 func (self *TarGzUnpackCommand) UnpackArchive() error {
 
-	// get a reader for the source file
-	f, _, tarReader, err := archive.TarGzReader(self.Source)
-	if err != nil {
-		return fmt.Errorf("error opening tar file %v for reading: %v",
-			self.Source, err)
+	// get a reader for the source file, or the remote one
+	var tarReader *tar.Reader
+	if self.RemoteFile != "" {
+		r, gz, err := openRemoteArchive(self.Bucket, self.RemoteFile,
+			self.Storage, self.AwsKey, self.AwsSecret)
+		if err != nil {
+			return fmt.Errorf("error opening remote archive %v for reading: %v",
+				self.RemoteFile, err)
+		}
+		defer r.Close()
+		defer gz.Close()
+		tarReader = tar.NewReader(gz)
+	} else {
+		f, _, reader, err := archive.TarGzReader(self.Source)
+		if err != nil {
+			return fmt.Errorf("error opening tar file %v for reading: %v",
+				self.Source, err)
+		}
+		defer f.Close()
+		tarReader = reader
 	}
-	defer f.Close()
 
 	// extract the actual tarball into the destination directory
 	if err := os.MkdirAll(self.DestDir, 0755); err != nil {
@@ -1,10 +1,13 @@
 package archive_test
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/model"
 	. "github.com/evergreen-ci/evergreen/plugin/builtin/archive"
 	"github.com/evergreen-ci/evergreen/plugin/plugintest"
 	"github.com/evergreen-ci/evergreen/testutil"
@@ -128,3 +131,64 @@ func TestTarGzCommandUnpackArchive(t *testing.T) {
 		})
 	})
 }
+
+func TestTarGzRemoteArchive(t *testing.T) {
+
+	Convey("With an archive kept with a storage driver", t, func() {
+
+		root, err := ioutil.TempDir("", "archive-storage")
+		testutil.HandleTestingErr(err, t, "Error creating storage root")
+		defer os.RemoveAll(root)
+		output := filepath.Join(testDataDir, "remote_output")
+		testutil.HandleTestingErr(os.RemoveAll(output), t, "Error removing output directory")
+		defer os.RemoveAll(output)
+
+		storageParams := map[string]interface{}{"driver": "local", "root": root}
+		conf := &model.TaskConfig{Expansions: command.NewExpansions(map[string]string{})}
+
+		Convey("a remote file without a bucket should cause an error", func() {
+			So((&TarGzPackCommand{}).ParseParams(map[string]interface{}{
+				"target":      "t.tgz",
+				"source_dir":  "s",
+				"include":     []string{"i"},
+				"remote_file": "archives/t.tgz",
+				"storage":     storageParams,
+			}), ShouldNotBeNil)
+			So((&TarGzUnpackCommand{}).ParseParams(map[string]interface{}{
+				"source":      "t.tgz",
+				"remote_file": "archives/t.tgz",
+				"bucket":      "b",
+				"dest_dir":    output,
+				"storage":     storageParams,
+			}), ShouldNotBeNil)
+		})
+
+		Convey("a packed archive should be uploaded and unpacked from the driver", func() {
+			target := filepath.Join(root, "target.tgz")
+			packCmd := &TarGzPackCommand{}
+			So(packCmd.ParseParams(map[string]interface{}{
+				"target":      target,
+				"source_dir":  testDataDir,
+				"include":     []string{"targz_me/dir1/**"},
+				"remote_file": "archives/target.tgz",
+				"bucket":      "b",
+				"storage":     storageParams,
+			}), ShouldBeNil)
+			So(packCmd.Execute(&plugintest.MockLogger{}, nil, conf, make(chan bool)), ShouldBeNil)
+
+			unpackCmd := &TarGzUnpackCommand{}
+			So(unpackCmd.ParseParams(map[string]interface{}{
+				"remote_file": "archives/target.tgz",
+				"bucket":      "b",
+				"dest_dir":    output,
+				"storage":     storageParams,
+			}), ShouldBeNil)
+			So(unpackCmd.UnpackArchive(), ShouldBeNil)
+
+			exists, err := util.FileExists(
+				filepath.Join(output, "targz_me/dir1/dir2/testfile.txt"))
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,209 @@
+package artifacts
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/storage"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+// PublishRequest describes an output a task is uploading or has uploaded.
+type PublishRequest struct {
+	Name     string `json:"name"`
+	FileName string `json:"file_name"`
+	Size     int64  `json:"size"`
+}
+
+// UploadURLResponse holds the URL to upload an output to.
+type UploadURLResponse struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+}
+
+// FetchRequest identifies an output of one of the requesting task's dependencies.
+// If Variant is empty, the requesting task's variant is used.
+type FetchRequest struct {
+	TaskName string `json:"task_name"`
+	Variant  string `json:"variant"`
+	Name     string `json:"name"`
+}
+
+// FetchResponse holds the URL to download an output from.
+type FetchResponse struct {
+	URL      string `json:"url"`
+	FileName string `json:"file_name"`
+	Size     int64  `json:"size"`
+	TaskId   string `json:"task_id"`
+}
+
+// readPublishRequest reads and validates a publish request, writing an error
+// response and returning nil if it's invalid.
+func (self *ArtifactsPlugin) readPublishRequest(w http.ResponseWriter, r *http.Request) (*task.Task, *PublishRequest) {
+	t := plugin.GetTask(r)
+	if t == nil {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return nil, nil
+	}
+	if !self.config.Enabled() {
+		http.Error(w, "storage for task outputs is not configured", http.StatusInternalServerError)
+		return nil, nil
+	}
+	req := &PublishRequest{}
+	if err := util.ReadJSONInto(r.Body, req); err != nil {
+		http.Error(w, fmt.Sprintf("error reading publish request: %v", err), http.StatusBadRequest)
+		return nil, nil
+	}
+	if err := validateOutputName(req.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil
+	}
+	if err := validateFileName(req.FileName); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil
+	}
+	return t, req
+}
+
+// uploadURLHandler returns a pre-signed URL for the task to upload an output to.
+func (self *ArtifactsPlugin) uploadURLHandler(w http.ResponseWriter, r *http.Request) {
+	t, req := self.readPublishRequest(w, r)
+	if req == nil {
+		return
+	}
+	outputPath := outputPath(self.config, t, req.Name, req.FileName)
+	uploadURL, err := self.config.UploadURL(outputPath)
+	if err != nil {
+		message := fmt.Sprintf("error signing upload URL for output '%v' of task %v: %v", req.Name, t.Id, err)
+		evergreen.Logger.Errorf(slogger.ERROR, message)
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	plugin.WriteJSON(w, http.StatusOK, UploadURLResponse{
+		URL:         uploadURL,
+		ContentType: storage.OutputContentType,
+	})
+}
+
+// publishHandler records an output the task has uploaded, replacing any output
+// of the same name the task published before.
+func (self *ArtifactsPlugin) publishHandler(w http.ResponseWriter, r *http.Request) {
+	t, req := self.readPublishRequest(w, r)
+	if req == nil {
+		return
+	}
+	output := &artifact.Output{
+		TaskId:       t.Id,
+		Execution:    t.Execution,
+		TaskName:     t.DisplayName,
+		BuildVariant: t.BuildVariant,
+		Name:         req.Name,
+		FileName:     req.FileName,
+		Size:         req.Size,
+		Bucket:       self.config.Bucket,
+		Path:         outputPath(self.config, t, req.Name, req.FileName),
+		CreateTime:   time.Now(),
+	}
+	if err := output.Upsert(); err != nil {
+		message := fmt.Sprintf("error saving output '%v' of task %v: %v", req.Name, t.Id, err)
+		evergreen.Logger.Errorf(slogger.ERROR, message)
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	plugin.WriteJSON(w, http.StatusOK, "Output published")
+}
+
+// fetchHandler resolves an output of one of the task's dependencies and returns a
+// pre-signed URL to download it from.
+func (self *ArtifactsPlugin) fetchHandler(w http.ResponseWriter, r *http.Request) {
+	t := plugin.GetTask(r)
+	if t == nil {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+	if !self.config.Enabled() {
+		http.Error(w, "storage for task outputs is not configured", http.StatusInternalServerError)
+		return
+	}
+	req := &FetchRequest{}
+	if err := util.ReadJSONInto(r.Body, req); err != nil {
+		http.Error(w, fmt.Sprintf("error reading fetch request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	depTask, err := findDependency(t, req.TaskName, req.Variant)
+	if err != nil {
+		message := fmt.Sprintf("error finding dependencies of task %v: %v", t.Id, err)
+		evergreen.Logger.Errorf(slogger.ERROR, message)
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	if depTask == nil {
+		http.Error(w, fmt.Sprintf("task '%v' on variant '%v' is not a dependency of %v",
+			req.TaskName, req.Variant, t.DisplayName), http.StatusBadRequest)
+		return
+	}
+
+	output, err := artifact.FindOneOutput(artifact.OutputByTaskIdAndName(depTask.Id, req.Name))
+	if err != nil {
+		message := fmt.Sprintf("error finding output '%v' of task %v: %v", req.Name, depTask.Id, err)
+		evergreen.Logger.Errorf(slogger.ERROR, message)
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	if output == nil {
+		http.Error(w, fmt.Sprintf("task %v has not published an output named '%v'",
+			depTask.Id, req.Name), http.StatusNotFound)
+		return
+	}
+	downloadURL, err := self.config.DownloadURL(output.Bucket, output.Path)
+	if err != nil {
+		message := fmt.Sprintf("error signing download URL for output '%v' of task %v: %v", req.Name, depTask.Id, err)
+		evergreen.Logger.Errorf(slogger.ERROR, message)
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	plugin.WriteJSON(w, http.StatusOK, FetchResponse{
+		URL:      downloadURL,
+		FileName: output.FileName,
+		Size:     output.Size,
+		TaskId:   depTask.Id,
+	})
+}
+
+// findDependency returns the task that t depends on with the given display name and
+// variant, or nil if t has no such dependency. An empty variant means t's variant.
+func findDependency(t *task.Task, taskName, variant string) (*task.Task, error) {
+	if len(t.DependsOn) == 0 {
+		return nil, nil
+	}
+	ids := make([]string, 0, len(t.DependsOn))
+	for _, dep := range t.DependsOn {
+		ids = append(ids, dep.TaskId)
+	}
+	deps, err := task.Find(task.ByIds(ids))
+	if err != nil {
+		return nil, err
+	}
+	return matchDependency(deps, taskName, variant, t.BuildVariant), nil
+}
+
+// matchDependency returns the dependency with the given display name and variant, or
+// nil if none matches. An empty variant means defaultVariant.
+func matchDependency(deps []task.Task, taskName, variant, defaultVariant string) *task.Task {
+	if variant == "" {
+		variant = defaultVariant
+	}
+	for i := range deps {
+		if deps[i].DisplayName == taskName && deps[i].BuildVariant == variant {
+			return &deps[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package artifacts
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/storage"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+func init() {
+	plugin.Publish(&ArtifactsPlugin{})
+}
+
+const (
+	ArtifactsPluginName = "artifacts"
+	PublishCmd          = "publish"
+	FetchCmd            = "fetch"
+
+	UploadURLAPIEndpoint = "upload_url"
+	PublishAPIEndpoint   = "publish"
+	FetchAPIEndpoint     = "fetch"
+)
+
+// outputPath returns where the task's output is stored in the bucket. Outputs
+// of each execution of a task are stored separately.
+func outputPath(c *storage.OutputsConfig, t *task.Task, name, fileName string) string {
+	return path.Join(c.Prefix, t.Project, t.Version, t.BuildVariant,
+		util.CleanForPath(t.DisplayName), strconv.Itoa(t.Execution), name, util.CleanForPath(fileName))
+}
+
+// validateOutputName makes sure an output's name can be used in its storage path.
+func validateOutputName(name string) error {
+	if name == "" {
+		return fmt.Errorf("output name cannot be blank")
+	}
+	if util.CleanForPath(name) != name || name == "." || name == ".." {
+		return fmt.Errorf("output name '%v' may only contain letters, numbers, '.', '-' and '_'", name)
+	}
+	return nil
+}
+
+// validateFileName makes sure an output's file name is a single path element, so
+// it can't put the output outside of its storage path or the fetch directory.
+func validateFileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("file name cannot be blank")
+	}
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("file name '%v' must be a single path element", name)
+	}
+	return nil
+}
+
+// ArtifactsPlugin has commands for publishing named outputs of a task and for
+// fetching the outputs of a task's dependencies. The server decides where outputs
+// are stored and hands out pre-signed URLs for them, so tasks need no storage
+// credentials or paths of their own.
+type ArtifactsPlugin struct {
+	config *storage.OutputsConfig
+}
+
+// Name returns the name of the plugin. Fulfills the Plugin interface.
+func (self *ArtifactsPlugin) Name() string {
+	return ArtifactsPluginName
+}
+
+// Configure reads the storage settings for outputs.
+func (self *ArtifactsPlugin) Configure(conf map[string]interface{}) error {
+	config, err := storage.ParseOutputsConfig(conf)
+	if err != nil {
+		return err
+	}
+	self.config = config
+	return nil
+}
+
+func (self *ArtifactsPlugin) GetAPIHandler() http.Handler {
+	r := http.NewServeMux()
+	r.HandleFunc(fmt.Sprintf("/%v", UploadURLAPIEndpoint), self.uploadURLHandler)
+	r.HandleFunc(fmt.Sprintf("/%v", PublishAPIEndpoint), self.publishHandler)
+	r.HandleFunc(fmt.Sprintf("/%v", FetchAPIEndpoint), self.fetchHandler)
+	r.HandleFunc("/", http.NotFound) // 404 any request not routable to these endpoints
+	return r
+}
+
+// NewCommand takes a command name as a string and returns the requested command,
+// or an error if the command does not exist. Fulfills the Plugin interface.
+func (self *ArtifactsPlugin) NewCommand(cmdName string) (plugin.Command, error) {
+	switch cmdName {
+	case PublishCmd:
+		return &PublishCommand{}, nil
+	case FetchCmd:
+		return &FetchCommand{}, nil
+	default:
+		return nil, &plugin.ErrUnknownCommand{CommandName: cmdName}
+	}
+}
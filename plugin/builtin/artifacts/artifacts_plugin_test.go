@@ -0,0 +1,74 @@
+package artifacts
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/storage"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestArtifactsParseParams(t *testing.T) {
+	Convey("With the artifacts plugin", t, func() {
+		artifactsPlugin := &ArtifactsPlugin{}
+
+		Convey("publish commands should require a valid name and a file", func() {
+			cmd, err := artifactsPlugin.NewCommand(PublishCmd)
+			So(err, ShouldBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"name": "binaries"}), ShouldNotBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"name": "../binaries", "file": "bin.tgz"}), ShouldNotBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"name": "${name}", "file": "bin.tgz"}), ShouldBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"name": "binaries", "file": "bin.tgz"}), ShouldBeNil)
+		})
+
+		Convey("fetch commands should require a task and a name", func() {
+			cmd, err := artifactsPlugin.NewCommand(FetchCmd)
+			So(err, ShouldBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"name": "binaries"}), ShouldNotBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"task": "compile", "name": "binaries"}), ShouldBeNil)
+		})
+	})
+}
+
+func TestValidateFileName(t *testing.T) {
+	Convey("Output file names should be a single path element", t, func() {
+		So(validateFileName("bin.tgz"), ShouldBeNil)
+		So(validateFileName(""), ShouldNotBeNil)
+		So(validateFileName(".."), ShouldNotBeNil)
+		So(validateFileName("../bin.tgz"), ShouldNotBeNil)
+		So(validateFileName("dir/bin.tgz"), ShouldNotBeNil)
+		So(validateFileName(`..\bin.tgz`), ShouldNotBeNil)
+		So(validateFileName("/etc/passwd"), ShouldNotBeNil)
+	})
+}
+
+func TestOutputPath(t *testing.T) {
+	Convey("Outputs should be stored per execution under the prefix", t, func() {
+		config, err := storage.ParseOutputsConfig(map[string]interface{}{
+			"bucket": "outputs", "aws_key": "key", "aws_secret": "secret", "prefix": "evg",
+		})
+		So(err, ShouldBeNil)
+		t := &task.Task{Project: "mci", Version: "v1", BuildVariant: "linux",
+			DisplayName: "compile", Execution: 2}
+		So(outputPath(config, t, "binaries", "bin.tgz"), ShouldEqual, "evg/mci/v1/linux/compile/2/binaries/bin.tgz")
+	})
+}
+
+func TestMatchDependency(t *testing.T) {
+	Convey("With a task's dependencies", t, func() {
+		deps := []task.Task{
+			{Id: "compile_linux", DisplayName: "compile", BuildVariant: "linux"},
+			{Id: "compile_windows", DisplayName: "compile", BuildVariant: "windows"},
+		}
+
+		Convey("the variant should default to the task's own", func() {
+			So(matchDependency(deps, "compile", "", "linux").Id, ShouldEqual, "compile_linux")
+			So(matchDependency(deps, "compile", "windows", "linux").Id, ShouldEqual, "compile_windows")
+		})
+
+		Convey("tasks that aren't dependencies should not match", func() {
+			So(matchDependency(deps, "lint", "", "linux"), ShouldBeNil)
+			So(matchDependency(deps, "compile", "osx", "linux"), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,134 @@
+package artifacts
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+)
+
+// FetchCommand downloads an output published by one of the task's dependencies.
+type FetchCommand struct {
+	// Task and Variant identify the dependency that published the output. The
+	// variant defaults to the task's own variant.
+	Task    string `mapstructure:"task" plugin:"expand"`
+	Variant string `mapstructure:"variant" plugin:"expand"`
+
+	// OutputName is the name the output was published under
+	OutputName string `mapstructure:"name" plugin:"expand"`
+
+	// Dest is where to download the output to, relative to the working directory.
+	// If it is a directory, or is empty, the output keeps its published file name.
+	Dest string `mapstructure:"dest" plugin:"expand"`
+}
+
+func (self *FetchCommand) Name() string {
+	return FetchCmd
+}
+
+func (self *FetchCommand) Plugin() string {
+	return ArtifactsPluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *FetchCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return fmt.Errorf("error decoding '%v' params: %v", self.Name(), err)
+	}
+	if self.Task == "" {
+		return fmt.Errorf("error validating '%v' params: task cannot be blank", self.Name())
+	}
+	if self.OutputName == "" {
+		return fmt.Errorf("error validating '%v' params: name cannot be blank", self.Name())
+	}
+	return nil
+}
+
+// Execute resolves and downloads the output.
+func (self *FetchCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
+		return fmt.Errorf("error expanding params: %v", err)
+	}
+	if !filepath.IsAbs(self.Dest) {
+		self.Dest = filepath.Join(conf.WorkDir, self.Dest)
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- self.fetch(pluginLogger, pluginCom)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of artifacts fetch command")
+		return nil
+	}
+}
+
+func (self *FetchCommand) fetch(pluginLogger plugin.Logger, pluginCom plugin.PluginCommunicator) error {
+	req := FetchRequest{TaskName: self.Task, Variant: self.Variant, Name: self.OutputName}
+	location := &FetchResponse{}
+	if err := postJSON(pluginCom, FetchAPIEndpoint, req, location); err != nil {
+		return fmt.Errorf("error resolving output '%v' of task '%v': %v", self.OutputName, self.Task, err)
+	}
+
+	if err := validateFileName(location.FileName); err != nil {
+		return fmt.Errorf("invalid output '%v' of task %v: %v", self.OutputName, location.TaskId, err)
+	}
+	dest := self.Dest
+	if fi, err := os.Stat(dest); err == nil && fi.IsDir() {
+		dest = filepath.Join(dest, location.FileName)
+	}
+	pluginLogger.LogTask(slogger.INFO, "Fetching output '%v' of task %v (%v bytes) to %v",
+		self.OutputName, location.TaskId, location.Size, dest)
+
+	retriableDownload := util.RetriableFunc(func() error {
+		if err := downloadFile(location.URL, dest); err != nil {
+			pluginLogger.LogExecution(slogger.WARN, "Error downloading output '%v': %v", self.OutputName, err)
+			return util.RetriableError{Failure: err}
+		}
+		return nil
+	})
+	if _, err := util.RetryArithmeticBackoff(retriableDownload, maxTransferAttempts, transferRetrySleep); err != nil {
+		return fmt.Errorf("error downloading output '%v': %v", self.OutputName, err)
+	}
+	pluginLogger.LogTask(slogger.INFO, "Fetched output '%v'", self.OutputName)
+	return nil
+}
+
+// downloadFile gets a pre-signed URL into the local file.
+func downloadFile(url, localFile string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(localFile), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(localFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
@@ -0,0 +1,193 @@
+package artifacts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	maxTransferAttempts = 5
+	transferRetrySleep  = 5 * time.Second
+)
+
+// PublishCommand uploads a file as a named output of the task, which dependent
+// tasks can fetch with the fetch command.
+type PublishCommand struct {
+	// OutputName is the name dependent tasks use to fetch the output
+	OutputName string `mapstructure:"name" plugin:"expand"`
+
+	// File is the path of the file to publish, relative to the working directory
+	File string `mapstructure:"file" plugin:"expand"`
+
+	// Optional, when set to true, causes this command to be skipped over without
+	// an error when the file does not exist.
+	Optional bool `mapstructure:"optional"`
+}
+
+func (self *PublishCommand) Name() string {
+	return PublishCmd
+}
+
+func (self *PublishCommand) Plugin() string {
+	return ArtifactsPluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *PublishCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return fmt.Errorf("error decoding '%v' params: %v", self.Name(), err)
+	}
+	if err := self.validateParams(); err != nil {
+		return fmt.Errorf("error validating '%v' params: %v", self.Name(), err)
+	}
+	return nil
+}
+
+func (self *PublishCommand) validateParams() error {
+	if err := validateOutputName(self.OutputName); err != nil && !plugin.IsExpandable(self.OutputName) {
+		return err
+	}
+	if self.File == "" {
+		return fmt.Errorf("file cannot be blank")
+	}
+	return nil
+}
+
+// Execute uploads and publishes the output.
+func (self *PublishCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
+		return fmt.Errorf("error expanding params: %v", err)
+	}
+	if err := validateOutputName(self.OutputName); err != nil {
+		return err
+	}
+	if !filepath.IsAbs(self.File) {
+		self.File = filepath.Join(conf.WorkDir, self.File)
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- self.publish(pluginLogger, pluginCom)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of artifacts publish command")
+		return nil
+	}
+}
+
+func (self *PublishCommand) publish(pluginLogger plugin.Logger, pluginCom plugin.PluginCommunicator) error {
+	fi, err := os.Stat(self.File)
+	if err != nil {
+		if os.IsNotExist(err) && self.Optional {
+			pluginLogger.LogTask(slogger.INFO, "Skipping publish of missing optional file %v", self.File)
+			return nil
+		}
+		return fmt.Errorf("error reading %v: %v", self.File, err)
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%v is a directory; archive it before publishing it", self.File)
+	}
+
+	req := PublishRequest{Name: self.OutputName, FileName: filepath.Base(self.File), Size: fi.Size()}
+	upload := &UploadURLResponse{}
+	if err = postJSON(pluginCom, UploadURLAPIEndpoint, req, upload); err != nil {
+		return fmt.Errorf("error getting upload location for output '%v': %v", self.OutputName, err)
+	}
+
+	pluginLogger.LogTask(slogger.INFO, "Publishing %v (%v bytes) as output '%v'",
+		self.File, fi.Size(), self.OutputName)
+	retriableUpload := util.RetriableFunc(func() error {
+		if err := uploadFile(upload.URL, upload.ContentType, self.File); err != nil {
+			pluginLogger.LogExecution(slogger.WARN, "Error uploading %v: %v", self.File, err)
+			return util.RetriableError{Failure: err}
+		}
+		return nil
+	})
+	if _, err = util.RetryArithmeticBackoff(retriableUpload, maxTransferAttempts, transferRetrySleep); err != nil {
+		return fmt.Errorf("error uploading %v: %v", self.File, err)
+	}
+
+	if err = postJSON(pluginCom, PublishAPIEndpoint, req, nil); err != nil {
+		return fmt.Errorf("error publishing output '%v': %v", self.OutputName, err)
+	}
+	pluginLogger.LogTask(slogger.INFO, "Published output '%v'", self.OutputName)
+	return nil
+}
+
+// uploadFile puts the file to a pre-signed URL.
+func uploadFile(url, contentType, localFile string) error {
+	f, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", url, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = fi.Size()
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code %v: %v", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// postJSON posts data to the plugin's API endpoint, retrying on connection errors,
+// and reads the response into out unless it is nil.
+func postJSON(pluginCom plugin.PluginCommunicator, endpoint string, data, out interface{}) error {
+	retriablePost := util.RetriableFunc(func() error {
+		resp, err := pluginCom.TaskPostJSON(endpoint, data)
+		if resp != nil {
+			defer resp.Body.Close()
+		}
+		if err != nil {
+			return util.RetriableError{Failure: err}
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(resp.Body)
+			err = fmt.Errorf("unexpected status code %v: %v", resp.StatusCode, string(body))
+			// server errors may be transient, but bad requests won't succeed on retry
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return util.RetriableError{Failure: err}
+			}
+			return err
+		}
+		if out == nil {
+			return nil
+		}
+		return util.ReadJSONInto(resp.Body, out)
+	})
+	_, err := util.RetryArithmeticBackoff(retriablePost, maxTransferAttempts, transferRetrySleep)
+	return err
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/coverage"
 	"github.com/evergreen-ci/evergreen/model/task"
 	"github.com/evergreen-ci/evergreen/model/user"
 	"github.com/evergreen-ci/evergreen/plugin"
@@ -23,9 +24,11 @@ const (
 	AttachPluginName      = "attach"
 	AttachResultsCmd      = "results"
 	AttachXunitResultsCmd = "xunit_results"
+	AttachCoverageCmd     = "coverage"
 
-	AttachResultsAPIEndpoint = "results"
-	AttachLogsAPIEndpoint    = "test_logs"
+	AttachResultsAPIEndpoint  = "results"
+	AttachLogsAPIEndpoint     = "test_logs"
+	AttachCoverageAPIEndpoint = "coverage"
 
 	AttachResultsPostRetries   = 5
 	AttachResultsRetrySleepSec = 10 * time.Second
@@ -44,6 +47,7 @@ func (self *AttachPlugin) Name() string {
 func (self *AttachPlugin) GetAPIHandler() http.Handler {
 	r := http.NewServeMux()
 	r.HandleFunc(fmt.Sprintf("/%v", AttachResultsAPIEndpoint), AttachResultsHandler)
+	r.HandleFunc(fmt.Sprintf("/%v", AttachCoverageAPIEndpoint), AttachCoverageHandler)
 	r.HandleFunc("/", http.NotFound) // 404 any request not routable to these endpoints
 	return r
 }
@@ -56,15 +60,24 @@ func (self *AttachPlugin) Configure(map[string]interface{}) error {
 	return nil
 }
 
+// privateFileURL is the UI route private files are downloaded through, which
+// checks the user's access, records the download and links to the file with a
+// link that expires.
+const privateFileURL = "/task_file/%v/%v"
+
 // stripHiddenFiles is a helper for only showing users the files they are allowed to see.
-func stripHiddenFiles(files []artifact.File, pluginUser *user.DBUser) []artifact.File {
+// Private files are linked to through the server instead of directly.
+func stripHiddenFiles(taskId string, files []artifact.File, pluginUser *user.DBUser) []artifact.File {
 	publicFiles := []artifact.File{}
-	for _, file := range files {
+	for i, file := range files {
 		switch {
 		case file.Visibility == artifact.None:
 			continue
 		case file.Visibility == artifact.Private && pluginUser == nil:
 			continue
+		case file.Visibility == artifact.Private:
+			file.Link = fmt.Sprintf(privateFileURL, taskId, i)
+			publicFiles = append(publicFiles, file)
 		default:
 			publicFiles = append(publicFiles, file)
 		}
@@ -72,8 +85,14 @@ func stripHiddenFiles(files []artifact.File, pluginUser *user.DBUser) []artifact
 	return publicFiles
 }
 
+// taskPanelData is the data for the attach plugin's panels on the task page.
+type taskPanelData struct {
+	Files    []artifact.File        `json:"files"`
+	Coverage *coverage.TaskCoverage `json:"coverage"`
+}
+
 // GetPanelConfig returns a plugin.PanelConfig struct representing panels
-// that will be added to the Task and Build pages.
+// that will be added to the Task, Build and Version pages.
 func (self *AttachPlugin) GetPanelConfig() (*plugin.PanelConfig, error) {
 	return &plugin.PanelConfig{
 		Panels: []plugin.UIPanel{
@@ -81,21 +100,37 @@ func (self *AttachPlugin) GetPanelConfig() (*plugin.PanelConfig, error) {
 				Page:     plugin.TaskPage,
 				Position: plugin.PageLeft,
 				PanelHTML: "<div ng-include=\"'/plugin/attach/static/partials/task_files_panel.html'\" " +
-					"ng-init='files=plugins.attach' ng-show='plugins.attach.length'></div>",
+					"ng-init='files=plugins.attach.files' ng-show='plugins.attach.files.length'></div>",
 				DataFunc: func(context plugin.UIContext) (interface{}, error) {
 					if context.Task == nil {
 						return nil, nil
 					}
+					data := taskPanelData{}
 					artifactEntry, err := artifact.FindOne(artifact.ByTaskId(context.Task.Id))
 					if err != nil {
 						return nil, fmt.Errorf("error finding artifact files for task: %v", err)
 					}
-					if artifactEntry == nil {
-						return nil, nil
+					if artifactEntry != nil {
+						data.Files = stripHiddenFiles(artifactEntry.TaskId, artifactEntry.Files, context.User)
 					}
-					return stripHiddenFiles(artifactEntry.Files, context.User), nil
+					data.Coverage, err = coverage.FindOne(coverage.ByTaskId(context.Task.Id).
+						WithoutFields(coverage.FilesKey + "." + coverage.FileLinesKey))
+					if err != nil {
+						return nil, fmt.Errorf("error finding coverage for task: %v", err)
+					}
+					// only show coverage attached during the execution being viewed
+					if data.Coverage != nil && data.Coverage.Execution != context.Task.Execution {
+						data.Coverage = nil
+					}
+					return data, nil
 				},
 			},
+			{
+				Page:     plugin.TaskPage,
+				Position: plugin.PageLeft,
+				PanelHTML: "<div ng-include=\"'/plugin/attach/static/partials/task_coverage_panel.html'\" " +
+					"ng-init='coverage=plugins.attach.coverage' ng-show='plugins.attach.coverage'></div>",
+			},
 			{
 				Page:     plugin.BuildPage,
 				Position: plugin.PageLeft,
@@ -111,11 +146,28 @@ func (self *AttachPlugin) GetPanelConfig() (*plugin.PanelConfig, error) {
 					}
 					for i := range taskArtifactFiles {
 						// remove hidden files if the user isn't logged in
-						taskArtifactFiles[i].Files = stripHiddenFiles(taskArtifactFiles[i].Files, context.User)
+						taskArtifactFiles[i].Files = stripHiddenFiles(taskArtifactFiles[i].TaskId,
+							taskArtifactFiles[i].Files, context.User)
 					}
 					return taskArtifactFiles, nil
 				},
 			},
+			{
+				Page:     plugin.VersionPage,
+				Position: plugin.PageLeft,
+				PanelHTML: "<div ng-include=\"'/plugin/attach/static/partials/version_coverage_panel.html'\" " +
+					"ng-init='coverageByTask=plugins.attach' ng-show='plugins.attach.length'></div>",
+				DataFunc: func(context plugin.UIContext) (interface{}, error) {
+					if context.Version == nil {
+						return nil, nil
+					}
+					taskCoverage, err := coverage.Find(coverage.ByVersion(context.Version.Id))
+					if err != nil {
+						return nil, fmt.Errorf("error finding coverage for version: %v", err)
+					}
+					return taskCoverage, nil
+				},
+			},
 		},
 	}, nil
 }
@@ -129,7 +181,13 @@ func (self *AttachPlugin) NewCommand(cmdName string) (plugin.Command,
 		return &AttachResultsCommand{}, nil
 	case AttachXunitResultsCmd:
 		return &AttachXUnitResultsCommand{}, nil
+	case AttachCoverageCmd:
+		return &AttachCoverageCommand{}, nil
 	default:
+		// results in any other registered format, e.g. "tap_results"
+		if cmd := newParsedResultsCommand(cmdName); cmd != nil {
+			return cmd, nil
+		}
 		return nil, fmt.Errorf("No such %v command: %v",
 			AttachPluginName, cmdName)
 	}
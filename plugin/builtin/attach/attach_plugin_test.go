@@ -19,7 +19,7 @@ func TestFileVisibility(t *testing.T) {
 		}
 
 		Convey("and no user", func() {
-			stripped := stripHiddenFiles(files, nil)
+			stripped := stripHiddenFiles("t1", files, nil)
 
 			Convey("the original array should be unmodified", func() {
 				So(len(files), ShouldEqual, 4)
@@ -33,7 +33,7 @@ func TestFileVisibility(t *testing.T) {
 		})
 
 		Convey("with a user", func() {
-			stripped := stripHiddenFiles(files, &user.DBUser{})
+			stripped := stripHiddenFiles("t1", files, &user.DBUser{})
 
 			Convey("the original array should be unmodified", func() {
 				So(len(files), ShouldEqual, 4)
@@ -45,7 +45,46 @@ func TestFileVisibility(t *testing.T) {
 				So(stripped[2].Name, ShouldEqual, "Unset")
 				So(len(stripped), ShouldEqual, 3)
 			})
+
+			Convey("private files should be linked to through the server", func() {
+				So(stripped[0].Link, ShouldEqual, "/task_file/t1/0")
+				So(files[0].Link, ShouldEqual, "")
+			})
+		})
+	})
+
+}
+
+func TestParsedResultsCommands(t *testing.T) {
+	Convey("With the attach plugin", t, func() {
+		attachPlugin := &AttachPlugin{}
+
+		Convey("each registered parser format should have a results command", func() {
+			for _, cmdName := range []string{"tap_results", "gotest_json_results", "junit_results"} {
+				cmd, err := attachPlugin.NewCommand(cmdName)
+				So(err, ShouldBeNil)
+				So(cmd.Name(), ShouldEqual, cmdName)
+				So(cmd.ParseParams(map[string]interface{}{}), ShouldNotBeNil)
+				So(cmd.ParseParams(map[string]interface{}{"file": "report.out"}), ShouldBeNil)
+			}
+		})
+
+		Convey("unknown formats should not have a command", func() {
+			_, err := attachPlugin.NewCommand("nunit_results")
+			So(err, ShouldNotBeNil)
 		})
 	})
+}
 
+func TestCoverageCommandParams(t *testing.T) {
+	Convey("With an attach.coverage command", t, func() {
+		cmd, err := (&AttachPlugin{}).NewCommand(AttachCoverageCmd)
+		So(err, ShouldBeNil)
+
+		Convey("a file and a known format should be required", func() {
+			So(cmd.ParseParams(map[string]interface{}{"format": "lcov"}), ShouldNotBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"file": "cover.out", "format": "jacoco"}), ShouldNotBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"file": "cover.out", "format": "gocover"}), ShouldBeNil)
+		})
+	})
 }
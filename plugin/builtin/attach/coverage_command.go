@@ -0,0 +1,164 @@
+package attach
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/plugin/builtin/attach/coverreport"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+)
+
+// AttachCoverageCommand parses code coverage reports and stores a summary of
+// the coverage by package and file for the task.
+type AttachCoverageCommand struct {
+	// File describes the relative path of the report to be parsed. Supports globbing.
+	// Note that this can also be described via expansions.
+	File string `mapstructure:"file" plugin:"expand"`
+
+	// Format is the format of the report: "gocover", "cobertura" or "lcov".
+	Format string `mapstructure:"format" plugin:"expand"`
+}
+
+func (self *AttachCoverageCommand) Name() string {
+	return AttachCoverageCmd
+}
+
+func (self *AttachCoverageCommand) Plugin() string {
+	return AttachPluginName
+}
+
+// ParseParams reads and validates the command parameters. This is required
+// to satisfy the 'Command' interface
+func (self *AttachCoverageCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return fmt.Errorf("error decoding '%v' params: %v", self.Name(), err)
+	}
+	if self.File == "" {
+		return fmt.Errorf("error validating '%v' params: file cannot be blank", self.Name())
+	}
+	if !util.SliceContains(coverreport.Formats, self.Format) {
+		return fmt.Errorf("error validating '%v' params: format must be one of %v",
+			self.Name(), coverreport.Formats)
+	}
+	return nil
+}
+
+// Execute carries out the AttachCoverageCommand command - this is required
+// to satisfy the 'Command' interface
+func (self *AttachCoverageCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	taskConfig *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, taskConfig.Expansions); err != nil {
+		return fmt.Errorf("error expanding params: %v", err)
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- self.parseAndSendCoverage(taskConfig, pluginLogger, pluginCom)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of attach coverage command")
+		return nil
+	}
+}
+
+func (self *AttachCoverageCommand) parseAndSendCoverage(taskConfig *model.TaskConfig,
+	pluginLogger plugin.Logger, pluginCom plugin.PluginCommunicator) error {
+	reportFilePaths, err := getFilePaths(taskConfig.WorkDir, self.File)
+	if err != nil {
+		return err
+	}
+	if len(reportFilePaths) == 0 {
+		return fmt.Errorf("no coverage reports found matching '%v'", self.File)
+	}
+
+	files := []coverage.FileCoverage{}
+	for _, reportFileLoc := range reportFilePaths {
+		file, err := os.Open(reportFileLoc)
+		if err != nil {
+			return fmt.Errorf("couldn't open coverage report: '%v'", err)
+		}
+		reportFiles, err := coverreport.Parse(self.Format, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("error parsing coverage report '%v': %v", reportFileLoc, err)
+		}
+		files = append(files, reportFiles...)
+	}
+
+	pluginLogger.LogExecution(slogger.INFO, "Attaching coverage for %v files", len(files))
+	resp, err := pluginCom.TaskPostJSON(AttachCoverageAPIEndpoint, files)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("error posting coverage: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("error posting coverage (%v): %v", resp.StatusCode, string(body))
+	}
+
+	pluginLogger.LogTask(slogger.INFO, "Attach coverage succeeded")
+	return nil
+}
+
+// AttachCoverageHandler is an API hook for receiving a task's coverage and merging
+// it into the coverage stored for the task's current execution.
+func AttachCoverageHandler(w http.ResponseWriter, r *http.Request) {
+	t := plugin.GetTask(r)
+	if t == nil {
+		http.Error(w, "task not found", http.StatusNotFound)
+		return
+	}
+	files := []coverage.FileCoverage{}
+	if err := util.ReadJSONInto(r.Body, &files); err != nil {
+		evergreen.Logger.Errorf(slogger.ERROR, "error reading coverage: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	taskCoverage, err := coverage.FindOne(coverage.ByTaskId(t.Id))
+	if err != nil {
+		message := fmt.Sprintf("error finding coverage for task %v: %v", t.Id, err)
+		evergreen.Logger.Errorf(slogger.ERROR, message)
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	// coverage from an earlier execution of a restarted task is replaced
+	if taskCoverage == nil || taskCoverage.Execution != t.Execution {
+		taskCoverage = &coverage.TaskCoverage{
+			TaskId:       t.Id,
+			Execution:    t.Execution,
+			TaskName:     t.DisplayName,
+			BuildVariant: t.BuildVariant,
+			BuildId:      t.BuildId,
+			Version:      t.Version,
+			Project:      t.Project,
+		}
+	}
+	taskCoverage.AddFiles(files)
+
+	if err = taskCoverage.Upsert(); err != nil {
+		message := fmt.Sprintf("error saving coverage for task %v: %v", t.Id, err)
+		evergreen.Logger.Errorf(slogger.ERROR, message)
+		http.Error(w, message, http.StatusInternalServerError)
+		return
+	}
+	plugin.WriteJSON(w, http.StatusOK, "Coverage successfully attached")
+}
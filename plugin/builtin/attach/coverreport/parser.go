@@ -0,0 +1,178 @@
+// Package coverreport parses code coverage reports into per-file coverage.
+package coverreport
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/model/coverage"
+)
+
+const (
+	GoCoverFormat   = "gocover"
+	CoberturaFormat = "cobertura"
+	LCOVFormat      = "lcov"
+)
+
+// Formats are the coverage report formats that can be parsed.
+var Formats = []string{GoCoverFormat, CoberturaFormat, LCOVFormat}
+
+// Parse reads a coverage report in the given format and returns the coverage of each file.
+func Parse(format string, reader io.Reader) ([]coverage.FileCoverage, error) {
+	switch format {
+	case GoCoverFormat:
+		return ParseGoCoverProfile(reader)
+	case CoberturaFormat:
+		return ParseCobertura(reader)
+	case LCOVFormat:
+		return ParseLCOV(reader)
+	default:
+		return nil, fmt.Errorf("unknown coverage format '%v'", format)
+	}
+}
+
+// lineCounts tracks the hits of each line of each file, keyed by file and line.
+type lineCounts map[string]map[string]coverage.LineCoverage
+
+func (lc lineCounts) add(file, line string, weight, hits int) {
+	if lc[file] == nil {
+		lc[file] = map[string]coverage.LineCoverage{}
+	}
+	// a line reported more than once, e.g. by several test binaries, gets the hits of every run
+	existing := lc[file][line]
+	lc[file][line] = coverage.LineCoverage{Line: line, Weight: weight, Hits: existing.Hits + hits}
+}
+
+// files returns the coverage of each file, using packageOf to group the files.
+func (lc lineCounts) files(packageOf func(string) string) []coverage.FileCoverage {
+	files := []coverage.FileCoverage{}
+	for name, lines := range lc {
+		fileLines := make([]coverage.LineCoverage, 0, len(lines))
+		for _, line := range lines {
+			fileLines = append(fileLines, line)
+		}
+		files = append(files, coverage.NewFileCoverage(name, packageOf(name), fileLines))
+	}
+	sort.Sort(filesByName(files))
+	return files
+}
+
+type filesByName []coverage.FileCoverage
+
+func (f filesByName) Len() int           { return len(f) }
+func (f filesByName) Less(i, j int) bool { return f[i].Name < f[j].Name }
+func (f filesByName) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+// ParseGoCoverProfile parses a profile written by `go test -coverprofile`. Coverage is
+// counted in statements, and files are grouped by their import path.
+func ParseGoCoverProfile(reader io.Reader) ([]coverage.FileCoverage, error) {
+	counts := lineCounts{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// e.g. "github.com/evergreen-ci/evergreen/util/slice.go:9.50,10.16 1 1"
+		fields := strings.Fields(line)
+		colon := strings.LastIndex(fields[0], ":")
+		if len(fields) != 3 || colon < 0 {
+			return nil, fmt.Errorf("invalid coverage profile line '%v'", line)
+		}
+		statements, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid statement count in line '%v'", line)
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid hit count in line '%v'", line)
+		}
+		counts.add(fields[0][:colon], fields[0][colon+1:], statements, count)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading coverage profile: %v", err)
+	}
+	return counts.files(path.Dir), nil
+}
+
+type coberturaReport struct {
+	Packages []struct {
+		Name    string `xml:"name,attr"`
+		Classes []struct {
+			Filename string `xml:"filename,attr"`
+			Lines    []struct {
+				Number string `xml:"number,attr"`
+				Hits   string `xml:"hits,attr"`
+			} `xml:"lines>line"`
+		} `xml:"classes>class"`
+	} `xml:"packages>package"`
+}
+
+// ParseCobertura parses a Cobertura XML report. Coverage is counted in lines, and files
+// are grouped by the report's packages.
+func ParseCobertura(reader io.Reader) ([]coverage.FileCoverage, error) {
+	report := coberturaReport{}
+	if err := xml.NewDecoder(reader).Decode(&report); err != nil {
+		return nil, fmt.Errorf("error parsing Cobertura XML: %v", err)
+	}
+
+	counts := lineCounts{}
+	packages := map[string]string{}
+	for _, pkg := range report.Packages {
+		for _, class := range pkg.Classes {
+			packages[class.Filename] = pkg.Name
+			for _, line := range class.Lines {
+				hits, err := strconv.ParseFloat(line.Hits, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid hits '%v' for line %v of %v",
+						line.Hits, line.Number, class.Filename)
+				}
+				counts.add(class.Filename, line.Number, 1, int(math.Ceil(hits)))
+			}
+		}
+	}
+	return counts.files(func(file string) string { return packages[file] }), nil
+}
+
+// ParseLCOV parses an LCOV tracefile, as written by geninfo, istanbul and others. Coverage
+// is counted in lines, and files are grouped by directory.
+func ParseLCOV(reader io.Reader) ([]coverage.FileCoverage, error) {
+	counts := lineCounts{}
+	file := ""
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			file = strings.TrimPrefix(line, "SF:")
+			// list files even if none of their lines are instrumented
+			if counts[file] == nil {
+				counts[file] = map[string]coverage.LineCoverage{}
+			}
+		case strings.HasPrefix(line, "DA:"):
+			// e.g. "DA:12,3" or "DA:12,3,checksum"
+			fields := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if file == "" || len(fields) < 2 {
+				return nil, fmt.Errorf("invalid LCOV line '%v'", line)
+			}
+			hits, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid hit count in LCOV line '%v'", line)
+			}
+			counts.add(file, fields[0], 1, hits)
+		case line == "end_of_record":
+			file = ""
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading LCOV file: %v", err)
+	}
+	return counts.files(path.Dir), nil
+}
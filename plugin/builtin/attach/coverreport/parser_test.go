@@ -0,0 +1,73 @@
+package coverreport
+
+import (
+	"os"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func parseFile(t *testing.T, format, path string) ([]coverage.FileCoverage, error) {
+	file, err := os.Open(path)
+	testutil.HandleTestingErr(err, t, "Error reading file")
+	defer file.Close()
+	return Parse(format, file)
+}
+
+func TestParse(t *testing.T) {
+	Convey("With coverage reports in each format", t, func() {
+		Convey("a go coverage profile should count statements by file", func() {
+			files, err := parseFile(t, GoCoverFormat, "testdata/cover.out")
+			So(err, ShouldBeNil)
+			So(files, ShouldResemble, []coverage.FileCoverage{
+				{Name: "example.com/pkg/a.go", Package: "example.com/pkg", Covered: 2, Total: 4,
+					Lines: []coverage.LineCoverage{
+						{Line: "3.20,5.2", Weight: 2, Hits: 1},
+						{Line: "7.20,9.2", Weight: 2, Hits: 0},
+					}},
+				{Name: "example.com/pkg/sub/b.go", Package: "example.com/pkg/sub", Covered: 1, Total: 1,
+					Lines: []coverage.LineCoverage{{Line: "3.20,5.2", Weight: 1, Hits: 1}}},
+			})
+		})
+
+		Convey("a Cobertura report should count lines and keep its packages", func() {
+			files, err := parseFile(t, CoberturaFormat, "testdata/cobertura.xml")
+			So(err, ShouldBeNil)
+			So(files, ShouldResemble, []coverage.FileCoverage{
+				{Name: "app/models.py", Package: "app", Covered: 1, Total: 2,
+					Lines: []coverage.LineCoverage{
+						{Line: "1", Weight: 1, Hits: 3},
+						{Line: "2", Weight: 1, Hits: 0},
+					}},
+				{Name: "app/views.py", Package: "app", Covered: 2, Total: 3,
+					Lines: []coverage.LineCoverage{
+						{Line: "1", Weight: 1, Hits: 1},
+						{Line: "4", Weight: 1, Hits: 1},
+						{Line: "9", Weight: 1, Hits: 0},
+					}},
+			})
+		})
+
+		Convey("an LCOV file should count lines by file", func() {
+			files, err := parseFile(t, LCOVFormat, "testdata/coverage.lcov")
+			So(err, ShouldBeNil)
+			So(files, ShouldResemble, []coverage.FileCoverage{
+				{Name: "src/index.js", Package: "src", Covered: 2, Total: 3,
+					Lines: []coverage.LineCoverage{
+						{Line: "1", Weight: 1, Hits: 1},
+						{Line: "2", Weight: 1, Hits: 0},
+						{Line: "3", Weight: 1, Hits: 4},
+					}},
+				{Name: "src/util/strings.js", Package: "src/util", Covered: 0, Total: 1,
+					Lines: []coverage.LineCoverage{{Line: "10", Weight: 1, Hits: 0}}},
+			})
+		})
+
+		Convey("unknown formats should be rejected", func() {
+			_, err := parseFile(t, "jacoco", "testdata/cover.out")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
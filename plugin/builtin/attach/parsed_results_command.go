@@ -0,0 +1,142 @@
+package attach
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/plugin/builtin/attach/parsers"
+	"github.com/mitchellh/mapstructure"
+)
+
+// parsedResultsCmdSuffix is appended to the name of a registered parser format to
+// get the name of the command that attaches results in that format,
+// e.g. "tap_results" for the "tap" format.
+const parsedResultsCmdSuffix = "_results"
+
+// AttachParsedResultsCommand parses test reports in one of the formats in the
+// parsers registry, and attaches the results and their logs to the task.
+type AttachParsedResultsCommand struct {
+	// File describes the relative path of the file to be parsed. Supports globbing.
+	// Note that this can also be described via expansions.
+	File string `mapstructure:"file" plugin:"expand"`
+
+	format string
+	parser parsers.Parser
+}
+
+// newParsedResultsCommand returns the command for the given command name, or nil if
+// there is no parser registered for it.
+func newParsedResultsCommand(cmdName string) *AttachParsedResultsCommand {
+	if !strings.HasSuffix(cmdName, parsedResultsCmdSuffix) {
+		return nil
+	}
+	format := strings.TrimSuffix(cmdName, parsedResultsCmdSuffix)
+	parser, ok := parsers.Get(format)
+	if !ok {
+		return nil
+	}
+	return &AttachParsedResultsCommand{format: format, parser: parser}
+}
+
+func (self *AttachParsedResultsCommand) Name() string {
+	return self.format + parsedResultsCmdSuffix
+}
+
+func (self *AttachParsedResultsCommand) Plugin() string {
+	return AttachPluginName
+}
+
+// ParseParams reads and validates the command parameters. This is required
+// to satisfy the 'Command' interface
+func (self *AttachParsedResultsCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return fmt.Errorf("error decoding '%v' params: %v", self.Name(), err)
+	}
+	if self.File == "" {
+		return fmt.Errorf("error validating '%v' params: file cannot be blank", self.Name())
+	}
+	return nil
+}
+
+// Execute carries out the AttachParsedResultsCommand command - this is required
+// to satisfy the 'Command' interface
+func (self *AttachParsedResultsCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	taskConfig *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, taskConfig.Expansions); err != nil {
+		return fmt.Errorf("error expanding params: %v", err)
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- self.parseAndUploadResults(taskConfig, pluginLogger, pluginCom)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of attach %v command", self.Name())
+		return nil
+	}
+}
+
+func (self *AttachParsedResultsCommand) parseAndUploadResults(
+	taskConfig *model.TaskConfig, pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator) error {
+	tests := []task.TestResult{}
+	logs := []*model.TestLog{}
+	logIdxToTestIdx := []int{}
+
+	reportFilePaths, err := getFilePaths(taskConfig.WorkDir, self.File)
+	if err != nil {
+		return err
+	}
+	if len(reportFilePaths) == 0 {
+		return fmt.Errorf("no %v files found matching '%v'", self.format, self.File)
+	}
+
+	start := time.Now()
+	for _, reportFileLoc := range reportFilePaths {
+		file, err := os.Open(reportFileLoc)
+		if err != nil {
+			return fmt.Errorf("couldn't open %v file: '%v'", self.format, err)
+		}
+
+		results, err := self.parser(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("error parsing %v file '%v': %v", self.format, reportFileLoc, err)
+		}
+
+		for _, result := range results {
+			test, log := result.ToModelTestResultAndLog(taskConfig.Task, start)
+			if log != nil {
+				logs = append(logs, log)
+				logIdxToTestIdx = append(logIdxToTestIdx, len(tests))
+			}
+			tests = append(tests, test)
+		}
+	}
+
+	for i, log := range logs {
+		logId, err := SendJSONLogs(taskConfig, pluginLogger, pluginCom, log)
+		if err != nil {
+			pluginLogger.LogTask(slogger.WARN, "Error uploading logs for %v", log.Name)
+			continue
+		}
+		tests[logIdxToTestIdx[i]].LogId = logId
+		tests[logIdxToTestIdx[i]].LineNum = 1
+	}
+
+	return SendJSONResults(taskConfig, pluginLogger, pluginCom, &task.TestResults{Results: tests})
+}
@@ -0,0 +1,111 @@
+package parsers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+)
+
+// GoTestJSONFormat is the name of the parser for `go test -json` event streams.
+const GoTestJSONFormat = "gotest_json"
+
+func init() {
+	Register(GoTestJSONFormat, ParseGoTestJSON)
+}
+
+// goTestEvent is a single event emitted by `go test -json` (see `go doc test2json`).
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// ParseGoTestJSON parses the event stream written by `go test -json`. Tests are named
+// after their package and test name, and keep their own output. A package that fails
+// without any failing tests, such as one that does not compile or panics during setup,
+// is reported as a failed result named after the package. Tests that never finished
+// are reported as failed. Lines that are not JSON events are ignored.
+func ParseGoTestJSON(reader io.Reader) ([]Result, error) {
+	results := []Result{}
+	index := map[string]int{}
+	packageOutput := map[string][]string{}
+	packageFailedTests := map[string]bool{}
+	finished := map[string]bool{}
+
+	scanner := bufio.NewScanner(reader)
+	// output events for verbose tests can be long
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		event := goTestEvent{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return nil, fmt.Errorf("error parsing go test event '%v': %v", line, err)
+		}
+
+		output := strings.TrimRight(event.Output, "\n")
+		if event.Test == "" {
+			switch event.Action {
+			case "output":
+				packageOutput[event.Package] = append(packageOutput[event.Package], output)
+			case "fail":
+				if !packageFailedTests[event.Package] {
+					results = append(results, Result{
+						Name:     event.Package,
+						Status:   evergreen.TestFailedStatus,
+						Duration: elapsed(event.Elapsed),
+						Output:   packageOutput[event.Package],
+					})
+				}
+			}
+			continue
+		}
+
+		key := event.Package + "." + event.Test
+		i, ok := index[key]
+		if !ok {
+			i = len(results)
+			index[key] = i
+			results = append(results, Result{Name: key, Status: evergreen.TestFailedStatus})
+		}
+		switch event.Action {
+		case "output":
+			results[i].Output = append(results[i].Output, output)
+		case "pass":
+			results[i].Status = evergreen.TestSucceededStatus
+		case "skip":
+			results[i].Status = evergreen.TestSkippedStatus
+		case "fail":
+			results[i].Status = evergreen.TestFailedStatus
+			packageFailedTests[event.Package] = true
+		}
+		if event.Action == "pass" || event.Action == "skip" || event.Action == "fail" {
+			results[i].Duration = elapsed(event.Elapsed)
+			finished[key] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading go test output: %v", err)
+	}
+
+	for key, i := range index {
+		if !finished[key] {
+			results[i].Output = append(results[i].Output, "test did not finish")
+		}
+	}
+	return results, nil
+}
+
+// elapsed converts the seconds reported by go test into a duration.
+func elapsed(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,128 @@
+package parsers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/plugin/builtin/attach/xunit"
+)
+
+// JUnitFormat is the name of the parser for JUnit XML reports.
+const JUnitFormat = "junit"
+
+func init() {
+	Register(JUnitFormat, ParseJUnit)
+}
+
+// ParseJUnit parses a JUnit XML report with the xunit parser, which reads either a
+// <testsuites> or a <testsuite> root, including the dialects written by pytest, surefire and
+// nose. Both failures and errors are reported as failed, and labelled in the test's output.
+// Each test's output also includes its properties, skip reason, and its own stdout and stderr.
+// Tests that only passed on a rerun are reported as passed, with the failures of the earlier
+// attempts in their output.
+func ParseJUnit(reader io.Reader) ([]Result, error) {
+	roots, err := xunit.ParseXMLResults(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing JUnit XML: %v", err)
+	}
+	results := []Result{}
+	for _, root := range roots {
+		results = junitResults(root, "", nil, results)
+	}
+	return results, nil
+}
+
+// junitResults appends the results of the suite and its nested suites. Properties
+// are inherited from enclosing suites.
+func junitResults(suite xunit.TestSuite, parent string, properties []xunit.Property, results []Result) []Result {
+	path := parent
+	if suite.Name != "" {
+		if path != "" {
+			path += "."
+		}
+		path += suite.Name
+	}
+	properties = append(append([]xunit.Property{}, properties...), suite.Properties...)
+
+	for _, tc := range suite.TestCases {
+		results = append(results, junitResult(tc, path, properties))
+	}
+	for _, nested := range suite.Suites {
+		results = junitResults(nested, path, properties, results)
+	}
+	return results
+}
+
+func junitResult(tc xunit.TestCase, suitePath string, suiteProperties []xunit.Property) Result {
+	result := Result{Status: evergreen.TestSucceededStatus}
+	switch {
+	case tc.ClassName != "":
+		result.Name = tc.ClassName + "." + tc.Name
+	case suitePath != "":
+		result.Name = suitePath + "." + tc.Name
+	default:
+		result.Name = tc.Name
+	}
+
+	result.Duration = elapsed(float64(tc.Time))
+
+	output := []string{}
+	for _, details := range tc.Failures {
+		result.Status = evergreen.TestFailedStatus
+		output = append(output, detailsLines(details, "FAILURE")...)
+	}
+	for _, details := range tc.Errors {
+		result.Status = evergreen.TestFailedStatus
+		output = append(output, detailsLines(details, "ERROR")...)
+	}
+	if tc.Skipped != nil && result.Status == evergreen.TestSucceededStatus {
+		result.Status = evergreen.TestSkippedStatus
+		output = append(output, detailsLines(*tc.Skipped, "SKIPPED")...)
+	}
+	for _, details := range tc.FlakyFailures {
+		output = append(output, detailsLines(details, "FAILURE ON EARLIER ATTEMPT")...)
+	}
+	for _, details := range tc.FlakyErrors {
+		output = append(output, detailsLines(details, "ERROR ON EARLIER ATTEMPT")...)
+	}
+	output = append(output, sectionLines("STDOUT", tc.SysOut)...)
+	output = append(output, sectionLines("STDERR", tc.SysErr)...)
+
+	// suite properties only add context to a log that exists for another reason
+	properties := tc.Properties
+	if len(output) > 0 {
+		properties = append(append([]xunit.Property{}, suiteProperties...), properties...)
+	}
+	for _, p := range properties {
+		result.Output = append(result.Output, fmt.Sprintf("PROPERTY: %v=%v", p.Name, p.Value))
+	}
+	result.Output = append(result.Output, output...)
+	return result
+}
+
+// detailsLines formats the details of a failure, error or skip as log lines.
+func detailsLines(d xunit.FailureDetails, label string) []string {
+	header := label
+	if d.Message != "" {
+		header = fmt.Sprintf("%v: %v", header, d.Message)
+	}
+	if d.Type != "" {
+		header = fmt.Sprintf("%v (%v)", header, d.Type)
+	}
+	lines := []string{header}
+	if content := strings.TrimSpace(d.Content); content != "" {
+		lines = append(lines, strings.Split(content, "\n")...)
+	}
+	return lines
+}
+
+// sectionLines returns the content under a header line, or nothing if it is empty.
+func sectionLines(header, content string) []string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil
+	}
+	return append([]string{header + ":"}, strings.Split(content, "\n")...)
+}
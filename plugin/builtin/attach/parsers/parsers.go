@@ -0,0 +1,82 @@
+// Package parsers converts test reports in various formats into Evergreen test
+// results and logs. Each format is registered by name so the attach plugin can
+// expose a command for it without any format-specific plumbing.
+package parsers
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+// Result is a single test parsed from a report, independent of the report's format.
+type Result struct {
+	// Name identifies the test, including any suite or class it belongs to.
+	Name string
+	// Status is one of the evergreen.Test*Status constants.
+	Status string
+	// Duration is how long the test took, or zero if the report does not say.
+	Duration time.Duration
+	// Output holds the log lines for the test, such as its failure message and stdout.
+	Output []string
+}
+
+// Parser reads a test report until the reader is exhausted and returns the tests in it.
+type Parser func(io.Reader) ([]Result, error)
+
+var registry = map[string]Parser{}
+
+// Register makes a parser available under the given format name. It panics if the
+// name is already taken, since that can only happen from a programming error.
+func Register(format string, parser Parser) {
+	if _, ok := registry[format]; ok {
+		panic(fmt.Sprintf("test result parser '%v' is already registered", format))
+	}
+	registry[format] = parser
+}
+
+// Get returns the parser registered for the format.
+func Get(format string) (Parser, bool) {
+	parser, ok := registry[format]
+	return parser, ok
+}
+
+// Formats returns the names of all registered formats, sorted.
+func Formats() []string {
+	formats := make([]string, 0, len(registry))
+	for format := range registry {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// ToModelTestResultAndLog converts a parsed result into an Evergreen task.TestResult
+// and model.TestLog, using start as the test's start time. A log is only created if
+// the result has output.
+func (r Result) ToModelTestResultAndLog(t *task.Task, start time.Time) (task.TestResult, *model.TestLog) {
+	res := task.TestResult{
+		// replace spaces, slashes, etc. with underscores since the name is used in log URLs
+		TestFile:  util.CleanForPath(r.Name),
+		Status:    r.Status,
+		StartTime: float64(start.Unix()),
+	}
+	res.EndTime = res.StartTime + r.Duration.Seconds()
+
+	if len(r.Output) == 0 {
+		return res, nil
+	}
+	log := &model.TestLog{
+		Name:          res.TestFile,
+		Task:          t.Id,
+		TaskExecution: t.Execution,
+		Lines:         r.Output,
+	}
+	res.URL = log.URL()
+	return res, log
+}
@@ -0,0 +1,116 @@
+package parsers
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func parseFile(t *testing.T, format, path string) []Result {
+	parser, ok := Get(format)
+	if !ok {
+		t.Fatalf("no parser registered for %v", format)
+	}
+	file, err := os.Open(path)
+	testutil.HandleTestingErr(err, t, "Error reading file")
+	defer file.Close()
+	results, err := parser(file)
+	testutil.HandleTestingErr(err, t, "Error parsing file")
+	return results
+}
+
+func TestRegistry(t *testing.T) {
+	Convey("The built-in formats should be registered", t, func() {
+		So(Formats(), ShouldResemble, []string{GoTestJSONFormat, JUnitFormat, TAPFormat})
+		So(func() { Register(TAPFormat, ParseTAP) }, ShouldPanic)
+	})
+}
+
+func TestParseTAP(t *testing.T) {
+	Convey("With a TAP file", t, func() {
+		results := parseFile(t, TAPFormat, "testdata/results.tap")
+		So(len(results), ShouldEqual, 5)
+
+		So(results[0].Name, ShouldEqual, "connects to the server")
+		So(results[0].Status, ShouldEqual, evergreen.TestSucceededStatus)
+		So(results[1].Status, ShouldEqual, evergreen.TestFailedStatus)
+		So(results[1].Output, ShouldContain, "  message: 'expected 3, got 4'")
+		So(results[2].Status, ShouldEqual, evergreen.TestSkippedStatus)
+		So(results[2].Output[0], ShouldEqual, "SKIP no cache configured")
+		So(results[3].Status, ShouldEqual, evergreen.TestSkippedStatus)
+		So(results[4].Name, ShouldEqual, "test_5")
+	})
+}
+
+func TestParseGoTestJSON(t *testing.T) {
+	Convey("With a go test -json event stream", t, func() {
+		results := parseFile(t, GoTestJSONFormat, "testdata/results.json")
+		So(len(results), ShouldEqual, 4)
+
+		So(results[0].Name, ShouldEqual, "example.com/pkg.TestPass")
+		So(results[0].Status, ShouldEqual, evergreen.TestSucceededStatus)
+		So(results[0].Duration, ShouldEqual, 1500*time.Millisecond)
+		So(results[1].Status, ShouldEqual, evergreen.TestFailedStatus)
+		So(results[1].Output, ShouldResemble, []string{"    pkg_test.go:12: wrong answer"})
+		So(results[2].Status, ShouldEqual, evergreen.TestSkippedStatus)
+
+		Convey("a package that failed without failing tests should be reported", func() {
+			So(results[3].Name, ShouldEqual, "example.com/broken")
+			So(results[3].Status, ShouldEqual, evergreen.TestFailedStatus)
+			So(results[3].Output, ShouldResemble, []string{"broken.go:3:1: syntax error"})
+		})
+	})
+}
+
+func TestParseJUnit(t *testing.T) {
+	Convey("With a JUnit file with nested suites", t, func() {
+		results := parseFile(t, JUnitFormat, "testdata/junit_nested.xml")
+		So(len(results), ShouldEqual, 5)
+
+		Convey("tests without a class should be named after their suites", func() {
+			So(results[0].Name, ShouldEqual, "integration.auth.test_login")
+			So(results[0].Duration, ShouldEqual, 1204500*time.Millisecond)
+			So(results[0].Output, ShouldBeEmpty)
+		})
+
+		Convey("failures should include inherited properties and stdout", func() {
+			So(results[1].Status, ShouldEqual, evergreen.TestFailedStatus)
+			So(results[1].Output, ShouldResemble, []string{
+				"PROPERTY: server=3.4.0",
+				"FAILURE: expected 200 (AssertionError)",
+				"assert status == 200",
+				"STDOUT:",
+				"logging out",
+				"done",
+			})
+		})
+
+		Convey("errors, skips and reruns should be distinguished", func() {
+			So(results[2].Name, ShouldEqual, "tests.test_db.test_connect[ipv6]")
+			So(results[2].Status, ShouldEqual, evergreen.TestFailedStatus)
+			So(results[2].Output[0], ShouldEqual, "ERROR: connection refused (OSError)")
+			So(results[3].Status, ShouldEqual, evergreen.TestSkippedStatus)
+			So(results[4].Status, ShouldEqual, evergreen.TestSucceededStatus)
+			So(results[4].Output[0], ShouldEqual, "FAILURE ON EARLIER ATTEMPT: timed out (TimeoutError)")
+		})
+
+		Convey("results should convert to test results with logs", func() {
+			testTask := &task.Task{Id: "t1", Execution: 2}
+			res, log := results[2].ToModelTestResultAndLog(testTask, time.Unix(100, 0))
+			So(res.TestFile, ShouldEqual, "tests.test_db.test_connect_ipv6_")
+			So(res.StartTime, ShouldEqual, 100)
+			So(res.EndTime, ShouldEqual, 100.01)
+			So(log, ShouldNotBeNil)
+			So(log.Name, ShouldEqual, res.TestFile)
+			So(res.URL, ShouldEqual, "/test_log/t1/2/tests.test_db.test_connect_ipv6_")
+
+			_, log = results[0].ToModelTestResultAndLog(testTask, time.Unix(100, 0))
+			So(log, ShouldBeNil)
+		})
+	})
+}
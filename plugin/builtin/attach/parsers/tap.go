@@ -0,0 +1,86 @@
+package parsers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen"
+)
+
+// TAPFormat is the name of the Test Anything Protocol parser.
+const TAPFormat = "tap"
+
+func init() {
+	Register(TAPFormat, ParseTAP)
+}
+
+// Match "ok"/"not ok", an optional test number, an optional description, and an
+// optional "# SKIP" or "# TODO" directive.
+var tapTestLineRegex = regexp.MustCompile(`^(not )?ok\b\s*([0-9]*)\s*(?:-\s*)?([^#]*?)\s*(?:#\s*(\S+)\s*(.*))?$`)
+
+// ParseTAP parses Test Anything Protocol output. Diagnostic lines and YAML blocks
+// following a test are kept as that test's output. Skipped tests, and failing tests
+// marked TODO, are reported as skipped. If the producer bails out, a failed result
+// is added for it.
+func ParseTAP(reader io.Reader) ([]Result, error) {
+	results := []Result{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		matches := tapTestLineRegex.FindStringSubmatch(trimmed)
+		switch {
+		// indented test lines are subtests, which are reported through their parent
+		case matches != nil && trimmed == line:
+			results = append(results, tapResult(matches, len(results)))
+		case strings.HasPrefix(trimmed, "Bail out!"):
+			results = append(results, Result{
+				Name:   "bail_out",
+				Status: evergreen.TestFailedStatus,
+				Output: []string{trimmed},
+			})
+			return results, nil
+		case len(results) > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "TAP version"):
+			last := &results[len(results)-1]
+			last.Output = append(last.Output, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading TAP output: %v", err)
+	}
+	return results, nil
+}
+
+// tapResult builds a result from the submatches of a TAP test line.
+func tapResult(matches []string, index int) Result {
+	failed, number, description := matches[1] != "", matches[2], matches[3]
+	directive := strings.ToUpper(matches[4])
+
+	name := description
+	if name == "" {
+		if number == "" {
+			number = fmt.Sprintf("%v", index+1)
+		}
+		name = "test_" + number
+	}
+
+	result := Result{Name: name, Status: evergreen.TestSucceededStatus}
+	switch {
+	case strings.HasPrefix(directive, "SKIP"):
+		result.Status = evergreen.TestSkippedStatus
+	case strings.HasPrefix(directive, "TODO"):
+		if failed {
+			result.Status = evergreen.TestSkippedStatus
+		}
+	case failed:
+		result.Status = evergreen.TestFailedStatus
+	}
+	if directive != "" {
+		result.Output = []string{strings.TrimSpace(fmt.Sprintf("%v %v", directive, matches[5]))}
+	}
+	return result
+}
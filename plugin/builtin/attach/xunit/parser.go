@@ -4,6 +4,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,23 +16,38 @@ import (
 
 type XUnitResults []TestSuite
 
+// TestSuite is a <testsuite>, or the <testsuites> root that contains them. Suites can
+// be nested, as written by some runners.
 type TestSuite struct {
-	Errors    int        `xml:"errors,attr"`
-	Failures  int        `xml:"failures,attr"`
-	Skip      int        `xml:"skip,attr"`
-	Name      string     `xml:"name,attr"`
-	TestCases []TestCase `xml:"testcase"`
-	SysOut    string     `xml:"system-out"`
-	SysErr    string     `xml:"system-err"`
+	Errors     int         `xml:"errors,attr"`
+	Failures   int         `xml:"failures,attr"`
+	Skip       int         `xml:"skip,attr"`
+	Name       string      `xml:"name,attr"`
+	Properties []Property  `xml:"properties>property"`
+	Suites     []TestSuite `xml:"testsuite"`
+	TestCases  []TestCase  `xml:"testcase"`
+	SysOut     string      `xml:"system-out"`
+	SysErr     string      `xml:"system-err"`
+}
+
+type Property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
 }
 
 type TestCase struct {
-	Name      string          `xml:"name,attr"`
-	Time      float64         `xml:"time,attr"`
-	ClassName string          `xml:"classname,attr"`
-	Failure   *FailureDetails `xml:"failure"`
-	Error     *FailureDetails `xml:"error"`
-	Skipped   *FailureDetails `xml:"skipped"`
+	Name       string           `xml:"name,attr"`
+	Time       Seconds          `xml:"time,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Properties []Property       `xml:"properties>property"`
+	Failures   []FailureDetails `xml:"failure"`
+	Errors     []FailureDetails `xml:"error"`
+	Skipped    *FailureDetails  `xml:"skipped"`
+	// failures of earlier attempts of a test that passed when rerun, as written by surefire
+	FlakyFailures []FailureDetails `xml:"flakyFailure"`
+	FlakyErrors   []FailureDetails `xml:"flakyError"`
+	SysOut        string           `xml:"system-out"`
+	SysErr        string           `xml:"system-err"`
 }
 
 type FailureDetails struct {
@@ -40,6 +56,22 @@ type FailureDetails struct {
 	Content string `xml:",chardata"`
 }
 
+// Seconds is the time a test took, in seconds.
+type Seconds float64
+
+// UnmarshalXMLAttr parses a time attribute, ignoring the thousands separators some
+// runners format large times with.
+func (s *Seconds) UnmarshalXMLAttr(attr xml.Attr) error {
+	seconds, err := strconv.ParseFloat(strings.Replace(attr.Value, ",", "", -1), 64)
+	if err != nil {
+		return fmt.Errorf("invalid time '%v'", attr.Value)
+	}
+	*s = Seconds(seconds)
+	return nil
+}
+
+// ParseXMLResults parses an xunit or JUnit XML report with either a <testsuites> or a
+// <testsuite> root, including the dialects written by pytest, surefire and nose.
 func ParseXMLResults(reader io.Reader) (XUnitResults, error) {
 	results := XUnitResults{}
 	if err := xml.NewDecoder(reader).Decode(&results); err != nil {
@@ -67,19 +99,19 @@ func (tc TestCase) ToModelTestResultAndLog(t *task.Task) (task.TestResult, *mode
 	res.TestFile = util.CleanForPath(res.TestFile)
 
 	res.StartTime = float64(time.Now().Unix())
-	res.EndTime = float64(res.StartTime + tc.Time)
+	res.EndTime = res.StartTime + float64(tc.Time)
 
-	// the presence of the Failure, Error, or Skipped fields
+	// the presence of failures, errors, or the Skipped field
 	// is used to indicate an unsuccessful test case. Logs
 	// can only be generated in failure cases, because xunit
 	// results only include messages if they did *not* succeed.
 	switch {
-	case tc.Failure != nil:
+	case len(tc.Failures) > 0:
 		res.Status = evergreen.TestFailedStatus
-		log = tc.Failure.toBasicTestLog("FAILURE")
-	case tc.Error != nil:
+		log = tc.Failures[0].toBasicTestLog("FAILURE")
+	case len(tc.Errors) > 0:
 		res.Status = evergreen.TestFailedStatus
-		log = tc.Error.toBasicTestLog("ERROR")
+		log = tc.Errors[0].toBasicTestLog("ERROR")
 	case tc.Skipped != nil:
 		res.Status = evergreen.TestSkippedStatus
 		log = tc.Skipped.toBasicTestLog("SKIPPED")
@@ -28,8 +28,8 @@ func TestXMLParsing(t *testing.T) {
 					So(res[0].Name, ShouldEqual, "nose2-junit")
 					So(res[0].TestCases[11].Name, ShouldEqual, "test_params_func:2")
 					So(res[0].TestCases[11].Time, ShouldEqual, 0.000098)
-					So(res[0].TestCases[11].Failure, ShouldNotBeNil)
-					So(res[0].TestCases[11].Failure.Message, ShouldEqual, "test failure")
+					So(len(res[0].TestCases[11].Failures), ShouldEqual, 1)
+					So(res[0].TestCases[11].Failures[0].Message, ShouldEqual, "test failure")
 				})
 			})
 		})
@@ -51,9 +51,9 @@ func TestXMLParsing(t *testing.T) {
 					So(res[0].TestCases[0].Name, ShouldEqual, "error")
 					So(res[0].TestCases[0].ClassName, ShouldEqual, "tests.ATest")
 					So(res[0].TestCases[0].Time, ShouldEqual, 0.0060)
-					So(res[0].TestCases[0].Failure, ShouldBeNil)
-					So(res[0].TestCases[0].Error, ShouldNotBeNil)
-					So(res[0].TestCases[0].Error.Type, ShouldEqual, "java.lang.RuntimeException")
+					So(res[0].TestCases[0].Failures, ShouldBeEmpty)
+					So(len(res[0].TestCases[0].Errors), ShouldEqual, 1)
+					So(res[0].TestCases[0].Errors[0].Type, ShouldEqual, "java.lang.RuntimeException")
 
 				})
 			})
@@ -76,8 +76,8 @@ func TestXMLParsing(t *testing.T) {
 					So(res[0].TestCases[0].Name, ShouldEqual, "test_uri_options")
 					So(res[0].TestCases[0].ClassName, ShouldEqual, "test.test_auth.TestAuthURIOptions")
 					So(res[0].TestCases[0].Time, ShouldEqual, 0.002)
-					So(res[0].TestCases[0].Failure, ShouldBeNil)
-					So(res[0].TestCases[0].Error, ShouldBeNil)
+					So(res[0].TestCases[0].Failures, ShouldBeEmpty)
+					So(res[0].TestCases[0].Errors, ShouldBeEmpty)
 					So(res[0].TestCases[0].Skipped, ShouldNotBeNil)
 					So(res[0].TestCases[0].Skipped.Type, ShouldEqual, "unittest.case.SkipTest")
 					So(res[0].TestCases[0].Skipped.Content, ShouldContainSubstring,
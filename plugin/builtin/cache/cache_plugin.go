@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/storage"
+)
+
+func init() {
+	plugin.Publish(&CachePlugin{})
+}
+
+const (
+	CacheRestoreCmd  = "restore"
+	CacheSaveCmd     = "save"
+	CachePluginName  = "cache"
+	cacheContentType = "application/x-gzip"
+
+	// cachePrefix is the prefix of every cache archive in the bucket. Archives
+	// are stored as <cachePrefix>/<project>/<key>.tar.gz
+	cachePrefix   = "cache"
+	archiveSuffix = ".tar.gz"
+)
+
+// CachePlugin has commands for saving directories to a cache shared by all
+// of a project's tasks and for restoring them on later tasks, so that
+// dependencies are not downloaded again on every host.
+type CachePlugin struct{}
+
+// Name returns the name of the plugin. Fulfills the Plugin interface.
+func (self *CachePlugin) Name() string {
+	return CachePluginName
+}
+
+// NewCommand takes a command name as a string and returns the requested command,
+// or an error if the command does not exist. Fulfills the Plugin interface.
+func (self *CachePlugin) NewCommand(cmdName string) (plugin.Command, error) {
+	switch cmdName {
+	case CacheRestoreCmd:
+		return &CacheRestoreCommand{}, nil
+	case CacheSaveCmd:
+		return &CacheSaveCommand{}, nil
+	default:
+		return nil, &plugin.ErrUnknownCommand{CommandName: cmdName}
+	}
+}
+
+// cacheEntry is an archive stored in the cache.
+type cacheEntry struct {
+	Path string
+	Size int64
+	// LastModified is an ISO 8601 timestamp, so entries sort by it lexically
+	LastModified string
+}
+
+// cacheStore is the storage the cache archives are kept in.
+type cacheStore interface {
+	// Exists returns whether an archive is stored at the path.
+	Exists(path string) (bool, error)
+	// List returns every archive whose path starts with the prefix.
+	List(prefix string) ([]cacheEntry, error)
+	// Get returns a reader for the archive at the path.
+	Get(path string) (io.ReadCloser, error)
+	// Put uploads the local file to the path.
+	Put(path, localFile string) error
+	// Remove deletes the archive at the path.
+	Remove(path string) error
+}
+
+// driverStore keeps cache archives in a bucket of a storage driver.
+type driverStore struct {
+	driver storage.Driver
+	bucket string
+}
+
+// newDriverStore returns a store for the bucket, using the driver selected by
+// the storage options.
+func newDriverStore(opts storage.Options, awsKey, awsSecret, bucket string) (*driverStore, error) {
+	driver, err := storage.GetDriver(opts, awsKey, awsSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &driverStore{driver: driver, bucket: bucket}, nil
+}
+
+func (s *driverStore) Exists(path string) (bool, error) {
+	return s.driver.Exists(s.bucket, path)
+}
+
+func (s *driverStore) List(prefix string) ([]cacheEntry, error) {
+	objects, err := s.driver.List(s.bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]cacheEntry, 0, len(objects))
+	for _, object := range objects {
+		entries = append(entries, cacheEntry{
+			Path:         object.Path,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+		})
+	}
+	return entries, nil
+}
+
+func (s *driverStore) Get(path string) (io.ReadCloser, error) {
+	return s.driver.Get(s.bucket, path)
+}
+
+func (s *driverStore) Put(path, localFile string) error {
+	f, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return s.driver.Put(s.bucket, path, f, fi.Size(), storage.PutOptions{ContentType: cacheContentType})
+}
+
+func (s *driverStore) Remove(path string) error {
+	return s.driver.Remove(s.bucket, path)
+}
+
+// projectPrefix returns the prefix of all of the project's archives.
+func projectPrefix(conf *model.TaskConfig) string {
+	project := conf.Task.Project
+	if conf.ProjectRef != nil {
+		project = conf.ProjectRef.Identifier
+	}
+	return path.Join(cachePrefix, project) + "/"
+}
+
+// validateKey makes sure a cache key can be used as part of an archive's path.
+func validateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("key cannot be blank")
+	}
+	if strings.Contains(key, "/") || strings.Contains(key, "..") {
+		return fmt.Errorf("key '%v' must not contain '/' or '..'", key)
+	}
+	return nil
+}
+
+// newestEntry returns the most recently saved archive, or nil if there are none.
+func newestEntry(entries []cacheEntry) *cacheEntry {
+	var newest *cacheEntry
+	for i := range entries {
+		if newest == nil || entries[i].LastModified > newest.LastModified {
+			newest = &entries[i]
+		}
+	}
+	return newest
+}
+
+// entriesToEvict returns the oldest archives that must be removed for the
+// total size of the entries to fit within maxBytes. The archive at keep is
+// never evicted.
+func entriesToEvict(entries []cacheEntry, maxBytes int64, keep string) []cacheEntry {
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+	sorted := make([]cacheEntry, len(entries))
+	copy(sorted, entries)
+	sort.Sort(entriesByAge(sorted))
+
+	evict := []cacheEntry{}
+	for _, entry := range sorted {
+		if total <= maxBytes {
+			break
+		}
+		if entry.Path == keep {
+			continue
+		}
+		evict = append(evict, entry)
+		total -= entry.Size
+	}
+	return evict
+}
+
+type entriesByAge []cacheEntry
+
+func (e entriesByAge) Len() int           { return len(e) }
+func (e entriesByAge) Less(i, j int) bool { return e[i].LastModified < e[j].LastModified }
+func (e entriesByAge) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+// formatSize returns a human-readable size.
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	default:
+		return fmt.Sprintf("%v B", bytes)
+	}
+}
@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/storage"
+	"github.com/evergreen-ci/evergreen/testutil"
+	"github.com/evergreen-ci/evergreen/util"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryStore is a cacheStore that keeps archives in memory. Archives put later
+// are considered newer.
+type memoryStore struct {
+	archives map[string][]byte
+	modified map[string]string
+	puts     int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{archives: map[string][]byte{}, modified: map[string]string{}}
+}
+
+func (m *memoryStore) Exists(path string) (bool, error) {
+	_, ok := m.archives[path]
+	return ok, nil
+}
+
+func (m *memoryStore) List(prefix string) ([]cacheEntry, error) {
+	entries := []cacheEntry{}
+	for path, data := range m.archives {
+		if strings.HasPrefix(path, prefix) {
+			entries = append(entries, cacheEntry{path, int64(len(data)), m.modified[path]})
+		}
+	}
+	return entries, nil
+}
+
+func (m *memoryStore) Get(path string) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(string(m.archives[path]))), nil
+}
+
+func (m *memoryStore) Put(path, localFile string) error {
+	data, err := ioutil.ReadFile(localFile)
+	if err != nil {
+		return err
+	}
+	m.puts++
+	m.archives[path] = data
+	m.modified[path] = fmt.Sprintf("z%04d", m.puts)
+	return nil
+}
+
+func (m *memoryStore) Remove(path string) error {
+	delete(m.archives, path)
+	return nil
+}
+
+func TestCacheParseParams(t *testing.T) {
+	Convey("With the cache plugin", t, func() {
+		cachePlugin := &CachePlugin{}
+		params := map[string]interface{}{
+			"aws_key":    "key",
+			"aws_secret": "secret",
+			"bucket":     "bucket",
+			"key":        "deps-${lockfile_hash}",
+			"include":    []string{"node_modules/**"},
+		}
+
+		Convey("a valid save command should parse", func() {
+			cmd, err := cachePlugin.NewCommand(CacheSaveCmd)
+			So(err, ShouldBeNil)
+			So(cmd.ParseParams(params), ShouldBeNil)
+		})
+
+		Convey("a save command without includes should not parse", func() {
+			delete(params, "include")
+			cmd, err := cachePlugin.NewCommand(CacheSaveCmd)
+			So(err, ShouldBeNil)
+			So(cmd.ParseParams(params), ShouldNotBeNil)
+		})
+
+		Convey("keys that are not a single path element should not parse", func() {
+			params["key"] = "../other-project/deps"
+			cmd, err := cachePlugin.NewCommand(CacheRestoreCmd)
+			So(err, ShouldBeNil)
+			So(cmd.ParseParams(params), ShouldNotBeNil)
+		})
+
+		Convey("a restore command with fallback keys should parse", func() {
+			params["fallback_keys"] = []string{"deps-"}
+			cmd, err := cachePlugin.NewCommand(CacheRestoreCmd)
+			So(err, ShouldBeNil)
+			So(cmd.ParseParams(params), ShouldBeNil)
+		})
+	})
+}
+
+func TestCacheSaveAndRestore(t *testing.T) {
+	Convey("With a cache store and a directory to cache", t, func() {
+		store := newMemoryStore()
+		logger := &testutil.NopLogger{}
+		sourceDir, err := ioutil.TempDir("", "cache-src")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(sourceDir)
+		destDir, err := ioutil.TempDir("", "cache-dest")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(destDir)
+		So(os.MkdirAll(filepath.Join(sourceDir, "deps"), 0755), ShouldBeNil)
+		So(ioutil.WriteFile(filepath.Join(sourceDir, "deps", "lib.txt"), []byte("lib"), 0644), ShouldBeNil)
+
+		save := func(key string, maxMB int64) {
+			cmd := &CacheSaveCommand{Key: key, SourceDir: sourceDir,
+				Include: []string{"deps/**"}, MaxProjectSizeMB: maxMB, store: store}
+			So(cmd.Save("cache/proj/", sourceDir, logger), ShouldBeNil)
+		}
+		restore := func(key string, fallbacks ...string) bool {
+			cmd := &CacheRestoreCommand{Key: key, FallbackKeys: fallbacks, DestDir: destDir, store: store}
+			So(cmd.Restore("cache/proj/", logger), ShouldBeNil)
+			exists, err := util.FileExists(filepath.Join(destDir, "deps", "lib.txt"))
+			So(err, ShouldBeNil)
+			return exists
+		}
+
+		Convey("a saved archive should be restored by its key", func() {
+			save("deps-1", 0)
+			So(store.archives, ShouldContainKey, "cache/proj/deps-1.tar.gz")
+			So(restore("deps-1"), ShouldBeTrue)
+		})
+
+		Convey("an existing key should not be saved again", func() {
+			save("deps-1", 0)
+			save("deps-1", 0)
+			So(store.puts, ShouldEqual, 1)
+		})
+
+		Convey("a missing key should fall back to the newest archive with a matching prefix", func() {
+			save("deps-1", 0)
+			save("deps-2", 0)
+			cmd := &CacheRestoreCommand{Key: "deps-3", FallbackKeys: []string{"other-", "deps-"}, store: store}
+			path, err := cmd.findArchive("cache/proj/")
+			So(err, ShouldBeNil)
+			So(path, ShouldEqual, "cache/proj/deps-2.tar.gz")
+		})
+
+		Convey("a cache miss should not be an error", func() {
+			So(restore("deps-1", "deps-"), ShouldBeFalse)
+		})
+
+		Convey("the oldest archives should be evicted when the project is over its limit", func() {
+			for _, key := range []string{"deps-1", "deps-2", "deps-3"} {
+				store.archives["cache/proj/"+key+archiveSuffix] = make([]byte, 600<<10)
+				store.modified["cache/proj/"+key+archiveSuffix] = key
+			}
+			save("deps-4", 1)
+			So(store.archives, ShouldNotContainKey, "cache/proj/deps-1.tar.gz")
+			So(store.archives, ShouldNotContainKey, "cache/proj/deps-2.tar.gz")
+			So(store.archives, ShouldContainKey, "cache/proj/deps-3.tar.gz")
+			So(store.archives, ShouldContainKey, "cache/proj/deps-4.tar.gz")
+		})
+
+		Convey("archives should round trip through the local storage driver", func() {
+			root, err := ioutil.TempDir("", "cache-storage")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(root)
+			localStore, err := newDriverStore(storage.Options{Driver: storage.LocalDriverName, Root: root}, "", "", "caches")
+			So(err, ShouldBeNil)
+
+			saveCmd := &CacheSaveCommand{Key: "deps-1", SourceDir: sourceDir,
+				Include: []string{"deps/**"}, store: localStore}
+			So(saveCmd.Save("cache/proj/", sourceDir, logger), ShouldBeNil)
+			exists, err := util.FileExists(filepath.Join(root, "caches", "cache", "proj", "deps-1.tar.gz"))
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+
+			restoreCmd := &CacheRestoreCommand{Key: "deps-2", FallbackKeys: []string{"deps-"},
+				DestDir: destDir, store: localStore}
+			So(restoreCmd.Restore("cache/proj/", logger), ShouldBeNil)
+			exists, err = util.FileExists(filepath.Join(destDir, "deps", "lib.txt"))
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+		})
+	})
+}
+
+func TestEntriesToEvict(t *testing.T) {
+	Convey("When choosing archives to evict", t, func() {
+		entries := []cacheEntry{
+			{"c", 10, "2016-03-03T00:00:00.000Z"},
+			{"a", 10, "2016-03-01T00:00:00.000Z"},
+			{"b", 10, "2016-03-02T00:00:00.000Z"},
+		}
+
+		Convey("nothing should be evicted under the limit", func() {
+			So(entriesToEvict(entries, 30, "c"), ShouldBeEmpty)
+		})
+
+		Convey("the oldest archives should be evicted first", func() {
+			evict := entriesToEvict(entries, 15, "c")
+			So(len(evict), ShouldEqual, 2)
+			So(evict[0].Path, ShouldEqual, "a")
+			So(evict[1].Path, ShouldEqual, "b")
+		})
+
+		Convey("the archive just saved should never be evicted", func() {
+			evict := entriesToEvict(entries, 5, "c")
+			So(len(evict), ShouldEqual, 2)
+		})
+	})
+}
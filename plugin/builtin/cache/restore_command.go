@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/plugin/builtin/archive"
+	"github.com/evergreen-ci/evergreen/storage"
+	"github.com/mitchellh/mapstructure"
+)
+
+// CacheRestoreCommand downloads the project's archive saved under a key and
+// unpacks it into a directory. If there is no archive for the key, the newest
+// archive whose key starts with one of the fallback keys is restored instead.
+// A cache miss is not an error.
+type CacheRestoreCommand struct {
+	// AwsKey and AwsSecret are the credentials for the cache's bucket
+	AwsKey    string `mapstructure:"aws_key" plugin:"expand"`
+	AwsSecret string `mapstructure:"aws_secret" plugin:"expand"`
+
+	// Bucket is the bucket the cache archives are stored in
+	Bucket string `mapstructure:"bucket" plugin:"expand"`
+
+	// Storage selects the storage driver the cache is kept with. If unset, the
+	// project's storage settings are used, which default to Amazon S3.
+	Storage storage.Options `mapstructure:"storage" plugin:"expand"`
+
+	// Key identifies the archive, e.g. "deps-${lockfile_hash}"
+	Key string `mapstructure:"key" plugin:"expand"`
+
+	// FallbackKeys are key prefixes tried in order when there is no archive
+	// for Key, e.g. "deps-"
+	FallbackKeys []string `mapstructure:"fallback_keys" plugin:"expand"`
+
+	// DestDir is the directory the archive is unpacked into. Defaults to the
+	// working directory.
+	DestDir string `mapstructure:"dest_dir" plugin:"expand"`
+
+	// store overrides the storage driver's store, for testing
+	store cacheStore
+}
+
+func (self *CacheRestoreCommand) Name() string {
+	return CacheRestoreCmd
+}
+
+func (self *CacheRestoreCommand) Plugin() string {
+	return CachePluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *CacheRestoreCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return fmt.Errorf("error decoding '%v' params: %v", self.Name(), err)
+	}
+	if err := self.validateParams(); err != nil {
+		return fmt.Errorf("error validating '%v' params: %v", self.Name(), err)
+	}
+	return nil
+}
+
+func (self *CacheRestoreCommand) validateParams() error {
+	if err := plugin.ValidateStorage(self.Storage, self.AwsKey, self.AwsSecret); err != nil {
+		return err
+	}
+	if self.Bucket == "" {
+		return fmt.Errorf("bucket cannot be blank")
+	}
+	if err := validateKey(self.Key); err != nil {
+		return err
+	}
+	for _, fallback := range self.FallbackKeys {
+		if err := validateKey(fallback); err != nil {
+			return fmt.Errorf("invalid fallback key: %v", err)
+		}
+	}
+	return nil
+}
+
+// Execute restores the archive.
+func (self *CacheRestoreCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
+		return fmt.Errorf("error expanding params: %v", err)
+	}
+	if conf.ProjectRef != nil {
+		self.Storage = storage.Choose(self.Storage, conf.ProjectRef.Storage)
+	}
+	if err := self.validateParams(); err != nil {
+		return fmt.Errorf("expanded params are not valid: %v", err)
+	}
+
+	if self.DestDir == "" {
+		self.DestDir = conf.WorkDir
+	} else if !filepath.IsAbs(self.DestDir) {
+		self.DestDir = filepath.Join(conf.WorkDir, self.DestDir)
+	}
+	if self.store == nil {
+		store, err := newDriverStore(self.Storage, self.AwsKey, self.AwsSecret, self.Bucket)
+		if err != nil {
+			return err
+		}
+		self.store = store
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- self.Restore(projectPrefix(conf), pluginLogger)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of cache restore command")
+		return nil
+	}
+}
+
+// Restore finds the archive to restore among the archives under the prefix
+// and unpacks it.
+func (self *CacheRestoreCommand) Restore(prefix string, pluginLogger plugin.Logger) error {
+	archivePath, err := self.findArchive(prefix)
+	if err != nil {
+		return fmt.Errorf("error looking up cache key '%v': %v", self.Key, err)
+	}
+	if archivePath == "" {
+		pluginLogger.LogTask(slogger.INFO, "Cache miss for key '%v'", self.Key)
+		return nil
+	}
+	pluginLogger.LogTask(slogger.INFO, "Restoring cache from %v", archivePath)
+
+	// download the archive so it can be unpacked by the archive plugin
+	tmp, err := ioutil.TempFile("", "cache")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	reader, err := self.store.Get(archivePath)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("error downloading %v: %v", archivePath, err)
+	}
+	size, err := io.Copy(tmp, reader)
+	reader.Close()
+	tmp.Close()
+	if err != nil {
+		return fmt.Errorf("error downloading %v: %v", archivePath, err)
+	}
+
+	unpack := &archive.TarGzUnpackCommand{Source: tmp.Name(), DestDir: self.DestDir}
+	if err := unpack.UnpackArchive(); err != nil {
+		return fmt.Errorf("error unpacking %v: %v", archivePath, err)
+	}
+	pluginLogger.LogTask(slogger.INFO, "Restored %v cache archive into %v",
+		formatSize(size), self.DestDir)
+	return nil
+}
+
+// findArchive returns the path of the archive for the key or, failing that, of the
+// newest archive matching the first fallback key that matches any. It returns an
+// empty path on a cache miss.
+func (self *CacheRestoreCommand) findArchive(prefix string) (string, error) {
+	exactPath := prefix + self.Key + archiveSuffix
+	exists, err := self.store.Exists(exactPath)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return exactPath, nil
+	}
+	for _, fallback := range self.FallbackKeys {
+		entries, err := self.store.List(prefix + fallback)
+		if err != nil {
+			return "", err
+		}
+		if newest := newestEntry(entries); newest != nil {
+			return newest.Path, nil
+		}
+	}
+	return "", nil
+}
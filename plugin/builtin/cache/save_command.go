@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/plugin/builtin/archive"
+	"github.com/evergreen-ci/evergreen/storage"
+	"github.com/mitchellh/mapstructure"
+)
+
+// CacheSaveCommand packs files into an archive and saves it in the project's
+// cache under a key. Archives are never overwritten, so a key should identify
+// its contents, e.g. by including a hash of a lockfile. After saving, the
+// project's oldest archives are evicted if the cache is over its size limit.
+type CacheSaveCommand struct {
+	// AwsKey and AwsSecret are the credentials for the cache's bucket
+	AwsKey    string `mapstructure:"aws_key" plugin:"expand"`
+	AwsSecret string `mapstructure:"aws_secret" plugin:"expand"`
+
+	// Bucket is the bucket the cache archives are stored in
+	Bucket string `mapstructure:"bucket" plugin:"expand"`
+
+	// Storage selects the storage driver the cache is kept with. If unset, the
+	// project's storage settings are used, which default to Amazon S3.
+	Storage storage.Options `mapstructure:"storage" plugin:"expand"`
+
+	// Key identifies the archive, e.g. "deps-${lockfile_hash}"
+	Key string `mapstructure:"key" plugin:"expand"`
+
+	// SourceDir is the directory to archive. Defaults to the working directory.
+	SourceDir string `mapstructure:"source_dir" plugin:"expand"`
+
+	// Include and ExcludeFiles are the filename blobs to archive, as for
+	// the archive plugin's targz_pack command
+	Include      []string `mapstructure:"include" plugin:"expand"`
+	ExcludeFiles []string `mapstructure:"exclude_files" plugin:"expand"`
+
+	// MaxProjectSizeMB is the size limit of all of the project's archives.
+	// If zero, archives are never evicted.
+	MaxProjectSizeMB int64 `mapstructure:"max_project_size_mb"`
+
+	// store overrides the storage driver's store, for testing
+	store cacheStore
+}
+
+func (self *CacheSaveCommand) Name() string {
+	return CacheSaveCmd
+}
+
+func (self *CacheSaveCommand) Plugin() string {
+	return CachePluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *CacheSaveCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return fmt.Errorf("error decoding '%v' params: %v", self.Name(), err)
+	}
+	if err := self.validateParams(); err != nil {
+		return fmt.Errorf("error validating '%v' params: %v", self.Name(), err)
+	}
+	return nil
+}
+
+func (self *CacheSaveCommand) validateParams() error {
+	if err := plugin.ValidateStorage(self.Storage, self.AwsKey, self.AwsSecret); err != nil {
+		return err
+	}
+	if self.Bucket == "" {
+		return fmt.Errorf("bucket cannot be blank")
+	}
+	if err := validateKey(self.Key); err != nil {
+		return err
+	}
+	if len(self.Include) == 0 {
+		return fmt.Errorf("include cannot be empty")
+	}
+	if self.MaxProjectSizeMB < 0 {
+		return fmt.Errorf("max_project_size_mb cannot be negative")
+	}
+	return nil
+}
+
+// Execute saves the archive.
+func (self *CacheSaveCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
+		return fmt.Errorf("error expanding params: %v", err)
+	}
+	if conf.ProjectRef != nil {
+		self.Storage = storage.Choose(self.Storage, conf.ProjectRef.Storage)
+	}
+	if err := self.validateParams(); err != nil {
+		return fmt.Errorf("expanded params are not valid: %v", err)
+	}
+
+	if self.SourceDir == "" {
+		self.SourceDir = conf.WorkDir
+	} else if !filepath.IsAbs(self.SourceDir) {
+		self.SourceDir = filepath.Join(conf.WorkDir, self.SourceDir)
+	}
+	if self.store == nil {
+		store, err := newDriverStore(self.Storage, self.AwsKey, self.AwsSecret, self.Bucket)
+		if err != nil {
+			return err
+		}
+		self.store = store
+	}
+
+	errChan := make(chan error)
+	go func() {
+		errChan <- self.Save(projectPrefix(conf), conf.WorkDir, pluginLogger)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		pluginLogger.LogExecution(slogger.INFO, "Received signal to terminate"+
+			" execution of cache save command")
+		return nil
+	}
+}
+
+// Save packs and uploads the archive under the prefix, then evicts old archives.
+func (self *CacheSaveCommand) Save(prefix, workDir string, pluginLogger plugin.Logger) error {
+	archivePath := prefix + self.Key + archiveSuffix
+	exists, err := self.store.Exists(archivePath)
+	if err != nil {
+		return fmt.Errorf("error looking up cache key '%v': %v", self.Key, err)
+	}
+	if exists {
+		pluginLogger.LogTask(slogger.INFO, "Cache key '%v' is already saved, skipping", self.Key)
+		return nil
+	}
+
+	tmp, err := ioutil.TempFile("", "cache")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %v", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	pack := &archive.TarGzPackCommand{
+		Target:       tmp.Name(),
+		SourceDir:    self.SourceDir,
+		Include:      self.Include,
+		ExcludeFiles: self.ExcludeFiles,
+	}
+	filesArchived, err := pack.BuildArchive(workDir, pluginLogger)
+	if err != nil {
+		return fmt.Errorf("error building cache archive: %v", err)
+	}
+	if filesArchived == 0 {
+		pluginLogger.LogTask(slogger.WARN, "No files matched for cache key '%v', skipping", self.Key)
+		return nil
+	}
+	fi, err := os.Stat(tmp.Name())
+	if err != nil {
+		return fmt.Errorf("error reading cache archive: %v", err)
+	}
+
+	pluginLogger.LogTask(slogger.INFO, "Saving %v files (%v) to %v",
+		filesArchived, formatSize(fi.Size()), archivePath)
+	if err = self.store.Put(archivePath, tmp.Name()); err != nil {
+		return fmt.Errorf("error uploading %v: %v", archivePath, err)
+	}
+
+	return self.evict(prefix, archivePath, pluginLogger)
+}
+
+// evict removes the project's oldest archives until its cache fits within
+// MaxProjectSizeMB, and reports the cache's size.
+func (self *CacheSaveCommand) evict(prefix, saved string, pluginLogger plugin.Logger) error {
+	entries, err := self.store.List(prefix)
+	if err != nil {
+		return fmt.Errorf("error listing cache: %v", err)
+	}
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+	pluginLogger.LogTask(slogger.INFO, "Project cache holds %v archives (%v)",
+		len(entries), formatSize(total))
+	if self.MaxProjectSizeMB == 0 {
+		return nil
+	}
+
+	for _, entry := range entriesToEvict(entries, self.MaxProjectSizeMB<<20, saved) {
+		pluginLogger.LogTask(slogger.INFO, "Evicting %v (%v)", entry.Path, formatSize(entry.Size))
+		// another task may have evicted it already, so failures are not fatal
+		if err := self.store.Remove(entry.Path); err != nil {
+			pluginLogger.LogTask(slogger.WARN, "Error evicting %v: %v", entry.Path, err)
+		}
+	}
+	return nil
+}
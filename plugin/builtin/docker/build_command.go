@@ -0,0 +1,144 @@
+package docker
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/mitchellh/mapstructure"
+)
+
+// DockerBuildCommand builds an image from a Dockerfile and tags it.
+type DockerBuildCommand struct {
+	// Context is the build's context directory, relative to the working
+	// directory. Defaults to the working directory.
+	Context string `mapstructure:"context" plugin:"expand"`
+
+	// Dockerfile is the path of the Dockerfile, relative to the working
+	// directory. Defaults to the Dockerfile in the context directory.
+	Dockerfile string `mapstructure:"dockerfile" plugin:"expand"`
+
+	// BuildArgs are passed to the build as --build-arg flags. Their values are
+	// not logged.
+	BuildArgs map[string]string `mapstructure:"build_args" plugin:"expand"`
+
+	// Tags are the names to tag the image with, e.g. "app:${revision}"
+	Tags []string `mapstructure:"tags" plugin:"expand"`
+
+	// Target is the stage of a multi-stage build to build
+	Target string `mapstructure:"target" plugin:"expand"`
+
+	// Pull, if set, always pulls newer versions of the base images
+	Pull bool `mapstructure:"pull"`
+
+	// NoCache, if set, builds the image without using the build cache
+	NoCache bool `mapstructure:"no_cache"`
+
+	// Auth is used to pull base images from a private registry
+	Auth RegistryAuth `mapstructure:"auth" plugin:"expand"`
+}
+
+func (self *DockerBuildCommand) Name() string {
+	return DockerBuildCmd
+}
+
+func (self *DockerBuildCommand) Plugin() string {
+	return DockerPluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *DockerBuildCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return fmt.Errorf("error decoding '%v' params: %v", self.Name(), err)
+	}
+	if err := self.validateParams(); err != nil {
+		return fmt.Errorf("error validating '%v' params: %v", self.Name(), err)
+	}
+	return nil
+}
+
+func (self *DockerBuildCommand) validateParams() error {
+	if len(self.Tags) == 0 {
+		return fmt.Errorf("tags cannot be empty")
+	}
+	return self.Auth.validate()
+}
+
+// buildArgs returns the arguments to the docker CLI for the build.
+func (self *DockerBuildCommand) buildArgs(taskId, workDir string) []string {
+	args := []string{"build", "--label", taskLabel(taskId)}
+	for _, tag := range self.Tags {
+		args = append(args, "--tag", tag)
+	}
+	if self.Dockerfile != "" {
+		args = append(args, "--file", absPath(workDir, self.Dockerfile))
+	}
+	// sort the build arguments so that they are logged in a stable order
+	names := make([]string, 0, len(self.BuildArgs))
+	for name := range self.BuildArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "--build-arg", fmt.Sprintf("%v=%v", name, self.BuildArgs[name]))
+	}
+	if self.Target != "" {
+		args = append(args, "--target", self.Target)
+	}
+	if self.Pull {
+		args = append(args, "--pull")
+	}
+	if self.NoCache {
+		args = append(args, "--no-cache")
+	}
+	context := self.Context
+	if context == "" {
+		context = "."
+	}
+	return append(args, absPath(workDir, context))
+}
+
+// Execute builds the image.
+func (self *DockerBuildCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
+		return fmt.Errorf("error expanding params: %v", err)
+	}
+	if err := self.validateParams(); err != nil {
+		return err
+	}
+
+	d := newDockerRunner(conf, pluginLogger)
+	if self.Auth.isSet() {
+		logout, err := d.login(self.Auth)
+		if err != nil {
+			return err
+		}
+		defer logout()
+	}
+
+	if err := d.run(stop, nil, self.buildArgs(conf.Task.Id, conf.WorkDir)...); err != nil {
+		return fmt.Errorf("error building image: %v", err)
+	}
+	imageId, err := d.output("inspect", "--format", "{{.Id}}", self.Tags[0])
+	if err != nil {
+		return fmt.Errorf("error inspecting built image: %v", err)
+	}
+	pluginLogger.LogTask(slogger.INFO, "Built image %v", imageId)
+	return nil
+}
+
+// absPath returns the path relative to the working directory, unless it is
+// already absolute.
+func absPath(workDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workDir, path)
+}
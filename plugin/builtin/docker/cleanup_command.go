@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"fmt"
+
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+)
+
+// DockerCleanupCommand removes the containers and images created by the task,
+// for use in a project's post commands. The agent also removes them when the
+// task is done, unless the project disables cleanup.
+type DockerCleanupCommand struct{}
+
+func (self *DockerCleanupCommand) Name() string {
+	return DockerCleanupCmd
+}
+
+func (self *DockerCleanupCommand) Plugin() string {
+	return DockerPluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *DockerCleanupCommand) ParseParams(params map[string]interface{}) error {
+	return nil
+}
+
+// Execute removes the task's containers and images, and the images it pulled.
+func (self *DockerCleanupCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	tasksMutex.Lock()
+	pulled := tasksUsingDocker[conf.Task.Id]
+	if _, ok := tasksUsingDocker[conf.Task.Id]; ok {
+		tasksUsingDocker[conf.Task.Id] = nil
+	}
+	tasksMutex.Unlock()
+
+	if err := cleanupTask(conf.Task.Id, pulled, pluginLogger); err != nil {
+		return fmt.Errorf("error cleaning up docker resources: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+func init() {
+	plugin.Publish(&DockerPlugin{})
+}
+
+const (
+	DockerPluginName = "docker"
+	DockerBuildCmd   = "build"
+	DockerPushCmd    = "push"
+	DockerRunCmd     = "run"
+	DockerCleanupCmd = "cleanup"
+
+	// TaskLabel is the label put on every image and container created by a
+	// task, so that they can be removed when the task is done.
+	TaskLabel = "evergreen.task_id"
+)
+
+// dockerBinary is the docker CLI the commands run. Overridden in tests.
+var dockerBinary = "docker"
+
+// tasksUsingDocker maps the ids of the tasks that have run a docker command on
+// this agent to the images they pulled, so that cleanup is only done for them.
+// Pulled images can't be labeled, so they are removed by reference.
+var (
+	tasksUsingDocker = map[string][]string{}
+	tasksMutex       sync.Mutex
+)
+
+// DockerPlugin has commands for building, pushing and running Docker images
+// with the docker CLI on the agent's machine. Images and containers created by
+// a task are removed when the task is done.
+type DockerPlugin struct{}
+
+// Name returns the name of the plugin. Fulfills the Plugin interface.
+func (self *DockerPlugin) Name() string {
+	return DockerPluginName
+}
+
+// NewCommand takes a command name as a string and returns the requested command,
+// or an error if the command does not exist. Fulfills the Plugin interface.
+func (self *DockerPlugin) NewCommand(cmdName string) (plugin.Command, error) {
+	switch cmdName {
+	case DockerBuildCmd:
+		return &DockerBuildCommand{}, nil
+	case DockerPushCmd:
+		return &DockerPushCommand{}, nil
+	case DockerRunCmd:
+		return &DockerRunCommand{}, nil
+	case DockerCleanupCmd:
+		return &DockerCleanupCommand{}, nil
+	default:
+		return nil, &plugin.ErrUnknownCommand{CommandName: cmdName}
+	}
+}
+
+// RegistryAuth holds the credentials for logging in to an image registry. They
+// are usually set from project variables, e.g. "password: ${docker_password}".
+type RegistryAuth struct {
+	// Registry is the registry's host. Defaults to Docker Hub.
+	Registry string `mapstructure:"registry" plugin:"expand"`
+	Username string `mapstructure:"username" plugin:"expand"`
+	Password string `mapstructure:"password" plugin:"expand"`
+}
+
+func (a *RegistryAuth) isSet() bool {
+	return a.Username != ""
+}
+
+func (a *RegistryAuth) validate() error {
+	if a.Username == "" && a.Password != "" {
+		return fmt.Errorf("auth username cannot be blank if a password is given")
+	}
+	if a.Username != "" && a.Password == "" {
+		return fmt.Errorf("auth password cannot be blank if a username is given")
+	}
+	return nil
+}
+
+// dockerRunner runs the docker CLI for a task, logging its output to the
+// task's logs.
+type dockerRunner struct {
+	taskId  string
+	workDir string
+	logger  plugin.Logger
+	stdout  io.Writer
+	stderr  io.Writer
+
+	// configDir is the docker client's config directory, which holds the
+	// registry credentials. If empty, the user's default config is used.
+	configDir string
+}
+
+// newDockerRunner returns a runner for the task, and marks the task as one that
+// needs its docker resources cleaned up.
+func newDockerRunner(conf *model.TaskConfig, pluginLogger plugin.Logger) *dockerRunner {
+	tasksMutex.Lock()
+	if _, ok := tasksUsingDocker[conf.Task.Id]; !ok {
+		tasksUsingDocker[conf.Task.Id] = nil
+	}
+	tasksMutex.Unlock()
+
+	return &dockerRunner{
+		taskId:  conf.Task.Id,
+		workDir: conf.WorkDir,
+		logger:  pluginLogger,
+		stdout:  util.NewLineBufferingWriter(pluginLogger.GetTaskLogWriter(slogger.INFO)),
+		stderr:  util.NewLineBufferingWriter(pluginLogger.GetTaskLogWriter(slogger.ERROR)),
+	}
+}
+
+// login logs in to the registry with a config directory of the runner's own,
+// so that the credentials are not left behind on the machine. The returned
+// function removes the credentials.
+func (d *dockerRunner) login(auth RegistryAuth) (func(), error) {
+	configDir, err := ioutil.TempDir("", "docker-config")
+	if err != nil {
+		return nil, fmt.Errorf("error creating docker config directory: %v", err)
+	}
+	d.configDir = configDir
+	logout := func() {
+		d.configDir = ""
+		if err := os.RemoveAll(configDir); err != nil {
+			d.logger.LogExecution(slogger.WARN, "Error removing docker config directory: %v", err)
+		}
+	}
+
+	args := []string{"login", "--username", auth.Username, "--password-stdin"}
+	if auth.Registry != "" {
+		args = append(args, auth.Registry)
+	}
+	if err = d.run(nil, strings.NewReader(auth.Password), args...); err != nil {
+		logout()
+		return nil, fmt.Errorf("error logging in to registry: %v", err)
+	}
+	return logout, nil
+}
+
+// pull pulls the image. If the image wasn't on the machine before, it is
+// recorded as the task's, to be removed when the task is done.
+func (d *dockerRunner) pull(stop chan bool, image string) error {
+	_, err := d.output("image", "inspect", "--format", "{{.Id}}", image)
+	existed := err == nil
+	if err = d.run(stop, nil, "pull", image); err != nil {
+		return err
+	}
+	if !existed {
+		tasksMutex.Lock()
+		tasksUsingDocker[d.taskId] = append(tasksUsingDocker[d.taskId], image)
+		tasksMutex.Unlock()
+	}
+	return nil
+}
+
+func (d *dockerRunner) command(args ...string) *exec.Cmd {
+	cmd := exec.Command(dockerBinary, args...)
+	cmd.Dir = d.workDir
+	cmd.Env = os.Environ()
+	if d.configDir != "" {
+		cmd.Env = append(cmd.Env, "DOCKER_CONFIG="+d.configDir)
+	}
+	return cmd
+}
+
+// run runs the docker CLI with the arguments, streaming its output to the task
+// logs. If the stop channel is signaled, the CLI is killed and onStop, if set,
+// is called to stop whatever the CLI had started.
+func (d *dockerRunner) run(stop chan bool, stdin io.Reader, args ...string) error {
+	return d.runWithStop(stop, nil, stdin, args...)
+}
+
+func (d *dockerRunner) runWithStop(stop chan bool, onStop func(), stdin io.Reader, args ...string) error {
+	d.logger.LogExecution(slogger.INFO, "Running: %v %v", dockerBinary, strings.Join(redactArgs(args), " "))
+	cmd := d.command(args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = d.stdout
+	cmd.Stderr = d.stderr
+	defer d.flush()
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-stop:
+		d.logger.LogExecution(slogger.INFO, "Got kill signal, stopping docker")
+		if err := cmd.Process.Kill(); err != nil {
+			d.logger.LogExecution(slogger.ERROR, "Error killing docker: %v", err)
+		}
+		if onStop != nil {
+			onStop()
+		}
+		return fmt.Errorf("docker command interrupted")
+	}
+}
+
+// output runs the docker CLI with the arguments and returns what it printed.
+func (d *dockerRunner) output(args ...string) (string, error) {
+	cmd := d.command(args...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%v: %v", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (d *dockerRunner) flush() {
+	if w, ok := d.stdout.(*util.LineBufferingWriter); ok {
+		w.Flush()
+	}
+	if w, ok := d.stderr.(*util.LineBufferingWriter); ok {
+		w.Flush()
+	}
+}
+
+// taskLabel returns the label for the images and containers of the task.
+func taskLabel(taskId string) string {
+	return fmt.Sprintf("%v=%v", TaskLabel, taskId)
+}
+
+// redactArgs hides the values of build arguments and environment variables, so
+// that secrets passed in them aren't written to the logs.
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 1; i < len(redacted); i++ {
+		switch redacted[i-1] {
+		case "--build-arg", "--env":
+			if eq := strings.Index(redacted[i], "="); eq >= 0 {
+				redacted[i] = redacted[i][:eq+1] + "***"
+			}
+		}
+	}
+	return redacted
+}
+
+// imageRepository returns the repository of an image reference, without its
+// tag or digest, e.g. "registry:5000/app" for "registry:5000/app:1.0".
+func imageRepository(image string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	lastSlash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > lastSlash {
+		image = image[:colon]
+	}
+	return image
+}
+
+// CleanupTask removes the containers and images created by the task, and the
+// images it pulled, if it ran any docker commands on this agent. Fulfills the
+// TaskCleanupPlugin interface.
+func (self *DockerPlugin) CleanupTask(taskId string, cleanup bool, pluginLogger plugin.Logger) error {
+	tasksMutex.Lock()
+	pulled, used := tasksUsingDocker[taskId]
+	delete(tasksUsingDocker, taskId)
+	tasksMutex.Unlock()
+	if !used || !cleanup {
+		return nil
+	}
+	return cleanupTask(taskId, pulled, pluginLogger)
+}
+
+// cleanupTask force-removes every container and image with the task's label,
+// then the images the task pulled.
+func cleanupTask(taskId string, pulled []string, pluginLogger plugin.Logger) error {
+	d := &dockerRunner{logger: pluginLogger}
+	filter := "label=" + taskLabel(taskId)
+
+	containers, err := d.output("ps", "--all", "--quiet", "--filter", filter)
+	if err != nil {
+		return fmt.Errorf("error listing containers: %v", err)
+	}
+	if ids := strings.Fields(containers); len(ids) > 0 {
+		pluginLogger.LogExecution(slogger.INFO, "Removing %v docker container(s)", len(ids))
+		if _, err = d.output(append([]string{"rm", "--force", "--volumes"}, ids...)...); err != nil {
+			return fmt.Errorf("error removing containers: %v", err)
+		}
+	}
+
+	images, err := d.output("images", "--quiet", "--filter", filter)
+	if err != nil {
+		return fmt.Errorf("error listing images: %v", err)
+	}
+	if ids := util.UniqueStrings(strings.Fields(images)); len(ids) > 0 {
+		pluginLogger.LogExecution(slogger.INFO, "Removing %v docker image(s)", len(ids))
+		if _, err = d.output(append([]string{"rmi", "--force"}, ids...)...); err != nil {
+			return fmt.Errorf("error removing images: %v", err)
+		}
+	}
+
+	if pulled = util.UniqueStrings(pulled); len(pulled) > 0 {
+		pluginLogger.LogExecution(slogger.INFO, "Removing %v pulled docker image(s)", len(pulled))
+		if _, err = d.output(append([]string{"rmi", "--force"}, pulled...)...); err != nil {
+			return fmt.Errorf("error removing pulled images: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDockerParseParams(t *testing.T) {
+	Convey("With the docker plugin", t, func() {
+		dockerPlugin := &DockerPlugin{}
+
+		Convey("a build command needs tags", func() {
+			cmd, err := dockerPlugin.NewCommand(DockerBuildCmd)
+			So(err, ShouldBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"context": "src"}), ShouldNotBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"tags": []string{"app:${revision}"}}), ShouldBeNil)
+		})
+
+		Convey("registry credentials must be complete", func() {
+			cmd, err := dockerPlugin.NewCommand(DockerPushCmd)
+			So(err, ShouldBeNil)
+			params := map[string]interface{}{
+				"images": []string{"registry:5000/app:1.0"},
+				"auth":   map[string]interface{}{"username": "ci"},
+			}
+			So(cmd.ParseParams(params), ShouldNotBeNil)
+			params["auth"] = map[string]interface{}{"username": "ci", "password": "${docker_password}"}
+			So(cmd.ParseParams(params), ShouldBeNil)
+		})
+
+		Convey("a run command needs an image and well-formed volumes", func() {
+			cmd, err := dockerPlugin.NewCommand(DockerRunCmd)
+			So(err, ShouldBeNil)
+			So(cmd.ParseParams(map[string]interface{}{}), ShouldNotBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"image": "golang", "volumes": []string{"src"}}), ShouldNotBeNil)
+			So(cmd.ParseParams(map[string]interface{}{"image": "golang", "volumes": []string{"src:/src"}}), ShouldBeNil)
+		})
+	})
+}
+
+func TestDockerArgs(t *testing.T) {
+	Convey("When building docker CLI arguments", t, func() {
+		Convey("builds should be labeled with the task and use paths in the working directory", func() {
+			cmd := &DockerBuildCommand{
+				Tags:       []string{"app:1.0", "app:latest"},
+				Dockerfile: "docker/Dockerfile",
+				BuildArgs:  map[string]string{"VERSION": "1.0", "TOKEN": "secret"},
+				NoCache:    true,
+			}
+			args := cmd.buildArgs("t1", "/work")
+			So(strings.Join(args, " "), ShouldEqual, "build --label evergreen.task_id=t1 --tag app:1.0 --tag app:latest "+
+				"--file /work/docker/Dockerfile --build-arg TOKEN=secret --build-arg VERSION=1.0 --no-cache /work")
+			So(strings.Join(redactArgs(args), " "), ShouldNotContainSubstring, "secret")
+		})
+
+		Convey("containers should be named, labeled and mount paths in the working directory", func() {
+			cmd := &DockerRunCommand{
+				Image:   "golang:1.6",
+				Command: []string{"go", "test"},
+				Env:     map[string]string{"GOPATH": "/go"},
+				Volumes: []string{"src:/go/src", "/tmp:/tmp"},
+			}
+			So(strings.Join(cmd.runArgs("t1", "evg-1", "/work"), " "), ShouldEqual,
+				"run --rm --name evg-1 --label evergreen.task_id=t1 --env GOPATH=/go "+
+					"--volume /work/src:/go/src --volume /tmp:/tmp golang:1.6 go test")
+		})
+
+		Convey("pushed images should be matched with the digest of their repository", func() {
+			So(imageRepository("registry:5000/app:1.0"), ShouldEqual, "registry:5000/app")
+			So(imageRepository("registry:5000/app"), ShouldEqual, "registry:5000/app")
+			So(imageRepository("app@sha256:abc"), ShouldEqual, "app")
+			digests := []string{"mirror/app@sha256:aaa", "registry:5000/app@sha256:bbb"}
+			So(findRepoDigest("registry:5000/app:1.0", digests), ShouldEqual, "registry:5000/app@sha256:bbb")
+			So(findRepoDigest("other:1.0", digests), ShouldEqual, "")
+		})
+
+		Convey("pushed images should link to their manifest in the registry", func() {
+			So(manifestURL("registry:5000/app@sha256:bbb"), ShouldEqual, "https://registry:5000/v2/app/manifests/sha256:bbb")
+			So(manifestURL("org/app@sha256:bbb"), ShouldEqual, "https://registry-1.docker.io/v2/org/app/manifests/sha256:bbb")
+			So(manifestURL("app@sha256:bbb"), ShouldEqual, "https://registry-1.docker.io/v2/library/app/manifests/sha256:bbb")
+		})
+	})
+}
+
+func TestCleanupTask(t *testing.T) {
+	Convey("With a fake docker CLI", t, func() {
+		dir, err := ioutil.TempDir("", "docker-cli")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		calls := filepath.Join(dir, "calls")
+		script := "#!/bin/sh\necho \"$@\" >> " + calls + "\ncase \"$1\" in ps) echo c1;; images) printf 'i1\\ni1\\n';; esac\n"
+		So(ioutil.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0755), ShouldBeNil)
+		defer func(binary string) { dockerBinary = binary }(dockerBinary)
+		dockerBinary = filepath.Join(dir, "docker")
+
+		Convey("tasks that never used docker should not be cleaned up", func() {
+			So((&DockerPlugin{}).CleanupTask("t1", true, &testutil.NopLogger{}), ShouldBeNil)
+			_, err := os.Stat(calls)
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+
+		Convey("the task's labeled containers and images and its pulled images should be removed", func() {
+			tasksUsingDocker["t1"] = []string{"golang:1.6"}
+			So((&DockerPlugin{}).CleanupTask("t1", true, &testutil.NopLogger{}), ShouldBeNil)
+			out, err := ioutil.ReadFile(calls)
+			So(err, ShouldBeNil)
+			So(string(out), ShouldEqual, "ps --all --quiet --filter label=evergreen.task_id=t1\n"+
+				"rm --force --volumes c1\n"+
+				"images --quiet --filter label=evergreen.task_id=t1\n"+
+				"rmi --force i1\n"+
+				"rmi --force golang:1.6\n")
+			So(tasksUsingDocker, ShouldNotContainKey, "t1")
+		})
+
+		Convey("tasks with cleanup disabled should only be forgotten", func() {
+			tasksUsingDocker["t1"] = []string{"golang:1.6"}
+			So((&DockerPlugin{}).CleanupTask("t1", false, &testutil.NopLogger{}), ShouldBeNil)
+			_, err := os.Stat(calls)
+			So(os.IsNotExist(err), ShouldBeTrue)
+			So(tasksUsingDocker, ShouldNotContainKey, "t1")
+		})
+
+		Convey("only images that weren't on the machine should be recorded as pulled", func() {
+			d := &dockerRunner{taskId: "t1", logger: &testutil.NopLogger{}, stdout: ioutil.Discard, stderr: ioutil.Discard}
+			tasksUsingDocker["t1"] = nil
+			defer delete(tasksUsingDocker, "t1")
+			// the fake CLI fails to inspect images named "new"
+			script := "#!/bin/sh\ncase \"$*\" in *inspect*new*) exit 1;; esac\n"
+			So(ioutil.WriteFile(dockerBinary, []byte(script), 0755), ShouldBeNil)
+			So(d.pull(nil, "golang:1.6"), ShouldBeNil)
+			So(d.pull(nil, "new:1.0"), ShouldBeNil)
+			So(tasksUsingDocker["t1"], ShouldResemble, []string{"new:1.0"})
+		})
+	})
+}
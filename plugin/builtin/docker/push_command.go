@@ -0,0 +1,128 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/mitchellh/mapstructure"
+)
+
+// DockerPushCommand pushes images to their registry, and attaches the digest of
+// each pushed image to the task's files.
+type DockerPushCommand struct {
+	// Images are the tagged images to push, e.g. "registry:5000/app:${revision}"
+	Images []string `mapstructure:"images" plugin:"expand"`
+
+	// Auth is used to log in to the images' registry
+	Auth RegistryAuth `mapstructure:"auth" plugin:"expand"`
+}
+
+func (self *DockerPushCommand) Name() string {
+	return DockerPushCmd
+}
+
+func (self *DockerPushCommand) Plugin() string {
+	return DockerPluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *DockerPushCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return fmt.Errorf("error decoding '%v' params: %v", self.Name(), err)
+	}
+	if err := self.validateParams(); err != nil {
+		return fmt.Errorf("error validating '%v' params: %v", self.Name(), err)
+	}
+	return nil
+}
+
+func (self *DockerPushCommand) validateParams() error {
+	if len(self.Images) == 0 {
+		return fmt.Errorf("images cannot be empty")
+	}
+	return self.Auth.validate()
+}
+
+// Execute pushes the images and attaches their digests.
+func (self *DockerPushCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
+		return fmt.Errorf("error expanding params: %v", err)
+	}
+	if err := self.validateParams(); err != nil {
+		return err
+	}
+
+	d := newDockerRunner(conf, pluginLogger)
+	if self.Auth.isSet() {
+		logout, err := d.login(self.Auth)
+		if err != nil {
+			return err
+		}
+		defer logout()
+	}
+
+	files := []*artifact.File{}
+	for _, image := range self.Images {
+		if err := d.run(stop, nil, "push", image); err != nil {
+			return fmt.Errorf("error pushing %v: %v", image, err)
+		}
+		repoDigests, err := d.output("inspect", "--format", "{{range .RepoDigests}}{{println .}}{{end}}", image)
+		if err != nil {
+			return fmt.Errorf("error inspecting %v: %v", image, err)
+		}
+		digest := findRepoDigest(image, strings.Fields(repoDigests))
+		if digest == "" {
+			pluginLogger.LogTask(slogger.WARN, "No digest found for pushed image %v", image)
+			continue
+		}
+		pluginLogger.LogTask(slogger.INFO, "Pushed %v as %v", image, digest)
+		files = append(files, &artifact.File{
+			Name:       fmt.Sprintf("docker image %v (%v)", image, digest),
+			Link:       manifestURL(digest),
+			Visibility: artifact.Public,
+		})
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+	if err := pluginCom.PostTaskFiles(files); err != nil {
+		return fmt.Errorf("error attaching image digests: %v", err)
+	}
+	return nil
+}
+
+// findRepoDigest returns the digest reference ("repository@sha256:...") of the
+// image in its own repository, out of all of the image's repository digests.
+func findRepoDigest(image string, repoDigests []string) string {
+	repository := imageRepository(image)
+	for _, repoDigest := range repoDigests {
+		if imageRepository(repoDigest) == repository {
+			return repoDigest
+		}
+	}
+	return ""
+}
+
+// manifestURL returns the registry API URL of the manifest of a digest
+// reference, e.g. "https://registry:5000/v2/app/manifests/sha256:..." for
+// "registry:5000/app@sha256:...". Images without a registry are on Docker Hub.
+func manifestURL(repoDigest string) string {
+	at := strings.Index(repoDigest, "@")
+	repository, digest := repoDigest[:at], repoDigest[at+1:]
+	registry := "registry-1.docker.io"
+	if slash := strings.Index(repository, "/"); slash < 0 {
+		repository = "library/" + repository
+	} else if host := repository[:slash]; strings.ContainsAny(host, ".:") || host == "localhost" {
+		registry, repository = host, repository[slash+1:]
+	}
+	return fmt.Sprintf("https://%v/v2/%v/manifests/%v", registry, repository, digest)
+}
@@ -0,0 +1,149 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/mitchellh/mapstructure"
+)
+
+// DockerRunCommand runs a command in a new container, streaming its output to
+// the task logs. The container is removed when it exits.
+type DockerRunCommand struct {
+	// Image is the image to run
+	Image string `mapstructure:"image" plugin:"expand"`
+
+	// Command is the command to run in the container, if not the image's default
+	Command []string `mapstructure:"command" plugin:"expand"`
+
+	// Env is the container's environment. Its values are not logged.
+	Env map[string]string `mapstructure:"env" plugin:"expand"`
+
+	// Volumes are mounted in the container, as "host_path:container_path".
+	// Relative host paths are relative to the working directory.
+	Volumes []string `mapstructure:"volumes" plugin:"expand"`
+
+	// WorkingDir is the working directory inside the container
+	WorkingDir string `mapstructure:"working_dir" plugin:"expand"`
+
+	// Network is the network to connect the container to
+	Network string `mapstructure:"network" plugin:"expand"`
+
+	// Pull, if set, pulls the image before running it
+	Pull bool `mapstructure:"pull"`
+
+	// Auth is used to pull the image from a private registry
+	Auth RegistryAuth `mapstructure:"auth" plugin:"expand"`
+
+	// ContinueOnError determines whether or not a failed command should cause
+	// the task to be marked as failed.
+	ContinueOnError bool `mapstructure:"continue_on_err"`
+}
+
+func (self *DockerRunCommand) Name() string {
+	return DockerRunCmd
+}
+
+func (self *DockerRunCommand) Plugin() string {
+	return DockerPluginName
+}
+
+// ParseParams reads in the given parameters for the command.
+func (self *DockerRunCommand) ParseParams(params map[string]interface{}) error {
+	if err := mapstructure.Decode(params, self); err != nil {
+		return fmt.Errorf("error decoding '%v' params: %v", self.Name(), err)
+	}
+	if err := self.validateParams(); err != nil {
+		return fmt.Errorf("error validating '%v' params: %v", self.Name(), err)
+	}
+	return nil
+}
+
+func (self *DockerRunCommand) validateParams() error {
+	if self.Image == "" {
+		return fmt.Errorf("image cannot be blank")
+	}
+	for _, volume := range self.Volumes {
+		if !strings.Contains(volume, ":") {
+			return fmt.Errorf("volume '%v' must be of the form host_path:container_path", volume)
+		}
+	}
+	return self.Auth.validate()
+}
+
+// runArgs returns the arguments to the docker CLI for running the container.
+func (self *DockerRunCommand) runArgs(taskId, containerName, workDir string) []string {
+	args := []string{"run", "--rm", "--name", containerName, "--label", taskLabel(taskId)}
+	// sort the environment so that it is logged in a stable order
+	names := make([]string, 0, len(self.Env))
+	for name := range self.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		args = append(args, "--env", fmt.Sprintf("%v=%v", name, self.Env[name]))
+	}
+	for _, volume := range self.Volumes {
+		parts := strings.SplitN(volume, ":", 2)
+		args = append(args, "--volume", absPath(workDir, parts[0])+":"+parts[1])
+	}
+	if self.WorkingDir != "" {
+		args = append(args, "--workdir", self.WorkingDir)
+	}
+	if self.Network != "" {
+		args = append(args, "--network", self.Network)
+	}
+	args = append(args, self.Image)
+	return append(args, self.Command...)
+}
+
+// Execute runs the container.
+func (self *DockerRunCommand) Execute(pluginLogger plugin.Logger,
+	pluginCom plugin.PluginCommunicator,
+	conf *model.TaskConfig,
+	stop chan bool) error {
+
+	if err := plugin.ExpandValues(self, conf.Expansions); err != nil {
+		return fmt.Errorf("error expanding params: %v", err)
+	}
+	if err := self.validateParams(); err != nil {
+		return err
+	}
+
+	d := newDockerRunner(conf, pluginLogger)
+	if self.Auth.isSet() {
+		logout, err := d.login(self.Auth)
+		if err != nil {
+			return err
+		}
+		defer logout()
+	}
+	if self.Pull {
+		if err := d.pull(stop, self.Image); err != nil {
+			return fmt.Errorf("error pulling %v: %v", self.Image, err)
+		}
+	}
+
+	// killing the docker CLI leaves the container running, so it is named in
+	// order to be removed if the task is stopped
+	containerName := "evg-" + util.RandomString()[:12]
+	removeContainer := func() {
+		if _, err := d.output("rm", "--force", containerName); err != nil {
+			pluginLogger.LogExecution(slogger.ERROR, "Error removing container %v: %v", containerName, err)
+		}
+	}
+	err := d.runWithStop(stop, removeContainer, nil, self.runArgs(conf.Task.Id, containerName, conf.WorkDir)...)
+	if err != nil {
+		if self.ContinueOnError {
+			pluginLogger.LogExecution(slogger.INFO, "(ignoring) Container finished with error: %v", err)
+			return nil
+		}
+		return fmt.Errorf("error running %v: %v", self.Image, err)
+	}
+	return nil
+}
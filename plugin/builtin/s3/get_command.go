@@ -13,9 +13,8 @@ import (
 	"github.com/evergreen-ci/evergreen/archive"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/plugin"
-	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/evergreen-ci/evergreen/util"
-	"github.com/goamz/goamz/aws"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -48,6 +47,10 @@ type S3GetCommand struct {
 	// downloaded to the specified directory.
 	LocalFile string `mapstructure:"local_file" plugin:"expand"`
 	ExtractTo string `mapstructure:"extract_to" plugin:"expand"`
+
+	// Storage selects the storage driver to get the file with. If unset, the
+	// project's storage settings are used, which default to Amazon S3.
+	Storage storage.Options `mapstructure:"storage" plugin:"expand"`
 }
 
 func (self *S3GetCommand) Name() string {
@@ -75,11 +78,8 @@ func (self *S3GetCommand) ParseParams(params map[string]interface{}) error {
 // Validate that all necessary params are set, and that only one of
 // local_file and extract_to is specified.
 func (self *S3GetCommand) validateParams() error {
-	if self.AwsKey == "" {
-		return fmt.Errorf("aws_key cannot be blank")
-	}
-	if self.AwsSecret == "" {
-		return fmt.Errorf("aws_secret cannot be blank")
+	if err := plugin.ValidateStorage(self.Storage, self.AwsKey, self.AwsSecret); err != nil {
+		return err
 	}
 	if self.RemoteFile == "" {
 		return fmt.Errorf("remote_file cannot be blank")
@@ -130,6 +130,11 @@ func (self *S3GetCommand) Execute(pluginLogger plugin.Logger,
 		return err
 	}
 
+	// fall back to the project's storage settings
+	if conf.ProjectRef != nil {
+		self.Storage = storage.Choose(self.Storage, conf.ProjectRef.Storage)
+	}
+
 	// validate the params
 	if err := self.validateParams(); err != nil {
 		return fmt.Errorf("expanded params are not valid: %v", err)
@@ -196,17 +201,13 @@ func (self *S3GetCommand) GetWithRetry(pluginLogger plugin.Logger) error {
 // Fetch the specified resource from s3.
 func (self *S3GetCommand) Get() error {
 
-	// get the appropriate session and bucket
-	auth := &aws.Auth{
-		AccessKey: self.AwsKey,
-		SecretKey: self.AwsSecret,
+	driver, err := storage.GetDriver(self.Storage, self.AwsKey, self.AwsSecret)
+	if err != nil {
+		return err
 	}
 
-	session := thirdparty.NewS3Session(auth, aws.USEast)
-	bucket := session.Bucket(self.Bucket)
-
-	// get a reader for the bucket
-	reader, err := bucket.GetReader(self.RemoteFile)
+	// get a reader for the file
+	reader, err := driver.Get(self.Bucket, self.RemoteFile)
 	if err != nil {
 		return fmt.Errorf("error getting bucket reader for file %v: %v",
 			self.RemoteFile, err)
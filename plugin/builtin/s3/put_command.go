@@ -11,10 +11,8 @@ import (
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/artifact"
 	"github.com/evergreen-ci/evergreen/plugin"
-	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/evergreen-ci/evergreen/util"
-	"github.com/goamz/goamz/aws"
-	"github.com/goamz/goamz/s3"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -23,7 +21,6 @@ var (
 	s3PutSleep                 = 5 * time.Second
 	attachResultsPostRetries   = 5
 	attachResultsRetrySleepSec = 10 * time.Second
-	s3baseURL                  = "https://s3.amazonaws.com/"
 )
 
 var errSkippedFile = errors.New("missing optional file was skipped")
@@ -75,6 +72,12 @@ type S3PutCommand struct {
 	// the path specified in local_file does not exist. Defaults to false, which triggers errors
 	// for missing files.
 	Optional bool `mapstructure:"optional"`
+
+	// Storage selects the storage driver to put the file with. If unset, the
+	// project's storage settings are used, which default to Amazon S3.
+	Storage storage.Options `mapstructure:"storage" plugin:"expand"`
+
+	driver storage.Driver
 }
 
 func (s3pc *S3PutCommand) Name() string {
@@ -102,11 +105,8 @@ func (s3pc *S3PutCommand) ParseParams(params map[string]interface{}) error {
 
 // Validate that all necessary params are set and valid.
 func (s3pc *S3PutCommand) validateParams() error {
-	if s3pc.AwsKey == "" {
-		return fmt.Errorf("aws_key cannot be blank")
-	}
-	if s3pc.AwsSecret == "" {
-		return fmt.Errorf("aws_secret cannot be blank")
+	if err := plugin.ValidateStorage(s3pc.Storage, s3pc.AwsKey, s3pc.AwsSecret); err != nil {
+		return err
 	}
 	if s3pc.LocalFile == "" {
 		return fmt.Errorf("local_file cannot be blank")
@@ -161,6 +161,11 @@ func (s3pc *S3PutCommand) Execute(log plugin.Logger,
 		return err
 	}
 
+	// fall back to the project's storage settings
+	if conf.ProjectRef != nil {
+		s3pc.Storage = storage.Choose(s3pc.Storage, conf.ProjectRef.Storage)
+	}
+
 	// validate the params
 	if err := s3pc.validateParams(); err != nil {
 		return fmt.Errorf("expanded params are not valid: %v", err)
@@ -244,35 +249,45 @@ func (s3pc *S3PutCommand) Put() error {
 	}
 	defer fileReader.Close()
 
-	// get the appropriate session and bucket
-	auth := &aws.Auth{
-		AccessKey: s3pc.AwsKey,
-		SecretKey: s3pc.AwsSecret,
+	driver, err := s3pc.getDriver()
+	if err != nil {
+		return err
 	}
-	session := thirdparty.NewS3Session(auth, aws.USEast)
 
-	bucket := session.Bucket(s3pc.Bucket)
-
-	options := s3.Options{}
 	// put the data
-	return bucket.PutReader(
+	return driver.Put(
+		s3pc.Bucket,
 		s3pc.RemoteFile,
 		fileReader,
 		fi.Size(),
-		s3pc.ContentType,
-		s3.ACL(s3pc.Permissions),
-		options,
+		storage.PutOptions{ContentType: s3pc.ContentType, Permissions: s3pc.Permissions},
 	)
 
 }
 
+// getDriver returns the storage driver selected by the command.
+func (s3pc *S3PutCommand) getDriver() (storage.Driver, error) {
+	if s3pc.driver == nil {
+		driver, err := storage.GetDriver(s3pc.Storage, s3pc.AwsKey, s3pc.AwsSecret)
+		if err != nil {
+			return nil, err
+		}
+		s3pc.driver = driver
+	}
+	return s3pc.driver, nil
+}
+
 // AttachTaskFiles is responsible for sending the
 // specified file to the API Server
 func (s3pc *S3PutCommand) AttachTaskFiles(log plugin.Logger,
 	com plugin.PluginCommunicator) error {
 
+	driver, err := s3pc.getDriver()
+	if err != nil {
+		return err
+	}
 	remoteFile := filepath.ToSlash(s3pc.RemoteFile)
-	fileLink := s3baseURL + s3pc.Bucket + "/" + remoteFile
+	fileLink := driver.URL(s3pc.Bucket, remoteFile)
 
 	displayName := s3pc.DisplayName
 	if displayName == "" {
@@ -282,9 +297,11 @@ func (s3pc *S3PutCommand) AttachTaskFiles(log plugin.Logger,
 		Name:       displayName,
 		Link:       fileLink,
 		Visibility: s3pc.Visibility,
+		Bucket:     s3pc.Bucket,
+		FileKey:    remoteFile,
 	}
 
-	err := com.PostTaskFiles([]*artifact.File{file})
+	err = com.PostTaskFiles([]*artifact.File{file})
 	if err != nil {
 		return fmt.Errorf("Attach files failed: %v", err)
 	}
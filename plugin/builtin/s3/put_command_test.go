@@ -189,6 +189,26 @@ func TestS3PutValidateParams(t *testing.T) {
 
 			})
 
+			Convey("the local storage driver should not need aws credentials", func() {
+
+				params := map[string]interface{}{
+					"local_file":   "local",
+					"remote_file":  "remote",
+					"bucket":       "bck",
+					"permissions":  "public-read",
+					"content_type": "application/x-tar",
+					"storage": map[string]interface{}{
+						"driver": "local",
+						"root":   "/data/blobs",
+					},
+				}
+				So(cmd.ParseParams(params), ShouldBeNil)
+				So(cmd.Storage.Root, ShouldEqual, "/data/blobs")
+
+				params["storage"] = map[string]interface{}{"driver": "local"}
+				So(cmd.ParseParams(params), ShouldNotBeNil)
+			})
+
 		})
 
 	})
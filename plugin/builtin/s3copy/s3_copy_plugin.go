@@ -14,9 +14,8 @@ import (
 	"github.com/evergreen-ci/evergreen/model/artifact"
 	"github.com/evergreen-ci/evergreen/model/version"
 	"github.com/evergreen-ci/evergreen/plugin"
-	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/evergreen-ci/evergreen/util"
-	"github.com/goamz/goamz/aws"
 	"github.com/goamz/goamz/s3"
 	"github.com/mitchellh/mapstructure"
 )
@@ -29,7 +28,6 @@ const (
 	s3CopyCmd         = "copy"
 	s3CopyPluginName  = "s3Copy"
 	s3CopyAPIEndpoint = "s3Copy"
-	s3baseURL         = "https://s3.amazonaws.com/"
 
 	s3CopyRetrySleepTimeSec = 5
 	s3CopyRetryNumRetries   = 5
@@ -48,6 +46,9 @@ type S3CopyRequest struct {
 	S3DestinationBucket string `json:"s3_destination_bucket"`
 	S3DestinationPath   string `json:"s3_destination_path"`
 	S3DisplayName       string `json:"display_name"`
+
+	// Storage selects the storage driver the server copies the file with
+	Storage storage.Options `json:"storage"`
 }
 
 // The S3CopyPlugin consists of zero or more files that are to be copied
@@ -60,10 +61,18 @@ type S3CopyCommand struct {
 
 	// An array of file copy configurations
 	S3CopyFiles []*s3CopyFile `mapstructure:"s3_copy_files" plugin:"expand"`
+
+	// Storage selects the storage driver to copy the files with. If unset, the
+	// project's storage settings are used, which default to Amazon S3.
+	Storage storage.Options `mapstructure:"storage" plugin:"expand"`
 }
 
 // S3CopyPlugin is used to copy files around in s3
-type S3CopyPlugin struct{}
+type S3CopyPlugin struct {
+	// localRoots are the directories the server may copy files in with the
+	// local storage driver, read from the "local_storage_roots" setting
+	localRoots []string
+}
 
 type s3CopyFile struct {
 	// Each source and destination is specified in the
@@ -105,15 +114,38 @@ func (scp *S3CopyPlugin) Name() string {
 
 func (scp *S3CopyPlugin) GetAPIHandler() http.Handler {
 	r := http.NewServeMux()
-	r.HandleFunc(fmt.Sprintf("/%v", s3CopyAPIEndpoint), S3CopyHandler) // POST
+	r.HandleFunc(fmt.Sprintf("/%v", s3CopyAPIEndpoint), scp.S3CopyHandler) // POST
 	r.HandleFunc("/", http.NotFound)
 	return r
 }
 
-func (self *S3CopyPlugin) Configure(map[string]interface{}) error {
+// Configure reads the local storage roots the server may copy files in.
+func (self *S3CopyPlugin) Configure(conf map[string]interface{}) error {
+	config := struct {
+		LocalStorageRoots []string `mapstructure:"local_storage_roots"`
+	}{}
+	if err := mapstructure.Decode(conf, &config); err != nil {
+		return fmt.Errorf("error decoding %v settings: %v", s3CopyPluginName, err)
+	}
+	self.localRoots = config.LocalStorageRoots
 	return nil
 }
 
+// allowsStorage returns whether the server may copy files with the storage
+// options. Tasks may only copy local files in the configured roots, so that
+// they can't read or overwrite arbitrary files on the server.
+func (self *S3CopyPlugin) allowsStorage(opts storage.Options) bool {
+	if opts.Driver != storage.LocalDriverName {
+		return true
+	}
+	for _, root := range self.localRoots {
+		if filepath.Clean(root) == filepath.Clean(opts.Root) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewCommand returns the S3CopyPlugin - this is to satisfy the
 // 'Plugin' interface
 func (scp *S3CopyPlugin) NewCommand(cmdName string) (plugin.Command, error) {
@@ -148,11 +180,18 @@ func (scc *S3CopyCommand) ParseParams(params map[string]interface{}) error {
 // validateParams is a helper function that ensures all
 // the fields necessary for carrying out an S3 copy operation are present
 func (scc *S3CopyCommand) validateParams() (err error) {
-	if scc.AwsKey == "" {
-		return fmt.Errorf("s3 AWS key cannot be blank")
+	if !plugin.IsExpandable(scc.Storage.Driver) {
+		if err := scc.Storage.Validate(); err != nil {
+			return err
+		}
 	}
-	if scc.AwsSecret == "" {
-		return fmt.Errorf("s3 AWS secret cannot be blank")
+	if scc.Storage.Driver != storage.LocalDriverName {
+		if scc.AwsKey == "" {
+			return fmt.Errorf("s3 AWS key cannot be blank")
+		}
+		if scc.AwsSecret == "" {
+			return fmt.Errorf("s3 AWS secret cannot be blank")
+		}
 	}
 	for _, s3CopyFile := range scc.S3CopyFiles {
 		if s3CopyFile.Source.Bucket == "" {
@@ -206,6 +245,14 @@ func (scc *S3CopyCommand) Execute(pluginLogger plugin.Logger,
 		return err
 	}
 
+	// fall back to the project's storage settings
+	if taskConfig.ProjectRef != nil {
+		scc.Storage = storage.Choose(scc.Storage, taskConfig.ProjectRef.Storage)
+	}
+	if err := scc.Storage.Validate(); err != nil {
+		return err
+	}
+
 	// validate the S3 copy parameters before running the task
 	if err := scc.validateS3CopyParams(); err != nil {
 		return err
@@ -250,6 +297,7 @@ func (scc *S3CopyCommand) S3Copy(taskConfig *model.TaskConfig,
 			S3DestinationBucket: s3CopyFile.Destination.Bucket,
 			S3DestinationPath:   s3CopyFile.Destination.Path,
 			S3DisplayName:       s3CopyFile.DisplayName,
+			Storage:             scc.Storage,
 		}
 		resp, err := pluginCom.TaskPostJSON(s3CopyAPIEndpoint, s3CopyReq)
 		if resp != nil {
@@ -282,7 +330,7 @@ func (scc *S3CopyCommand) S3Copy(taskConfig *model.TaskConfig,
 // Takes a request for a task's file to be copied from
 // one s3 location to another. Ensures that if the destination
 // file path already exists, no file copy is performed.
-func S3CopyHandler(w http.ResponseWriter, r *http.Request) {
+func (scp *S3CopyPlugin) S3CopyHandler(w http.ResponseWriter, r *http.Request) {
 	task := plugin.GetTask(r)
 	if task == nil {
 		http.Error(w, "task not found", http.StatusNotFound)
@@ -295,6 +343,16 @@ func S3CopyHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if !scp.allowsStorage(s3CopyReq.Storage) {
+		http.Error(w, fmt.Sprintf("copying files in local storage root '%v' is not allowed",
+			s3CopyReq.Storage.Root), http.StatusForbidden)
+		return
+	}
+	driver, err := storage.GetDriver(s3CopyReq.Storage, s3CopyReq.AwsKey, s3CopyReq.AwsSecret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Get the version for this task, so we can check if it has
 	// any already-done pushes
@@ -339,21 +397,16 @@ func S3CopyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Now copy the file into the permanent location
-	auth := &aws.Auth{
-		AccessKey: s3CopyReq.AwsKey,
-		SecretKey: s3CopyReq.AwsSecret,
-	}
-
 	evergreen.Logger.Logf(slogger.INFO, "performing S3 copy: '%v' => '%v'",
 		copyFromLocation, copyToLocation)
 
 	_, err = util.RetryArithmeticBackoff(func() error {
-		err := thirdparty.S3CopyFile(auth,
+		err := driver.Copy(
 			s3CopyReq.S3SourceBucket,
 			s3CopyReq.S3SourcePath,
 			s3CopyReq.S3DestinationBucket,
 			s3CopyReq.S3DestinationPath,
-			string(s3.PublicRead),
+			storage.PutOptions{Permissions: string(s3.PublicRead)},
 		)
 		if err != nil {
 			evergreen.Logger.Logf(slogger.ERROR, "S3 copy failed for task %v, "+
@@ -387,8 +440,12 @@ func S3CopyHandler(w http.ResponseWriter, r *http.Request) {
 func (c *S3CopyCommand) AttachTaskFiles(pluginLogger plugin.Logger,
 	pluginCom plugin.PluginCommunicator, request S3CopyRequest) error {
 
+	driver, err := storage.GetDriver(request.Storage, request.AwsKey, request.AwsSecret)
+	if err != nil {
+		return err
+	}
 	remotePath := filepath.ToSlash(request.S3DestinationPath)
-	fileLink := s3baseURL + request.S3DestinationBucket + "/" + remotePath
+	fileLink := driver.URL(request.S3DestinationBucket, remotePath)
 
 	displayName := request.S3DisplayName
 
@@ -404,7 +461,7 @@ func (c *S3CopyCommand) AttachTaskFiles(pluginLogger plugin.Logger,
 
 	files := []*artifact.File{&file}
 
-	err := pluginCom.PostTaskFiles(files)
+	err = pluginCom.PostTaskFiles(files)
 	if err != nil {
 		return fmt.Errorf("Attach files failed: %v", err)
 	}
@@ -40,13 +40,15 @@ type Registry interface {
 	// Parse the parameters in the given command and return a corresponding
 	// Command. Returns ErrUnknownPlugin if the command refers to
 	// a plugin that isn't registered, or some other error if the plugin
-	// can't parse valid parameters from the command.
+	// can't parse valid parameters from the command. The commands of parallel
+	// blocks are returned in the order they are listed.
 	GetCommands(command model.PluginCommandConf,
 		funcs map[string]*model.YAMLCommandSet) ([]Command, error)
 
 	// ParseCommandConf takes a plugin command and either returns a list of
 	// command(s) defined by the function (if the plugin command is a function),
-	// or a list containing the command itself otherwise.
+	// or a list containing the command itself otherwise. Parallel blocks are
+	// returned as they are, once their structure has been checked.
 	ParseCommandConf(command model.PluginCommandConf,
 		funcs map[string]*model.YAMLCommandSet) ([]model.PluginCommandConf, error)
 }
@@ -122,6 +124,16 @@ type CommandPlugin interface {
 	NewCommand(commandName string) (Command, error)
 }
 
+// TaskCleanupPlugin is implemented by command plugins that leave resources on
+// the agent's machine, which the agent removes when a task is done.
+type TaskCleanupPlugin interface {
+	CommandPlugin
+
+	// CleanupTask removes what the task left behind. If cleanup is false, as
+	// when the project disables cleanup, the plugin only forgets the task.
+	CleanupTask(taskId string, cleanup bool, logger Logger) error
+}
+
 // APIPlugin is implemented by plugins that need to add new API hooks for
 // new task commands.
 // TODO: should this also require PluginCommand be implemented?
@@ -271,11 +283,25 @@ func (sr *SimpleRegistry) Register(p CommandPlugin) error {
 
 func (sr *SimpleRegistry) ParseCommandConf(cmd model.PluginCommandConf, funcs map[string]*model.YAMLCommandSet) ([]model.PluginCommandConf, error) {
 
+	if err := validateCondition(cmd); err != nil {
+		return nil, err
+	}
+
+	if cmd.Parallel != nil {
+		if err := validateParallelBlock(cmd); err != nil {
+			return nil, err
+		}
+		return []model.PluginCommandConf{cmd}, nil
+	}
+
 	if funcName := cmd.Function; funcName != "" {
 		cmds, ok := funcs[funcName]
 		if !ok {
 			return nil, fmt.Errorf("function '%v' not found in project functions", funcName)
 		}
+		if _, err := cmds.ResolveVars(cmd.Vars); err != nil {
+			return nil, fmt.Errorf("invalid vars for function '%v': %v", funcName, err)
+		}
 
 		cmdList := cmds.List()
 
@@ -286,6 +312,23 @@ func (sr *SimpleRegistry) ParseCommandConf(cmd model.PluginCommandConf, funcs ma
 				return nil, fmt.Errorf("can not reference a function within "+
 					"a function: '%v' referenced within '%v'", c.Function, funcName)
 			}
+			if err := validateCondition(c); err != nil {
+				return nil, fmt.Errorf("error in '%v': %v", funcName, err)
+			}
+			if c.Parallel != nil {
+				if err := validateParallelBlock(c); err != nil {
+					return nil, fmt.Errorf("invalid parallel block in '%v': %v", funcName, err)
+				}
+				for _, pc := range c.Parallel.Commands {
+					if pc.Function != "" {
+						return nil, fmt.Errorf("can not reference a function within "+
+							"a function: '%v' referenced within '%v'", pc.Function, funcName)
+					}
+					if err := validateCondition(pc); err != nil {
+						return nil, fmt.Errorf("error in '%v': %v", funcName, err)
+					}
+				}
+			}
 
 			// if no command specific type, use the function's command type
 			if c.Type == "" {
@@ -294,7 +337,7 @@ func (sr *SimpleRegistry) ParseCommandConf(cmd model.PluginCommandConf, funcs ma
 
 			// use function name if no command display name exists
 			if c.DisplayName == "" {
-				c.DisplayName = fmt.Sprintf(`'%v' in "%v"`, c.Command, funcName)
+				c.DisplayName = fmt.Sprintf(`'%v' in "%v"`, c.GetDisplayName(), funcName)
 			}
 
 			cmdsParsed = append(cmdsParsed, c)
@@ -306,6 +349,26 @@ func (sr *SimpleRegistry) ParseCommandConf(cmd model.PluginCommandConf, funcs ma
 	return []model.PluginCommandConf{cmd}, nil
 }
 
+// validateCondition checks the syntax of the command's condition, if it has one.
+func validateCondition(cmd model.PluginCommandConf) error {
+	if cmd.If == "" {
+		return nil
+	}
+	if _, err := model.ParseCondition(cmd.If); err != nil {
+		return fmt.Errorf("invalid condition '%v': %v", cmd.If, err)
+	}
+	return nil
+}
+
+// validateParallelBlock checks that a parallel block is not also a command or
+// function, and that its own commands are valid.
+func validateParallelBlock(cmd model.PluginCommandConf) error {
+	if cmd.Command != "" || cmd.Function != "" {
+		return fmt.Errorf("a parallel block can not also be a command or function")
+	}
+	return cmd.Parallel.Validate()
+}
+
 // GetCommands finds a registered plugin for the given plugin command config
 // Returns ErrUnknownPlugin if the cmd refers to a plugin that isn't registered,
 // or some other error if the plugin can't parse valid parameters from the conf.
@@ -319,6 +382,17 @@ func (sr *SimpleRegistry) GetCommands(cmd model.PluginCommandConf, funcs map[str
 	cmdsParsed := make([]Command, 0, len(cmds))
 
 	for _, c := range cmds {
+		if c.Parallel != nil {
+			for _, pc := range c.Parallel.Commands {
+				parallelCmds, err := sr.GetCommands(pc, funcs)
+				if err != nil {
+					return nil, err
+				}
+				cmdsParsed = append(cmdsParsed, parallelCmds...)
+			}
+			continue
+		}
+
 		pluginNameParts := strings.Split(c.Command, ".")
 		if len(pluginNameParts) != 2 {
 			return nil, fmt.Errorf("Value of 'command' should be formatted: 'plugin_name.command_name'")
@@ -0,0 +1,29 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/evergreen-ci/evergreen/storage"
+)
+
+// ValidateStorage checks a command's storage options, and that the credentials
+// remote drivers need are set.
+func ValidateStorage(opts storage.Options, awsKey, awsSecret string) error {
+	// the driver can't be known until the expansions are applied
+	if IsExpandable(opts.Driver) {
+		return nil
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.Driver == storage.LocalDriverName {
+		return nil
+	}
+	if awsKey == "" {
+		return fmt.Errorf("aws_key cannot be blank")
+	}
+	if awsSecret == "" {
+		return fmt.Errorf("aws_secret cannot be blank")
+	}
+	return nil
+}
@@ -22,6 +22,18 @@ mciModule.controller('DistrosCtrl', function($scope, $window, $location, mciDist
   }, {
     'id': 'docker',
     'display': 'Docker'
+  }, {
+    'id': 'openstack',
+    'display': 'OpenStack'
+  }, {
+    'id': 'gce',
+    'display': 'Google Compute Engine'
+  }, {
+    'id': 'libvirt',
+    'display': 'libvirt (On-Premise VM)'
+  }, {
+    'id': 'kubernetes',
+    'display': 'Kubernetes (Pod)'
   }];
 
   $scope.architectures = [{
@@ -166,6 +178,87 @@ mciModule.controller('DistrosCtrl', function($scope, $window, $location, mciDist
     $scope.activeDistro.settings.mount_points.splice(index, 1);
   }
 
+  $scope.addSpotOption = function() {
+    if ($scope.activeDistro.settings.spot_options == null) {
+      $scope.activeDistro.settings.spot_options = [];
+    }
+    $scope.activeDistro.settings.spot_options.push({});
+    $scope.scrollElement('#spot-options-table');
+  }
+
+  $scope.removeSpotOption = function(spot_option) {
+    var index = $scope.activeDistro.settings.spot_options.indexOf(spot_option);
+    $scope.activeDistro.settings.spot_options.splice(index, 1);
+  }
+
+  $scope.addWarmPoolSchedule = function() {
+    if ($scope.activeDistro.warm_pool == null) {
+      $scope.activeDistro.warm_pool = {};
+    }
+    if ($scope.activeDistro.warm_pool.schedules == null) {
+      $scope.activeDistro.warm_pool.schedules = [];
+    }
+    $scope.activeDistro.warm_pool.schedules.push({});
+    $scope.scrollElement('#warm-pool-schedules-table');
+  }
+
+  $scope.removeWarmPoolSchedule = function(schedule) {
+    var index = $scope.activeDistro.warm_pool.schedules.indexOf(schedule);
+    $scope.activeDistro.warm_pool.schedules.splice(index, 1);
+  }
+
+  $scope.addProvisionStep = function() {
+    if ($scope.activeDistro.provisioning == null) {
+      $scope.activeDistro.provisioning = {};
+    }
+    if ($scope.activeDistro.provisioning.steps == null) {
+      $scope.activeDistro.provisioning.steps = [];
+    }
+    $scope.activeDistro.provisioning.steps.push({});
+    $scope.scrollElement('#provisioning-steps');
+  }
+
+  $scope.removeProvisionStep = function(step) {
+    var index = $scope.activeDistro.provisioning.steps.indexOf(step);
+    $scope.activeDistro.provisioning.steps.splice(index, 1);
+  }
+
+  $scope.addProvisionFile = function(step) {
+    if (step.files == null) {
+      step.files = [];
+    }
+    step.files.push({});
+  }
+
+  $scope.removeProvisionFile = function(step, file) {
+    var index = step.files.indexOf(file);
+    step.files.splice(index, 1);
+  }
+
+  // the provider setting each provider's image is in
+  var imageSettings = {
+    'ec2': 'ami',
+    'ec2-spot': 'ami',
+    'docker': 'image_name',
+    'openstack': 'image_id',
+    'gce': 'image',
+    'libvirt': 'base_volume',
+    'kubernetes': 'image',
+  };
+
+  $scope.startImageRollout = function() {
+    $scope.activeDistro.image_rollout = {
+      'setting': imageSettings[$scope.activeDistro.provider],
+      'percent': 10,
+      'min_tasks': 50,
+      'max_failure_rate_increase': 0.05,
+    };
+  }
+
+  $scope.cancelImageRollout = function() {
+    $scope.activeDistro.image_rollout = null;
+  }
+
   $scope.addSSHOption = function() {
     if ($scope.activeDistro.ssh_options == null) {
       $scope.activeDistro.ssh_options = [];
@@ -274,6 +367,8 @@ mciModule.controller('DistrosCtrl', function($scope, $window, $location, mciDist
       }
       newDistro.settings = _.clone($scope.activeDistro.settings);
       newDistro.expansions = _.clone($scope.activeDistro.expansions);
+      newDistro.warm_pool = _.clone($scope.activeDistro.warm_pool);
+      newDistro.provisioning = angular.copy($scope.activeDistro.provisioning);
 
       $scope.distros.unshift(newDistro);
       $scope.hasNew = true;
@@ -99,6 +99,31 @@ mciModule.controller('ProjectCtrl', function($scope, $window, $http, $location)
     $scope.isDirty = true;
   }
 
+  // addQuarantinedTest adds a test file to the settingsFormData's list of quarantined tests
+  $scope.addQuarantinedTest = function(){
+    $scope.settingsFormData.quarantined_tests.push($scope.quarantined_test);
+    $scope.quarantined_test = "";
+  }
+
+  // removeQuarantinedTest removes the test file located at index
+  $scope.removeQuarantinedTest = function(index){
+    $scope.settingsFormData.quarantined_tests.splice(index, 1);
+    $scope.isDirty = true;
+  }
+
+  // addPrivateFileUser adds a username to the settingsFormData's list of users who
+  // may download private task files
+  $scope.addPrivateFileUser = function(){
+    $scope.settingsFormData.private_file_users.push($scope.private_file_user);
+    $scope.private_file_user = "";
+  }
+
+  // removePrivateFileUser removes the username located at index
+  $scope.removePrivateFileUser = function(index){
+    $scope.settingsFormData.private_file_users.splice(index, 1);
+    $scope.isDirty = true;
+  }
+
 
   $scope.addProject = function() {
     $scope.modalOpen = false;
@@ -149,8 +174,11 @@ mciModule.controller('ProjectCtrl', function($scope, $window, $http, $location)
           enabled: $scope.projectRef.enabled,
           private: $scope.projectRef.private,
           alert_config: $scope.projectRef.alert_config || {},
+          duration_alerts: $scope.projectRef.duration_alerts || {},
           repotracker_error: $scope.projectRef.repotracker_error || {},
           admins : $scope.projectRef.admins || [],
+          quarantined_tests : $scope.projectRef.quarantined_tests || [],
+          private_file_users : $scope.projectRef.private_file_users || [],
         };
 
         $scope.displayName = $scope.projectRef.display_name ? $scope.projectRef.display_name : $scope.projectRef.identifier;
@@ -191,6 +219,12 @@ mciModule.controller('ProjectCtrl', function($scope, $window, $http, $location)
     if ($scope.admin_name) {
       $scope.addAdmin();
     }
+    if ($scope.quarantined_test) {
+      $scope.addQuarantinedTest();
+    }
+    if ($scope.private_file_user) {
+      $scope.addPrivateFileUser();
+    }
     $http.post('/project/' + $scope.settingsFormData.identifier, $scope.settingsFormData).
       success(function(data, status) {
         $scope.saveMessage = "Settings Saved.";
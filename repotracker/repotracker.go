@@ -7,6 +7,7 @@ import (
 	"github.com/10gen-labs/slogger/v1"
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/alert"
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/version"
 	"github.com/evergreen-ci/evergreen/notify"
@@ -220,9 +221,8 @@ func (repoTracker *RepoTracker) sendFailureNotification(lastRevision string,
 		projectRef.Owner, projectRef.Repo, projectRef.Branch)
 	message := fmt.Sprintf("Could not find last known revision '%v' "+
 		"within the most recent %v revisions at %v: %v", lastRevision, max, url, err)
-	nErr := notify.NotifyAdmins(subject, message, settings)
-	if nErr != nil {
-		evergreen.Logger.Logf(slogger.ERROR, "error sending email: %v", nErr)
+	if nErr := alert.EnqueueOpsAlert(subject, message); nErr != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "error queueing ops alert: %v", nErr)
 	}
 }
 
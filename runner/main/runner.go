@@ -14,7 +14,10 @@ import (
 
 	"github.com/10gen-labs/slogger/v1"
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/alerts"
 	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/alert"
+	"github.com/evergreen-ci/evergreen/model/cloudstats"
 	"github.com/evergreen-ci/evergreen/notify"
 	. "github.com/evergreen-ci/evergreen/runner"
 	"github.com/evergreen-ci/evergreen/util"
@@ -89,9 +92,19 @@ func main() {
 
 	// wait for all the processes to exit
 	wg.Wait()
+	flushCloudStats()
 	evergreen.Logger.Logf(slogger.INFO, "Cleanly terminated all %v processes", len(Runners))
 }
 
+// notifyRunnerFailure queues an ops alert about a runner's failure. The alerter
+// can't deliver alerts about itself, so its failures are e-mailed to the admins.
+func notifyRunnerFailure(r ProcessRunner, subject, message string, s *evergreen.Settings) error {
+	if r.Name() == alerts.RunnerName {
+		return notify.NotifyAdmins(subject, message, s)
+	}
+	return alert.EnqueueOpsAlert(subject, message)
+}
+
 // startRunners starts a goroutine for each runner exposed via Runners. It
 // returns a channel on which all runners listen on, for when to terminate.
 func startRunners(wg *sync.WaitGroup, s *evergreen.Settings) chan bool {
@@ -113,8 +126,8 @@ func startRunners(wg *sync.WaitGroup, s *evergreen.Settings) chan bool {
 				if err := r.Run(s); err != nil {
 					subject := fmt.Sprintf("%v failure", r.Name())
 					evergreen.Logger.Logf(slogger.ERROR, "%v", err)
-					if err = notify.NotifyAdmins(subject, err.Error(), s); err != nil {
-						evergreen.Logger.Logf(slogger.ERROR, "Error sending email: %v", err)
+					if err = notifyRunnerFailure(r, subject, err.Error(), s); err != nil {
+						evergreen.Logger.Logf(slogger.ERROR, "Error notifying admins: %v", err)
 					}
 				}
 				select {
@@ -149,8 +162,17 @@ func runProcessByName(name string, settings *evergreen.Settings) error {
 			if err := r.Run(settings); err != nil {
 				evergreen.Logger.Logf(slogger.ERROR, "Error: %v", err)
 			}
+			flushCloudStats()
 			return nil
 		}
 	}
 	return fmt.Errorf("process '%v' does not exist", name)
 }
+
+// flushCloudStats records the calls to cloud providers that haven't been
+// flushed yet, before the runner exits.
+func flushCloudStats() {
+	if err := cloudstats.FlushCalls(); err != nil {
+		evergreen.Logger.Logf(slogger.ERROR, "Error: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/event"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/task"
+)
+
+// imageStats are the tasks that have finished on a distro's hosts spawned from
+// one version of its image.
+type imageStats struct {
+	Tasks    int `bson:"tasks" json:"tasks"`
+	Failures int `bson:"failures" json:"failures"`
+}
+
+func (self imageStats) failureRate() float64 {
+	if self.Tasks == 0 {
+		return 0
+	}
+	return float64(self.Failures) / float64(self.Tasks)
+}
+
+// rolloutResult is the data of the event logged when a rollout ends.
+type rolloutResult struct {
+	Rollout distro.ImageRollout `bson:"rollout" json:"rollout"`
+	Image   imageStats          `bson:"image" json:"image"`
+	New     imageStats          `bson:"new_image" json:"new_image"`
+}
+
+type rolloutDecision int
+
+const (
+	rolloutContinue rolloutDecision = iota
+	rolloutPromote
+	rolloutRollBack
+)
+
+// decideRollout returns whether to promote or roll back the image being rolled
+// out, given the tasks that have finished on hosts spawned from the distro's
+// image and from the new image since the rollout started.
+func decideRollout(rollout *distro.ImageRollout, image, newImage imageStats) rolloutDecision {
+	if newImage.Tasks < rollout.MinTasks {
+		return rolloutContinue
+	}
+	if newImage.failureRate() > image.failureRate()+rollout.MaxFailureRateIncrease {
+		return rolloutRollBack
+	}
+	return rolloutPromote
+}
+
+// findImageStats returns the tasks that have finished since the given time on
+// the distro's hosts spawned from the given version of its image.
+func findImageStats(distroId string, version int, since time.Time) (imageStats, error) {
+	stats := imageStats{}
+	hosts, err := host.Find(host.ByImageVersionRunningSince(distroId, version, since).WithFields(host.IdKey))
+	if err != nil {
+		return stats, fmt.Errorf("error finding hosts: %v", err)
+	}
+	if len(hosts) == 0 {
+		return stats, nil
+	}
+	hostIds := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		hostIds = append(hostIds, h.Id)
+	}
+
+	stats.Tasks, err = task.Count(task.ByHostIdsFinishedSince(hostIds, evergreen.CompletedStatuses, since))
+	if err != nil {
+		return stats, fmt.Errorf("error counting tasks: %v", err)
+	}
+	stats.Failures, err = task.Count(task.ByHostIdsFinishedSince(hostIds, []string{evergreen.TaskFailed}, since))
+	if err != nil {
+		return stats, fmt.Errorf("error counting failed tasks: %v", err)
+	}
+	return stats, nil
+}
+
+// checkImageRollouts promotes the images being rolled out to distros whose new
+// image's hosts have run enough tasks without failing more often than the
+// distro's other hosts, and rolls back the others that have run enough tasks.
+func checkImageRollouts(distros []distro.Distro) {
+	for i := range distros {
+		d := &distros[i]
+		if d.ImageRollout == nil {
+			continue
+		}
+		if err := checkImageRollout(d); err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Error checking image rollout for distro %v: %v", d.Id, err)
+		}
+	}
+}
+
+func checkImageRollout(d *distro.Distro) error {
+	rollout := *d.ImageRollout
+	image, err := findImageStats(d.Id, d.ImageVersion, rollout.StartTime)
+	if err != nil {
+		return err
+	}
+	newImage, err := findImageStats(d.Id, rollout.Version, rollout.StartTime)
+	if err != nil {
+		return err
+	}
+	result := rolloutResult{rollout, image, newImage}
+
+	switch decideRollout(&rollout, image, newImage) {
+	case rolloutPromote:
+		oldVersion := d.ImageVersion
+		d.PromoteImage()
+		if err = d.UpdateImage(rollout.Setting); err != nil {
+			return fmt.Errorf("error promoting image: %v", err)
+		}
+		evergreen.Logger.Logf(slogger.INFO, "Promoted image %v of distro %v after %v of %v tasks failed",
+			rollout.Image, d.Id, newImage.Failures, newImage.Tasks)
+		event.LogDistroImagePromoted(d.Id, result)
+
+		// stopped hosts of the old image won't be started again
+		if err = decommissionHosts(host.StoppedByDistroIdAndImageVersion(d.Id, oldVersion)); err != nil {
+			return fmt.Errorf("error finding stopped hosts spawned from old image: %v", err)
+		}
+	case rolloutRollBack:
+		d.ImageRollout = nil
+		if err = d.UpdateImage(""); err != nil {
+			return fmt.Errorf("error rolling back image: %v", err)
+		}
+		evergreen.Logger.Logf(slogger.WARN, "Rolled back image %v of distro %v after %v of %v tasks failed",
+			rollout.Image, d.Id, newImage.Failures, newImage.Tasks)
+		event.LogDistroImageRolledBack(d.Id, result)
+
+		// the new image's hosts are terminated once they're idle, and its
+		// stopped hosts right away
+		if err = decommissionHosts(host.ByDistroIdAndImageVersion(d.Id, rollout.Version)); err != nil {
+			return fmt.Errorf("error finding hosts spawned from rolled back image: %v", err)
+		}
+		if err = decommissionHosts(host.StoppedByDistroIdAndImageVersion(d.Id, rollout.Version)); err != nil {
+			return fmt.Errorf("error finding stopped hosts spawned from rolled back image: %v", err)
+		}
+	}
+	return nil
+}
+
+// decommissionHosts decommissions the hosts the query returns, so that the
+// monitor terminates them once they're idle.
+func decommissionHosts(query db.Q) error {
+	hosts, err := host.Find(query)
+	if err != nil {
+		return err
+	}
+	for _, h := range hosts {
+		if err = h.SetDecommissioned(); err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Error decommissioning host %v: %v", h.Id, err)
+		}
+	}
+	return nil
+}
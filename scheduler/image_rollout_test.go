@@ -0,0 +1,178 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/model/task"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestImageRollouts(t *testing.T) {
+	Convey("With a distro rolling out a new image to 20% of its hosts", t, func() {
+		d := &distro.Distro{
+			Id:               "d1",
+			ProviderSettings: &map[string]interface{}{"ami": "ami-1", "instance_type": "m3.large"},
+			ImageVersion:     3,
+			ImageRollout: &distro.ImageRollout{
+				Setting:                "ami",
+				Image:                  "ami-2",
+				Percent:                20,
+				MinTasks:               10,
+				MaxFailureRateIncrease: 0.1,
+			},
+		}
+		d.ImageRollout.Start(d, nil)
+
+		Convey("the rollout should get the next image version", func() {
+			So(d.ImageRollout.Version, ShouldEqual, 4)
+			So(d.LatestImageVersion, ShouldEqual, 4)
+
+			rollout := *d.ImageRollout
+			So(rollout.Start(d, d.ImageRollout), ShouldBeFalse)
+			So(rollout.Version, ShouldEqual, 4)
+
+			d.ImageRollout = nil
+			So(rollout.Start(d, nil), ShouldBeTrue)
+			So(rollout.Version, ShouldEqual, 5)
+		})
+
+		Convey("the rollout's share of hosts should be spawned from the new image", func() {
+			So(d.SpawnFromRollout(0, 0), ShouldBeTrue)
+			So(d.SpawnFromRollout(1, 1), ShouldBeFalse)
+			So(d.SpawnFromRollout(1, 4), ShouldBeFalse)
+			So(d.SpawnFromRollout(1, 5), ShouldBeTrue)
+			So(d.SpawnFromRollout(2, 9), ShouldBeFalse)
+			So(d.SpawnFromRollout(2, 10), ShouldBeTrue)
+		})
+
+		Convey("hosts spawned from the new image should have its settings and version", func() {
+			spawnDistro := d.WithRolloutImage()
+			So((*spawnDistro.ProviderSettings)["ami"], ShouldEqual, "ami-2")
+			So((*spawnDistro.ProviderSettings)["instance_type"], ShouldEqual, "m3.large")
+			So(spawnDistro.ImageVersion, ShouldEqual, 4)
+			So((*d.ProviderSettings)["ami"], ShouldEqual, "ami-1")
+			So(d.ImageVersion, ShouldEqual, 3)
+		})
+
+		Convey("promoting the new image should make it the distro's", func() {
+			d.PromoteImage()
+			So((*d.ProviderSettings)["ami"], ShouldEqual, "ami-2")
+			So(d.ImageVersion, ShouldEqual, 4)
+			So(d.ImageRollout, ShouldBeNil)
+		})
+
+		Convey("the new image should be kept until enough tasks have run on it", func() {
+			So(decideRollout(d.ImageRollout, imageStats{100, 5}, imageStats{9, 9}), ShouldEqual, rolloutContinue)
+		})
+
+		Convey("the new image should be promoted if its tasks don't fail much more often", func() {
+			So(decideRollout(d.ImageRollout, imageStats{100, 5}, imageStats{10, 1}), ShouldEqual, rolloutPromote)
+			So(decideRollout(d.ImageRollout, imageStats{}, imageStats{10, 1}), ShouldEqual, rolloutPromote)
+		})
+
+		Convey("the new image should be rolled back if its tasks fail much more often", func() {
+			So(decideRollout(d.ImageRollout, imageStats{100, 5}, imageStats{10, 2}), ShouldEqual, rolloutRollBack)
+			So(decideRollout(d.ImageRollout, imageStats{}, imageStats{20, 3}), ShouldEqual, rolloutRollBack)
+		})
+	})
+}
+
+func TestCheckImageRollout(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(schedulerTestConf))
+
+	Convey("With a distro that has run enough tasks on its new image's hosts", t, func() {
+		testutil.HandleTestingErr(db.ClearCollections(distro.Collection, host.Collection, task.Collection),
+			t, "error clearing collections")
+
+		d := &distro.Distro{
+			Id:               "d1",
+			ProviderSettings: &map[string]interface{}{"ami": "ami-1", "instance_type": "m3.large"},
+			ImageVersion:     3,
+			ImageRollout: &distro.ImageRollout{
+				Setting:                "ami",
+				Image:                  "ami-2",
+				Percent:                20,
+				MinTasks:               10,
+				MaxFailureRateIncrease: 0.1,
+			},
+		}
+		d.ImageRollout.Start(d, nil)
+		d.ImageRollout.StartTime = time.Now().Add(-time.Hour)
+		testutil.HandleTestingErr(d.Insert(), t, "error inserting distro")
+
+		// the distro's instance type is edited after the scheduler read it
+		testutil.HandleTestingErr(db.Update(distro.Collection, bson.M{distro.IdKey: d.Id},
+			bson.M{"$set": bson.M{distro.ProviderSettingsKey + ".instance_type": "m4.large"}}),
+			t, "error updating distro")
+
+		insertHost := func(id string, version int, status string) {
+			h := &host.Host{
+				Id:        id,
+				Distro:    distro.Distro{Id: d.Id, ImageVersion: version},
+				StartedBy: evergreen.User,
+				Status:    status,
+			}
+			testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+		}
+		insertHost("old-stopped", 3, evergreen.HostStopped)
+		insertHost("new-running", 4, evergreen.HostRunning)
+		insertHost("new-stopped", 4, evergreen.HostStopped)
+
+		insertTasks := func(status string) {
+			for i := 0; i < 10; i++ {
+				tsk := &task.Task{
+					Id:         fmt.Sprintf("t%v", i),
+					HostId:     "new-running",
+					Status:     status,
+					FinishTime: time.Now(),
+				}
+				testutil.HandleTestingErr(tsk.Insert(), t, "error inserting task")
+			}
+		}
+		hostStatus := func(id string) string {
+			h, err := host.FindOne(host.ById(id))
+			So(err, ShouldBeNil)
+			return h.Status
+		}
+
+		Convey("promoting the new image should only update its setting and retire the old stopped hosts", func() {
+			insertTasks(evergreen.TaskSucceeded)
+			So(checkImageRollout(d), ShouldBeNil)
+
+			promoted, err := distro.FindOne(distro.ById(d.Id))
+			So(err, ShouldBeNil)
+			So(promoted.ImageRollout, ShouldBeNil)
+			So(promoted.ImageVersion, ShouldEqual, 4)
+			So((*promoted.ProviderSettings)["ami"], ShouldEqual, "ami-2")
+			So((*promoted.ProviderSettings)["instance_type"], ShouldEqual, "m4.large")
+
+			So(hostStatus("old-stopped"), ShouldEqual, evergreen.HostDecommissioned)
+			So(hostStatus("new-running"), ShouldEqual, evergreen.HostRunning)
+			So(hostStatus("new-stopped"), ShouldEqual, evergreen.HostStopped)
+		})
+
+		Convey("rolling back the new image should retire its hosts, including stopped ones", func() {
+			insertTasks(evergreen.TaskFailed)
+			So(checkImageRollout(d), ShouldBeNil)
+
+			rolledBack, err := distro.FindOne(distro.ById(d.Id))
+			So(err, ShouldBeNil)
+			So(rolledBack.ImageRollout, ShouldBeNil)
+			So(rolledBack.ImageVersion, ShouldEqual, 3)
+			So((*rolledBack.ProviderSettings)["ami"], ShouldEqual, "ami-1")
+			So((*rolledBack.ProviderSettings)["instance_type"], ShouldEqual, "m4.large")
+
+			So(hostStatus("old-stopped"), ShouldEqual, evergreen.HostStopped)
+			So(hostStatus("new-running"), ShouldEqual, evergreen.HostDecommissioned)
+			So(hostStatus("new-stopped"), ShouldEqual, evergreen.HostDecommissioned)
+		})
+	})
+}
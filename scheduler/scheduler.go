@@ -7,8 +7,10 @@ import (
 
 	"github.com/10gen-labs/slogger/v1"
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/cloudstats"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
@@ -69,6 +71,11 @@ func (s *Scheduler) Schedule() error {
 		return fmt.Errorf("Error finding distros: %v", err)
 	}
 
+	// promote or roll back the images being rolled out to distros whose new
+	// image's hosts have run enough tasks
+	evergreen.Logger.Logf(slogger.INFO, "Checking image rollouts...")
+	checkImageRollouts(distros)
+
 	taskIdToMinQueuePos := make(map[string]int)
 
 	// get the expected run duration of all runnable tasks
@@ -116,8 +123,17 @@ func (s *Scheduler) Schedule() error {
 			return fmt.Errorf("Error saving task queue: %v", err)
 		}
 
-		// track scheduled time for prioritized tasks
+		// track how long the oldest queued task has been waiting, before the
+		// scheduled time is set for newly queued tasks
 		scheduledTime := time.Now()
+		var maxWaitTime time.Duration
+		for _, t := range prioritizedTasks {
+			if !util.IsZeroTime(t.ScheduledTime) && scheduledTime.Sub(t.ScheduledTime) > maxWaitTime {
+				maxWaitTime = scheduledTime.Sub(t.ScheduledTime)
+			}
+		}
+
+		// track scheduled time for prioritized tasks
 		err = task.SetTasksScheduledTime(prioritizedTasks, scheduledTime)
 		if err != nil {
 			return fmt.Errorf("Error setting scheduled time for prioritized "+
@@ -134,6 +150,7 @@ func (s *Scheduler) Schedule() error {
 			TaskQueueLength:  len(queuedTasks),
 			NumHostsRunning:  0,
 			ExpectedDuration: time.Duration(summedNanoSeconds),
+			MaxWaitTime:      maxWaitTime,
 		}
 	}
 
@@ -184,6 +201,11 @@ func (s *Scheduler) Schedule() error {
 			err)
 	}
 
+	// keep the distros' warm pools of idle hosts, even when they have no
+	// tasks queued
+	newHostsNeeded = addWarmPoolHosts(newHostsNeeded, distrosByName, hostsByDistro,
+		taskQueueItems, time.Now())
+
 	// spawn up the hosts
 	hostsSpawned, err := s.spawnHosts(newHostsNeeded)
 	if err != nil {
@@ -360,10 +382,48 @@ func (s *Scheduler) spawnHosts(newHostsNeeded map[string]int) (
 				continue
 			}
 
-			newHost, err := cloudManager.SpawnInstance(d, evergreen.User, false)
+			// spawn some of the distro's hosts from the image being rolled
+			// out to it
+			spawnDistro := d
+			if d.ImageRollout != nil {
+				rolloutHosts, err := host.Count(host.ByDistroIdAndImageVersion(distroId, d.ImageRollout.Version))
+				if err != nil {
+					evergreen.Logger.Logf(slogger.ERROR, "Error counting hosts spawned from new image of distro %v: %v",
+						distroId, err)
+					continue
+				}
+				if d.SpawnFromRollout(rolloutHosts, len(allDistroHosts)) {
+					spawnDistro = d.WithRolloutImage()
+				}
+			}
+
+			// start a stopped host from the same image, if the distro has
+			// one, since it'll be ready sooner than a new one
+			if stopper, ok := cloudManager.(cloud.Stopper); ok {
+				restarted, err := restartStoppedHost(distroId, spawnDistro.ImageVersion, stopper)
+				if err != nil {
+					evergreen.Logger.Errorf(slogger.ERROR, "Error restarting host: %v", err)
+				}
+				if restarted != nil {
+					hostsSpawnedPerDistro[distroId] =
+						append(hostsSpawnedPerDistro[distroId], *restarted)
+					continue
+				}
+			}
+
+			newHost, err := cloudManager.SpawnInstance(spawnDistro, evergreen.User, false)
+			if cloudstats.IsBreakerOpen(err) {
+				evergreen.Logger.Logf(slogger.WARN, "Skipping distro %v: %v", distroId, err)
+				break
+			}
+			if cloud.IsNotReady(err) {
+				evergreen.Logger.Logf(slogger.INFO, "Not spawning hosts for distro %v yet: %v", distroId, err)
+				break
+			}
 			if err != nil {
 				evergreen.Logger.Errorf(slogger.ERROR, "Error spawning instance: %v,",
 					err)
+				event.LogDistroSpawnFailed(distroId, err.Error())
 				continue
 			}
 			hostsSpawnedPerDistro[distroId] =
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/util"
+)
+
+// addWarmPoolHosts adds the hosts each distro needs, on top of the ones the
+// host allocator asked for, to keep its warm pool of idle hosts once its
+// queued tasks have been dispatched. Only running hosts and hosts that are
+// starting up count as idle, and distros aren't taken past their pool size.
+func addWarmPoolHosts(newHostsNeeded map[string]int, distros map[string]distro.Distro,
+	existingDistroHosts map[string][]host.Host, taskQueueItems map[string][]model.TaskQueueItem,
+	now time.Time) map[string]int {
+	withWarmPools := map[string]int{}
+	for distroId, numHosts := range newHostsNeeded {
+		withWarmPools[distroId] = numHosts
+	}
+
+	for distroId, d := range distros {
+		minIdle := d.WarmPool.MinIdleAt(now)
+		if minIdle <= 0 {
+			continue
+		}
+
+		existingHosts := existingDistroHosts[distroId]
+		free := 0
+		for _, h := range existingHosts {
+			if h.RunningTask == "" && countsAsIdle(h.Status) {
+				free++
+			}
+		}
+
+		idleAfterQueue := free + withWarmPools[distroId] - len(taskQueueItems[distroId])
+		if idleAfterQueue < 0 {
+			idleAfterQueue = 0
+		}
+		deficit := minIdle - idleAfterQueue
+		if deficit <= 0 {
+			continue
+		}
+
+		available := d.PoolSize - len(existingHosts) - withWarmPools[distroId]
+		deficit = util.Min(deficit, available)
+		if deficit <= 0 {
+			continue
+		}
+
+		evergreen.Logger.Logf(slogger.INFO, "Adding %v hosts to keep %v idle hosts for distro %v",
+			deficit, minIdle, distroId)
+		withWarmPools[distroId] += deficit
+	}
+	return withWarmPools
+}
+
+// countsAsIdle returns whether a host with the given status can take tasks,
+// either now or once it's up. Hosts that failed to provision, or that are on
+// their way out, never will.
+func countsAsIdle(status string) bool {
+	switch status {
+	case evergreen.HostRunning, evergreen.HostUninitialized, evergreen.HostInitializing:
+		return true
+	}
+	return false
+}
+
+// restartStoppedHost starts the most recently stopped host of the distro
+// spawned from the given version of its image, if it has one, rather than
+// spawning a new host. Hosts that fail to start are decommissioned, so that
+// they're terminated.
+func restartStoppedHost(distroId string, imageVersion int, stopper cloud.Stopper) (*host.Host, error) {
+	stopped, err := host.FindOne(host.StoppedByDistroIdAndImageVersion(distroId, imageVersion))
+	if err != nil {
+		return nil, fmt.Errorf("error finding stopped hosts for distro %v: %v", distroId, err)
+	}
+	if stopped == nil {
+		return nil, nil
+	}
+
+	if err = stopper.StartInstance(stopped); err != nil {
+		if decommissionErr := stopped.SetDecommissioned(); decommissionErr != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Error decommissioning host %v: %v",
+				stopped.Id, decommissionErr)
+		}
+		return nil, fmt.Errorf("error starting stopped host %v: %v", stopped.Id, err)
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Started stopped host %v for distro %v", stopped.Id, distroId)
+	return stopped, nil
+}
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/db"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/distro"
+	"github.com/evergreen-ci/evergreen/model/host"
+	"github.com/evergreen-ci/evergreen/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAddWarmPoolHosts(t *testing.T) {
+	Convey("With a distro that keeps 3 idle hosts", t, func() {
+		now := time.Now()
+		distros := map[string]distro.Distro{
+			"d1": {Id: "d1", PoolSize: 10, WarmPool: distro.WarmPool{MinIdle: 3}},
+			"d2": {Id: "d2", PoolSize: 10},
+		}
+		busy := host.Host{RunningTask: "t", Status: evergreen.HostRunning}
+		free := host.Host{Status: evergreen.HostRunning}
+		starting := host.Host{Status: evergreen.HostUninitialized}
+		failed := host.Host{Status: evergreen.HostProvisionFailed}
+		queue := func(n int) []model.TaskQueueItem {
+			return make([]model.TaskQueueItem, n)
+		}
+
+		Convey("hosts should be added to an empty distro with no tasks", func() {
+			needed := addWarmPoolHosts(map[string]int{}, distros, nil, nil, now)
+			So(needed, ShouldResemble, map[string]int{"d1": 3})
+		})
+
+		Convey("free hosts should count towards the pool once the queue is dispatched", func() {
+			hosts := map[string][]host.Host{"d1": {busy, free, free}}
+			needed := addWarmPoolHosts(map[string]int{}, distros, hosts, nil, now)
+			So(needed["d1"], ShouldEqual, 1)
+
+			needed = addWarmPoolHosts(map[string]int{}, distros, hosts,
+				map[string][]model.TaskQueueItem{"d1": queue(2)}, now)
+			So(needed["d1"], ShouldEqual, 3)
+		})
+
+		Convey("hosts that are starting up should count towards the pool", func() {
+			hosts := map[string][]host.Host{"d1": {starting, free}}
+			needed := addWarmPoolHosts(map[string]int{}, distros, hosts, nil, now)
+			So(needed["d1"], ShouldEqual, 1)
+		})
+
+		Convey("hosts that failed to provision shouldn't count towards the pool", func() {
+			hosts := map[string][]host.Host{"d1": {failed, failed, free}}
+			needed := addWarmPoolHosts(map[string]int{}, distros, hosts, nil, now)
+			So(needed["d1"], ShouldEqual, 2)
+		})
+
+		Convey("hosts the allocator asked for should count towards the pool", func() {
+			needed := addWarmPoolHosts(map[string]int{"d1": 4, "d2": 1}, distros, nil,
+				map[string][]model.TaskQueueItem{"d1": queue(4), "d2": queue(1)}, now)
+			So(needed, ShouldResemble, map[string]int{"d1": 7, "d2": 1})
+		})
+
+		Convey("distros shouldn't be taken past their pool size", func() {
+			hosts := map[string][]host.Host{"d1": {busy, busy, busy, busy, busy, busy, busy, busy, busy}}
+			needed := addWarmPoolHosts(map[string]int{}, distros, hosts, nil, now)
+			So(needed["d1"], ShouldEqual, 1)
+		})
+	})
+}
+
+// fakeStopper starts hosts, or fails to with the given error.
+type fakeStopper struct {
+	err error
+}
+
+func (self *fakeStopper) StopInstance(h *host.Host) error {
+	return h.SetStopped()
+}
+
+func (self *fakeStopper) StartInstance(h *host.Host) error {
+	if self.err != nil {
+		return self.err
+	}
+	return h.SetRestarted()
+}
+
+func TestRestartStoppedHost(t *testing.T) {
+	db.SetGlobalSessionProvider(db.SessionFactoryFromConfig(schedulerTestConf))
+
+	Convey("With a distro with stopped hosts", t, func() {
+		testutil.HandleTestingErr(db.Clear(host.Collection), t, "error clearing hosts collection")
+
+		now := time.Now()
+		for i, id := range []string{"older", "newer"} {
+			h := &host.Host{
+				Id:        id,
+				Distro:    distro.Distro{Id: "d1"},
+				StartedBy: evergreen.User,
+				Status:    evergreen.HostStopped,
+				StopTime:  now.Add(time.Duration(i) * time.Minute),
+			}
+			testutil.HandleTestingErr(h.Insert(), t, "error inserting host")
+		}
+
+		Convey("the most recently stopped host should be started", func() {
+			restarted, err := restartStoppedHost("d1", 0, &fakeStopper{})
+			So(err, ShouldBeNil)
+			So(restarted, ShouldNotBeNil)
+			So(restarted.Id, ShouldEqual, "newer")
+
+			h, err := host.FindOne(host.ById("newer"))
+			So(err, ShouldBeNil)
+			So(h.Status, ShouldEqual, evergreen.HostUninitialized)
+		})
+
+		Convey("hosts that fail to start should be decommissioned", func() {
+			restarted, err := restartStoppedHost("d1", 0, &fakeStopper{err: errors.New("no capacity")})
+			So(err, ShouldNotBeNil)
+			So(restarted, ShouldBeNil)
+
+			h, err := host.FindOne(host.ById("newer"))
+			So(err, ShouldBeNil)
+			So(h.Status, ShouldEqual, evergreen.HostDecommissioned)
+		})
+
+		Convey("hosts spawned from other versions of the distro's image shouldn't be started", func() {
+			restarted, err := restartStoppedHost("d1", 2, &fakeStopper{})
+			So(err, ShouldBeNil)
+			So(restarted, ShouldBeNil)
+		})
+
+		Convey("nothing should be started for distros without stopped hosts", func() {
+			restarted, err := restartStoppedHost("d2", 0, &fakeStopper{})
+			So(err, ShouldBeNil)
+			So(restarted, ShouldBeNil)
+		})
+	})
+}
@@ -2,6 +2,10 @@
 db.artifact_files.ensureIndex({ "task" : 1 })
 db.artifact_files.ensureIndex({ "build" : 1 })
 
+//======artifact_downloads======//
+db.artifact_downloads.ensureIndex({ "task_id" : 1, "time" : -1 })
+db.artifact_downloads.ensureIndex({ "user" : 1, "time" : -1 })
+
 //======builds======//
 db.builds.ensureIndex({ "build_variant" : 1, "status" : 1, "order" : 1 })
 db.builds.ensureIndex({ "start_time" : 1 })
@@ -49,6 +53,8 @@ db.tasks.ensureIndex({ "status" : 1, "finish_time" : 1 })
 db.tasks.ensureIndex({ "version" : 1, "display_name" : 1 })
 db.tasks.ensureIndex({ "order" : 1, "display_name" : 1 })
 db.tasks.ensureIndex({ "status": 1, "start_time" : 1, "finish_time" : 1})
+db.tasks.ensureIndex({ "branch" : 1, "r" : 1, "finish_time" : 1 })
+db.tasks.ensureIndex({ "branch" : 1, "r" : 1, "test_results.test_file" : 1, "test_results.status" : 1 })
 
 //======versions======//
 db.versions.ensureIndex({ "order" : 1 })
@@ -59,3 +65,19 @@ db.versions.ensureIndex({ "versions.build_variant_status.build_variant" : 1, "ve
 
 //======alerts=======//
 db.alerts.createIndex({queue_status:1})
+
+//======alertrecord======//
+db.alertrecord.ensureIndex({ "type" : 1, "recipient" : 1, "sent_at" : 1 })
+
+//======alertdigests======//
+db.alertdigests.ensureIndex({ "recipient" : 1, "version_id" : 1 }, { unique : true })
+db.alertdigests.ensureIndex({ "created_at" : 1 })
+
+//======heldalerts======//
+db.heldalerts.ensureIndex({ "created_at" : 1 })
+
+//======task_coverage======//
+db.task_coverage.ensureIndex({ "version" : 1, "build_variant" : 1, "task_name" : 1 })
+
+//======task_outputs======//
+db.task_outputs.ensureIndex({ "task_id" : 1, "name" : 1 })
@@ -20,6 +20,7 @@ import (
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/alert"
 	"github.com/evergreen-ci/evergreen/model/artifact"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
@@ -28,6 +29,7 @@ import (
 	"github.com/evergreen-ci/evergreen/notify"
 	"github.com/evergreen-ci/evergreen/plugin"
 	_ "github.com/evergreen-ci/evergreen/plugin/config"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/evergreen-ci/evergreen/taskrunner"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/evergreen-ci/evergreen/validator"
@@ -51,6 +53,9 @@ type APIServer struct {
 	UserManager auth.UserManager
 	Settings    evergreen.Settings
 	plugins     []plugin.APIPlugin
+
+	// outputs holds the storage settings of published task outputs
+	outputs *storage.OutputsConfig
 }
 
 const (
@@ -65,7 +70,12 @@ func NewAPIServer(settings *evergreen.Settings, plugins []plugin.APIPlugin) (*AP
 		return nil, err
 	}
 
-	return &APIServer{render.New(render.Options{}), authManager, *settings, plugins}, nil
+	outputs, err := storage.ParseOutputsConfig(settings.Plugins[storage.OutputsSettingsKey])
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIServer{render.New(render.Options{}), authManager, *settings, plugins, outputs}, nil
 }
 
 // MustHaveTask get the task from an HTTP Request.
@@ -267,6 +277,10 @@ func (as *APIServer) EndTask(w http.ResponseWriter, r *http.Request) {
 	}
 	defer releaseGlobalLock(r.RemoteAddr, t.Id)
 
+	if model.ApplyTestQuarantine(t, projectRef, details) {
+		evergreen.Logger.Logf(slogger.INFO, "Task %v only failed quarantined tests; marking it successful", t.Id)
+	}
+
 	// mark task as finished
 	err = model.MarkEnd(t.Id, APIServerLockTitle, finishTime, details, project, projectRef.DeactivatePrevious)
 	if err != nil {
@@ -281,6 +295,10 @@ func (as *APIServer) EndTask(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			evergreen.Logger.Logf(slogger.ERROR, "Error processing alert triggers for task %v: %v", t.Id, err)
 		}
+		err = alerts.RunTaskDurationTriggers(t.Id)
+		if err != nil {
+			evergreen.Logger.Logf(slogger.ERROR, "Error processing duration alert triggers for task %v: %v", t.Id, err)
+		}
 	} else {
 		//TODO(EVG-223) process patch-specific triggers
 	}
@@ -675,8 +693,8 @@ func (as *APIServer) hostReady(w http.ResponseWriter, r *http.Request) {
 		hostLink := fmt.Sprintf("%v/host/%v", as.Settings.Ui.Url, hostObj.Id)
 		message := fmt.Sprintf("Provisioning failed on %v host -- %v (%v). %v",
 			hostObj.Distro.Id, hostObj.Id, hostObj.Host, hostLink)
-		if err = notify.NotifyAdmins(subject, message, &as.Settings); err != nil {
-			evergreen.Logger.Errorf(slogger.ERROR, "Error sending email: %v", err)
+		if err = alert.EnqueueOpsAlert(subject, message); err != nil {
+			evergreen.Logger.Errorf(slogger.ERROR, "Error queueing ops alert: %v", err)
 		}
 
 		// get/store setup logs
@@ -705,8 +723,8 @@ func (as *APIServer) hostReady(w http.ResponseWriter, r *http.Request) {
 			notify.ProvisionFailurePreface, hostObj.Distro.Id)
 		message := fmt.Sprintf("Failed to get cloud manager for host %v with provider %v: %v",
 			hostObj.Id, hostObj.Provider, err)
-		if err = notify.NotifyAdmins(subject, message, &as.Settings); err != nil {
-			evergreen.Logger.Errorf(slogger.ERROR, "Error sending email: %v", err)
+		if err = alert.EnqueueOpsAlert(subject, message); err != nil {
+			evergreen.Logger.Errorf(slogger.ERROR, "Error queueing ops alert: %v", err)
 		}
 		return
 	}
@@ -878,6 +896,11 @@ func (as *APIServer) GetSettings() evergreen.Settings {
 	return as.Settings
 }
 
+// GetOutputs returns the storage settings of published task outputs.
+func (as *APIServer) GetOutputs() *storage.OutputsConfig {
+	return as.outputs
+}
+
 // Handler returns the root handler for all APIServer endpoints.
 func (as *APIServer) Handler() (http.Handler, error) {
 	root := mux.NewRouter()
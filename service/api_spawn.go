@@ -9,6 +9,7 @@ import (
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/alerts"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
+	"github.com/evergreen-ci/evergreen/model/alert"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/event"
 	"github.com/evergreen-ci/evergreen/model/host"
@@ -143,8 +144,8 @@ func (as *APIServer) spawnHostReady(w http.ResponseWriter, r *http.Request) {
 		// send notification to the Evergreen team about this provisioning failure
 		subject := fmt.Sprintf("%v Spawn provisioning failure on %v", notify.ProvisionFailurePreface, host.Distro.Id)
 		message := fmt.Sprintf("Provisioning failed on %v host %v for user %v", host.Distro.Id, host.Host, host.StartedBy)
-		if err = notify.NotifyAdmins(subject, message, &as.Settings); err != nil {
-			evergreen.Logger.Errorf(slogger.ERROR, "Error sending email: %v", err)
+		if err = alert.EnqueueOpsAlert(subject, message); err != nil {
+			evergreen.Logger.Errorf(slogger.ERROR, "Error queueing ops alert: %v", err)
 		}
 
 		// get/store setup logs
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model/cloudstats"
+	"github.com/evergreen-ci/evergreen/model/user"
+)
+
+// spawnBreakerStatus is a distro's spawn breaker and whether it's blocking spawns.
+type spawnBreakerStatus struct {
+	cloudstats.SpawnBreaker
+	Open bool `json:"open"`
+}
+
+// findCloudStats returns the stats of the calls to each cloud provider and the
+// spawn breakers of distros whose spawns have failed.
+func findCloudStats() ([]cloudstats.ProviderStats, []spawnBreakerStatus, error) {
+	providers, err := cloudstats.FindAllProviderStats()
+	if err != nil {
+		return nil, nil, err
+	}
+	breakers, err := cloudstats.FindAllSpawnBreakers()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	statuses := make([]spawnBreakerStatus, 0, len(breakers))
+	for _, breaker := range breakers {
+		statuses = append(statuses, spawnBreakerStatus{breaker, breaker.IsOpen(now)})
+	}
+	return providers, statuses, nil
+}
+
+// cloudStatsPage shows the calls made to each cloud provider and the distros
+// whose spawns have failed.
+func (uis *UIServer) cloudStatsPage(w http.ResponseWriter, r *http.Request) {
+	providers, breakers, err := findCloudStats()
+	if err != nil {
+		uis.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	data := struct {
+		ProjectData      projectContext
+		User             *user.DBUser
+		Providers        []cloudstats.ProviderStats
+		SpawnBreakers    []spawnBreakerStatus
+		BreakerThreshold int
+	}{MustHaveProjectContext(r), GetUser(r), providers, breakers, cloudstats.BreakerThreshold}
+
+	uis.WriteHTML(w, http.StatusOK, data, "base", "cloud_stats.html", "base_angular.html", "menu.html")
+}
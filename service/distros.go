@@ -61,6 +61,18 @@ func (uis *UIServer) modifyDistro(w http.ResponseWriter, r *http.Request) {
 
 	newDistro := *oldDistro
 
+	// keep the existing rollout, since unmarshaling writes over the one it
+	// points to
+	var oldRollout *distro.ImageRollout
+	if oldDistro.ImageRollout != nil {
+		rollout := *oldDistro.ImageRollout
+		oldRollout = &rollout
+	}
+
+	// replace the provisioning rather than unmarshaling into its steps, which
+	// would keep the fields of the existing steps that the request leaves out
+	newDistro.Provisioning = distro.Provisioning{}
+
 	// attempt to unmarshal data into distros field for type validation
 	if err = json.Unmarshal(b, &newDistro); err != nil {
 		message := fmt.Sprintf("error unmarshaling request: %v", err)
@@ -86,6 +98,8 @@ func (uis *UIServer) modifyDistro(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rolloutStarted := newDistro.ImageRollout != nil && newDistro.ImageRollout.Start(&newDistro, oldRollout)
+
 	if err = newDistro.Update(); err != nil {
 		message := fmt.Sprintf("error updating distro: %v", err)
 		PushFlash(uis.CookieStore, r, w, NewErrorFlash(message))
@@ -94,6 +108,9 @@ func (uis *UIServer) modifyDistro(w http.ResponseWriter, r *http.Request) {
 	}
 
 	event.LogDistroModified(id, u.Username(), newDistro)
+	if rolloutStarted {
+		event.LogDistroImageRolloutStarted(id, u.Username(), newDistro.ImageRollout)
+	}
 
 	PushFlash(uis.CookieStore, r, w, NewSuccessFlash(fmt.Sprintf("Distro %v successfully updated.", id)))
 	uis.WriteJSON(w, http.StatusOK, "distro successfully updated")
@@ -182,6 +199,8 @@ func (uis *UIServer) addDistro(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rolloutStarted := d.ImageRollout != nil && d.ImageRollout.Start(&d, nil)
+
 	if err = d.Insert(); err != nil {
 		message := fmt.Sprintf("error inserting distro '%v': %v", d.Id, err)
 		PushFlash(uis.CookieStore, r, w, NewErrorFlash(message))
@@ -190,6 +209,9 @@ func (uis *UIServer) addDistro(w http.ResponseWriter, r *http.Request) {
 	}
 
 	event.LogDistroAdded(d.Id, u.Username(), d)
+	if rolloutStarted {
+		event.LogDistroImageRolloutStarted(d.Id, u.Username(), d.ImageRollout)
+	}
 
 	PushFlash(uis.CookieStore, r, w, NewSuccessFlash(fmt.Sprintf("Distro %v successfully added.", d.Id)))
 	uis.WriteJSON(w, http.StatusOK, "distro successfully added")
@@ -59,6 +59,11 @@ func (uis *UIServer) hostPage(w http.ResponseWriter, r *http.Request) {
 		uis.LoggedError(w, r, http.StatusInternalServerError, err)
 		return
 	}
+	provisionResults, err := host.FindProvisionResults(id)
+	if err != nil {
+		uis.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
 	runningTask := &task.Task{}
 	if h.RunningTask != "" {
 		runningTask, err = task.FindOne(task.ById(h.RunningTask))
@@ -70,13 +75,14 @@ func (uis *UIServer) hostPage(w http.ResponseWriter, r *http.Request) {
 
 	flashes := PopFlashes(uis.CookieStore, r, w)
 	uis.WriteHTML(w, http.StatusOK, struct {
-		Flashes     []interface{}
-		Events      []event.Event
-		Host        *host.Host
-		RunningTask *task.Task
-		User        *user.DBUser
-		ProjectData projectContext
-	}{flashes, events, h, runningTask, GetUser(r), projCtx},
+		Flashes          []interface{}
+		Events           []event.Event
+		Host             *host.Host
+		ProvisionResults []host.ProvisionStepResult
+		RunningTask      *task.Task
+		User             *user.DBUser
+		ProjectData      projectContext
+	}{flashes, events, h, provisionResults, runningTask, GetUser(r), projCtx},
 		"base", "host.html", "base_angular.html", "menu.html")
 }
 
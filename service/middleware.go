@@ -19,6 +19,7 @@ import (
 	"github.com/evergreen-ci/evergreen/model/user"
 	"github.com/evergreen-ci/evergreen/model/version"
 	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/gorilla/context"
 	"github.com/gorilla/mux"
@@ -122,6 +123,11 @@ func (uis *UIServer) GetSettings() evergreen.Settings {
 	return uis.Settings
 }
 
+// GetOutputs returns the storage settings of published task outputs.
+func (uis *UIServer) GetOutputs() *storage.OutputsConfig {
+	return uis.Outputs
+}
+
 // withPluginUser takes a request and makes the user accessible to plugin code.
 func withPluginUser(next http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
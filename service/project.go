@@ -8,6 +8,7 @@ import (
 	"github.com/evergreen-ci/evergreen/alerts"
 	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/user"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/gorilla/mux"
 	"gopkg.in/mgo.v2/bson"
@@ -56,7 +57,9 @@ func (uis *UIServer) projectsPage(w http.ResponseWriter, r *http.Request) {
 
 	// construct a json-marshaling friendly representation of our supported triggers
 	allTaskTriggers := []interface{}{}
-	for _, taskTrigger := range alerts.AvailableTaskFailTriggers {
+	taskTriggers := append([]alerts.Trigger{}, alerts.AvailableTaskFailTriggers...)
+	taskTriggers = append(taskTriggers, alerts.AvailableTaskDurationTriggers...)
+	for _, taskTrigger := range taskTriggers {
 		allTaskTriggers = append(allTaskTriggers, struct {
 			Id      string `json:"id"`
 			Display string `json:"display"`
@@ -145,7 +148,14 @@ func (uis *UIServer) modifyProject(w http.ResponseWriter, r *http.Request) {
 		Owner              string            `json:"owner_name"`
 		Repo               string            `json:"repo_name"`
 		Admins             []string          `json:"admins"`
-		AlertConfig        map[string][]struct {
+		QuarantinedTests   []string          `json:"quarantined_tests"`
+		PrivateFileUsers   []string          `json:"private_file_users"`
+		Storage            storage.Options   `json:"storage"`
+		DurationAlerts     struct {
+			Factor        float64 `json:"factor"`
+			ThresholdSecs int     `json:"threshold_secs"`
+		} `json:"duration_alerts"`
+		AlertConfig map[string][]struct {
 			Provider string                 `json:"provider"`
 			Settings map[string]interface{} `json:"settings"`
 		} `json:"alert_config"`
@@ -168,6 +178,17 @@ func (uis *UIServer) modifyProject(w http.ResponseWriter, r *http.Request) {
 	projectRef.DeactivatePrevious = responseRef.DeactivatePrevious
 	projectRef.Repo = responseRef.Repo
 	projectRef.Admins = responseRef.Admins
+	projectRef.QuarantinedTests = responseRef.QuarantinedTests
+	projectRef.PrivateFileUsers = responseRef.PrivateFileUsers
+	if err = responseRef.Storage.Validate(); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid storage settings: %v", err), http.StatusBadRequest)
+		return
+	}
+	projectRef.Storage = responseRef.Storage
+	projectRef.DurationAlerts = model.DurationAlertSettings{
+		Factor:        responseRef.DurationAlerts.Factor,
+		ThresholdSecs: responseRef.DurationAlerts.ThresholdSecs,
+	}
 	projectRef.Identifier = id
 
 	projectRef.Alerts = map[string][]model.AlertConfig{}
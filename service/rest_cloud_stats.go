@@ -0,0 +1,25 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evergreen-ci/evergreen/model/cloudstats"
+)
+
+// getCloudStats returns a JSON response with the calls made to each cloud provider,
+// by method, and the spawn breakers of distros whose spawns have failed.
+func (restapi restAPI) getCloudStats(w http.ResponseWriter, r *http.Request) {
+	providers, breakers, err := findCloudStats()
+	if err != nil {
+		restapi.WriteJSON(w, http.StatusInternalServerError, responseError{
+			Message: fmt.Sprintf("error finding cloud stats: %v", err),
+		})
+		return
+	}
+
+	restapi.WriteJSON(w, http.StatusOK, struct {
+		Providers     []cloudstats.ProviderStats `json:"providers"`
+		SpawnBreakers []spawnBreakerStatus       `json:"spawn_breakers"`
+	}{providers, breakers})
+}
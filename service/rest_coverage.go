@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model/coverage"
+	"github.com/evergreen-ci/evergreen/model/version"
+)
+
+// getVersionCoverage returns a JSON response with the coverage attached by each task
+// of the requested version_id.
+func (restapi restAPI) getVersionCoverage(w http.ResponseWriter, r *http.Request) {
+	projCtx := MustHaveRESTContext(r)
+	if projCtx.Version == nil {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: "version not found"})
+		return
+	}
+
+	taskCoverage, err := coverage.Find(coverage.ByVersion(projCtx.Version.Id))
+	if err != nil {
+		msg := fmt.Sprintf("Error finding coverage for version '%v'", projCtx.Version.Id)
+		evergreen.Logger.Logf(slogger.ERROR, "%v: %v", msg, err)
+		restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+		return
+	}
+	restapi.WriteJSON(w, http.StatusOK, taskCoverage)
+}
+
+// getPatchCoverage returns a JSON response comparing the coverage of each task in the
+// requested patch_id with the same task in the mainline version of the patch's base revision.
+func (restapi restAPI) getPatchCoverage(w http.ResponseWriter, r *http.Request) {
+	projCtx := MustHaveRESTContext(r)
+	p := projCtx.Patch
+	if p == nil {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: "patch not found"})
+		return
+	}
+	if p.Version == "" {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: "patch has not been finalized"})
+		return
+	}
+
+	baseVersion, err := version.FindOne(version.ByProjectIdAndRevision(p.Project, p.Githash))
+	if err != nil {
+		msg := fmt.Sprintf("Error finding base version for patch '%v'", p.Id.Hex())
+		evergreen.Logger.Logf(slogger.ERROR, "%v: %v", msg, err)
+		restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+		return
+	}
+	if baseVersion == nil {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{
+			Message: fmt.Sprintf("no base version found for revision '%v'", p.Githash),
+		})
+		return
+	}
+
+	baseCoverage, err := coverage.Find(coverage.ByVersion(baseVersion.Id))
+	if err != nil {
+		msg := fmt.Sprintf("Error finding coverage for version '%v'", baseVersion.Id)
+		evergreen.Logger.Logf(slogger.ERROR, "%v: %v", msg, err)
+		restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+		return
+	}
+	patchCoverage, err := coverage.Find(coverage.ByVersion(p.Version))
+	if err != nil {
+		msg := fmt.Sprintf("Error finding coverage for version '%v'", p.Version)
+		evergreen.Logger.Logf(slogger.ERROR, "%v: %v", msg, err)
+		restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+		return
+	}
+
+	restapi.WriteJSON(w, http.StatusOK, struct {
+		PatchId      string           `json:"patch_id"`
+		BaseVersion  string           `json:"base_version"`
+		PatchVersion string           `json:"patch_version"`
+		Tasks        []coverage.Delta `json:"tasks"`
+	}{p.Id.Hex(), baseVersion.Id, p.Version, coverage.Compare(baseCoverage, patchCoverage)})
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/model"
+)
+
+// getFlakyTests returns a JSON response with the flaky tests of the requested project_id,
+// computed from the test results of the last `days` days (14 by default) and optionally
+// limited to one build `variant`.
+func (restapi restAPI) getFlakyTests(w http.ResponseWriter, r *http.Request) {
+	projCtx := MustHaveRESTContext(r)
+	ref := projCtx.ProjectRef
+	if ref == nil {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: "error finding project"})
+		return
+	}
+
+	window := model.FlakyTestWindow
+	if daysStr := r.FormValue("days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			restapi.WriteJSON(w, http.StatusBadRequest, responseError{
+				Message: fmt.Sprintf("invalid number of days '%v'", daysStr),
+			})
+			return
+		}
+		window = time.Duration(days) * 24 * time.Hour
+	}
+
+	variant := r.FormValue("variant")
+	flaky, err := model.FindFlakyTests(ref, variant, time.Now().Add(-window))
+	if err != nil {
+		restapi.WriteJSON(w, http.StatusInternalServerError, responseError{
+			Message: fmt.Sprintf("error finding flaky tests: %v", err),
+		})
+		return
+	}
+
+	restapi.WriteJSON(w, http.StatusOK, struct {
+		Project     string            `json:"project"`
+		Variant     string            `json:"variant,omitempty"`
+		Since       time.Time         `json:"since"`
+		Quarantined []string          `json:"quarantined_tests"`
+		Tests       []model.FlakyTest `json:"tests"`
+	}{ref.Identifier, variant, time.Now().Add(-window), ref.QuarantinedTests, flaky})
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/gorilla/context"
 	"github.com/gorilla/mux"
 )
@@ -18,6 +19,7 @@ const RestContext restContextKey = 0
 type restAPIService interface {
 	WriteJSON(w http.ResponseWriter, status int, data interface{})
 	GetSettings() evergreen.Settings
+	GetOutputs() *storage.OutputsConfig
 	LoggedError(http.ResponseWriter, *http.Request, int, error)
 }
 
@@ -89,16 +91,23 @@ func AttachRESTHandler(root *mux.Router, service restAPIService) http.Handler {
 	rtr.HandleFunc("/projects/{project_id}/versions", rest.loadCtx(rest.getRecentVersions)).Name("recent_versions").Methods("GET")
 	rtr.HandleFunc("/projects/{project_id}/revisions/{revision}", rest.loadCtx(rest.getVersionInfoViaRevision)).Name("version_info_via_revision").Methods("GET")
 	rtr.HandleFunc("/projects/{project_id}/last_green", rest.loadCtx(rest.lastGreen)).Name("last_green_version").Methods("GET")
+	rtr.HandleFunc("/projects/{project_id}/flaky_tests", rest.loadCtx(rest.getFlakyTests)).Name("flaky_tests").Methods("GET")
+	rtr.HandleFunc("/projects/{project_id}/test_history", rest.loadCtx(rest.getTestHistory)).Name("test_history").Methods("GET")
 	rtr.HandleFunc("/patches/{patch_id}", rest.loadCtx(rest.getPatch)).Name("patch_info").Methods("GET")
+	rtr.HandleFunc("/patches/{patch_id}/coverage", rest.loadCtx(rest.getPatchCoverage)).Name("patch_coverage").Methods("GET")
 	rtr.HandleFunc("/versions/{version_id}", rest.loadCtx(rest.getVersionInfo)).Name("version_info").Methods("GET")
 	rtr.HandleFunc("/versions/{version_id}", requireUser(rest.loadCtx(rest.modifyVersionInfo), nil)).Name("").Methods("PATCH")
 	rtr.HandleFunc("/versions/{version_id}/status", rest.loadCtx(rest.getVersionStatus)).Name("version_status").Methods("GET")
 	rtr.HandleFunc("/versions/{version_id}/config", rest.loadCtx(rest.getVersionConfig)).Name("version_config").Methods("GET")
+	rtr.HandleFunc("/versions/{version_id}/coverage", rest.loadCtx(rest.getVersionCoverage)).Name("version_coverage").Methods("GET")
 	rtr.HandleFunc("/builds/{build_id}", rest.loadCtx(rest.getBuildInfo)).Name("build_info").Methods("GET")
 	rtr.HandleFunc("/builds/{build_id}/status", rest.loadCtx(rest.getBuildStatus)).Name("build_status").Methods("GET")
 	rtr.HandleFunc("/tasks/{task_id}", rest.loadCtx(rest.getTaskInfo)).Name("task_info").Methods("GET")
 	rtr.HandleFunc("/tasks/{task_id}/status", rest.loadCtx(rest.getTaskStatus)).Name("task_status").Methods("GET")
+	rtr.HandleFunc("/tasks/{task_id}/files/{file_index}", requireUser(rest.loadCtx(rest.getTaskFile), nil)).Name("task_file").Methods("GET")
+	rtr.HandleFunc("/tasks/{task_id}/file_downloads", requireUser(rest.loadCtx(rest.getTaskFileDownloads), nil)).Name("task_file_downloads").Methods("GET")
 	rtr.HandleFunc("/tasks/{task_name}/history", rest.loadCtx(rest.getTaskHistory)).Name("task_history").Methods("GET")
+	rtr.HandleFunc("/cloud_stats", requireUser(rest.getCloudStats, nil)).Name("cloud_stats").Methods("GET")
 	return root
 
 }
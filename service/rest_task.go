@@ -56,6 +56,9 @@ type RestTask struct {
 
 	// Artifacts and binaries
 	Files []taskFile `json:"files"`
+
+	// Outputs published for dependent tasks, with links that expire
+	Outputs []taskFile `json:"outputs"`
 }
 
 type taskStatusDetails struct {
@@ -148,16 +151,53 @@ func (restapi restAPI) getTaskInfo(w http.ResponseWriter, r *http.Request) {
 		return
 
 	}
+	// only users the project allows see private files, linked through the file
+	// endpoint, which records the download and sends them on to a link that expires
+	settings := restapi.GetSettings()
+	canDownloadPrivate := canDownloadPrivateFiles(GetUser(r), projCtx.ProjectRef, settings.SuperUsers)
 	for _, entry := range entries {
-		for _, _file := range entry.Files {
+		for i := range entry.Files {
+			_file := &entry.Files[i]
+			if _file.Visibility == artifact.None || (_file.Visibility == artifact.Private && !canDownloadPrivate) {
+				continue
+			}
 			file := taskFile{
 				Name: _file.Name,
 				URL:  _file.Link,
 			}
+			if _file.Visibility == artifact.Private {
+				file.URL = fmt.Sprintf("%v/rest/v1/tasks/%v/files/%v", settings.Ui.Url, entry.TaskId, i)
+			}
 			destTask.Files = append(destTask.Files, file)
 		}
 	}
 
+	// Copy over published outputs. Their links are signed, so like private
+	// files they are only shown to users the project allows.
+	outputsConfig := restapi.GetOutputs()
+	if outputsConfig.Enabled() && canDownloadPrivate {
+		outputs, err := artifact.FindOutputs(artifact.OutputsByTaskId(srcTask.Id))
+		if err != nil {
+			msg := fmt.Sprintf("Error finding outputs of task '%v'", srcTask.Id)
+			evergreen.Logger.Logf(slogger.ERROR, "%v: %v", msg, err)
+			restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+			return
+		}
+		for i := range outputs {
+			downloadURL, err := outputsConfig.DownloadURL(outputs[i].Bucket, outputs[i].Path)
+			if err != nil {
+				msg := fmt.Sprintf("Error signing URL of output '%v' of task '%v'", outputs[i].Name, srcTask.Id)
+				evergreen.Logger.Logf(slogger.ERROR, "%v: %v", msg, err)
+				restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+				return
+			}
+			destTask.Outputs = append(destTask.Outputs, taskFile{
+				Name: outputs[i].Name,
+				URL:  downloadURL,
+			})
+		}
+	}
+
 	if projCtx.Patch != nil {
 		destTask.PatchNumber = projCtx.Patch.PatchNumber
 		destTask.PatchId = projCtx.Patch.Id.Hex()
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+)
+
+// restTestHistory is the response for a page of test history.
+type restTestHistory struct {
+	Results []model.TestHistoryResult `json:"results"`
+	// NextOffset is the offset of the next page, or unset if this is the last page.
+	NextOffset int `json:"next_offset,omitempty"`
+}
+
+// getTestHistory returns the individual test results of the requested project_id across
+// revisions. Results can be filtered with the comma-separated `variants`, `tasks` and
+// `statuses` parameters, a `test` name regex, a `requester`, and an `after`/`before` range
+// of RFC 3339 task finish times. Pages are selected with `limit` and `offset`.
+func (restapi restAPI) getTestHistory(w http.ResponseWriter, r *http.Request) {
+	projCtx := MustHaveRESTContext(r)
+	if projCtx.ProjectRef == nil {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: "error finding project"})
+		return
+	}
+
+	params, err := parseTestHistoryParameters(r)
+	if err != nil {
+		restapi.WriteJSON(w, http.StatusBadRequest, responseError{Message: err.Error()})
+		return
+	}
+	params.ProjectId = projCtx.ProjectRef.Identifier
+	if err = params.Validate(); err != nil {
+		restapi.WriteJSON(w, http.StatusBadRequest, responseError{Message: err.Error()})
+		return
+	}
+
+	results, err := model.GetTestHistory(params)
+	if err != nil {
+		msg := fmt.Sprintf("Error finding test history for project '%v'", params.ProjectId)
+		evergreen.Logger.Logf(slogger.ERROR, "%v: %v", msg, err)
+		restapi.WriteJSON(w, http.StatusInternalServerError, responseError{Message: msg})
+		return
+	}
+
+	history := restTestHistory{Results: results}
+	if len(results) == params.Limit {
+		history.NextOffset = params.Offset + params.Limit
+	}
+	restapi.WriteJSON(w, http.StatusOK, history)
+}
+
+// parseTestHistoryParameters reads the test history filters from the request's query string.
+func parseTestHistoryParameters(r *http.Request) (*model.TestHistoryParameters, error) {
+	params := &model.TestHistoryParameters{
+		BuildVariants: splitListParam(r.FormValue("variants")),
+		TaskNames:     splitListParam(r.FormValue("tasks")),
+		TestStatuses:  splitListParam(r.FormValue("statuses")),
+		TestNameRegex: r.FormValue("test"),
+	}
+
+	switch requester := r.FormValue("requester"); requester {
+	case "", "mainline", evergreen.RepotrackerVersionRequester:
+		params.Requester = evergreen.RepotrackerVersionRequester
+	case "patch", evergreen.PatchVersionRequester:
+		params.Requester = evergreen.PatchVersionRequester
+	default:
+		return nil, fmt.Errorf("invalid requester '%v'", requester)
+	}
+
+	var err error
+	if params.After, err = parseTimeParam(r, "after"); err != nil {
+		return nil, err
+	}
+	if params.Before, err = parseTimeParam(r, "before"); err != nil {
+		return nil, err
+	}
+	if params.Limit, err = parseIntParam(r, "limit"); err != nil {
+		return nil, err
+	}
+	if params.Offset, err = parseIntParam(r, "offset"); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// splitListParam splits a comma-separated query parameter, dropping empty entries.
+func splitListParam(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func parseTimeParam(r *http.Request, name string) (time.Time, error) {
+	value := r.FormValue(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %v time '%v': must be RFC 3339", name, value)
+	}
+	return t, nil
+}
+
+func parseIntParam(r *http.Request, name string) (int, error) {
+	value := r.FormValue(name)
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %v '%v'", name, value)
+	}
+	return i, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseTestHistoryParameters(t *testing.T) {
+	Convey("When parsing test history parameters", t, func() {
+		Convey("list and time filters should be read from the query string", func() {
+			r, err := http.NewRequest("GET",
+				"/projects/p/test_history?variants=a,,b&statuses=fail&test=^foo&after=2016-01-02T15:04:05Z&limit=10", nil)
+			So(err, ShouldBeNil)
+			params, err := parseTestHistoryParameters(r)
+			So(err, ShouldBeNil)
+			So(params.BuildVariants, ShouldResemble, []string{"a", "b"})
+			So(params.TaskNames, ShouldBeEmpty)
+			So(params.TestStatuses, ShouldResemble, []string{evergreen.TestFailedStatus})
+			So(params.TestNameRegex, ShouldEqual, "^foo")
+			So(params.Requester, ShouldEqual, evergreen.RepotrackerVersionRequester)
+			So(params.After.Year(), ShouldEqual, 2016)
+			So(params.Before.IsZero(), ShouldBeTrue)
+			So(params.Limit, ShouldEqual, 10)
+		})
+
+		Convey("invalid values should be rejected", func() {
+			for _, query := range []string{"requester=nightly", "after=yesterday", "limit=ten"} {
+				r, err := http.NewRequest("GET", "/projects/p/test_history?"+query, nil)
+				So(err, ShouldBeNil)
+				_, err = parseTestHistoryParameters(r)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
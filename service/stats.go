@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
 	"github.com/evergreen-ci/evergreen/model/build"
 	"github.com/evergreen-ci/evergreen/model/patch"
 	"github.com/evergreen-ci/evergreen/model/task"
@@ -251,3 +252,30 @@ func (uis *UIServer) taskTimingJSON(w http.ResponseWriter, r *http.Request) {
 
 	uis.WriteJSON(w, http.StatusOK, data)
 }
+
+// flakyTestsPage shows the flaky test report for the project, computed from the
+// test results of the last 14 days.
+func (uis *UIServer) flakyTestsPage(w http.ResponseWriter, r *http.Request) {
+	projCtx := MustHaveProjectContext(r)
+
+	if projCtx.ProjectRef == nil {
+		uis.ProjectNotFound(projCtx, w, r)
+		return
+	}
+
+	since := time.Now().Add(-model.FlakyTestWindow)
+	flaky, err := model.FindFlakyTests(projCtx.ProjectRef, r.FormValue("variant"), since)
+	if err != nil {
+		uis.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	data := struct {
+		ProjectData projectContext
+		User        *user.DBUser
+		Since       time.Time
+		Tests       []model.FlakyTest
+	}{projCtx, GetUser(r), since, flaky}
+
+	uis.WriteHTML(w, http.StatusOK, data, "base", "flaky_tests.html", "base_angular.html", "menu.html")
+}
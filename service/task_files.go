@@ -0,0 +1,256 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/10gen-labs/slogger/v1"
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/user"
+	"github.com/evergreen-ci/evergreen/storage"
+	"github.com/evergreen-ci/evergreen/util"
+	"github.com/gorilla/mux"
+)
+
+// defaultFileLinkExpiration is how long signed links to private files are valid
+// for, unless the artifacts settings say otherwise.
+const defaultFileLinkExpiration = 60 * time.Minute
+
+// errUnsignableFile is returned for private files in buckets the server has no
+// credentials for, since it can't link to them without giving the file away.
+var errUnsignableFile = errors.New("the server can't sign links to this file")
+
+// fileStorage returns the driver for the bucket a private file is stored in, or
+// nil if the server has no credentials for it.
+func fileStorage(conf *evergreen.ArtifactsConfig, file *artifact.File) (storage.Driver, error) {
+	if file.Bucket == "" || file.FileKey == "" {
+		return nil, nil
+	}
+	bucketConf, ok := conf.Buckets[file.Bucket]
+	if !ok {
+		return nil, nil
+	}
+	opts := storage.Options{
+		Driver:    bucketConf.Driver,
+		Endpoint:  bucketConf.Endpoint,
+		Region:    bucketConf.Region,
+		PathStyle: bucketConf.PathStyle,
+		Root:      bucketConf.Root,
+	}
+	return storage.GetDriver(opts, bucketConf.AwsKey, bucketConf.AwsSecret)
+}
+
+// signedFileLink returns a link to the private file that expires.
+func signedFileLink(conf *evergreen.ArtifactsConfig, driver storage.Driver, file *artifact.File) (string, error) {
+	expiration := defaultFileLinkExpiration
+	if conf.LinkExpirationMinutes > 0 {
+		expiration = time.Duration(conf.LinkExpirationMinutes) * time.Minute
+	}
+	return driver.Presign("GET", file.Bucket, file.FileKey, "", time.Now().Add(expiration))
+}
+
+// recordDownload adds the download of a task's file by the user to the audit log.
+func recordDownload(r *http.Request, taskId, userId, source string, file *artifact.File) error {
+	download := &artifact.Download{
+		TaskId:     taskId,
+		FileName:   file.Name,
+		Bucket:     file.Bucket,
+		FileKey:    file.FileKey,
+		User:       userId,
+		RemoteAddr: r.RemoteAddr,
+		Source:     source,
+		Time:       time.Now(),
+	}
+	if err := download.Insert(); err != nil {
+		return fmt.Errorf("error recording download of %v: %v", file.Name, err)
+	}
+	return nil
+}
+
+// canDownloadPrivateFiles returns whether the user may download the private files
+// of the project's tasks: superusers and the project's admins always may, and
+// other users may if the project lists them, or lists no one.
+func canDownloadPrivateFiles(u *user.DBUser, projectRef *model.ProjectRef, superUsers []string) bool {
+	if u == nil {
+		return false
+	}
+	if len(superUsers) == 0 || util.SliceContains(superUsers, u.Id) {
+		return true
+	}
+	if projectRef == nil {
+		return false
+	}
+	if isAdmin(u, projectRef) || len(projectRef.PrivateFileUsers) == 0 {
+		return true
+	}
+	return util.SliceContains(projectRef.PrivateFileUsers, u.Id)
+}
+
+// findTaskFile returns one of the task's files by its index in the task's files,
+// or nil if the task has no such file.
+func findTaskFile(taskId, fileIndex string) (*artifact.File, error) {
+	index, err := strconv.Atoi(fileIndex)
+	if err != nil {
+		return nil, nil
+	}
+	entry, err := artifact.FindOne(artifact.ByTaskId(taskId))
+	if err != nil {
+		return nil, fmt.Errorf("Error finding task files: %v", err)
+	}
+	if entry == nil || index < 0 || index >= len(entry.Files) || entry.Files[index].Visibility == artifact.None {
+		return nil, nil
+	}
+	return &entry.Files[index], nil
+}
+
+// sendPrivateFile records the download of a private file by the user, then
+// redirects them to a link to it that expires, or streams it if it's in local
+// storage. It returns errUnsignableFile, without recording anything, if the
+// server has no credentials for the file's bucket.
+func sendPrivateFile(w http.ResponseWriter, r *http.Request, conf *evergreen.ArtifactsConfig,
+	taskId, userId, source string, file *artifact.File) error {
+	driver, err := fileStorage(conf, file)
+	if err != nil {
+		return fmt.Errorf("Error opening storage for %v: %v", file.Name, err)
+	}
+	if driver == nil {
+		return errUnsignableFile
+	}
+	if err = recordDownload(r, taskId, userId, source, file); err != nil {
+		return err
+	}
+
+	if conf.Buckets[file.Bucket].Driver != storage.LocalDriverName {
+		link, err := signedFileLink(conf, driver, file)
+		if err != nil {
+			return fmt.Errorf("Error signing link to %v: %v", file.Name, err)
+		}
+		http.Redirect(w, r, link, http.StatusFound)
+		return nil
+	}
+
+	reader, err := driver.Get(file.Bucket, file.FileKey)
+	if err != nil {
+		return fmt.Errorf("Error reading %v: %v", file.Name, err)
+	}
+	defer reader.Close()
+	fileName := path.Base(file.FileKey)
+	if contentType := mime.TypeByExtension(path.Ext(fileName)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	if _, err = io.Copy(w, reader); err != nil {
+		evergreen.Logger.Errorf(slogger.ERROR, "Error streaming %v: %v", file.Name, err)
+	}
+	return nil
+}
+
+// taskFile sends the user to one of the task's files, by its index in the task's
+// files. Private files can only be downloaded by logged-in users the project
+// allows; the download is recorded and the user is redirected to a link that
+// expires. Private files in local storage are streamed by the server.
+func (uis *UIServer) taskFile(w http.ResponseWriter, r *http.Request) {
+	projCtx := MustHaveProjectContext(r)
+	if projCtx.Task == nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	file, err := findTaskFile(projCtx.Task.Id, mux.Vars(r)["file_index"])
+	if err != nil {
+		uis.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if file == nil {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	// only public files are linked to directly
+	if file.Visibility != artifact.Private {
+		http.Redirect(w, r, file.Link, http.StatusFound)
+		return
+	}
+
+	user := GetUser(r)
+	if user == nil {
+		uis.RedirectToLogin(w, r)
+		return
+	}
+	if !canDownloadPrivateFiles(user, projCtx.ProjectRef, uis.Settings.SuperUsers) {
+		http.Error(w, "Not authorized to download this file", http.StatusForbidden)
+		return
+	}
+	err = sendPrivateFile(w, r, &uis.Settings.Artifacts, projCtx.Task.Id, user.Id, artifact.DownloadSourceUI, file)
+	if err == errUnsignableFile {
+		http.Error(w, "Not found", http.StatusNotFound)
+	} else if err != nil {
+		uis.LoggedError(w, r, http.StatusInternalServerError, err)
+	}
+}
+
+// getTaskFile sends the caller to one of the task's files, by its index in the
+// task's files, as taskFile does for the UI.
+func (restapi restAPI) getTaskFile(w http.ResponseWriter, r *http.Request) {
+	projCtx := MustHaveRESTContext(r)
+	if projCtx.Task == nil {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: "error finding task"})
+		return
+	}
+	file, err := findTaskFile(projCtx.Task.Id, mux.Vars(r)["file_index"])
+	if err != nil {
+		restapi.LoggedError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	if file == nil {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: "error finding file"})
+		return
+	}
+	// only public files are linked to directly
+	if file.Visibility != artifact.Private {
+		http.Redirect(w, r, file.Link, http.StatusFound)
+		return
+	}
+
+	u := GetUser(r)
+	settings := restapi.GetSettings()
+	if !canDownloadPrivateFiles(u, projCtx.ProjectRef, settings.SuperUsers) {
+		restapi.WriteJSON(w, http.StatusForbidden, responseError{Message: "not authorized to download this file"})
+		return
+	}
+	err = sendPrivateFile(w, r, &settings.Artifacts, projCtx.Task.Id, u.Id, artifact.DownloadSourceREST, file)
+	if err == errUnsignableFile {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: err.Error()})
+	} else if err != nil {
+		restapi.LoggedError(w, r, http.StatusInternalServerError, err)
+	}
+}
+
+// getTaskFileDownloads returns the audit log of downloads of the task's private
+// files. Only superusers and the project's admins may read it.
+func (restapi restAPI) getTaskFileDownloads(w http.ResponseWriter, r *http.Request) {
+	projCtx := MustHaveRESTContext(r)
+	if projCtx.Task == nil {
+		restapi.WriteJSON(w, http.StatusNotFound, responseError{Message: "error finding task"})
+		return
+	}
+	u := GetUser(r)
+	superUsers := restapi.GetSettings().SuperUsers
+	if !util.SliceContains(superUsers, u.Id) && len(superUsers) > 0 &&
+		(projCtx.ProjectRef == nil || !isAdmin(u, projCtx.ProjectRef)) {
+		restapi.WriteJSON(w, http.StatusForbidden, responseError{Message: "not authorized to view downloads"})
+		return
+	}
+	downloads, err := artifact.FindDownloads(artifact.DownloadsByTaskId(projCtx.Task.Id))
+	if err != nil {
+		restapi.LoggedError(w, r, http.StatusInternalServerError, fmt.Errorf("Error finding downloads: %v", err))
+		return
+	}
+	restapi.WriteJSON(w, http.StatusOK, downloads)
+}
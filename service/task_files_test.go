@@ -0,0 +1,68 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/model"
+	"github.com/evergreen-ci/evergreen/model/artifact"
+	"github.com/evergreen-ci/evergreen/model/user"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCanDownloadPrivateFiles(t *testing.T) {
+	Convey("With a project that has an admin", t, func() {
+		superUsers := []string{"root"}
+		projectRef := &model.ProjectRef{Identifier: "mci", Admins: []string{"admin"}}
+		admin := &user.DBUser{Id: "admin"}
+		member := &user.DBUser{Id: "member"}
+		other := &user.DBUser{Id: "other"}
+
+		Convey("anonymous users should never be allowed", func() {
+			So(canDownloadPrivateFiles(nil, projectRef, superUsers), ShouldBeFalse)
+			So(canDownloadPrivateFiles(nil, projectRef, nil), ShouldBeFalse)
+		})
+
+		Convey("superusers and admins should always be allowed", func() {
+			projectRef.PrivateFileUsers = []string{"member"}
+			So(canDownloadPrivateFiles(&user.DBUser{Id: "root"}, projectRef, superUsers), ShouldBeTrue)
+			So(canDownloadPrivateFiles(admin, projectRef, superUsers), ShouldBeTrue)
+		})
+
+		Convey("any logged-in user should be allowed if the project lists no one", func() {
+			So(canDownloadPrivateFiles(other, projectRef, superUsers), ShouldBeTrue)
+		})
+
+		Convey("only listed users should be allowed if the project lists some", func() {
+			projectRef.PrivateFileUsers = []string{"member"}
+			So(canDownloadPrivateFiles(member, projectRef, superUsers), ShouldBeTrue)
+			So(canDownloadPrivateFiles(other, projectRef, superUsers), ShouldBeFalse)
+		})
+
+		Convey("tasks without a project should only be downloadable by superusers", func() {
+			So(canDownloadPrivateFiles(admin, nil, superUsers), ShouldBeFalse)
+			So(canDownloadPrivateFiles(&user.DBUser{Id: "root"}, nil, superUsers), ShouldBeTrue)
+		})
+	})
+}
+
+func TestSendUnsignableFile(t *testing.T) {
+	Convey("With a private file in a bucket the server has no credentials for", t, func() {
+		conf := &evergreen.ArtifactsConfig{
+			Buckets: map[string]evergreen.ArtifactBucketConfig{"signed": {AwsKey: "key", AwsSecret: "secret"}},
+		}
+		file := &artifact.File{Name: "dump", Bucket: "unsigned", FileKey: "dump.tgz",
+			Link: "https://s3.amazonaws.com/unsigned/dump.tgz", Visibility: artifact.Private}
+
+		Convey("it should not be sent, nor its permanent link given away", func() {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "/task_file/t1/0", nil)
+			So(err, ShouldBeNil)
+			err = sendPrivateFile(w, r, conf, "t1", "user", artifact.DownloadSourceUI, file)
+			So(err, ShouldEqual, errUnsignableFile)
+			So(w.Header().Get("Location"), ShouldEqual, "")
+		})
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/evergreen-ci/evergreen/auth"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/plugin"
+	"github.com/evergreen-ci/evergreen/storage"
 	"github.com/evergreen-ci/evergreen/util"
 	"github.com/evergreen-ci/render"
 	"github.com/gorilla/mux"
@@ -46,6 +47,9 @@ type UIServer struct {
 	CookieStore     *sessions.CookieStore
 	PluginTemplates map[string]*htmlTemplate.Template
 
+	// Outputs holds the storage settings of published task outputs
+	Outputs *storage.OutputsConfig
+
 	plugin.PanelManager
 }
 
@@ -69,6 +73,11 @@ func NewUIServer(settings *evergreen.Settings, home string) (*UIServer, error) {
 
 	uis.PluginTemplates = map[string]*htmlTemplate.Template{}
 
+	uis.Outputs, err = storage.ParseOutputsConfig(settings.Plugins[storage.OutputsSettingsKey])
+	if err != nil {
+		return nil, err
+	}
+
 	return uis, nil
 }
 
@@ -125,6 +134,7 @@ func (uis *UIServer) NewRouter() (*mux.Router, error) {
 	r.HandleFunc("/json/task_log/{task_id}", uis.loadCtx(uis.taskLog))
 	r.HandleFunc("/json/task_log/{task_id}/{execution}", uis.loadCtx(uis.taskLog))
 	r.HandleFunc("/task_log_raw/{task_id}/{execution}", uis.loadCtx(uis.taskLogRaw))
+	r.HandleFunc("/task_file/{task_id}/{file_index}", uis.loadCtx(uis.taskFile)).Methods("GET")
 
 	// Test Logs
 	r.HandleFunc("/test_log/{task_id}/{task_execution}/{test_name}", uis.loadCtx(uis.testLog))
@@ -154,6 +164,7 @@ func (uis *UIServer) NewRouter() (*mux.Router, error) {
 	r.HandleFunc("/distros/{distro_id}", uis.requireSuperUser(uis.loadCtx(uis.addDistro))).Methods("PUT")
 	r.HandleFunc("/distros/{distro_id}", uis.requireSuperUser(uis.loadCtx(uis.modifyDistro))).Methods("POST")
 	r.HandleFunc("/distros/{distro_id}", uis.requireSuperUser(uis.loadCtx(uis.removeDistro))).Methods("DELETE")
+	r.HandleFunc("/cloud_stats", requireLogin(uis.loadCtx(uis.cloudStatsPage))).Methods("GET")
 
 	// Event Logs
 	r.HandleFunc("/event_log/{resource_type}/{resource_id:[\\w_\\-\\:\\.\\@]+}", uis.loadCtx(uis.fullEventLogs))
@@ -208,6 +219,7 @@ func (uis *UIServer) NewRouter() (*mux.Router, error) {
 	r.HandleFunc("/task_timing/{project_id}", requireLogin(uis.loadCtx(uis.taskTimingPage))).Methods("GET")
 	r.HandleFunc("/json/task_timing/{project_id}/{build_variant}/{request}/{task_name}", requireLogin(uis.loadCtx(uis.taskTimingJSON))).Methods("GET")
 	r.HandleFunc("/json/task_timing/{project_id}/{build_variant}/{request}", requireLogin(uis.loadCtx(uis.taskTimingJSON))).Methods("GET")
+	r.HandleFunc("/flaky_tests/{project_id}", requireLogin(uis.loadCtx(uis.flakyTestsPage))).Methods("GET")
 
 	// Project routes
 	r.HandleFunc("/projects", requireLogin(uis.loadCtx(uis.projectsPage))).Methods("GET")
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalDriver stores objects as files in a directory per bucket under its root.
+// Buckets are created as objects are put to them. Permissions are ignored.
+type LocalDriver struct {
+	Root string
+}
+
+// NewLocalDriver returns a driver storing buckets under the root directory.
+func NewLocalDriver(root string) *LocalDriver {
+	return &LocalDriver{Root: root}
+}
+
+// bucketDir returns the directory the bucket's objects are stored in.
+func (d *LocalDriver) bucketDir(bucket string) (string, error) {
+	if bucket == "" || strings.ContainsAny(bucket, `/\`) || bucket == "." || bucket == ".." {
+		return "", fmt.Errorf("invalid bucket name '%v'", bucket)
+	}
+	return filepath.Join(d.Root, bucket), nil
+}
+
+// filePath returns where the object at path in the bucket is stored, making sure
+// it doesn't escape the bucket's directory.
+func (d *LocalDriver) filePath(bucket, path string) (string, error) {
+	dir, err := d.bucketDir(bucket)
+	if err != nil {
+		return "", err
+	}
+	cleaned := filepath.Clean("/" + filepath.FromSlash(path))
+	if cleaned == string(filepath.Separator) {
+		return "", fmt.Errorf("object path cannot be blank")
+	}
+	return filepath.Join(dir, cleaned), nil
+}
+
+func (d *LocalDriver) Put(bucket, path string, r io.Reader, size int64, opts PutOptions) error {
+	dest, err := d.filePath(bucket, path)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so readers never see a partial object
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), ".put-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("expected %v bytes for %v but read %v", size, path, written)
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+func (d *LocalDriver) Get(bucket, path string) (io.ReadCloser, error) {
+	src, err := d.filePath(bucket, path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(src)
+}
+
+func (d *LocalDriver) Copy(fromBucket, fromPath, toBucket, toPath string, opts PutOptions) error {
+	src, err := d.Get(fromBucket, fromPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return d.Put(toBucket, toPath, src, -1, opts)
+}
+
+func (d *LocalDriver) List(bucket, prefix string) ([]Object, error) {
+	bucketDir, err := d.bucketDir(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []Object{}
+	err = filepath.Walk(bucketDir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && file == bucketDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if fi.IsDir() || strings.HasPrefix(fi.Name(), ".put-") {
+			return nil
+		}
+		rel, err := filepath.Rel(bucketDir, file)
+		if err != nil {
+			return err
+		}
+		objectPath := filepath.ToSlash(rel)
+		if !strings.HasPrefix(objectPath, prefix) {
+			return nil
+		}
+		objects = append(objects, Object{
+			Path:         objectPath,
+			Size:         fi.Size(),
+			LastModified: fi.ModTime().UTC().Format(LastModifiedFormat),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func (d *LocalDriver) Exists(bucket, path string) (bool, error) {
+	file, err := d.filePath(bucket, path)
+	if err != nil {
+		return false, err
+	}
+	fi, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !fi.IsDir(), nil
+}
+
+func (d *LocalDriver) Remove(bucket, path string) error {
+	file, err := d.filePath(bucket, path)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(file); os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// URL returns a file URL for the object.
+func (d *LocalDriver) URL(bucket, path string) string {
+	file, err := d.filePath(bucket, path)
+	if err != nil {
+		file = filepath.Join(d.Root, bucket, filepath.FromSlash(path))
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(file)}).String()
+}
+
+// Presign returns the object's file URL, since local files need no signature.
+// The URL can only be used on the machine the files are stored on.
+func (d *LocalDriver) Presign(method, bucket, path, contentType string, expires time.Time) (string, error) {
+	if _, err := d.filePath(bucket, path); err != nil {
+		return "", err
+	}
+	return d.URL(bucket, path), nil
+}
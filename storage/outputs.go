@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// OutputsSettingsKey is the section of the plugins settings that configures
+	// where published task outputs are stored.
+	OutputsSettingsKey = "artifacts"
+
+	// DefaultOutputURLExpiration is how long upload and download URLs of
+	// outputs are valid for
+	DefaultOutputURLExpiration = 60 * time.Minute
+
+	// OutputContentType is the content type outputs are uploaded with
+	OutputContentType = "application/octet-stream"
+)
+
+// OutputsConfig holds the settings for where the server stores published task
+// outputs, along with the driver for them. It is read once when a server starts.
+type OutputsConfig struct {
+	Bucket    string `mapstructure:"bucket"`
+	AwsKey    string `mapstructure:"aws_key"`
+	AwsSecret string `mapstructure:"aws_secret"`
+
+	// Prefix is prepended to the path of every output in the bucket
+	Prefix string `mapstructure:"prefix"`
+
+	// URLExpirationMinutes is how long upload and download URLs are valid for.
+	// Defaults to DefaultOutputURLExpiration.
+	URLExpirationMinutes int `mapstructure:"url_expiration_minutes"`
+
+	// Storage selects the storage driver outputs are kept with. Defaults to
+	// Amazon S3.
+	Storage Options `mapstructure:"storage"`
+
+	driver Driver
+}
+
+// ParseOutputsConfig reads the outputs settings. The settings may be empty, in
+// which case outputs can't be published or fetched.
+func ParseOutputsConfig(conf map[string]interface{}) (*OutputsConfig, error) {
+	config := &OutputsConfig{}
+	if err := mapstructure.Decode(conf, config); err != nil {
+		return nil, fmt.Errorf("error decoding %v settings: %v", OutputsSettingsKey, err)
+	}
+	if config.Bucket == "" {
+		return config, nil
+	}
+	if err := config.Storage.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %v settings: %v", OutputsSettingsKey, err)
+	}
+	// agents upload and download outputs over HTTP, which they can't do with
+	// the file URLs of the local driver
+	if config.Storage.Driver == LocalDriverName {
+		return nil, fmt.Errorf("invalid %v settings: outputs can't be stored with the %v storage driver",
+			OutputsSettingsKey, LocalDriverName)
+	}
+	if config.AwsKey == "" || config.AwsSecret == "" {
+		return nil, fmt.Errorf("invalid %v settings: aws_key and aws_secret cannot be blank", OutputsSettingsKey)
+	}
+	driver, err := GetDriver(config.Storage, config.AwsKey, config.AwsSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error getting storage driver for %v: %v", OutputsSettingsKey, err)
+	}
+	config.driver = driver
+	return config, nil
+}
+
+// Enabled returns whether storage for outputs is configured.
+func (c *OutputsConfig) Enabled() bool {
+	return c != nil && c.Bucket != ""
+}
+
+func (c *OutputsConfig) expiration() time.Duration {
+	if c.URLExpirationMinutes <= 0 {
+		return DefaultOutputURLExpiration
+	}
+	return time.Duration(c.URLExpirationMinutes) * time.Minute
+}
+
+// UploadURL returns a pre-signed URL for uploading an output to the path.
+func (c *OutputsConfig) UploadURL(outputPath string) (string, error) {
+	return c.driver.Presign("PUT", c.Bucket, outputPath, OutputContentType,
+		time.Now().Add(c.expiration()))
+}
+
+// DownloadURL returns a pre-signed URL for downloading the output stored at the
+// path in the bucket.
+func (c *OutputsConfig) DownloadURL(bucket, outputPath string) (string, error) {
+	return c.driver.Presign("GET", bucket, outputPath, "", time.Now().Add(c.expiration()))
+}
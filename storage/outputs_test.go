@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOutputsConfig(t *testing.T) {
+	Convey("With the outputs settings", t, func() {
+		Convey("empty settings should leave outputs disabled", func() {
+			config, err := ParseOutputsConfig(nil)
+			So(err, ShouldBeNil)
+			So(config.Enabled(), ShouldBeFalse)
+		})
+
+		Convey("a bucket without credentials should be rejected", func() {
+			_, err := ParseOutputsConfig(map[string]interface{}{"bucket": "outputs"})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("outputs should be linked with signed URLs", func() {
+			config, err := ParseOutputsConfig(map[string]interface{}{
+				"bucket": "outputs", "aws_key": "key", "aws_secret": "secret",
+			})
+			So(err, ShouldBeNil)
+			So(config.Enabled(), ShouldBeTrue)
+
+			downloadURL, err := config.DownloadURL("outputs", "mci/bin.tgz")
+			So(err, ShouldBeNil)
+			link, err := url.Parse(downloadURL)
+			So(err, ShouldBeNil)
+			So(link.Path, ShouldEndWith, "/mci/bin.tgz")
+			So(link.Query().Get("Signature"), ShouldNotEqual, "")
+		})
+
+		Convey("outputs should be linked through the configured storage driver", func() {
+			config, err := ParseOutputsConfig(map[string]interface{}{
+				"bucket": "outputs", "aws_key": "key", "aws_secret": "secret",
+				"storage": map[string]interface{}{"endpoint": "https://minio.example.com", "path_style": true},
+			})
+			So(err, ShouldBeNil)
+
+			uploadURL, err := config.UploadURL("mci/bin.tgz")
+			So(err, ShouldBeNil)
+			So(uploadURL, ShouldStartWith, "https://minio.example.com/outputs/mci/bin.tgz?")
+		})
+
+		Convey("the local storage driver should be rejected, since agents can't use its links", func() {
+			_, err := ParseOutputsConfig(map[string]interface{}{
+				"bucket":  "outputs",
+				"storage": map[string]interface{}{"driver": "local", "root": "/srv/outputs"},
+			})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/evergreen-ci/evergreen/thirdparty"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/s3"
+)
+
+// s3ListPageSize is the number of objects to list per request.
+const s3ListPageSize = 1000
+
+// S3Driver stores objects in Amazon S3 or an S3-compatible service.
+type S3Driver struct {
+	auth    aws.Auth
+	session *s3.S3
+}
+
+// NewS3Driver returns a driver for the endpoint and region in the options.
+func NewS3Driver(opts Options, key, secret string) (*S3Driver, error) {
+	region, err := s3Region(opts)
+	if err != nil {
+		return nil, err
+	}
+	auth := aws.Auth{AccessKey: key, SecretKey: secret}
+	return &S3Driver{auth: auth, session: thirdparty.NewS3Session(&auth, region)}, nil
+}
+
+// s3Region returns the goamz region addressing the options' endpoint.
+func s3Region(opts Options) (aws.Region, error) {
+	region := aws.USEast
+	if opts.Region != "" {
+		var ok bool
+		if region, ok = aws.Regions[opts.Region]; !ok {
+			return aws.Region{}, fmt.Errorf("no known S3 region '%v'", opts.Region)
+		}
+	}
+	if opts.Endpoint != "" {
+		endpoint, err := url.Parse(opts.Endpoint)
+		if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+			return aws.Region{}, fmt.Errorf("S3 endpoint '%v' is not a valid URL", opts.Endpoint)
+		}
+		region.S3Endpoint = strings.TrimSuffix(opts.Endpoint, "/")
+		region.S3BucketEndpoint = ""
+		if !opts.PathStyle {
+			region.S3BucketEndpoint = endpoint.Scheme + "://${bucket}." + endpoint.Host
+		}
+	} else if opts.PathStyle {
+		region.S3BucketEndpoint = ""
+	}
+	return region, nil
+}
+
+func (d *S3Driver) Put(bucket, path string, r io.Reader, size int64, opts PutOptions) error {
+	return d.session.Bucket(bucket).PutReader(path, r, size, opts.ContentType,
+		s3ACL(opts.Permissions), s3.Options{})
+}
+
+func (d *S3Driver) Get(bucket, path string) (io.ReadCloser, error) {
+	return d.session.Bucket(bucket).GetReader(path)
+}
+
+func (d *S3Driver) Copy(fromBucket, fromPath, toBucket, toPath string, opts PutOptions) error {
+	source := (&url.URL{Path: fromBucket + "/" + strings.TrimPrefix(fromPath, "/")}).EscapedPath()
+	_, err := d.session.Bucket(toBucket).PutCopy(toPath, s3ACL(opts.Permissions),
+		s3.CopyOptions{ContentType: opts.ContentType}, source)
+	return err
+}
+
+func (d *S3Driver) List(bucket, prefix string) ([]Object, error) {
+	objects := []Object{}
+	marker := ""
+	for {
+		resp, err := d.session.Bucket(bucket).List(prefix, "", marker, s3ListPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range resp.Contents {
+			objects = append(objects, Object{
+				Path:         key.Key,
+				Size:         key.Size,
+				LastModified: key.LastModified,
+			})
+		}
+		if !resp.IsTruncated || len(resp.Contents) == 0 {
+			return objects, nil
+		}
+		marker = resp.Contents[len(resp.Contents)-1].Key
+	}
+}
+
+func (d *S3Driver) Exists(bucket, path string) (bool, error) {
+	return d.session.Bucket(bucket).Exists(path)
+}
+
+func (d *S3Driver) Remove(bucket, path string) error {
+	return d.session.Bucket(bucket).Del(path)
+}
+
+func (d *S3Driver) URL(bucket, path string) string {
+	return d.session.Bucket(bucket).URL(path)
+}
+
+func (d *S3Driver) Presign(method, bucket, path, contentType string, expires time.Time) (string, error) {
+	objectURL, err := url.Parse(d.URL(bucket, path))
+	if err != nil {
+		return "", err
+	}
+	return thirdparty.SignS3URL(d.auth, method, objectURL, bucket, path, contentType, expires), nil
+}
+
+// s3ACL returns the canned ACL for the permissions, defaulting to private.
+func s3ACL(permissions string) s3.ACL {
+	if permissions == "" {
+		return s3.Private
+	}
+	return s3.ACL(permissions)
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// S3DriverName stores blobs in Amazon S3, or in an S3-compatible service
+	// when an endpoint is given.
+	S3DriverName = "s3"
+	// LocalDriverName stores blobs on the local filesystem, for tests and for
+	// installations without an object store.
+	LocalDriverName = "local"
+
+	// LastModifiedFormat is the ISO 8601 layout of an Object's LastModified time,
+	// the same layout S3 uses, so objects sort by it lexically.
+	LastModifiedFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// Driver is a blob store holding objects at paths within buckets.
+type Driver interface {
+	// Put stores size bytes read from r at the path.
+	Put(bucket, path string, r io.Reader, size int64, opts PutOptions) error
+	// Get returns a reader for the object at the path.
+	Get(bucket, path string) (io.ReadCloser, error)
+	// Copy stores a copy of one object at another path.
+	Copy(fromBucket, fromPath, toBucket, toPath string, opts PutOptions) error
+	// List returns every object whose path starts with the prefix.
+	List(bucket, prefix string) ([]Object, error)
+	// Exists returns whether an object is stored at the path.
+	Exists(bucket, path string) (bool, error)
+	// Remove deletes the object at the path.
+	Remove(bucket, path string) error
+	// URL returns an unsigned link to the object at the path.
+	URL(bucket, path string) string
+	// Presign returns a link that lets anyone holding it make a request with the
+	// given method on the object at the path until it expires.
+	Presign(method, bucket, path, contentType string, expires time.Time) (string, error)
+}
+
+// PutOptions describe an object being stored.
+type PutOptions struct {
+	ContentType string
+	// Permissions is the canned ACL to apply to the object, if the driver has ACLs
+	Permissions string
+}
+
+// Object is an object stored by a Driver.
+type Object struct {
+	Path         string
+	Size         int64
+	LastModified string
+}
+
+// Options select and configure a Driver. They can be set on a command, or on a
+// project for every command that doesn't set its own.
+type Options struct {
+	// Driver is the name of the driver to use. Defaults to S3DriverName.
+	Driver string `mapstructure:"driver" bson:"driver" json:"driver" yaml:"driver" plugin:"expand"`
+
+	// Endpoint is the URL of an S3-compatible service, used instead of Amazon S3.
+	Endpoint string `mapstructure:"endpoint" bson:"endpoint" json:"endpoint" yaml:"endpoint" plugin:"expand"`
+
+	// Region is the Amazon S3 region. Defaults to us-east-1.
+	Region string `mapstructure:"region" bson:"region" json:"region" yaml:"region" plugin:"expand"`
+
+	// PathStyle addresses buckets as the first element of the path instead of
+	// as a subdomain of the endpoint, which most S3-compatible services require.
+	PathStyle bool `mapstructure:"path_style" bson:"path_style" json:"path_style" yaml:"path_style"`
+
+	// Root is the directory the local driver stores buckets in.
+	Root string `mapstructure:"root" bson:"root" json:"root" yaml:"root" plugin:"expand"`
+}
+
+// IsZero returns whether the options are unset.
+func (o Options) IsZero() bool {
+	return o == Options{}
+}
+
+// Choose returns the first of the options that is set, or the zero options,
+// which select Amazon S3.
+func Choose(options ...Options) Options {
+	for _, o := range options {
+		if !o.IsZero() {
+			return o
+		}
+	}
+	return Options{}
+}
+
+// Validate checks that the options name a known driver and configure it fully.
+func (o Options) Validate() error {
+	switch o.Driver {
+	case "", S3DriverName:
+		if o.Root != "" {
+			return fmt.Errorf("root can only be set for the %v storage driver", LocalDriverName)
+		}
+	case LocalDriverName:
+		if o.Root == "" {
+			return fmt.Errorf("the %v storage driver requires a root directory", LocalDriverName)
+		}
+	default:
+		return fmt.Errorf("no known storage driver '%v'", o.Driver)
+	}
+	return nil
+}
+
+// GetDriver returns the driver selected by the options, authenticating to
+// remote stores with the given key and secret.
+func GetDriver(opts Options, key, secret string) (Driver, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	switch opts.Driver {
+	case LocalDriverName:
+		return NewLocalDriver(opts.Root), nil
+	default:
+		return NewS3Driver(opts, key, secret)
+	}
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetDriver(t *testing.T) {
+	Convey("When selecting a storage driver", t, func() {
+		Convey("the zero options should select Amazon S3", func() {
+			driver, err := GetDriver(Options{}, "key", "secret")
+			So(err, ShouldBeNil)
+			So(driver.URL("bucket", "dir/file.tgz"), ShouldEqual, "https://s3.amazonaws.com/bucket/dir/file.tgz")
+		})
+
+		Convey("the first options that are set should be chosen", func() {
+			local := Options{Driver: LocalDriverName, Root: "/tmp"}
+			So(Choose(Options{}, local, Options{Driver: S3DriverName}), ShouldResemble, local)
+			So(Choose(Options{}, Options{}).IsZero(), ShouldBeTrue)
+		})
+
+		Convey("unknown drivers and incomplete options should be rejected", func() {
+			_, err := GetDriver(Options{Driver: "ftp"}, "", "")
+			So(err, ShouldNotBeNil)
+			_, err = GetDriver(Options{Driver: LocalDriverName}, "", "")
+			So(err, ShouldNotBeNil)
+			_, err = GetDriver(Options{Region: "moon-west-1"}, "key", "secret")
+			So(err, ShouldNotBeNil)
+			_, err = GetDriver(Options{Endpoint: "minio:9000"}, "key", "secret")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("S3-compatible endpoints should be addressed by subdomain or by path", func() {
+			driver, err := GetDriver(Options{Endpoint: "https://storage.example.com"}, "key", "secret")
+			So(err, ShouldBeNil)
+			So(driver.URL("bucket", "file.tgz"), ShouldEqual, "https://bucket.storage.example.com/file.tgz")
+
+			driver, err = GetDriver(Options{Endpoint: "http://minio:9000/", PathStyle: true}, "key", "secret")
+			So(err, ShouldBeNil)
+			So(driver.URL("bucket", "file.tgz"), ShouldEqual, "http://minio:9000/bucket/file.tgz")
+
+			link, err := driver.Presign("GET", "bucket", "file.tgz", "", time.Now().Add(time.Hour))
+			So(err, ShouldBeNil)
+			signed, err := url.Parse(link)
+			So(err, ShouldBeNil)
+			So(signed.Host, ShouldEqual, "minio:9000")
+			So(signed.Path, ShouldEqual, "/bucket/file.tgz")
+			So(signed.Query().Get("Signature"), ShouldNotEqual, "")
+		})
+	})
+}
+
+func TestLocalDriver(t *testing.T) {
+	Convey("With a local storage driver", t, func() {
+		root, err := ioutil.TempDir("", "storage")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(root)
+		driver, err := GetDriver(Options{Driver: LocalDriverName, Root: root}, "", "")
+		So(err, ShouldBeNil)
+
+		put := func(path, contents string) {
+			So(driver.Put("bucket", path, strings.NewReader(contents), int64(len(contents)), PutOptions{}), ShouldBeNil)
+		}
+		get := func(bucket, path string) string {
+			reader, err := driver.Get(bucket, path)
+			So(err, ShouldBeNil)
+			defer reader.Close()
+			contents, err := ioutil.ReadAll(reader)
+			So(err, ShouldBeNil)
+			return string(contents)
+		}
+
+		Convey("objects that are put should be gotten, copied and removed", func() {
+			put("dir/a.txt", "hello")
+			So(get("bucket", "dir/a.txt"), ShouldEqual, "hello")
+
+			So(driver.Copy("bucket", "dir/a.txt", "other", "b.txt", PutOptions{}), ShouldBeNil)
+			So(get("other", "b.txt"), ShouldEqual, "hello")
+
+			So(driver.Remove("bucket", "dir/a.txt"), ShouldBeNil)
+			exists, err := driver.Exists("bucket", "dir/a.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeFalse)
+			So(driver.Remove("bucket", "dir/a.txt"), ShouldBeNil)
+		})
+
+		Convey("objects should be listed by prefix", func() {
+			put("cache/a.tgz", "aa")
+			put("cache/b.tgz", "bbb")
+			put("other/c.tgz", "c")
+
+			objects, err := driver.List("bucket", "cache/")
+			So(err, ShouldBeNil)
+			So(len(objects), ShouldEqual, 2)
+			So(objects[0].Path, ShouldEqual, "cache/a.tgz")
+			So(objects[1].Size, ShouldEqual, 3)
+			_, err = time.Parse(LastModifiedFormat, objects[0].LastModified)
+			So(err, ShouldBeNil)
+
+			objects, err = driver.List("empty", "")
+			So(err, ShouldBeNil)
+			So(objects, ShouldBeEmpty)
+		})
+
+		Convey("short reads should not leave partial objects", func() {
+			err := driver.Put("bucket", "short.txt", bytes.NewReader([]byte("abc")), 10, PutOptions{})
+			So(err, ShouldNotBeNil)
+			exists, err := driver.Exists("bucket", "short.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeFalse)
+		})
+
+		Convey("paths should not escape their bucket", func() {
+			put("../../escaped.txt", "x")
+			exists, err := driver.Exists("bucket", "escaped.txt")
+			So(err, ShouldBeNil)
+			So(exists, ShouldBeTrue)
+			_, err = driver.Get("..", "escaped.txt")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("links should be file URLs", func() {
+			link, err := driver.Presign("GET", "bucket", "dir/a.txt", "", time.Now())
+			So(err, ShouldBeNil)
+			So(link, ShouldEqual, "file://"+root+"/bucket/dir/a.txt")
+		})
+	})
+}
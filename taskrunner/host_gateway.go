@@ -10,8 +10,10 @@ import (
 
 	"github.com/10gen-labs/slogger/v1"
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/command"
+	"github.com/evergreen-ci/evergreen/hostutil"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	"github.com/evergreen-ci/evergreen/model/host"
 	"github.com/evergreen-ci/evergreen/model/task"
@@ -55,6 +57,9 @@ func (agbh *AgentHostGateway) RunTaskOnHost(settings *evergreen.Settings, taskTo
 	if err != nil {
 		return "", fmt.Errorf("Failed to get cloud host for %v: %v", hostObj.Id, err)
 	}
+	if executor, ok := cloudHost.Executor(); ok {
+		return agbh.runTaskWithExecutor(settings, taskToRun, hostObj, executor)
+	}
 	sshOptions, err := cloudHost.GetSSHOptions()
 	if err != nil {
 		return "", fmt.Errorf("Error getting ssh options for host %v: %v", hostObj.Id, err)
@@ -207,17 +212,21 @@ func (agbh *AgentHostGateway) prepRemoteHost(hostObj host.Host, sshOptions []str
 	return preSCPAgentRevision, nil
 }
 
-// Start the agent process on the specified remote host, and have it run the specified task.
-func startAgentOnRemote(apiURL string, task *task.Task, hostObj *host.Host, sshOptions []string) error {
+// agentCommand returns the command that runs the agent on the host for the task.
+func agentCommand(apiURL string, task *task.Task, hostObj *host.Host) string {
 	// the path to the agent binary on the remote machine
 	pathToExecutable := filepath.Join(hostObj.Distro.WorkDir, "main")
 
-	// build the command to run on the remote machine
-	remoteCmd := fmt.Sprintf(
+	return fmt.Sprintf(
 		`%v -api_server "%v" -task_id "%v" -task_secret "%v" -log_prefix "%v" -https_cert "%v" -pid_file "%v"`,
 		pathToExecutable, apiURL, task.Id, task.Secret, filepath.Join(hostObj.Distro.WorkDir,
 			agentFile), "", filepath.Join(hostObj.Distro.WorkDir, pidFile),
 	)
+}
+
+// Start the agent process on the specified remote host, and have it run the specified task.
+func startAgentOnRemote(apiURL string, task *task.Task, hostObj *host.Host, sshOptions []string) error {
+	remoteCmd := agentCommand(apiURL, task, hostObj)
 	evergreen.Logger.Logf(slogger.INFO, "%v", remoteCmd)
 
 	// compute any info necessary to ssh into the host
@@ -253,3 +262,42 @@ func startAgentOnRemote(apiURL string, task *task.Task, hostObj *host.Host, sshO
 
 	return nil
 }
+
+// runTaskWithExecutor is RunTaskOnHost for hosts whose provider runs commands
+// through its API rather than over SSH. It creates the agent's directory
+// and copies the agent over in a single command, then starts it in the
+// background.
+func (agbh *AgentHostGateway) runTaskWithExecutor(settings *evergreen.Settings, taskToRun task.Task,
+	hostObj host.Host, executor cloud.Executor) (string, error) {
+	execSubPath, err := executableSubPath(hostObj.Distro.Id)
+	if err != nil {
+		return "", fmt.Errorf("error computing subpath to executable: %v", err)
+	}
+
+	agentRevision, err := agbh.GetAgentRevision()
+	if err != nil {
+		evergreen.Logger.Errorf(slogger.ERROR, "Error getting agent revision: %v", err)
+	}
+
+	evergreen.Logger.Logf(slogger.INFO, "Prepping remote host %v...", hostObj.Id)
+	err = hostutil.ExecCopyFile(&hostObj, filepath.Join(agbh.ExecutablesDir, execSubPath),
+		filepath.Join(hostObj.Distro.WorkDir, "main"), executor, SCPTimeout)
+	if err != nil {
+		return "", fmt.Errorf("error prepping remote host %v: %v", hostObj.Id, err)
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Prepping host %v finished successfully", hostObj.Id)
+
+	evergreen.Logger.Logf(slogger.INFO, "Starting agent on host %v for task %v...", hostObj.Id, taskToRun.Id)
+	remoteCmd := agentCommand(settings.ApiUrl, &taskToRun, &hostObj)
+	startAgentLog, err := hostutil.ExecCommand(&hostObj,
+		fmt.Sprintf("nohup %v > /tmp/start 2>&1 &", remoteCmd), executor, StartAgentTimeout)
+	if err != nil {
+		if err == util.ErrTimedOut {
+			return "", fmt.Errorf("starting agent timed out")
+		}
+		return "", fmt.Errorf("error starting agent on host %v (%v): %v", hostObj.Id, err, startAgentLog)
+	}
+	evergreen.Logger.Logf(slogger.INFO, "Agent successfully started for task %v", taskToRun.Id)
+
+	return agentRevision, nil
+}
@@ -0,0 +1,21 @@
+package testutil
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/10gen-labs/slogger/v1"
+)
+
+// NopLogger is a plugin.Logger that discards all logs, for the tests of plugins
+// that can't use plugintest.MockLogger, since plugintest imports every
+// installed plugin.
+type NopLogger struct{}
+
+func (_ *NopLogger) Flush()                                                                   {}
+func (_ *NopLogger) LogLocal(level slogger.Level, messageFmt string, args ...interface{})     {}
+func (_ *NopLogger) LogExecution(level slogger.Level, messageFmt string, args ...interface{}) {}
+func (_ *NopLogger) LogTask(level slogger.Level, messageFmt string, args ...interface{})      {}
+func (_ *NopLogger) LogSystem(level slogger.Level, messageFmt string, args ...interface{})    {}
+func (_ *NopLogger) GetTaskLogWriter(level slogger.Level) io.Writer                           { return ioutil.Discard }
+func (_ *NopLogger) GetSystemLogWriter(level slogger.Level) io.Writer                         { return ioutil.Discard }
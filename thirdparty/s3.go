@@ -15,6 +15,7 @@ import (
 	"os"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -250,6 +251,37 @@ func PutS3File(pushAuth *aws.Auth, localFilePath, s3URL, contentType string) err
 	return nil
 }
 
+// PresignS3URL returns a URL that lets anyone holding it make a request with the given
+// method on the object at path in the bucket, until the URL expires. Requests other than
+// GETs must send the given Content-Type.
+func PresignS3URL(auth aws.Auth, method, bucket, path, contentType string, expires time.Time) string {
+	objectURL := &url.URL{
+		Scheme: "https",
+		Host:   bucket + ".s3.amazonaws.com",
+		Path:   "/" + strings.TrimPrefix(path, "/"),
+	}
+	return SignS3URL(auth, method, objectURL, bucket, path, contentType, expires)
+}
+
+// SignS3URL adds query string authentication to the URL of the object at path in the
+// bucket, like PresignS3URL, for object URLs on any S3-compatible endpoint.
+func SignS3URL(auth aws.Auth, method string, objectURL *url.URL, bucket, path, contentType string,
+	expires time.Time) string {
+	resource := (&url.URL{Path: "/" + strings.TrimPrefix(path, "/")}).EscapedPath()
+	expiresParam := strconv.FormatInt(expires.Unix(), 10)
+	payload := method + "\n\n" + contentType + "\n" + expiresParam + "\n/" + bucket + resource
+	hash := hmac.New(sha1.New, []byte(auth.SecretKey))
+	hash.Write([]byte(payload))
+
+	params := url.Values{}
+	params.Set("AWSAccessKeyId", auth.AccessKey)
+	params.Set("Expires", expiresParam)
+	params.Set("Signature", base64.StdEncoding.EncodeToString(hash.Sum(nil)))
+	signedURL := *objectURL
+	signedURL.RawQuery = params.Encode()
+	return signedURL.String()
+}
+
 func GetS3File(auth *aws.Auth, s3URL string) (io.ReadCloser, error) {
 	urlParsed, err := url.Parse(s3URL)
 	if err != nil {
@@ -4,6 +4,8 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
@@ -28,6 +30,20 @@ func WriteJSON(w *http.ResponseWriter, data interface{}, status int) {
 	(*w).Write([]byte(jsonBytes))
 }
 
+// ResponseError returns an error with the status and message of a failed
+// request. If the response body is a JSON object with a message, as the errors
+// of many APIs are, that is the message; otherwise the body is.
+func ResponseError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	result := struct {
+		Message string `json:"message"`
+	}{}
+	if json.Unmarshal(body, &result) == nil && result.Message != "" {
+		return fmt.Errorf("%v: %v", resp.Status, result.Message)
+	}
+	return fmt.Errorf("%v: %v", resp.Status, strings.TrimSpace(string(body)))
+}
+
 // MakeTlsConfig creates a TLS Config from a certificate and key.
 func MakeTlsConfig(cert string, key string) (*tls.Config, error) {
 	// Adapted from http.ListenAndServeTLS
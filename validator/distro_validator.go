@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/evergreen-ci/evergreen"
+	"github.com/evergreen-ci/evergreen/cloud"
 	"github.com/evergreen-ci/evergreen/cloud/providers"
 	"github.com/evergreen-ci/evergreen/cloud/providers/static"
 	"github.com/evergreen-ci/evergreen/model/distro"
@@ -19,6 +20,9 @@ var distroSyntaxValidators = []distroValidator{
 	ensureHasRequiredFields,
 	ensureValidSSHOptions,
 	ensureValidExpansions,
+	ensureValidWarmPool,
+	ensureValidImageRollout,
+	ensureValidProvisioning,
 }
 
 // CheckDistro checks if the distro configuration syntax is valid. Returns
@@ -142,3 +146,73 @@ func ensureValidSSHOptions(d *distro.Distro, s *evergreen.Settings) []Validation
 	}
 	return nil
 }
+
+// ensureValidWarmPool checks that the distro's warm pool fits in its pool
+// size, and that its hosts can be stopped if it stops them.
+func ensureValidWarmPool(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	pool := d.WarmPool
+	if err := pool.Validate(); err != nil {
+		return []ValidationError{{Error, fmt.Sprintf("distro has an invalid warm pool: %v", err)}}
+	}
+
+	errs := []ValidationError{}
+	minIdle := pool.MinIdle
+	for _, schedule := range pool.Schedules {
+		if schedule.MinIdle > minIdle {
+			minIdle = schedule.MinIdle
+		}
+	}
+	if minIdle > d.PoolSize {
+		errs = append(errs, ValidationError{Error, fmt.Sprintf(
+			"distro's warm pool of %v idle hosts is larger than its pool size of %v", minIdle, d.PoolSize)})
+	}
+
+	if pool.StopIdle {
+		mgr, err := providers.GetCloudManager(d.Provider, s)
+		if err == nil {
+			if _, ok := mgr.(cloud.Stopper); !ok {
+				errs = append(errs, ValidationError{Warning, fmt.Sprintf(
+					"provider '%v' can't stop hosts, so idle hosts will be terminated", d.Provider)})
+			}
+		}
+	}
+	return errs
+}
+
+// ensureValidImageRollout checks that the image being rolled out to the distro
+// replaces one of its provider settings.
+func ensureValidImageRollout(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	rollout := d.ImageRollout
+	if rollout == nil {
+		return nil
+	}
+	if err := rollout.Validate(); err != nil {
+		return []ValidationError{{Error, fmt.Sprintf("distro has an invalid image rollout: %v", err)}}
+	}
+	if d.Provider == static.ProviderName {
+		return []ValidationError{{Error, "images can't be rolled out to static distros"}}
+	}
+	if d.ProviderSettings != nil {
+		if value, ok := (*d.ProviderSettings)[rollout.Setting]; ok {
+			if _, isString := value.(string); !isString {
+				return []ValidationError{{Error, fmt.Sprintf(
+					"provider setting '%v' isn't text, so can't be replaced by the image being rolled out", rollout.Setting)}}
+			}
+			return nil
+		}
+	}
+	return []ValidationError{{Warning, fmt.Sprintf(
+		"distro has no provider setting '%v' for the image being rolled out to replace", rollout.Setting)}}
+}
+
+// ensureValidProvisioning checks that the distro's provisioning steps and ready
+// check are valid.
+func ensureValidProvisioning(d *distro.Distro, s *evergreen.Settings) []ValidationError {
+	if err := d.Provisioning.Validate(); err != nil {
+		return []ValidationError{{Error, fmt.Sprintf("distro has invalid provisioning: %v", err)}}
+	}
+	if d.Provisioning.ReadyCheck == "" && d.Provisioning.ReadyTimeoutSecs > 0 {
+		return []ValidationError{{Warning, "distro has a ready check timeout but no ready check"}}
+	}
+	return nil
+}
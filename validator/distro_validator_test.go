@@ -5,6 +5,10 @@ import (
 
 	"github.com/evergreen-ci/evergreen"
 	"github.com/evergreen-ci/evergreen/cloud/providers/ec2"
+	"github.com/evergreen-ci/evergreen/cloud/providers/gce"
+	"github.com/evergreen-ci/evergreen/cloud/providers/kubernetes"
+	"github.com/evergreen-ci/evergreen/cloud/providers/libvirt"
+	"github.com/evergreen-ci/evergreen/cloud/providers/openstack"
 	"github.com/evergreen-ci/evergreen/db"
 	"github.com/evergreen-ci/evergreen/model/distro"
 	_ "github.com/evergreen-ci/evergreen/plugin/config"
@@ -201,6 +205,122 @@ func TestEnsureHasRequiredFields(t *testing.T) {
 	})
 }
 
+func TestEnsureHasRequiredOpenStackFields(t *testing.T) {
+	Convey("When validating an OpenStack distro...", t, func() {
+		d := &distro.Distro{Id: "a", Arch: "a", User: "a", SSHKey: "a", WorkDir: "a",
+			Provider: openstack.ProviderName,
+			ProviderSettings: &map[string]interface{}{
+				"image_id":        "a",
+				"flavor_id":       "a",
+				"key_name":        "a",
+				"security_groups": []string{"default"},
+			},
+		}
+		Convey("no error should be returned if the distro contains all required provider settings", func() {
+			So(ensureHasRequiredFields(d, conf), ShouldResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if the distro does not contain the flavor", func() {
+			delete(*d.ProviderSettings, "flavor_id")
+			So(ensureHasRequiredFields(d, conf), ShouldNotResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if a security group is blank", func() {
+			(*d.ProviderSettings)["security_groups"] = []string{"default", ""}
+			So(ensureHasRequiredFields(d, conf), ShouldNotResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if OpenStack isn't configured", func() {
+			unconfigured := *conf
+			unconfigured.Providers.OpenStack = evergreen.OpenStackConfig{}
+			So(ensureHasRequiredFields(d, &unconfigured), ShouldNotResemble, []ValidationError{})
+		})
+	})
+}
+
+func TestEnsureHasRequiredGCEFields(t *testing.T) {
+	Convey("When validating a GCE distro...", t, func() {
+		d := &distro.Distro{Id: "a", Arch: "a", User: "a", SSHKey: "a", WorkDir: "a",
+			Provider: gce.ProviderName,
+			ProviderSettings: &map[string]interface{}{
+				"zone":         "us-east1-b",
+				"machine_type": "n1-standard-4",
+				"image":        "a",
+				"preemptible":  true,
+			},
+		}
+		Convey("no error should be returned if the distro contains all required provider settings", func() {
+			So(ensureHasRequiredFields(d, conf), ShouldResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if the distro does not contain the machine type", func() {
+			delete(*d.ProviderSettings, "machine_type")
+			So(ensureHasRequiredFields(d, conf), ShouldNotResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if the disk type is unknown", func() {
+			(*d.ProviderSettings)["disk_type"] = "local-ssd"
+			So(ensureHasRequiredFields(d, conf), ShouldNotResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if GCE isn't configured", func() {
+			unconfigured := *conf
+			unconfigured.Providers.GCE = evergreen.GCEConfig{}
+			So(ensureHasRequiredFields(d, &unconfigured), ShouldNotResemble, []ValidationError{})
+		})
+	})
+}
+
+func TestEnsureHasRequiredLibvirtFields(t *testing.T) {
+	Convey("When validating a libvirt distro...", t, func() {
+		d := &distro.Distro{Id: "a", Arch: "a", User: "a", SSHKey: "a", WorkDir: "a",
+			Provider: libvirt.ProviderName,
+			ProviderSettings: &map[string]interface{}{
+				"uri":          "qemu+ssh://evergreen@hv1/system",
+				"base_volume":  "a",
+				"disk_size_gb": 40,
+				"memory_mb":    4096,
+				"vcpus":        2,
+			},
+		}
+		Convey("no error should be returned if the distro contains all required provider settings", func() {
+			So(ensureHasRequiredFields(d, conf), ShouldResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if the distro does not contain the base volume", func() {
+			delete(*d.ProviderSettings, "base_volume")
+			So(ensureHasRequiredFields(d, conf), ShouldNotResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if the distro has no memory", func() {
+			(*d.ProviderSettings)["memory_mb"] = 0
+			So(ensureHasRequiredFields(d, conf), ShouldNotResemble, []ValidationError{})
+		})
+	})
+}
+
+func TestEnsureHasRequiredKubernetesFields(t *testing.T) {
+	Convey("When validating a Kubernetes distro...", t, func() {
+		d := &distro.Distro{Id: "a", Arch: "a", User: "a", SSHKey: "a", WorkDir: "a",
+			Provider: kubernetes.ProviderName,
+			ProviderSettings: &map[string]interface{}{
+				"image":         "evergreen/ubuntu1604",
+				"cpu":           "2",
+				"memory":        "4Gi",
+				"node_selector": []string{"pool=evergreen"},
+			},
+		}
+		Convey("no error should be returned if the distro contains all required provider settings", func() {
+			So(ensureHasRequiredFields(d, conf), ShouldResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if the distro does not contain the image", func() {
+			delete(*d.ProviderSettings, "image")
+			So(ensureHasRequiredFields(d, conf), ShouldNotResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if a node selector isn't a label", func() {
+			(*d.ProviderSettings)["node_selector"] = []string{"evergreen"}
+			So(ensureHasRequiredFields(d, conf), ShouldNotResemble, []ValidationError{})
+		})
+		Convey("an error should be returned if Kubernetes isn't configured", func() {
+			unconfigured := *conf
+			unconfigured.Providers.Kubernetes = evergreen.KubernetesConfig{}
+			So(ensureHasRequiredFields(d, &unconfigured), ShouldNotResemble, []ValidationError{})
+		})
+	})
+}
+
 func TestEnsureValidExpansions(t *testing.T) {
 	Convey("When validating a distro's expansions...", t, func() {
 		Convey("if any key is blank, an error should be returned", func() {
@@ -240,3 +360,97 @@ func TestEnsureValidSSHOptions(t *testing.T) {
 		})
 	})
 }
+
+func TestEnsureValidImageRollout(t *testing.T) {
+	Convey("When validating a distro's image rollout...", t, func() {
+		d := &distro.Distro{
+			Provider:         "ec2",
+			ProviderSettings: &map[string]interface{}{"ami": "ami-1"},
+			ImageRollout: &distro.ImageRollout{
+				Setting:                "ami",
+				Image:                  "ami-2",
+				Percent:                10,
+				MinTasks:               50,
+				MaxFailureRateIncrease: 0.05,
+			},
+		}
+		Convey("a valid rollout should return no errors", func() {
+			So(ensureValidImageRollout(d, conf), ShouldBeNil)
+		})
+		Convey("an invalid percentage should return an error", func() {
+			d.ImageRollout.Percent = 0
+			errs := ensureValidImageRollout(d, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("a setting the distro doesn't have should return a warning", func() {
+			d.ImageRollout.Setting = "image"
+			errs := ensureValidImageRollout(d, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Warning)
+		})
+		Convey("a setting that isn't text should return an error", func() {
+			d.Provider = "digitalocean"
+			d.ProviderSettings = &map[string]interface{}{"image_id": 1234}
+			d.ImageRollout.Setting = "image_id"
+			errs := ensureValidImageRollout(d, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("static distros should return an error", func() {
+			d.Provider = "static"
+			errs := ensureValidImageRollout(d, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+	})
+}
+
+func TestEnsureValidProvisioning(t *testing.T) {
+	Convey("When validating a distro's provisioning...", t, func() {
+		d := &distro.Distro{
+			Setup: "echo setup",
+			Provisioning: distro.Provisioning{
+				Steps: []distro.ProvisionStep{
+					{Name: "install", Script: "echo install", TimeoutSecs: 600, Retries: 2},
+					{Name: "config", Files: []distro.ProvisionFile{{Path: "agent.yml", Content: "url: ${url}"}}},
+				},
+				ReadyCheck:       "echo ready",
+				ReadyTimeoutSecs: 60,
+			},
+		}
+		Convey("valid provisioning should return no errors", func() {
+			So(ensureValidProvisioning(d, conf), ShouldBeNil)
+		})
+		Convey("steps with the same name should return an error", func() {
+			d.Provisioning.Steps[1].Name = "install"
+			errs := ensureValidProvisioning(d, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("a step with the name of the setup step should return an error", func() {
+			d.Provisioning.Steps[0].Name = distro.SetupStepName
+			errs := ensureValidProvisioning(d, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("a step with no script or files should return an error", func() {
+			d.Provisioning.Steps[1].Files = nil
+			errs := ensureValidProvisioning(d, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("a negative retry count should return an error", func() {
+			d.Provisioning.Steps[0].Retries = -1
+			errs := ensureValidProvisioning(d, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Error)
+		})
+		Convey("a ready check timeout without a ready check should return a warning", func() {
+			d.Provisioning.ReadyCheck = ""
+			errs := ensureValidProvisioning(d, conf)
+			So(len(errs), ShouldEqual, 1)
+			So(errs[0].Level, ShouldEqual, Warning)
+		})
+	})
+}
@@ -487,6 +487,8 @@ func validateCommands(section string, project *model.Project, registry plugin.Re
 		if err != nil {
 			if cmd.Function != "" {
 				command = fmt.Sprintf("'%v' function", cmd.Function)
+			} else if cmd.Parallel != nil {
+				command = "parallel block"
 			}
 			errs = append(errs, ValidationError{Message: fmt.Sprintf("%v section in %v: %v", section, command, err)})
 		}
@@ -522,6 +524,14 @@ func validatePluginCommands(project *model.Project) []ValidationError {
 
 	// validate each function definition
 	for funcName, commands := range project.Functions {
+		if err := commands.ValidateParams(); err != nil {
+			errs = append(errs,
+				ValidationError{
+					Message: fmt.Sprintf("'%v' project's '%v' definition has invalid params: %v",
+						project.Identifier, funcName, err),
+				},
+			)
+		}
 		valErrs := validateCommands("functions", project, pluginRegistry, commands.List())
 		for _, err := range valErrs {
 			errs = append(errs,
@@ -542,6 +552,19 @@ func validatePluginCommands(project *model.Project) []ValidationError {
 				)
 
 			}
+			if c.Parallel == nil {
+				continue
+			}
+			for _, pc := range c.Parallel.Commands {
+				if pc.Function != "" {
+					errs = append(errs,
+						ValidationError{
+							Message: fmt.Sprintf("can not reference a function within a "+
+								"function: '%v' referenced within a parallel block in '%v'", pc.Function, funcName),
+						},
+					)
+				}
+			}
 		}
 
 		// this checks for duplicate function definitions in the project.
@@ -557,16 +580,25 @@ func validatePluginCommands(project *model.Project) []ValidationError {
 	}
 
 	if project.Pre != nil {
+		if len(project.Pre.Params) > 0 {
+			errs = append(errs, ValidationError{Message: "params can only be declared by functions, not the pre section"})
+		}
 		// validate project pre section
 		errs = append(errs, validateCommands("pre", project, pluginRegistry, project.Pre.List())...)
 	}
 
 	if project.Post != nil {
+		if len(project.Post.Params) > 0 {
+			errs = append(errs, ValidationError{Message: "params can only be declared by functions, not the post section"})
+		}
 		// validate project post section
 		errs = append(errs, validateCommands("post", project, pluginRegistry, project.Post.List())...)
 	}
 
 	if project.Timeout != nil {
+		if len(project.Timeout.Params) > 0 {
+			errs = append(errs, ValidationError{Message: "params can only be declared by functions, not the timeout section"})
+		}
 		// validate project timeout section
 		errs = append(errs, validateCommands("timeout", project, pluginRegistry, project.Timeout.List())...)
 	}
@@ -1235,6 +1235,143 @@ func TestValidatePluginCommands(t *testing.T) {
 			}
 			So(validatePluginCommands(project), ShouldResemble, []ValidationError{})
 		})
+		Convey("parallel blocks of valid commands and functions should be valid", func() {
+			project := &model.Project{
+				Functions: map[string]*model.YAMLCommandSet{
+					"parse": {
+						SingleCommand: &model.PluginCommandConf{
+							Command: "gotest.parse_files",
+							Params:  map[string]interface{}{"files": []interface{}{"test"}},
+						},
+					},
+				},
+				Tasks: []model.ProjectTask{
+					{
+						Name: "compile",
+						Commands: []model.PluginCommandConf{
+							{
+								Parallel: &model.ParallelBlock{
+									OnFailure: model.ParallelWaitAll,
+									Commands: []model.PluginCommandConf{
+										{Command: "shell.exec", Params: map[string]interface{}{"script": "make"}},
+										{Function: "parse"},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			So(validatePluginCommands(project), ShouldResemble, []ValidationError{})
+		})
+		Convey("an error should be thrown for invalid parallel blocks", func() {
+			shellExec := model.PluginCommandConf{Command: "shell.exec", Params: map[string]interface{}{"script": "make"}}
+			project := &model.Project{
+				Functions: map[string]*model.YAMLCommandSet{
+					"parse": {
+						SingleCommand: &model.PluginCommandConf{
+							Parallel: &model.ParallelBlock{
+								Commands: []model.PluginCommandConf{{Function: "other"}},
+							},
+						},
+					},
+				},
+				Tasks: []model.ProjectTask{
+					{
+						Name: "compile",
+						Commands: []model.PluginCommandConf{
+							{Parallel: &model.ParallelBlock{}},
+							{Parallel: &model.ParallelBlock{OnFailure: "retry", Commands: []model.PluginCommandConf{shellExec}}},
+							{Parallel: &model.ParallelBlock{Commands: []model.PluginCommandConf{
+								{Parallel: &model.ParallelBlock{Commands: []model.PluginCommandConf{shellExec}}},
+							}}},
+							{Parallel: &model.ParallelBlock{Commands: []model.PluginCommandConf{
+								{Command: "s3.put"},
+							}}},
+						},
+					},
+				},
+			}
+			So(len(validatePluginCommands(project)), ShouldEqual, 6)
+		})
+		Convey("an error should be thrown for malformed conditions", func() {
+			shellExec := model.PluginCommandConf{Command: "shell.exec", Params: map[string]interface{}{"script": "make"}}
+			conditional := func(cond string) model.PluginCommandConf {
+				cmd := shellExec
+				cmd.If = cond
+				return cmd
+			}
+			project := &model.Project{
+				Functions: map[string]*model.YAMLCommandSet{
+					"make": {
+						SingleCommand: func() *model.PluginCommandConf {
+							cmd := conditional(`exists "is_patch"`)
+							return &cmd
+						}(),
+					},
+				},
+				Tasks: []model.ProjectTask{
+					{
+						Name: "compile",
+						Commands: []model.PluginCommandConf{
+							conditional(`${is_patch} == "true"`),
+							conditional(`${is_patch} ==`),
+							{Function: "make"},
+							{Parallel: &model.ParallelBlock{Commands: []model.PluginCommandConf{
+								conditional(`(${is_patch}`),
+							}}},
+						},
+					},
+				},
+			}
+			So(len(validatePluginCommands(project)), ShouldEqual, 4)
+		})
+		Convey("an error should be thrown for missing or unknown function vars", func() {
+			project := &model.Project{
+				Functions: map[string]*model.YAMLCommandSet{
+					"make": {
+						MultiCommand: []model.PluginCommandConf{
+							{Command: "shell.exec", Params: map[string]interface{}{"script": "make ${target}"}},
+						},
+						Params: []model.FunctionParam{
+							{Name: "target", Required: true},
+							{Name: "jobs", Default: "4"},
+						},
+					},
+				},
+				Tasks: []model.ProjectTask{
+					{
+						Name: "compile",
+						Commands: []model.PluginCommandConf{
+							{Function: "make", Vars: map[string]string{"target": "all"}},
+							{Function: "make", Vars: map[string]string{"target": "all", "jobs": "8"}},
+							{Function: "make", Vars: map[string]string{"jobs": "8"}},
+							{Function: "make", Vars: map[string]string{"target": "all", "tagret": "all"}},
+						},
+					},
+				},
+			}
+			So(len(validatePluginCommands(project)), ShouldEqual, 2)
+		})
+		Convey("an error should be thrown for malformed function params", func() {
+			project := &model.Project{
+				Functions: map[string]*model.YAMLCommandSet{
+					"make": {
+						MultiCommand: []model.PluginCommandConf{
+							{Command: "shell.exec", Params: map[string]interface{}{"script": "make"}},
+						},
+						Params: []model.FunctionParam{{Name: "target"}, {Name: "target"}},
+					},
+				},
+				Pre: &model.YAMLCommandSet{
+					MultiCommand: []model.PluginCommandConf{
+						{Command: "shell.exec", Params: map[string]interface{}{"script": "make"}},
+					},
+					Params: []model.FunctionParam{{Name: "target"}},
+				},
+			}
+			So(len(validatePluginCommands(project)), ShouldEqual, 2)
+		})
 	})
 }
 